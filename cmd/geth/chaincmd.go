@@ -35,10 +35,13 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/urfave/cli/v2"
 )
 
@@ -115,6 +118,41 @@ Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
 be gzipped.`,
+	}
+	importHistoryCommand = &cli.Command{
+		Action:    importHistory,
+		Name:      "import-history",
+		Usage:     "Import an Era1 archive directory",
+		ArgsUsage: "<dir>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.TxLookupLimitFlag,
+		}, utils.DatabasePathFlags),
+		Description: `
+The import-history command imports blocks, receipts and total difficulties from
+the Era1 archives in the given directory. The archives are verified against the
+checksums.txt file and their accumulator roots before the import. The blocks are
+not executed, the import must start from the genesis or continue the already
+present chain segment.`,
+	}
+	exportHistoryCommand = &cli.Command{
+		Action:    exportHistory,
+		Name:      "export-history",
+		Usage:     "Export blockchain history to Era1 archives",
+		ArgsUsage: "<dir> <blockNumFirst> <blockNumLast>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+		}, utils.DatabasePathFlags),
+		Description: `
+The export-history command exports the blocks, receipts and total difficulties of
+the given range into Era1 archives of 8192 blocks each, written to the specified
+directory along with a checksums.txt file. The first block must be the start of
+an epoch.
+
+Archives placed in the "era" folder of the ancient directory are used to serve
+the bodies and receipts of the pruned chain history.`,
 	}
 	importPreimagesCommand = &cli.Command{
 		Action:    importPreimages,
@@ -192,10 +230,18 @@ func initGenesis(ctx *cli.Context) error {
 		if err != nil {
 			utils.Fatalf("Failed to open database: %v", err)
 		}
-		_, hash, err := core.SetupGenesisBlock(chaindb, genesis)
+		var triedb *trie.Database
+		if name == "chaindata" {
+			triedb = utils.MakeTrieDatabase(ctx, chaindb, true, false)
+		} else {
+			// The light client only supports the hash-based scheme
+			triedb = trie.NewDatabaseWithConfig(chaindb, &trie.Config{Preimages: true})
+		}
+		_, hash, err := core.SetupGenesisBlockWithTrieDB(chaindb, triedb, genesis, nil)
 		if err != nil {
 			utils.Fatalf("Failed to write genesis block: %v", err)
 		}
+		triedb.Close()
 		chaindb.Close()
 		log.Info("Successfully wrote genesis state", "database", name, "hash", hash)
 	}
@@ -331,6 +377,65 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+// importHistory imports the chain history from the Era1 archives in the
+// specified directory.
+func importHistory(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	err := utils.ImportHistory(chain, ctx.Args().First(), historyNetworkName(chain))
+	chain.Stop()
+	if err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
+// exportHistory exports the chain history of the specified range into Era1
+// archives.
+func exportHistory(ctx *cli.Context) error {
+	if ctx.Args().Len() != 3 {
+		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	start := time.Now()
+
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if head := chain.CurrentFastBlock(); last > head.NumberU64() {
+		utils.Fatalf("Export error: block number %d larger than head block %d\n", last, head.NumberU64())
+	}
+	if err := utils.ExportHistory(chain, ctx.Args().First(), historyNetworkName(chain), first, last, era.MaxEra1Size); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// historyNetworkName returns the network name used in the Era1 archive names,
+// falling back to the chain id for unnamed networks.
+func historyNetworkName(chain *core.BlockChain) string {
+	id := chain.Config().ChainID.String()
+	if name, ok := params.NetworkNames[id]; ok {
+		return name
+	}
+	return id
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if ctx.Args().Len() < 1 {
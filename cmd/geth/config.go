@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/tracers/js"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
@@ -164,6 +165,12 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 		cfg.Eth.OverrideTerminalTotalDifficultyPassed = &override
 	}
 
+	// Load the custom JavaScript tracers before exposing the tracing APIs
+	if ctx.IsSet(utils.TraceJSDirFlag.Name) {
+		if err := js.LoadTracers(ctx.String(utils.TraceJSDirFlag.Name)); err != nil {
+			utils.Fatalf("Failed to load JavaScript tracers: %v", err)
+		}
+	}
 	backend, eth := utils.RegisterEthService(stack, &cfg.Eth)
 
 	// Warn users to migrate if they have a legacy freezer format.
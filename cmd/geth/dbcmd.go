@@ -71,6 +71,7 @@ Remove blockchain and state databases`,
 			dbMetadataCmd,
 			dbMigrateFreezerCmd,
 			dbCheckStateContentCmd,
+			dbPruneHistoryCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -206,6 +207,21 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		Description: `The freezer-migrate command checks your database for receipts in a legacy format and updates those.
 WARNING: please back-up the receipt files in your ancients before running this command.`,
 	}
+	dbPruneHistoryCmd = &cli.Command{
+		Action:    pruneHistory,
+		Name:      "prune-history",
+		Usage:     "Prune the block bodies and receipts below the given block",
+		ArgsUsage: "<number>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabasePathFlags),
+		Description: `The prune-history command deletes the bodies and receipts of all the blocks
+below the given number from the ancient store, retaining the headers. Only the
+chain segment which has already been moved into the ancient store is pruned.
+
+The pruned history is no longer served over RPC or to the network peers, and the
+operation can't be undone without resyncing the node.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -737,6 +753,7 @@ func showMetaData(ctx *cli.Context) error {
 		{"snapshotRecoveryNumber", pp(rawdb.ReadSnapshotRecoveryNumber(db))},
 		{"snapshotRoot", fmt.Sprintf("%v", rawdb.ReadSnapshotRoot(db))},
 		{"txIndexTail", pp(rawdb.ReadTxIndexTail(db))},
+		{"historyTail", fmt.Sprintf("%d", rawdb.ReadHistoryTail(db))},
 		{"fastTxLookupLimit", pp(rawdb.ReadFastTxLookupLimit(db))},
 	}...)
 	table := tablewriter.NewWriter(os.Stdout)
@@ -785,6 +802,30 @@ func freezerMigrate(ctx *cli.Context) error {
 	return nil
 }
 
+func pruneHistory(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	cutoff, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	if cutoff > frozen {
+		log.Warn("Capped pruning cutoff to the ancient store", "requested", cutoff, "frozen", frozen)
+	}
+	return rawdb.PruneChainHistory(db, cutoff)
+}
+
 // dbHasLegacyReceipts checks freezer entries for legacy receipts. It stops at the first
 // non-empty receipt and checks its format. The index of this first non-empty element is
 // the second return parameter.
@@ -83,6 +83,9 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolSnapshotFlag,
+		utils.TxPoolResnapshotFlag,
+		utils.TxPoolRecordFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
@@ -90,11 +93,29 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateLifetimeFlag,
+		utils.TxPoolBlobSlotsFlag,
+		utils.TxPoolAccountRateFlag,
+		utils.TxPoolAccountBurstFlag,
+		utils.TxPoolGlobalRateFlag,
+		utils.TxPoolGlobalBurstFlag,
+		utils.TxPoolPeerRateFlag,
+		utils.TxPoolPeerBurstFlag,
+		utils.TxPoolAllowSendersFlag,
+		utils.TxPoolDenySendersFlag,
+		utils.TxPoolAllowRecipientsFlag,
+		utils.TxPoolDenyRecipientsFlag,
+		utils.TxPoolDenyMethodsFlag,
+		utils.TxPoolMinFeeCapFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.StateSchemeFlag,
+		utils.StateHistoryFlag,
+		utils.HistoryRetainFlag,
+		utils.KZGTrustedSetupFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
 		utils.LightEgressFlag,
@@ -133,6 +154,7 @@ var (
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerifyFlag,
+		utils.MinerOrderingFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -152,6 +174,8 @@ var (
 		utils.GpoPercentileFlag,
 		utils.GpoMaxGasPriceFlag,
 		utils.GpoIgnoreGasPriceFlag,
+		utils.GpoStrategyFlag,
+		utils.GpoFixedTipFlag,
 		utils.MinerNotifyFullFlag,
 		utils.IgnoreLegacyReceiptsFlag,
 		configFileFlag,
@@ -184,6 +208,9 @@ var (
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.TraceIndexFlag,
+		utils.TraceIndexConfigFlag,
+		utils.TraceJSDirFlag,
 		utils.AllowUnprotectedTxs,
 	}
 
@@ -215,6 +242,8 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		importHistoryCommand,
+		exportHistoryCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		removedbCommand,
@@ -243,6 +272,8 @@ func init() {
 		snapshotCommand,
 		// See verkle.go
 		verkleCommand,
+		// See txpoolcmd.go
+		txpoolCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
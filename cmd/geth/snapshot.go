@@ -170,6 +170,10 @@ func pruneState(ctx *cli.Context) error {
 	defer stack.Close()
 
 	chaindb := utils.MakeChainDatabase(ctx, stack, false)
+	if rawdb.ReadStateScheme(chaindb) == rawdb.PathScheme {
+		log.Error("Offline pruning is not required for path scheme")
+		return errors.New("offline pruning is not supported in path scheme")
+	}
 	pruner, err := pruner.NewPruner(chaindb, stack.ResolvePath(""), stack.ResolvePath(config.Eth.TrieCleanCacheJournal), ctx.Uint64(utils.BloomFilterSizeFlag.Name))
 	if err != nil {
 		log.Error("Failed to open snapshot tree", "err", err)
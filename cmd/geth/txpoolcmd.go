@@ -0,0 +1,160 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	cli "github.com/urfave/cli/v2"
+)
+
+var (
+	txpoolReplayIntervalFlag = &cli.DurationFlag{
+		Name:  "interval",
+		Usage: "Interval of recorded time between the reported pool statistics (0 = only at the end)",
+		Value: time.Minute,
+	}
+	txpoolReplayReasonsFlag = &cli.BoolFlag{
+		Name:  "reasons",
+		Usage: "Print the breakdown of rejections and evictions by reason at the end",
+	}
+
+	// txpoolTuningFlags are the transaction pool settings honored during replays.
+	txpoolTuningFlags = []cli.Flag{
+		utils.TxPoolLocalsFlag,
+		utils.TxPoolNoLocalsFlag,
+		utils.TxPoolPriceLimitFlag,
+		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolAccountSlotsFlag,
+		utils.TxPoolGlobalSlotsFlag,
+		utils.TxPoolAccountQueueFlag,
+		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateLifetimeFlag,
+		utils.TxPoolBlobSlotsFlag,
+		utils.TxPoolAccountRateFlag,
+		utils.TxPoolAccountBurstFlag,
+		utils.TxPoolGlobalRateFlag,
+		utils.TxPoolGlobalBurstFlag,
+		utils.TxPoolAllowSendersFlag,
+		utils.TxPoolDenySendersFlag,
+		utils.TxPoolAllowRecipientsFlag,
+		utils.TxPoolDenyRecipientsFlag,
+		utils.TxPoolDenyMethodsFlag,
+		utils.TxPoolMinFeeCapFlag,
+	}
+
+	txpoolCommand = &cli.Command{
+		Name:     "txpool",
+		Usage:    "Transaction pool tuning utilities",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "replay",
+				Usage:     "Replay a transaction pool recording into a simulated pool",
+				ArgsUsage: "<recording>",
+				Action:    replayTxPool,
+				Flags: flags.Merge([]cli.Flag{
+					txpoolReplayIntervalFlag,
+					txpoolReplayReasonsFlag,
+				}, txpoolTuningFlags),
+				Description: `
+geth txpool replay [--txpool.* flags] <recording>
+
+Feeds a transaction stream recorded on a live node with --txpool.record into a
+fresh transaction pool backed by an in-memory chain, reporting admissions,
+rejections, replacements, evictions and the pending/queued pool sizes over the
+recorded time. The pool is configured with the given --txpool.* flags, allowing
+the effects of different settings to be compared offline.
+`,
+			},
+		},
+	}
+)
+
+// replayTxPool replays a transaction pool recording with the configured pool
+// settings, printing the evolution of the pool to stdout.
+func replayTxPool(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need the recording file as the only argument")
+	}
+	input, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	config := ethconfig.Defaults.TxPool
+	utils.SetTxPoolConfig(ctx, &config)
+
+	fmt.Printf("%-20s %10s %9s %9s %9s %9s %9s %9s %9s %9s\n",
+		"time", "head", "received", "admitted", "rejected", "replaced", "evicted", "included", "pending", "queued")
+
+	var last *core.TxPoolReplayStats
+	report := func(stats *core.TxPoolReplayStats) {
+		fmt.Printf("%-20s %10d %9d %9d %9d %9d %9d %9d %9d %9d\n",
+			stats.Time.UTC().Format("2006-01-02 15:04:05"), stats.Head, stats.Received, stats.Admitted,
+			stats.Rejected, stats.Replaced, stats.Evicted, stats.Included, stats.Pending, stats.Queued)
+		last = stats
+	}
+	if err := core.ReplayTxPool(input, config, ctx.Duration(txpoolReplayIntervalFlag.Name), report); err != nil {
+		return err
+	}
+	if ctx.Bool(txpoolReplayReasonsFlag.Name) && last != nil {
+		printReasons("Rejections", last.Rejections)
+		printReasons("Evictions", last.Evictions)
+	}
+	return nil
+}
+
+// printReasons prints a breakdown of transaction counts by reason, most common
+// reasons first.
+func printReasons(title string, reasons map[string]int) {
+	fmt.Printf("\n%s:\n", title)
+	if len(reasons) == 0 {
+		fmt.Println("  none")
+		return
+	}
+	keys := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		keys = append(keys, reason)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if reasons[keys[i]] != reasons[keys[j]] {
+			return reasons[keys[i]] > reasons[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	width := 0
+	for _, reason := range keys {
+		if len(reason) > width {
+			width = len(reason)
+		}
+	}
+	for _, reason := range keys {
+		fmt.Printf("  %s%s %9d\n", reason, strings.Repeat(" ", width-len(reason)), reasons[reason])
+	}
+}
@@ -20,11 +20,16 @@ package utils
 import (
 	"bufio"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -38,9 +43,11 @@ import (
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/urfave/cli/v2"
 )
 
@@ -287,6 +294,298 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	return nil
 }
 
+// historyChecksums is the name of the file listing the checksums of the
+// exported history archives.
+const historyChecksums = "checksums.txt"
+
+// ExportHistory exports the chain history into Era1 archives in the specified
+// directory, each holding step blocks. The first block must be the start of an
+// epoch. The sha256 checksums of the archives are written into a checksum file
+// along them.
+func ExportHistory(bc *core.BlockChain, dir string, network string, first, last, step uint64) error {
+	log.Info("Exporting blockchain history", "dir", dir)
+	if step == 0 || step > era.MaxEra1Size {
+		return fmt.Errorf("invalid epoch size %d", step)
+	}
+	if first%step != 0 {
+		return fmt.Errorf("first block %d is not the start of an epoch", first)
+	}
+	if head := bc.CurrentBlock().NumberU64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	if first > last {
+		return fmt.Errorf("invalid range: first (%d) > last (%d)", first, last)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		start     = time.Now()
+		reported  = time.Now()
+		checksums []string
+	)
+	for from := first; from <= last; from += step {
+		to := from + step - 1
+		if to > last {
+			to = last
+		}
+		name, checksum, err := exportEpoch(bc, dir, network, from, to, step)
+		if err != nil {
+			return err
+		}
+		checksums = append(checksums, fmt.Sprintf("%s  %s", checksum, name))
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", to, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, historyChecksums), []byte(strings.Join(checksums, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain history", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportEpoch writes the given block range into an Era1 archive, returning the
+// file name and the hex encoded sha256 checksum of the archive.
+func exportEpoch(bc *core.BlockChain, dir string, network string, from, to, step uint64) (string, string, error) {
+	tmp := filepath.Join(dir, fmt.Sprintf("%s-%05d.era1.tmp", network, from/step))
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	var (
+		hasher = sha256.New()
+		b      = era.NewBuilder(io.MultiWriter(f, hasher))
+	)
+	for number := from; number <= to; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			if number < bc.HistoryPruningCutoff() {
+				return "", "", fmt.Errorf("export failed on #%d: %w", number, core.ErrHistoryPruned)
+			}
+			return "", "", fmt.Errorf("export failed on #%d: not found", number)
+		}
+		receipts := bc.GetReceiptsByHash(block.Hash())
+		if receipts == nil {
+			return "", "", fmt.Errorf("export failed on #%d: receipts not found", number)
+		}
+		td := bc.GetTd(block.Hash(), number)
+		if td == nil {
+			return "", "", fmt.Errorf("export failed on #%d: total difficulty not found", number)
+		}
+		if err := b.Add(block, receipts, td); err != nil {
+			return "", "", err
+		}
+	}
+	root, err := b.Finalize()
+	if err != nil {
+		return "", "", fmt.Errorf("export failed to finalize #%d-%d: %w", from, to, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", "", err
+	}
+	name := era.Filename(network, int(from/step), root)
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return "", "", err
+	}
+	return name, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// readHistoryChecksums reads the checksum file of the exported history archives,
+// returning the checksums mapped to the file names.
+func readHistoryChecksums(dir string) (map[string]string, error) {
+	blob, err := os.ReadFile(filepath.Join(dir, historyChecksums))
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string)
+	for _, line := range strings.Split(string(blob), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		checksums[fields[1]] = fields[0]
+	}
+	return checksums, nil
+}
+
+// ImportHistory imports the chain history from the Era1 archives in the given
+// directory. The archives are verified against the checksum file and their own
+// accumulator roots, and the blocks are written straight into the ancient store
+// without executing them. The import must start from the genesis or continue
+// the already present chain segment.
+func ImportHistory(chain *core.BlockChain, dir string, network string) error {
+	// Watch for Ctrl-C while the import is running.
+	// If a signal is received, the import will stop at the next archive.
+	interrupt := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during import, stopping at next archive")
+		}
+		close(stop)
+	}()
+	checkInterrupt := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
+
+	log.Info("Importing blockchain history", "dir", dir)
+
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no era1 files found for network %s in %s", network, dir)
+	}
+	checksums, err := readHistoryChecksums(dir)
+	if err != nil {
+		return fmt.Errorf("unable to read checksums: %w", err)
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported = 0
+	)
+	for _, name := range entries {
+		if checkInterrupt() {
+			return fmt.Errorf("interrupted")
+		}
+		n, err := importEpoch(chain, filepath.Join(dir, name), checksums[name])
+		if err != nil {
+			return fmt.Errorf("error importing %s: %w", name, err)
+		}
+		imported += n
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Importing blocks", "file", name, "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	log.Info("Imported blockchain history", "blocks", imported, "head", chain.CurrentFastBlock().NumberU64(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// importEpoch verifies and imports a single Era1 archive, returning the number
+// of the imported blocks.
+func importEpoch(chain *core.BlockChain, path string, checksum string) (int, error) {
+	if checksum == "" {
+		return 0, errors.New("checksum is missing")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if have := hex.EncodeToString(hasher.Sum(nil)); have != checksum {
+		f.Close()
+		return 0, fmt.Errorf("checksum mismatch: have %s, want %s", have, checksum)
+	}
+	e, err := era.From(f)
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	defer e.Close()
+
+	if err := e.Verify(); err != nil {
+		return 0, err
+	}
+	head := chain.CurrentFastBlock().NumberU64()
+	if e.Start() > head+1 {
+		return 0, fmt.Errorf("gap in the chain: head #%d, archive starts at #%d", head, e.Start())
+	}
+	var (
+		headers  []*types.Header
+		blocks   types.Blocks
+		receipts []types.Receipts
+		tds      []*big.Int
+		it       = era.NewIterator(e)
+	)
+	for it.Next() {
+		block := it.Block()
+		if block.NumberU64() <= head {
+			// Ensure the known blocks belong to the same chain
+			if chain.GetCanonicalHash(block.NumberU64()) != block.Hash() {
+				return 0, fmt.Errorf("block #%d mismatch with the local chain", block.NumberU64())
+			}
+			continue
+		}
+		if err := verifyHistoryBlock(block, it.Receipts()); err != nil {
+			return 0, err
+		}
+		headers = append(headers, block.Header())
+		blocks = append(blocks, block)
+		receipts = append(receipts, it.Receipts())
+		tds = append(tds, it.TotalDifficulty())
+	}
+	if it.Error() != nil {
+		return 0, it.Error()
+	}
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+	if _, err := chain.InsertHeaderChain(headers, 0); err != nil {
+		return 0, err
+	}
+	if _, err := chain.InsertReceiptChain(blocks, receipts, math.MaxUint64); err != nil {
+		return 0, err
+	}
+	// The total difficulties are only committed to by the accumulator, ensure
+	// they match the local chain.
+	last := blocks[len(blocks)-1]
+	if td := chain.GetTd(last.Hash(), last.NumberU64()); td == nil || td.Cmp(tds[len(tds)-1]) != 0 {
+		return 0, fmt.Errorf("total difficulty mismatch at #%d: have %v, want %v", last.NumberU64(), td, tds[len(tds)-1])
+	}
+	return len(blocks), nil
+}
+
+// verifyHistoryBlock checks that the body and receipts of an archived block
+// match the commitments in its header.
+func verifyHistoryBlock(block *types.Block, receipts types.Receipts) error {
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != block.TxHash() {
+		return fmt.Errorf("transaction root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, block.TxHash())
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+		return fmt.Errorf("uncle root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, block.UncleHash())
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != block.ReceiptHash() {
+		return fmt.Errorf("receipt root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, block.ReceiptHash())
+	}
+	if want := block.Header().WithdrawalsHash; want != nil {
+		if block.Withdrawals() == nil {
+			return fmt.Errorf("missing withdrawals at #%d", block.NumberU64())
+		}
+		if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *want {
+			return fmt.Errorf("withdrawals root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, *want)
+		}
+	} else if block.Withdrawals() != nil {
+		return fmt.Errorf("unexpected withdrawals at #%d", block.NumberU64())
+	}
+	return nil
+}
+
 // ImportPreimages imports a batch of exported hash preimages into the database.
 // It's a part of the deprecated functionality, should be removed in the future.
 func ImportPreimages(db ethdb.Database, fn string) error {
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
@@ -38,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
 	"github.com/ethereum/go-ethereum/eth"
 	ethcatalyst "github.com/ethereum/go-ethereum/eth/catalyst"
 	"github.com/ethereum/go-ethereum/eth/downloader"
@@ -65,6 +67,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/netutil"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	gopsutil "github.com/shirou/gopsutil/mem"
 	"github.com/urfave/cli/v2"
@@ -95,6 +98,12 @@ var (
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
 		Category: flags.EthCategory,
 	}
+	DBEngineFlag = &cli.StringFlag{
+		Name:     "db.engine",
+		Usage:    "Backing database implementation to use for new databases ('leveldb' or 'pebble')",
+		Value:    "leveldb",
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &flags.DirectoryFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -242,6 +251,29 @@ var (
 		Value:    ethconfig.Defaults.TxLookupLimit,
 		Category: flags.EthCategory,
 	}
+	StateSchemeFlag = &cli.StringFlag{
+		Name:     "state.scheme",
+		Usage:    "Scheme to use for storing ethereum state ('hash' or 'path', default = scheme of the existing database, otherwise 'hash')",
+		Category: flags.EthCategory,
+	}
+	StateHistoryFlag = &cli.Uint64Flag{
+		Name:     "state.history",
+		Usage:    "Number of recent blocks to retain state history for, only relevant in the path scheme (0 = entire chain)",
+		Value:    ethconfig.Defaults.StateHistory,
+		Category: flags.EthCategory,
+	}
+	HistoryRetainFlag = &cli.Uint64Flag{
+		Name:     "history.retain",
+		Usage:    "Number of recent blocks to retain bodies and receipts for, older ones are pruned from the ancient store (0 = entire chain)",
+		Value:    ethconfig.Defaults.HistoryRetain,
+		Category: flags.EthCategory,
+	}
+	KZGTrustedSetupFlag = &cli.PathFlag{
+		Name:      "kzg.trustedsetup",
+		Usage:     "JSON file holding the KZG trusted setup ceremony output, required for blob transactions and the point evaluation precompile",
+		TakesFile: true,
+		Category:  flags.EthCategory,
+	}
 	LightKDFFlag = &cli.BoolFlag{
 		Name:     "lightkdf",
 		Usage:    "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -396,6 +428,22 @@ var (
 		Value:    core.DefaultTxPoolConfig.Rejournal,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolSnapshotFlag = &cli.StringFlag{
+		Name:     "txpool.snapshot",
+		Usage:    "Disk snapshot of all pooled transactions to survive node restarts (disabled if empty)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolResnapshotFlag = &cli.DurationFlag{
+		Name:     "txpool.resnapshot",
+		Usage:    "Time interval to regenerate the transaction pool snapshot",
+		Value:    core.DefaultTxPoolConfig.Resnapshot,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRecordFlag = &cli.StringFlag{
+		Name:     "txpool.record",
+		Usage:    "File to record the incoming transaction stream into for offline replays (disabled if empty)",
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolPriceLimitFlag = &cli.Uint64Flag{
 		Name:     "txpool.pricelimit",
 		Usage:    "Minimum gas price limit to enforce for acceptance into the pool",
@@ -438,6 +486,85 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolPrivateLifetimeFlag = &cli.Uint64Flag{
+		Name:     "txpool.privatelifetime",
+		Usage:    "Number of blocks privately submitted transactions are kept for",
+		Value:    ethconfig.Defaults.TxPool.PrivateLifetime,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolBlobSlotsFlag = &cli.Uint64Flag{
+		Name:     "txpool.blobslots",
+		Usage:    "Maximum number of blobs whose sidecars are kept by the transaction pool",
+		Value:    ethconfig.Defaults.TxPool.BlobSlots,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolAccountRateFlag = &cli.Float64Flag{
+		Name:     "txpool.accountrate",
+		Usage:    "Remote transactions accepted per second per sender (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPool.AccountRate,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolAccountBurstFlag = &cli.Uint64Flag{
+		Name:     "txpool.accountburst",
+		Usage:    "Maximum number of remote transactions a sender may submit at once",
+		Value:    ethconfig.Defaults.TxPool.AccountBurst,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolGlobalRateFlag = &cli.Float64Flag{
+		Name:     "txpool.globalrate",
+		Usage:    "Remote transactions accepted per second for all senders (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPool.GlobalRate,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolGlobalBurstFlag = &cli.Uint64Flag{
+		Name:     "txpool.globalburst",
+		Usage:    "Maximum number of remote transactions all senders may submit at once",
+		Value:    ethconfig.Defaults.TxPool.GlobalBurst,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolPeerRateFlag = &cli.Float64Flag{
+		Name:     "txpool.peerrate",
+		Usage:    "Transactions accepted per second from a single peer (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPool.PeerRate,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolPeerBurstFlag = &cli.Uint64Flag{
+		Name:     "txpool.peerburst",
+		Usage:    "Maximum number of transactions a single peer may deliver at once",
+		Value:    ethconfig.Defaults.TxPool.PeerBurst,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolAllowSendersFlag = &cli.StringFlag{
+		Name:     "txpool.allowsenders",
+		Usage:    "Comma separated accounts exclusively allowed to send transactions into the pool",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolDenySendersFlag = &cli.StringFlag{
+		Name:     "txpool.denysenders",
+		Usage:    "Comma separated accounts whose transactions are rejected by the pool",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolAllowRecipientsFlag = &cli.StringFlag{
+		Name:     "txpool.allowrecipients",
+		Usage:    "Comma separated accounts exclusively allowed as transaction recipients (disables contract creation)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolDenyRecipientsFlag = &cli.StringFlag{
+		Name:     "txpool.denyrecipients",
+		Usage:    "Comma separated accounts whose incoming transactions are rejected by the pool",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolDenyMethodsFlag = &cli.StringFlag{
+		Name:     "txpool.denymethods",
+		Usage:    "Comma separated contract methods to reject, as <contract>:<4byte selector>",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolMinFeeCapFlag = &cli.Uint64Flag{
+		Name:     "txpool.minfeecap",
+		Usage:    "Minimum fee cap (gas price) to enforce on all transactions, including locals",
+		Value:    ethconfig.Defaults.TxPool.MinFeeCap,
+		Category: flags.TxPoolCategory,
+	}
 
 	// Performance tuning settings
 	CacheFlag = &cli.IntFlag{
@@ -560,6 +687,12 @@ var (
 		Usage:    "Disable remote sealing verification",
 		Category: flags.MinerCategory,
 	}
+	MinerOrderingFlag = &cli.StringFlag{
+		Name:     "miner.ordering",
+		Usage:    "Transaction ordering strategy of built blocks (greedy, fifo, revenue)",
+		Value:    ethconfig.Defaults.Miner.Ordering,
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -612,6 +745,21 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	TraceIndexFlag = &cli.StringFlag{
+		Name:     "trace.index",
+		Usage:    "Native tracer run on every imported block to serve its block traces from disk (disabled if empty)",
+		Category: flags.APICategory,
+	}
+	TraceIndexConfigFlag = &cli.StringFlag{
+		Name:     "trace.indexconfig",
+		Usage:    "JSON config of the trace index tracer",
+		Category: flags.APICategory,
+	}
+	TraceJSDirFlag = &cli.StringFlag{
+		Name:     "trace.jsdir",
+		Usage:    "Directory of JavaScript tracers callable by file name",
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -881,6 +1029,18 @@ var (
 		Value:    ethconfig.Defaults.GPO.IgnorePrice.Int64(),
 		Category: flags.GasPriceCategory,
 	}
+	GpoStrategyFlag = &cli.StringFlag{
+		Name:     "gpo.strategy",
+		Usage:    "Priority fee estimation strategy (percentile, pool, feehistory, basefee)",
+		Value:    gasprice.StrategyPercentile,
+		Category: flags.GasPriceCategory,
+	}
+	GpoFixedTipFlag = &cli.Int64Flag{
+		Name:     "gpo.fixedtip",
+		Usage:    "Priority fee recommended on top of the base fee by the basefee strategy",
+		Value:    gasprice.DefaultFixedTip.Int64(),
+		Category: flags.GasPriceCategory,
+	}
 
 	// Metrics flags
 	MetricsEnabledFlag = &cli.BoolFlag{
@@ -997,6 +1157,7 @@ var (
 		DataDirFlag,
 		AncientFlag,
 		RemoteDBFlag,
+		DBEngineFlag,
 	}
 )
 
@@ -1473,6 +1634,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.String(KeyStoreDirFlag.Name)
 	}
+	if ctx.IsSet(DBEngineFlag.Name) {
+		dbEngine := ctx.String(DBEngineFlag.Name)
+		if dbEngine != rawdb.DBLeveldb && dbEngine != rawdb.DBPebble {
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", dbEngine)
+		}
+		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
+		cfg.DBEngine = dbEngine
+	}
 	if ctx.IsSet(DeveloperFlag.Name) {
 		cfg.UseLightweightKDF = true
 	}
@@ -1557,9 +1726,16 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config, light bool) {
 	if ctx.IsSet(GpoIgnoreGasPriceFlag.Name) {
 		cfg.IgnorePrice = big.NewInt(ctx.Int64(GpoIgnoreGasPriceFlag.Name))
 	}
+	if ctx.IsSet(GpoStrategyFlag.Name) {
+		cfg.Strategy = ctx.String(GpoStrategyFlag.Name)
+	}
+	if ctx.IsSet(GpoFixedTipFlag.Name) {
+		cfg.FixedTip = big.NewInt(ctx.Int64(GpoFixedTipFlag.Name))
+	}
 }
 
-func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
+// SetTxPoolConfig applies txpool-related command line flags to the config.
+func SetTxPoolConfig(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.IsSet(TxPoolLocalsFlag.Name) {
 		locals := strings.Split(ctx.String(TxPoolLocalsFlag.Name), ",")
 		for _, account := range locals {
@@ -1579,6 +1755,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.IsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.Duration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.IsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.String(TxPoolSnapshotFlag.Name)
+	}
+	if ctx.IsSet(TxPoolResnapshotFlag.Name) {
+		cfg.Resnapshot = ctx.Duration(TxPoolResnapshotFlag.Name)
+	}
+	if ctx.IsSet(TxPoolRecordFlag.Name) {
+		cfg.Record = ctx.String(TxPoolRecordFlag.Name)
+	}
 	if ctx.IsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.Uint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1600,6 +1785,68 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.IsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.Duration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.IsSet(TxPoolPrivateLifetimeFlag.Name) {
+		cfg.PrivateLifetime = ctx.Uint64(TxPoolPrivateLifetimeFlag.Name)
+	}
+	if ctx.IsSet(TxPoolBlobSlotsFlag.Name) {
+		cfg.BlobSlots = ctx.Uint64(TxPoolBlobSlotsFlag.Name)
+	}
+	if ctx.IsSet(TxPoolAccountRateFlag.Name) {
+		cfg.AccountRate = ctx.Float64(TxPoolAccountRateFlag.Name)
+	}
+	if ctx.IsSet(TxPoolAccountBurstFlag.Name) {
+		cfg.AccountBurst = ctx.Uint64(TxPoolAccountBurstFlag.Name)
+	}
+	if ctx.IsSet(TxPoolGlobalRateFlag.Name) {
+		cfg.GlobalRate = ctx.Float64(TxPoolGlobalRateFlag.Name)
+	}
+	if ctx.IsSet(TxPoolGlobalBurstFlag.Name) {
+		cfg.GlobalBurst = ctx.Uint64(TxPoolGlobalBurstFlag.Name)
+	}
+	if ctx.IsSet(TxPoolPeerRateFlag.Name) {
+		cfg.PeerRate = ctx.Float64(TxPoolPeerRateFlag.Name)
+	}
+	if ctx.IsSet(TxPoolPeerBurstFlag.Name) {
+		cfg.PeerBurst = ctx.Uint64(TxPoolPeerBurstFlag.Name)
+	}
+	if ctx.IsSet(TxPoolAllowSendersFlag.Name) {
+		cfg.AllowSenders = splitAndParseAddresses(ctx, TxPoolAllowSendersFlag.Name)
+	}
+	if ctx.IsSet(TxPoolDenySendersFlag.Name) {
+		cfg.DenySenders = splitAndParseAddresses(ctx, TxPoolDenySendersFlag.Name)
+	}
+	if ctx.IsSet(TxPoolAllowRecipientsFlag.Name) {
+		cfg.AllowRecipients = splitAndParseAddresses(ctx, TxPoolAllowRecipientsFlag.Name)
+	}
+	if ctx.IsSet(TxPoolDenyRecipientsFlag.Name) {
+		cfg.DenyRecipients = splitAndParseAddresses(ctx, TxPoolDenyRecipientsFlag.Name)
+	}
+	if ctx.IsSet(TxPoolDenyMethodsFlag.Name) {
+		cfg.DenyMethods = nil
+		for _, method := range SplitAndTrim(ctx.String(TxPoolDenyMethodsFlag.Name)) {
+			rule, err := core.ParseTxMethodRule(method)
+			if err != nil {
+				Fatalf("Invalid method in --%s: %v", TxPoolDenyMethodsFlag.Name, err)
+			}
+			cfg.DenyMethods = append(cfg.DenyMethods, rule)
+		}
+	}
+	if ctx.IsSet(TxPoolMinFeeCapFlag.Name) {
+		cfg.MinFeeCap = ctx.Uint64(TxPoolMinFeeCapFlag.Name)
+	}
+}
+
+// splitAndParseAddresses parses a comma separated list of accounts from the
+// given flag, aborting on any invalid entry.
+func splitAndParseAddresses(ctx *cli.Context, name string) []common.Address {
+	var addrs []common.Address
+	for _, account := range SplitAndTrim(ctx.String(name)) {
+		if !common.IsHexAddress(account) {
+			Fatalf("Invalid account in --%s: %s", name, account)
+		}
+		addrs = append(addrs, common.HexToAddress(account))
+	}
+	return addrs
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1649,11 +1896,31 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNoVerifyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerifyFlag.Name)
 	}
+	if ctx.IsSet(MinerOrderingFlag.Name) {
+		cfg.Ordering = ctx.String(MinerOrderingFlag.Name)
+		if _, err := miner.OrderingStrategyByName(cfg.Ordering); err != nil {
+			Fatalf("Invalid --%s: %v", MinerOrderingFlag.Name, err)
+		}
+	}
 	if ctx.IsSet(LegacyMinerGasTargetFlag.Name) {
 		log.Warn("The generic --miner.gastarget flag is deprecated and will be removed in the future!")
 	}
 }
 
+// setKZGTrustedSetup loads the KZG trusted setup from the file specified by
+// the user. Without a setup, blob commitments and proofs are refused and nodes
+// of chains scheduling Cancun refuse to start.
+func setKZGTrustedSetup(ctx *cli.Context) {
+	if !ctx.IsSet(KZGTrustedSetupFlag.Name) {
+		return
+	}
+	path := ctx.Path(KZGTrustedSetupFlag.Name)
+	if err := kzg.LoadTrustedSetup(path); err != nil {
+		Fatalf("Failed to load KZG trusted setup from %s: %v", path, err)
+	}
+	log.Info("Loaded KZG trusted setup", "path", path)
+}
+
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
 	requiredBlocks := ctx.String(EthRequiredBlocksFlag.Name)
 	if requiredBlocks == "" {
@@ -1742,10 +2009,11 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	}
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO, ctx.String(SyncModeFlag.Name) == "light")
-	setTxPool(ctx, &cfg.TxPool)
+	SetTxPoolConfig(ctx, &cfg.TxPool)
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
+	setKZGTrustedSetup(ctx)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1800,6 +2068,21 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(StateSchemeFlag.Name) {
+		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
+	}
+	if ctx.IsSet(BloomFilterSizeFlag.Name) {
+		cfg.PruningBloomSize = ctx.Uint64(BloomFilterSizeFlag.Name)
+	}
+	if ctx.IsSet(StateHistoryFlag.Name) {
+		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
+	}
+	if ctx.IsSet(HistoryRetainFlag.Name) {
+		cfg.HistoryRetain = ctx.Uint64(HistoryRetainFlag.Name)
+	}
+	if cfg.NoPruning && cfg.StateScheme == rawdb.PathScheme {
+		Fatalf("--%s=archive is not compatible with --%s=%s", GCModeFlag.Name, StateSchemeFlag.Name, rawdb.PathScheme)
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheTrieFlag.Name) / 100
 	}
@@ -1849,6 +2132,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.String(TraceIndexFlag.Name)
+	}
+	if ctx.IsSet(TraceIndexConfigFlag.Name) {
+		cfg.TraceIndexConfig = ctx.String(TraceIndexConfigFlag.Name)
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -2012,6 +2301,17 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		Fatalf("Failed to register the Engine API service: %v", err)
 	}
 	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	if cfg.TraceIndex != "" {
+		var config json.RawMessage
+		if cfg.TraceIndexConfig != "" {
+			config = json.RawMessage(cfg.TraceIndexConfig)
+		}
+		indexer, err := tracers.NewTraceIndexer(backend.APIBackend, cfg.TraceIndex, config)
+		if err != nil {
+			Fatalf("Failed to create the trace indexer: %v", err)
+		}
+		stack.RegisterLifecycle(indexer)
+	}
 	return backend.APIBackend, backend
 }
 
@@ -2170,6 +2470,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (*core.BlockChain, ethdb.Data
 		gspec   = MakeGenesis(ctx)
 		chainDb = MakeChainDatabase(ctx, stack, false) // TODO(rjl493456442) support read-only database
 	)
+	setKZGTrustedSetup(ctx)
 	cliqueConfig, err := core.LoadCliqueConfig(chainDb, gspec)
 	if err != nil {
 		Fatalf("%v", err)
@@ -2190,7 +2491,13 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (*core.BlockChain, ethdb.Data
 		TrieTimeLimit:       ethconfig.Defaults.TrieTimeout,
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
 		Preimages:           ctx.Bool(CachePreimagesFlag.Name),
+		StateHistory:        ctx.Uint64(StateHistoryFlag.Name),
 	}
+	scheme, err := rawdb.ParseStateScheme(ctx.String(StateSchemeFlag.Name), chainDb)
+	if err != nil {
+		Fatalf("%v", err)
+	}
+	cache.StateScheme = scheme
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
 		log.Info("Enabling recording of key preimages since archive mode is used")
@@ -2212,9 +2519,29 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (*core.BlockChain, ethdb.Data
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
 	}
+	if chain.Config().CancunBlock != nil && !kzg.TrustedSetupLoaded() {
+		Fatalf("Cancun is scheduled but no KZG trusted setup is loaded, use --%s", KZGTrustedSetupFlag.Name)
+	}
 	return chain, chainDb
 }
 
+// MakeTrieDatabase constructs a trie database based on the configured scheme.
+func MakeTrieDatabase(ctx *cli.Context, disk ethdb.Database, preimage bool, readOnly bool) *trie.Database {
+	scheme, err := rawdb.ParseStateScheme(ctx.String(StateSchemeFlag.Name), disk)
+	if err != nil {
+		Fatalf("%v", err)
+	}
+	config := &trie.Config{
+		Preimages: preimage,
+		Scheme:    scheme,
+		ReadOnly:  readOnly,
+	}
+	if scheme == rawdb.PathScheme {
+		config.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
+	}
+	return trie.NewDatabaseWithConfig(disk, config)
+}
+
 // MakeConsolePreloads retrieves the absolute paths for the console JavaScript
 // scripts to preload before starting.
 func MakeConsolePreloads(ctx *cli.Context) []string {
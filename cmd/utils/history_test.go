@@ -0,0 +1,121 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the chain history can be exported into era archives and imported
+// into a fresh database.
+func TestHistoryImportAndExport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	// Generate and insert a chain with a transaction in each block
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 40, func(i int, g *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(g.TxNonce(address), common.Address{0xaa}, big.NewInt(1000), params.TxGas, g.BaseFee(), nil), signer, key)
+		g.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Export the history in epochs of 16 blocks
+	dir := t.TempDir()
+	if err := ExportHistory(chain, dir, "test", 3, 40, 16); err == nil {
+		t.Fatal("unaligned export accepted")
+	}
+	if err := ExportHistory(chain, dir, "test", 0, 40, 16); err != nil {
+		t.Fatalf("failed to export history: %v", err)
+	}
+	entries, err := era.ReadDir(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to read archives: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("archive count mismatch: have %d, want 3", len(entries))
+	}
+	checksums, err := readHistoryChecksums(dir)
+	if err != nil || len(checksums) != 3 {
+		t.Fatalf("failed to read checksums: %v, %d entries", err, len(checksums))
+	}
+	// Import the history into a fresh database
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	imported, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer imported.Stop()
+
+	if err := ImportHistory(imported, dir, "test"); err != nil {
+		t.Fatalf("failed to import history: %v", err)
+	}
+	if head := imported.CurrentFastBlock().NumberU64(); head != 40 {
+		t.Fatalf("head mismatch: have %d, want 40", head)
+	}
+	for _, block := range blocks {
+		if have := imported.GetBlockByNumber(block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Fatalf("block %d mismatch", block.NumberU64())
+		}
+		if have := imported.GetReceiptsByHash(block.Hash()); len(have) != 1 {
+			t.Fatalf("block %d: receipts mismatch", block.NumberU64())
+		}
+	}
+	// Importing again is a noop, a tampered archive is rejected
+	if err := ImportHistory(imported, dir, "test"); err != nil {
+		t.Fatalf("failed to reimport history: %v", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, entries[0]), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	f.Write([]byte{0x00})
+	f.Close()
+	if err := ImportHistory(imported, dir, "test"); err == nil {
+		t.Fatal("tampered archive accepted")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package misc
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	minDataGasPrice            = big.NewInt(params.MinDataGasPrice)
+	dataGasPriceUpdateFraction = big.NewInt(params.DataGasPriceUpdateFraction)
+)
+
+// VerifyEip4844Header verifies that the excess data gas of the header matches
+// the one derived from its parent, given the number of blobs the parent contains.
+func VerifyEip4844Header(parent, header *types.Header, parentBlobs int) error {
+	if header.ExcessDataGas == nil {
+		return fmt.Errorf("header is missing excessDataGas")
+	}
+	expected := CalcExcessDataGas(parent.ExcessDataGas, parentBlobs)
+	if header.ExcessDataGas.Cmp(expected) != 0 {
+		return fmt.Errorf("invalid excessDataGas: have %s, want %s, parentExcessDataGas %s, parentBlobs %d",
+			header.ExcessDataGas, expected, parent.ExcessDataGas, parentBlobs)
+	}
+	return nil
+}
+
+// CalcExcessDataGas calculates the excess data gas of a block based on the
+// excess data gas of its parent and the number of blobs the parent contains.
+// A nil parent excess is treated as zero, as is the case at the fork block.
+func CalcExcessDataGas(parentExcessDataGas *big.Int, parentBlobs int) *big.Int {
+	excess := new(big.Int)
+	if parentExcessDataGas != nil {
+		excess.Set(parentExcessDataGas)
+	}
+	excess.Add(excess, new(big.Int).SetUint64(uint64(parentBlobs)*params.DataGasPerBlob))
+
+	target := new(big.Int).SetUint64(params.TargetDataGasPerBlock)
+	if excess.Cmp(target) < 0 {
+		return new(big.Int)
+	}
+	return excess.Sub(excess, target)
+}
+
+// CalcDataGasPrice calculates the price of a unit of data gas in a block with
+// the given excess data gas. A nil excess (pre-Cancun) yields the minimum price.
+func CalcDataGasPrice(excessDataGas *big.Int) *big.Int {
+	if excessDataGas == nil {
+		return new(big.Int).Set(minDataGasPrice)
+	}
+	return fakeExponential(minDataGasPrice, excessDataGas, dataGasPriceUpdateFraction)
+}
+
+// CountBlobs returns the number of blobs referenced by the given transactions.
+func CountBlobs(txs []*types.Transaction) int {
+	var blobs int
+	for _, tx := range txs {
+		blobs += len(tx.DataHashes())
+	}
+	return blobs
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using
+// Taylor expansion.
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	var (
+		output = new(big.Int)
+		accum  = new(big.Int).Mul(factor, denominator)
+	)
+	for i := 1; accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(int64(i)))
+	}
+	return output.Div(output, denominator)
+}
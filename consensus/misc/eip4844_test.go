@@ -0,0 +1,108 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package misc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCalcExcessDataGas(t *testing.T) {
+	var tests = []struct {
+		excess int64
+		blobs  int
+		want   int64
+	}{
+		// The excess data gas should not increase from zero if the used blob
+		// slots are below - or equal - to the target.
+		{0, 0, 0},
+		{0, 1, 0},
+		{0, params.TargetDataGasPerBlock / params.DataGasPerBlob, 0},
+
+		// If the target blob gas is exceeded, the excessDataGas should increase
+		// by however much it was overshot
+		{0, (params.TargetDataGasPerBlock / params.DataGasPerBlob) + 1, params.DataGasPerBlob},
+		{1, (params.TargetDataGasPerBlock / params.DataGasPerBlob) + 1, params.DataGasPerBlob + 1},
+		{1, (params.TargetDataGasPerBlock / params.DataGasPerBlob) + 2, 2*params.DataGasPerBlob + 1},
+
+		// The excess data gas should decrease by however much the target was
+		// under-shot, capped at zero.
+		{params.TargetDataGasPerBlock, params.TargetDataGasPerBlock / params.DataGasPerBlob, params.TargetDataGasPerBlock},
+		{params.TargetDataGasPerBlock, (params.TargetDataGasPerBlock / params.DataGasPerBlob) - 1, params.TargetDataGasPerBlock - params.DataGasPerBlob},
+		{params.DataGasPerBlob - 1, 0, 0},
+	}
+	for i, tt := range tests {
+		result := CalcExcessDataGas(big.NewInt(tt.excess), tt.blobs)
+		if result.Int64() != tt.want {
+			t.Errorf("test %d: excess data gas mismatch: have %v, want %v", i, result, tt.want)
+		}
+	}
+	if result := CalcExcessDataGas(nil, 0); result.Sign() != 0 {
+		t.Errorf("nil parent excess data gas mismatch: have %v, want 0", result)
+	}
+}
+
+func TestCalcDataGasPrice(t *testing.T) {
+	var tests = []struct {
+		excess int64
+		price  int64
+	}{
+		{0, 1},
+		{1542706, 1},
+		{1542707, 2},
+		{10 * 1024 * 1024, 111},
+	}
+	for i, tt := range tests {
+		have := CalcDataGasPrice(big.NewInt(tt.excess))
+		if have.Int64() != tt.price {
+			t.Errorf("test %d: data gas price mismatch: have %v, want %v", i, have, tt.price)
+		}
+	}
+}
+
+func TestFakeExponential(t *testing.T) {
+	var tests = []struct {
+		factor      int64
+		numerator   int64
+		denominator int64
+		want        int64
+	}{
+		// When numerator == 0 the return value should always equal the value of factor
+		{1, 0, 1, 1},
+		{38493, 0, 1000, 38493},
+		{0, 1234, 2345, 0}, // should be 0
+		{1, 2, 1, 6},       // approximate 7.389
+		{1, 4, 2, 6},
+		{1, 3, 1, 16}, // approximate 20.09
+		{1, 6, 2, 18},
+		{1, 4, 1, 49}, // approximate 54.60
+		{1, 8, 2, 50},
+		{10, 8, 2, 542}, // approximate 540.598
+		{11, 8, 2, 596}, // approximate 600.58
+		{1, 5, 1, 136},  // approximate 148.4
+		{1, 5, 2, 11},   // approximate 12.18
+		{2, 5, 2, 23},   // approximate 24.36
+	}
+	for i, tt := range tests {
+		have := fakeExponential(big.NewInt(tt.factor), big.NewInt(tt.numerator), big.NewInt(tt.denominator))
+		if have.Int64() != tt.want {
+			t.Errorf("test %d: fake exponential mismatch: have %v want %v", i, have, tt.want)
+		}
+	}
+}
@@ -30,9 +30,10 @@ import (
 
 // PayloadAttributesV1 structure described at https://github.com/ethereum/execution-apis/pull/74
 type PayloadAttributesV1 struct {
-	Timestamp             uint64         `json:"timestamp"     gencodec:"required"`
-	Random                common.Hash    `json:"prevRandao"        gencodec:"required"`
-	SuggestedFeeRecipient common.Address `json:"suggestedFeeRecipient"  gencodec:"required"`
+	Timestamp             uint64              `json:"timestamp"     gencodec:"required"`
+	Random                common.Hash         `json:"prevRandao"        gencodec:"required"`
+	SuggestedFeeRecipient common.Address      `json:"suggestedFeeRecipient"  gencodec:"required"`
+	Withdrawals           []*types.Withdrawal `json:"withdrawals"`
 }
 
 // JSON type overrides for PayloadAttributesV1.
@@ -44,20 +45,22 @@ type payloadAttributesMarshaling struct {
 
 // ExecutableDataV1 structure described at https://github.com/ethereum/execution-apis/tree/main/src/engine/specification.md
 type ExecutableDataV1 struct {
-	ParentHash    common.Hash    `json:"parentHash"    gencodec:"required"`
-	FeeRecipient  common.Address `json:"feeRecipient"  gencodec:"required"`
-	StateRoot     common.Hash    `json:"stateRoot"     gencodec:"required"`
-	ReceiptsRoot  common.Hash    `json:"receiptsRoot"  gencodec:"required"`
-	LogsBloom     []byte         `json:"logsBloom"     gencodec:"required"`
-	Random        common.Hash    `json:"prevRandao"    gencodec:"required"`
-	Number        uint64         `json:"blockNumber"   gencodec:"required"`
-	GasLimit      uint64         `json:"gasLimit"      gencodec:"required"`
-	GasUsed       uint64         `json:"gasUsed"       gencodec:"required"`
-	Timestamp     uint64         `json:"timestamp"     gencodec:"required"`
-	ExtraData     []byte         `json:"extraData"     gencodec:"required"`
-	BaseFeePerGas *big.Int       `json:"baseFeePerGas" gencodec:"required"`
-	BlockHash     common.Hash    `json:"blockHash"     gencodec:"required"`
-	Transactions  [][]byte       `json:"transactions"  gencodec:"required"`
+	ParentHash    common.Hash         `json:"parentHash"    gencodec:"required"`
+	FeeRecipient  common.Address      `json:"feeRecipient"  gencodec:"required"`
+	StateRoot     common.Hash         `json:"stateRoot"     gencodec:"required"`
+	ReceiptsRoot  common.Hash         `json:"receiptsRoot"  gencodec:"required"`
+	LogsBloom     []byte              `json:"logsBloom"     gencodec:"required"`
+	Random        common.Hash         `json:"prevRandao"    gencodec:"required"`
+	Number        uint64              `json:"blockNumber"   gencodec:"required"`
+	GasLimit      uint64              `json:"gasLimit"      gencodec:"required"`
+	GasUsed       uint64              `json:"gasUsed"       gencodec:"required"`
+	Timestamp     uint64              `json:"timestamp"     gencodec:"required"`
+	ExtraData     []byte              `json:"extraData"     gencodec:"required"`
+	BaseFeePerGas *big.Int            `json:"baseFeePerGas" gencodec:"required"`
+	BlockHash     common.Hash         `json:"blockHash"     gencodec:"required"`
+	Transactions  [][]byte            `json:"transactions"  gencodec:"required"`
+	Withdrawals   []*types.Withdrawal `json:"withdrawals"`
+	ExcessDataGas *big.Int            `json:"excessDataGas"`
 }
 
 // JSON type overrides for executableData.
@@ -67,11 +70,27 @@ type executableDataMarshaling struct {
 	GasUsed       hexutil.Uint64
 	Timestamp     hexutil.Uint64
 	BaseFeePerGas *hexutil.Big
+	ExcessDataGas *hexutil.Big
 	ExtraData     hexutil.Bytes
 	LogsBloom     hexutil.Bytes
 	Transactions  []hexutil.Bytes
 }
 
+// BlobsBundleV1 holds the blobs of the blob transactions included in a built
+// payload, along with their KZG commitments and proofs.
+type BlobsBundleV1 struct {
+	Commitments []hexutil.Bytes `json:"commitments"`
+	Proofs      []hexutil.Bytes `json:"proofs"`
+	Blobs       []hexutil.Bytes `json:"blobs"`
+}
+
+// ExecutionPayloadEnvelope wraps a built payload along with the blobs of the
+// blob transactions it contains.
+type ExecutionPayloadEnvelope struct {
+	ExecutionPayload *ExecutableDataV1 `json:"executionPayload"`
+	BlobsBundle      *BlobsBundleV1    `json:"blobsBundle"`
+}
+
 type PayloadStatusV1 struct {
 	Status          string       `json:"status"`
 	LatestValidHash *common.Hash `json:"latestValidHash"`
@@ -141,7 +160,9 @@ func decodeTransactions(enc [][]byte) ([]*types.Transaction, error) {
 //	uncleHash = emptyUncleHash
 //	difficulty = 0
 //
-// and that the blockhash of the constructed block matches the parameters.
+// and that the blockhash of the constructed block matches the parameters. A
+// non-nil withdrawal list, even if empty, results in a withdrawals root being
+// set in the header.
 func ExecutableDataToBlock(params ExecutableDataV1) (*types.Block, error) {
 	txs, err := decodeTransactions(params.Transactions)
 	if err != nil {
@@ -174,7 +195,17 @@ func ExecutableDataToBlock(params ExecutableDataV1) (*types.Block, error) {
 		Extra:       params.ExtraData,
 		MixDigest:   params.Random,
 	}
-	block := types.NewBlockWithHeader(header).WithBody(txs, nil /* uncles */)
+	if params.Withdrawals != nil {
+		h := types.DeriveSha(types.Withdrawals(params.Withdrawals), trie.NewStackTrie(nil))
+		header.WithdrawalsHash = &h
+	}
+	if params.ExcessDataGas != nil {
+		if params.ExcessDataGas.Sign() == -1 || params.ExcessDataGas.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid excessDataGas: %v", params.ExcessDataGas)
+		}
+		header.ExcessDataGas = params.ExcessDataGas
+	}
+	block := types.NewBlockWithHeader(header).WithBody(txs, nil /* uncles */).WithWithdrawals(params.Withdrawals)
 	if block.Hash() != params.BlockHash {
 		return nil, fmt.Errorf("blockhash mismatch, want %x, got %x", params.BlockHash, block.Hash())
 	}
@@ -199,5 +230,25 @@ func BlockToExecutableData(block *types.Block) *ExecutableDataV1 {
 		Transactions:  encodeTransactions(block.Transactions()),
 		Random:        block.MixDigest(),
 		ExtraData:     block.Extra(),
+		Withdrawals:   block.Withdrawals(),
+		ExcessDataGas: block.ExcessDataGas(),
+	}
+}
+
+// SidecarsToBlobsBundle constructs the blobs bundle of a payload from the
+// sidecars of the blob transactions it contains.
+func SidecarsToBlobsBundle(sidecars []*types.BlobTxSidecar) *BlobsBundleV1 {
+	bundle := &BlobsBundleV1{
+		Commitments: []hexutil.Bytes{},
+		Proofs:      []hexutil.Bytes{},
+		Blobs:       []hexutil.Bytes{},
+	}
+	for _, sidecar := range sidecars {
+		for i := range sidecar.Blobs {
+			bundle.Commitments = append(bundle.Commitments, hexutil.Bytes(sidecar.Commitments[i][:]))
+			bundle.Proofs = append(bundle.Proofs, hexutil.Bytes(sidecar.Proofs[i][:]))
+			bundle.Blobs = append(bundle.Blobs, hexutil.Bytes(sidecar.Blobs[i][:]))
+		}
 	}
+	return bundle
 }
@@ -17,9 +17,11 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -65,6 +67,48 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
+	// Withdrawals are present after the Shanghai fork.
+	if header.WithdrawalsHash != nil {
+		// Withdrawals list must be present in body after Shanghai.
+		if block.Withdrawals() == nil {
+			return errors.New("missing withdrawals in block body")
+		}
+		if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+			return fmt.Errorf("withdrawals root hash mismatch: have %x, want %x", hash, *header.WithdrawalsHash)
+		}
+	} else if block.Withdrawals() != nil {
+		// Withdrawals are not allowed prior to shanghai fork
+		return errors.New("withdrawals present in block body")
+	}
+	// Blob transactions are allowed after the Cancun fork, their sidecars are
+	// never part of the block body.
+	if header.ExcessDataGas != nil {
+		if blobs := misc.CountBlobs(block.Transactions()); blobs > params.MaxBlobsPerBlock {
+			return fmt.Errorf("too many blobs in block: have %d, max %d", blobs, params.MaxBlobsPerBlock)
+		}
+		for i, tx := range block.Transactions() {
+			if tx.Type() != types.BlobTxType {
+				continue
+			}
+			if tx.BlobTxSidecar() != nil {
+				return fmt.Errorf("blob sidecar present in block body, tx %d", i)
+			}
+			if err := types.ValidateBlobTx(tx); err != nil {
+				return fmt.Errorf("invalid blob transaction %d: %w", i, err)
+			}
+		}
+		if parent := v.bc.GetBlock(block.ParentHash(), block.NumberU64()-1); parent != nil {
+			if err := misc.VerifyEip4844Header(parent.Header(), header, misc.CountBlobs(parent.Transactions())); err != nil {
+				return err
+			}
+		}
+	} else {
+		for i, tx := range block.Transactions() {
+			if tx.Type() == types.BlobTxType {
+				return fmt.Errorf("blob transaction %d present before cancun", i)
+			}
+		}
+	}
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
 		if !v.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
 			return consensus.ErrUnknownAncestor
@@ -134,6 +134,9 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
+	StateHistory        uint64        // Number of blocks from head whose state histories are reserved (path scheme only)
+	HistoryRetain       uint64        // Number of blocks from head whose bodies and receipts are retained (0 = entire chain)
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -146,6 +149,18 @@ var defaultCacheConfig = &CacheConfig{
 	TrieTimeLimit:  5 * time.Minute,
 	SnapshotLimit:  256,
 	SnapshotWait:   true,
+	StateScheme:    rawdb.HashScheme,
+}
+
+// triedbConfig derives the configuration for the trie database.
+func (c *CacheConfig) triedbConfig() *trie.Config {
+	return &trie.Config{
+		Cache:        c.TrieCleanLimit,
+		Journal:      c.TrieCleanJournal,
+		Preimages:    c.Preimages,
+		Scheme:       c.StateScheme,
+		StateHistory: c.StateHistory,
+	}
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -232,11 +247,20 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	txLookupCache, _ := lru.New(txLookupCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 
+	// The path-based scheme only maintains a limited number of recent states,
+	// it can't be used to run an archive node.
+	if cacheConfig.StateScheme == rawdb.PathScheme && cacheConfig.TrieDirtyDisabled {
+		return nil, errors.New("path-based state scheme is not compatible with archive mode")
+	}
+	// Open the trie database with the provided configuration
+	triedb := trie.NewDatabaseWithConfig(db, cacheConfig.triedbConfig())
+
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
 	// stored one from database.
-	chainConfig, genesisHash, genesisErr := SetupGenesisBlockWithOverride(db, genesis, overrides)
+	chainConfig, genesisHash, genesisErr := SetupGenesisBlockWithTrieDB(db, triedb, genesis, overrides)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		triedb.Close()
 		return nil, genesisErr
 	}
 	log.Info("")
@@ -248,15 +272,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	log.Info("")
 
 	bc := &BlockChain{
-		chainConfig: chainConfig,
-		cacheConfig: cacheConfig,
-		db:          db,
-		triegc:      prque.New(nil),
-		stateCache: state.NewDatabaseWithConfig(db, &trie.Config{
-			Cache:     cacheConfig.TrieCleanLimit,
-			Journal:   cacheConfig.TrieCleanJournal,
-			Preimages: cacheConfig.Preimages,
-		}),
+		chainConfig:   chainConfig,
+		cacheConfig:   cacheConfig,
+		db:            db,
+		triegc:        prque.New(nil),
+		stateCache:    state.NewDatabaseWithNodeDB(db, triedb),
 		quit:          make(chan struct{}),
 		chainmu:       syncx.NewClosableMutex(),
 		bodyCache:     bodyCache,
@@ -414,6 +434,12 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		go bc.maintainTxIndex(txIndexBlock)
 	}
 
+	// Start chain history pruner if only recent bodies and receipts are retained.
+	if bc.cacheConfig.HistoryRetain != 0 {
+		bc.wg.Add(1)
+		go bc.maintainHistory()
+	}
+
 	// If periodic cache journal is required, spin it up.
 	if bc.cacheConfig.TrieCleanRejournal > 0 {
 		if bc.cacheConfig.TrieCleanRejournal < time.Minute {
@@ -596,6 +622,15 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 					if root != (common.Hash{}) && !beyondRoot && newHeadBlock.Root() == root {
 						beyondRoot, rootNumber = true, newHeadBlock.NumberU64()
 					}
+					if _, err := state.New(newHeadBlock.Root(), bc.stateCache, bc.snaps); err != nil && bc.stateCache.TrieDB().Recoverable(newHeadBlock.Root()) {
+						// In the path-based scheme the state can be reverted with the
+						// state histories, try it before rewinding further.
+						if err := bc.stateCache.TrieDB().Recover(newHeadBlock.Root()); err != nil {
+							log.Error("Failed to recover state", "number", newHeadBlock.NumberU64(), "root", newHeadBlock.Root(), "err", err)
+						} else {
+							log.Info("Recovered state from histories", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash(), "root", newHeadBlock.Root())
+						}
+					}
 					if _, err := state.New(newHeadBlock.Root(), bc.stateCache, bc.snaps); err != nil {
 						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
 						if pivot == nil || newHeadBlock.NumberU64() > *pivot {
@@ -618,8 +653,13 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 							// rewinding destination can be the earliest block stored in the chain
 							// if the historical chain pruning is enabled. In that case the logic
 							// needs to be improved here.
+							if !bc.HasState(bc.genesisBlock.Root()) && bc.stateCache.TrieDB().Recoverable(bc.genesisBlock.Root()) {
+								if err := bc.stateCache.TrieDB().Recover(bc.genesisBlock.Root()); err != nil {
+									log.Error("Failed to recover genesis state", "err", err)
+								}
+							}
 							if !bc.HasState(bc.genesisBlock.Root()) {
-								if err := CommitGenesisState(bc.db, bc.genesisBlock.Hash()); err != nil {
+								if err := CommitGenesisState(bc.db, bc.stateCache.TrieDB(), bc.genesisBlock.Hash()); err != nil {
 									log.Crit("Failed to commit genesis state", "err", err)
 								}
 								log.Debug("Recommitted genesis state to disk")
@@ -722,6 +762,28 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 	return rootNumber, bc.loadLastState()
 }
 
+// PersistState flushes the state with the given root from the in-memory trie
+// database into disk, keeping it accessible after it's dropped from memory. It's
+// only supported in the hash-based scheme.
+func (bc *BlockChain) PersistState(root common.Hash) error {
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	triedb := bc.stateCache.TrieDB()
+	if triedb.Scheme() == rawdb.PathScheme {
+		return errors.New("not supported in path scheme")
+	}
+	if err := triedb.Commit(root, false, nil); err != nil {
+		return err
+	}
+	if root != types.EmptyRootHash && !rawdb.HasTrieNode(bc.db, root) {
+		return fmt.Errorf("state %x is not available", root)
+	}
+	return nil
+}
+
 // SnapSyncCommitHead sets the current head block to the one defined by the hash
 // irrelevant what the chain contents were prior.
 func (bc *BlockChain) SnapSyncCommitHead(hash common.Hash) error {
@@ -730,6 +792,13 @@ func (bc *BlockChain) SnapSyncCommitHead(hash common.Hash) error {
 	if block == nil {
 		return fmt.Errorf("non existent block [%x..]", hash[:4])
 	}
+	// In the path-based scheme the synced state is written into disk directly,
+	// reset the trie database on top of it.
+	if bc.stateCache.TrieDB().Scheme() == rawdb.PathScheme {
+		if err := bc.stateCache.TrieDB().Reset(block.Root()); err != nil {
+			return err
+		}
+	}
 	if _, err := trie.NewStateTrie(common.Hash{}, block.Root(), bc.stateCache.TrieDB()); err != nil {
 		return err
 	}
@@ -891,7 +960,14 @@ func (bc *BlockChain) Stop() {
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
 	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
 	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
-	if !bc.cacheConfig.TrieDirtyDisabled {
+	//
+	// In the path-based scheme the in-memory layers are journalled instead,
+	// they will be reloaded on the next startup.
+	if bc.stateCache.TrieDB().Scheme() == rawdb.PathScheme {
+		if err := bc.stateCache.TrieDB().Journal(bc.CurrentBlock().Root()); err != nil {
+			log.Error("Failed to journal in-memory trie nodes", "err", err)
+		}
+	} else if !bc.cacheConfig.TrieDirtyDisabled {
 		triedb := bc.stateCache.TrieDB()
 
 		for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
@@ -927,6 +1003,10 @@ func (bc *BlockChain) Stop() {
 		triedb := bc.stateCache.TrieDB()
 		triedb.SaveCache(bc.cacheConfig.TrieCleanJournal)
 	}
+	// Close the trie database, release all the held resources.
+	if err := bc.stateCache.TrieDB().Close(); err != nil {
+		log.Error("Failed to close trie database", "err", err)
+	}
 	log.Info("Blockchain stopped")
 }
 
@@ -1300,6 +1380,12 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	}
 	triedb := bc.stateCache.TrieDB()
 
+	// In the path-based scheme the state changes are already tracked as an
+	// in-memory layer, only cap the layers to the allowance and the bottom
+	// ones are flattened into disk.
+	if triedb.Scheme() == rawdb.PathScheme {
+		return triedb.CapLayers(root, TriesInMemory)
+	}
 	// If we're running an archive node, always flush
 	if bc.cacheConfig.TrieDirtyDisabled {
 		return triedb.Commit(root, false, nil)
@@ -2374,6 +2460,38 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 	}
 }
 
+// maintainHistory is responsible for deleting the bodies and receipts of the
+// blocks outside the configured retention window. Only the data which has
+// already been moved into the ancient store is pruned.
+//
+// The user can adjust the retention window at any time, but widening it will
+// not bring the pruned history back.
+func (bc *BlockChain) maintainHistory() {
+	defer bc.wg.Done()
+
+	headCh := make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	if sub == nil {
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-headCh:
+			number := head.Block.NumberU64()
+			if number < bc.cacheConfig.HistoryRetain {
+				continue
+			}
+			if err := rawdb.PruneChainHistory(bc.db, number-bc.cacheConfig.HistoryRetain+1); err != nil {
+				log.Warn("Failed to prune chain history", "err", err)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
 	rawdb.WriteBadBlock(bc.db, block)
@@ -225,6 +225,24 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// HistoryPruningCutoff returns the number of the first block whose body and
+// receipts are retained in the database. The history of all the canonical
+// blocks below it, apart from the genesis, has been pruned.
+func (bc *BlockChain) HistoryPruningCutoff() uint64 {
+	return rawdb.ReadHistoryTail(bc.db)
+}
+
+// IsHistoryPruned reports whether the body and receipts of the block with the
+// given hash have been pruned from the database. Only canonical blocks can be
+// pruned.
+func (bc *BlockChain) IsHistoryPruned(hash common.Hash) bool {
+	number := bc.hc.GetBlockNumber(hash)
+	if number == nil || *number == 0 || *number >= bc.HistoryPruningCutoff() {
+		return false
+	}
+	return bc.GetCanonicalHash(*number) == hash
+}
+
 // GetUnclesInChain retrieves all the uncles from a given block backwards until
 // a specific distance is reached.
 func (bc *BlockChain) GetUnclesInChain(block *types.Block, length int) []*types.Header {
@@ -2590,6 +2590,87 @@ func TestTransactionIndices(t *testing.T) {
 	}
 }
 
+// Tests that the pruned chain history is reported as such, retaining the headers
+// and the genesis block.
+func TestHistoryPruning(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(100000000000000000)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: funds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 128, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	ancientDb, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer ancientDb.Close()
+
+	// Import all blocks into ancient db and prune the older half
+	chain, err := NewBlockChain(ancientDb, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers, 0); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, 128); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.Stop()
+
+	if err := rawdb.PruneChainHistory(ancientDb, 64); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	// Reopen the chain, the genesis must still be available
+	chain, err = NewBlockChain(ancientDb, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if cutoff := chain.HistoryPruningCutoff(); cutoff != 64 {
+		t.Fatalf("pruning cutoff mismatch: have %d, want 64", cutoff)
+	}
+	if chain.Genesis() == nil || chain.IsHistoryPruned(chain.Genesis().Hash()) {
+		t.Fatal("genesis is pruned")
+	}
+	for _, block := range blocks {
+		var (
+			hash   = block.Hash()
+			number = block.NumberU64()
+			pruned = number < 64
+		)
+		if chain.GetHeaderByNumber(number) == nil {
+			t.Fatalf("block %d: header is missing", number)
+		}
+		if have := chain.IsHistoryPruned(hash); have != pruned {
+			t.Fatalf("block %d: pruned mismatch, have %v, want %v", number, have, pruned)
+		}
+		if have := chain.GetBlockByNumber(number) == nil; have != pruned {
+			t.Fatalf("block %d: body mismatch, missing %v, pruned %v", number, have, pruned)
+		}
+		if have := chain.GetReceiptsByHash(hash) == nil; have != pruned {
+			t.Fatalf("block %d: receipts mismatch, missing %v, pruned %v", number, have, pruned)
+		}
+	}
+}
+
 func TestSkipStaleTxIndicesInSnapSync(t *testing.T) {
 	// Configure and generate a sample block chain
 	var (
@@ -3784,3 +3865,135 @@ func TestCanonicalHashMarker(t *testing.T) {
 		}
 	}
 }
+
+// Tests that withdrawals are credited to their recipients post-Shanghai and
+// that the withdrawals are persisted along with the block bodies.
+func TestWithdrawals(t *testing.T) {
+	var (
+		aa     = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		bb     = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		config = *params.TestChainConfig
+		engine = beacon.New(ethash.NewFaker())
+	)
+	config.TerminalTotalDifficulty = common.Big0
+	config.ShanghaiBlock = common.Big0
+
+	gspec := &Genesis{Config: &config, Difficulty: common.Big0}
+	if gspec.ToBlock().Header().WithdrawalsHash == nil {
+		t.Fatalf("genesis withdrawals root missing")
+	}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 2, func(i int, b *BlockGen) {
+		b.SetDifficulty(common.Big0)
+		b.AddWithdrawal(&types.Withdrawal{Validator: 1, Address: aa, Amount: 5})
+		if i == 1 {
+			b.AddWithdrawal(&types.Withdrawal{Validator: 2, Address: bb, Amount: 3})
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Ensure blocks carrying tampered withdrawals are rejected
+	bad := blocks[0].WithWithdrawals([]*types.Withdrawal{{Index: 0, Validator: 1, Address: bb, Amount: 5}})
+	if err := chain.Validator().ValidateBody(bad); err == nil || errors.Is(err, ErrKnownBlock) {
+		t.Errorf("tampered withdrawals accepted: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	state, _ := chain.State()
+	gwei := big.NewInt(params.GWei)
+	if have, want := state.GetBalance(aa), new(big.Int).Mul(big.NewInt(10), gwei); have.Cmp(want) != 0 {
+		t.Errorf("balance mismatch for aa: have %v, want %v", have, want)
+	}
+	if have, want := state.GetBalance(bb), new(big.Int).Mul(big.NewInt(3), gwei); have.Cmp(want) != 0 {
+		t.Errorf("balance mismatch for bb: have %v, want %v", have, want)
+	}
+	head := chain.GetBlockByNumber(2)
+	if len(head.Withdrawals()) != 2 {
+		t.Fatalf("withdrawal count mismatch: have %d, want 2", len(head.Withdrawals()))
+	}
+	for i, w := range head.Withdrawals() {
+		if w.Index != uint64(i+1) {
+			t.Errorf("withdrawal %d: index mismatch: have %d, want %d", i, w.Index, i+1)
+		}
+	}
+}
+
+// Tests that in the path-based scheme the chain can be rewound beyond the
+// in-memory states by reverting the persistent state with state histories,
+// and that the in-memory states survive a restart.
+func TestPathSchemeSetHead(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(100000000000000000)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: funds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2*TriesInMemory, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{byte(i)}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("Failed to create persistent database: %v", err)
+	}
+	defer db.Close()
+
+	config := *defaultCacheConfig
+	config.StateScheme = rawdb.PathScheme
+
+	chain, err := NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("Failed to insert block %d: %v", n, err)
+	}
+	if !chain.HasState(blocks[len(blocks)-1].Root()) {
+		t.Fatal("Head state is missing")
+	}
+	// The states beyond the in-memory layers are only reachable via histories
+	target := blocks[TriesInMemory/2]
+	if chain.HasState(target.Root()) {
+		t.Fatal("Historical state is unexpectedly available")
+	}
+	if err := chain.SetHead(target.NumberU64()); err != nil {
+		t.Fatalf("Failed to rewind chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != target.Hash() {
+		t.Fatalf("Head block mismatch, want %d, have %d", target.NumberU64(), head.NumberU64())
+	}
+	if !chain.HasState(target.Root()) {
+		t.Fatal("Rewound state is missing")
+	}
+	// Re-import the rewound blocks and ensure the head state is retained
+	// across a restart.
+	if n, err := chain.InsertChain(blocks[target.NumberU64():]); err != nil {
+		t.Fatalf("Failed to reinsert block %d: %v", n, err)
+	}
+	chain.Stop()
+
+	chain, err = NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to recreate chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("Head block mismatch after restart, want %d, have %d", len(blocks), head.NumberU64())
+	}
+	if !chain.HasState(blocks[len(blocks)-2].Root()) {
+		t.Fatal("In-memory state is missing after restart")
+	}
+}
@@ -45,6 +45,8 @@ type BlockGen struct {
 	receipts []*types.Receipt
 	uncles   []*types.Header
 
+	withdrawals []*types.Withdrawal
+
 	config *params.ChainConfig
 	engine consensus.Engine
 }
@@ -181,6 +183,30 @@ func (b *BlockGen) AddUncle(h *types.Header) {
 	b.uncles = append(b.uncles, h)
 }
 
+// AddWithdrawal adds a withdrawal to the generated block. The withdrawal
+// index is assigned automatically, continuing from the previous blocks.
+func (b *BlockGen) AddWithdrawal(w *types.Withdrawal) {
+	cpy := *w
+	cpy.Index = b.nextWithdrawalIndex()
+	b.withdrawals = append(b.withdrawals, &cpy)
+}
+
+// nextWithdrawalIndex computes the index of the next withdrawal.
+func (b *BlockGen) nextWithdrawalIndex() uint64 {
+	if len(b.withdrawals) != 0 {
+		return b.withdrawals[len(b.withdrawals)-1].Index + 1
+	}
+	for i := b.i - 1; i >= 0; i-- {
+		if wd := b.chain[i].Withdrawals(); len(wd) != 0 {
+			return wd[len(wd)-1].Index + 1
+		}
+	}
+	if wd := b.parent.Withdrawals(); len(wd) != 0 {
+		return wd[len(wd)-1].Index + 1
+	}
+	return 0
+}
+
 // PrevBlock returns a previously generated block by number. It panics if
 // num is greater or equal to the number of the block being generated.
 // For index -1, PrevBlock returns the parent block given to GenerateChain.
@@ -258,7 +284,7 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		}
 		if b.engine != nil {
 			// Finalize and seal the block
-			block, _ := b.engine.FinalizeAndAssemble(chainreader, b.header, statedb, b.txs, b.uncles, b.receipts)
+			block, _ := b.engine.FinalizeAndAssemble(chainreader, b.header, statedb, b.txs, b.uncles, b.receipts, b.withdrawals)
 
 			// Write state changes to db
 			root, err := statedb.Commit(config.IsEIP158(b.header.Number))
@@ -326,6 +352,9 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 			header.GasLimit = CalcGasLimit(parentGasLimit, parentGasLimit)
 		}
 	}
+	if chain.Config().IsCancun(header.Number) {
+		header.ExcessDataGas = misc.CalcExcessDataGas(parent.Header().ExcessDataGas, misc.CountBlobs(parent.Transactions()))
+	}
 	return header
 }
 
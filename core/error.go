@@ -32,6 +32,10 @@ var (
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
 
+	// ErrHistoryPruned is returned when the requested block body or receipts
+	// have been pruned from the local database.
+	ErrHistoryPruned = errors.New("pruned history unavailable")
+
 	errSideChainReceipts = errors.New("side blocks can't be accepted as ancient chain data")
 )
 
@@ -96,4 +100,8 @@ var (
 
 	// ErrSenderNoEOA is returned if the sender of a transaction is a contract.
 	ErrSenderNoEOA = errors.New("sender not an eoa")
+
+	// ErrDataFeeCapTooLow is returned if the data gas fee cap of a blob
+	// transaction is less than the data gas price of the block.
+	ErrDataFeeCapTooLow = errors.New("max fee per data gas less than block data gas price")
 )
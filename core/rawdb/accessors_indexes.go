@@ -106,6 +106,11 @@ func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, com
 	}
 	body := ReadBody(db, blockHash, *blockNumber)
 	if body == nil {
+		// The lookups of the pruned chain history are retained, don't
+		// report them as corruptions.
+		if *blockNumber < ReadHistoryTail(db) {
+			return nil, common.Hash{}, 0, 0
+		}
 		log.Error("Transaction referenced missing", "number", *blockNumber, "hash", blockHash)
 		return nil, common.Hash{}, 0, 0
 	}
@@ -132,6 +137,9 @@ func ReadReceipt(db ethdb.Reader, hash common.Hash, config *params.ChainConfig)
 	}
 	// Read all the receipts from the block and return the one with the matching hash
 	receipts := ReadReceipts(db, blockHash, *blockNumber, config)
+	if receipts == nil && *blockNumber < ReadHistoryTail(db) {
+		return nil, common.Hash{}, 0, 0
+	}
 	for receiptIndex, receipt := range receipts {
 		if receipt.TxHash == hash {
 			return receipt, blockHash, *blockNumber, uint64(receiptIndex)
@@ -177,3 +177,43 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	check(1, 1, params.RinkebyGenesisHash, true)
 }
+
+// Tests that block traces are stored per tracer config and decompressed on read.
+func TestBlockTracesStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var (
+		hash    = common.Hash{0x01}
+		config1 = common.Hash{0x02}
+		config2 = common.Hash{0x03}
+		traces  = bytes.Repeat([]byte(`{"result":{"type":"CALL"}},`), 16)
+	)
+	if HasBlockTraces(db, 1, hash, config1) || ReadBlockTraces(db, 1, hash, config1) != nil {
+		t.Fatalf("non existent traces returned")
+	}
+	WriteBlockTraces(db, 1, hash, config1, traces)
+	if !HasBlockTraces(db, 1, hash, config1) {
+		t.Fatalf("stored traces not found")
+	}
+	if have := ReadBlockTraces(db, 1, hash, config1); !bytes.Equal(have, traces) {
+		t.Fatalf("traces mismatch: have %s, want %s", have, traces)
+	}
+	if raw, _ := db.Get(blockTracesKey(1, hash, config1)); len(raw) >= len(traces) {
+		t.Errorf("traces not compressed: %d >= %d bytes", len(raw), len(traces))
+	}
+	if ReadBlockTraces(db, 1, hash, config2) != nil {
+		t.Fatalf("traces returned for different tracer config")
+	}
+	WriteBlockTraces(db, 1, hash, config2, traces)
+	WriteBlockTraces(db, 2, hash, config1, traces)
+	if hashes, configs := ReadAllBlockTraceIDs(db, 1); len(hashes) != 2 || hashes[0] != hash || hashes[1] != hash || len(configs) != 2 {
+		t.Fatalf("trace ids mismatch: have %x/%x", hashes, configs)
+	}
+	DeleteBlockTraces(db, 1, hash, config1)
+	if HasBlockTraces(db, 1, hash, config1) {
+		t.Fatalf("deleted traces still present")
+	}
+	if hashes, configs := ReadAllBlockTraceIDs(db, 1); len(hashes) != 1 || configs[0] != config2 {
+		t.Fatalf("trace ids mismatch after deletion: have %x/%x", hashes, configs)
+	}
+}
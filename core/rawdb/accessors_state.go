@@ -17,6 +17,8 @@
 package rawdb
 
 import (
+	"encoding/binary"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
@@ -118,3 +120,74 @@ func DeleteTrieNode(db ethdb.KeyValueWriter, hash common.Hash) {
 		log.Crit("Failed to delete trie node", "err", err)
 	}
 }
+
+// ReadStatePruningStatus retrieves the serialized online state pruning status
+// saved at the last shutdown.
+func ReadStatePruningStatus(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(statePruningKey)
+	return data
+}
+
+// WriteStatePruningStatus stores the serialized online state pruning status to
+// resume the pruning after restarts.
+func WriteStatePruningStatus(db ethdb.KeyValueWriter, status []byte) {
+	if err := db.Put(statePruningKey, status); err != nil {
+		log.Crit("Failed to store state pruning status", "err", err)
+	}
+}
+
+// DeleteStatePruningStatus deletes the serialized online state pruning status.
+func DeleteStatePruningStatus(db ethdb.KeyValueWriter) {
+	if err := db.Delete(statePruningKey); err != nil {
+		log.Crit("Failed to remove state pruning status", "err", err)
+	}
+}
+
+// WriteStatePruningLog stores the concatenated hashes of the trie nodes flushed
+// into disk while the online state pruning is running.
+func WriteStatePruningLog(db ethdb.KeyValueWriter, seq uint64, hashes []byte) {
+	if err := db.Put(statePruningLogKey(seq), hashes); err != nil {
+		log.Crit("Failed to store state pruning log", "err", err)
+	}
+}
+
+// ReadStatePruningLogs iterates all the stored state pruning logs in order,
+// invoking the callback with the sequence number and the concatenated hashes.
+func ReadStatePruningLogs(db ethdb.Iteratee, fn func(seq uint64, hashes []byte) error) error {
+	it := db.NewIterator(statePruningLogPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(statePruningLogPrefix)+8 {
+			continue
+		}
+		if err := fn(binary.BigEndian.Uint64(key[len(statePruningLogPrefix):]), it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// DeleteStatePruningLogs deletes all the stored state pruning logs.
+func DeleteStatePruningLogs(db ethdb.KeyValueStore) {
+	it := db.NewIterator(statePruningLogPrefix, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if len(it.Key()) != len(statePruningLogPrefix)+8 {
+			continue
+		}
+		batch.Delete(it.Key())
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to remove state pruning logs", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to remove state pruning logs", "err", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang/snappy"
+)
+
+// ReadBlockTraces retrieves the traces of all the transactions in a block, as
+// produced by the tracer config with the given hash. Nil is returned if the
+// block was not traced with that config.
+func ReadBlockTraces(db ethdb.KeyValueReader, number uint64, hash common.Hash, config common.Hash) []byte {
+	data, _ := db.Get(blockTracesKey(number, hash, config))
+	if len(data) == 0 {
+		return nil
+	}
+	traces, err := snappy.Decode(nil, data)
+	if err != nil {
+		log.Error("Invalid block traces", "number", number, "hash", hash, "config", config, "err", err)
+		return nil
+	}
+	return traces
+}
+
+// HasBlockTraces verifies the existence of the traces of a block produced by
+// the tracer config with the given hash.
+func HasBlockTraces(db ethdb.KeyValueReader, number uint64, hash common.Hash, config common.Hash) bool {
+	has, _ := db.Has(blockTracesKey(number, hash, config))
+	return has
+}
+
+// WriteBlockTraces stores the traces of all the transactions in a block, as
+// produced by the tracer config with the given hash, in compressed form.
+func WriteBlockTraces(db ethdb.KeyValueWriter, number uint64, hash common.Hash, config common.Hash, traces []byte) {
+	if err := db.Put(blockTracesKey(number, hash, config), snappy.Encode(nil, traces)); err != nil {
+		log.Crit("Failed to store block traces", "err", err)
+	}
+}
+
+// ReadAllBlockTraceIDs retrieves the hashes of all the blocks with the given
+// number having traces stored, along with the hashes of the tracer configs that
+// produced them. A block traced with multiple configs is returned repeatedly.
+func ReadAllBlockTraceIDs(db ethdb.Iteratee, number uint64) ([]common.Hash, []common.Hash) {
+	prefix := blockTracesKeyPrefix(number)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var hashes, configs []common.Hash
+	for it.Next() {
+		if key := it.Key(); len(key) == len(prefix)+2*common.HashLength {
+			hashes = append(hashes, common.BytesToHash(key[len(prefix):len(prefix)+common.HashLength]))
+			configs = append(configs, common.BytesToHash(key[len(prefix)+common.HashLength:]))
+		}
+	}
+	return hashes, configs
+}
+
+// DeleteBlockTraces removes the traces of a block produced by the tracer config
+// with the given hash.
+func DeleteBlockTraces(db ethdb.KeyValueWriter, number uint64, hash common.Hash, config common.Hash) {
+	if err := db.Delete(blockTracesKey(number, hash, config)); err != nil {
+		log.Crit("Failed to delete block traces", "err", err)
+	}
+}
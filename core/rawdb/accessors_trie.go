@@ -0,0 +1,339 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/crypto/sha3"
+)
+
+// The list of state schemes supported by the trie database.
+const (
+	// HashScheme is the legacy hash-based state scheme with which trie nodes
+	// are stored in the disk with node hash as the database key. The advantage
+	// of this scheme is that different versions of trie nodes can be stored
+	// in disk, which is very beneficial for constructing archive nodes. The
+	// drawback is it will store different trie nodes on the same path to
+	// different locations on the disk with no data locality, and it's
+	// unfriendly for designing state pruning.
+	HashScheme = "hash"
+
+	// PathScheme is the new path-based state scheme with which trie nodes are
+	// stored in the disk with node path as the database key. This scheme will
+	// only store one version of state data in the disk, which means that the
+	// state pruning operation is native. At the same time, this scheme will
+	// put adjacent trie nodes in the same area of the disk with good data
+	// locality property.
+	PathScheme = "path"
+)
+
+// nodeHasher is used to compute the keccak256 hash of the provided data.
+type nodeHasher struct{ sha crypto.KeccakState }
+
+var nodeHasherPool = sync.Pool{
+	New: func() interface{} { return &nodeHasher{sha: sha3.NewLegacyKeccak256().(crypto.KeccakState)} },
+}
+
+func newNodeHasher() *nodeHasher {
+	return nodeHasherPool.Get().(*nodeHasher)
+}
+
+func (h *nodeHasher) hash(data []byte) common.Hash {
+	return crypto.HashData(h.sha, data)
+}
+
+func (h *nodeHasher) release() {
+	nodeHasherPool.Put(h)
+}
+
+// ReadAccountTrieNode retrieves the account trie node and the associated node
+// hash with the specified node path.
+func ReadAccountTrieNode(db ethdb.KeyValueReader, path []byte) ([]byte, common.Hash) {
+	data, err := db.Get(accountTrieNodeKey(path))
+	if err != nil {
+		return nil, common.Hash{}
+	}
+	h := newNodeHasher()
+	defer h.release()
+	return data, h.hash(data)
+}
+
+// HasAccountTrieNode checks the account trie node presence with the specified
+// node path and the associated node hash.
+func HasAccountTrieNode(db ethdb.KeyValueReader, path []byte, hash common.Hash) bool {
+	data, err := db.Get(accountTrieNodeKey(path))
+	if err != nil {
+		return false
+	}
+	h := newNodeHasher()
+	defer h.release()
+	return h.hash(data) == hash
+}
+
+// WriteAccountTrieNode writes the provided account trie node into database.
+func WriteAccountTrieNode(db ethdb.KeyValueWriter, path []byte, node []byte) {
+	if err := db.Put(accountTrieNodeKey(path), node); err != nil {
+		log.Crit("Failed to store account trie node", "err", err)
+	}
+}
+
+// DeleteAccountTrieNode deletes the specified account trie node from the database.
+func DeleteAccountTrieNode(db ethdb.KeyValueWriter, path []byte) {
+	if err := db.Delete(accountTrieNodeKey(path)); err != nil {
+		log.Crit("Failed to delete account trie node", "err", err)
+	}
+}
+
+// ReadStorageTrieNode retrieves the storage trie node and the associated node
+// hash with the specified node path.
+func ReadStorageTrieNode(db ethdb.KeyValueReader, accountHash common.Hash, path []byte) ([]byte, common.Hash) {
+	data, err := db.Get(storageTrieNodeKey(accountHash, path))
+	if err != nil {
+		return nil, common.Hash{}
+	}
+	h := newNodeHasher()
+	defer h.release()
+	return data, h.hash(data)
+}
+
+// HasStorageTrieNode checks the storage trie node presence with the provided
+// node path and the associated node hash.
+func HasStorageTrieNode(db ethdb.KeyValueReader, accountHash common.Hash, path []byte, hash common.Hash) bool {
+	data, err := db.Get(storageTrieNodeKey(accountHash, path))
+	if err != nil {
+		return false
+	}
+	h := newNodeHasher()
+	defer h.release()
+	return h.hash(data) == hash
+}
+
+// WriteStorageTrieNode writes the provided storage trie node into database.
+func WriteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, path []byte, node []byte) {
+	if err := db.Put(storageTrieNodeKey(accountHash, path), node); err != nil {
+		log.Crit("Failed to store storage trie node", "err", err)
+	}
+}
+
+// DeleteStorageTrieNode deletes the specified storage trie node from the database.
+func DeleteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, path []byte) {
+	if err := db.Delete(storageTrieNodeKey(accountHash, path)); err != nil {
+		log.Crit("Failed to delete storage trie node", "err", err)
+	}
+}
+
+// ReadTrieNodeWithPath retrieves the trie node and the associated node hash
+// from the path-based storage. The owner is zero for account trie nodes and
+// the owning account hash for storage trie nodes.
+func ReadTrieNodeWithPath(db ethdb.KeyValueReader, owner common.Hash, path []byte) ([]byte, common.Hash) {
+	if owner == (common.Hash{}) {
+		return ReadAccountTrieNode(db, path)
+	}
+	return ReadStorageTrieNode(db, owner, path)
+}
+
+// WriteTrieNodeWithPath writes the trie node into the path-based storage.
+func WriteTrieNodeWithPath(db ethdb.KeyValueWriter, owner common.Hash, path []byte, node []byte) {
+	if owner == (common.Hash{}) {
+		WriteAccountTrieNode(db, path, node)
+	} else {
+		WriteStorageTrieNode(db, owner, path, node)
+	}
+}
+
+// DeleteTrieNodeWithPath deletes the trie node from the path-based storage.
+func DeleteTrieNodeWithPath(db ethdb.KeyValueWriter, owner common.Hash, path []byte) {
+	if owner == (common.Hash{}) {
+		DeleteAccountTrieNode(db, path)
+	} else {
+		DeleteStorageTrieNode(db, owner, path)
+	}
+}
+
+// HasTrieNodeWithScheme checks the trie node presence with the provided node
+// info and the associated node hash in the given state scheme.
+func HasTrieNodeWithScheme(db ethdb.KeyValueReader, owner common.Hash, path []byte, hash common.Hash, scheme string) bool {
+	switch scheme {
+	case HashScheme:
+		return HasTrieNode(db, hash)
+	case PathScheme:
+		if owner == (common.Hash{}) {
+			return HasAccountTrieNode(db, path, hash)
+		}
+		return HasStorageTrieNode(db, owner, path, hash)
+	default:
+		panic(fmt.Sprintf("Unknown scheme %v", scheme))
+	}
+}
+
+// WriteTrieNodeWithScheme writes the trie node into database with the provided
+// node info in the given state scheme.
+func WriteTrieNodeWithScheme(db ethdb.KeyValueWriter, owner common.Hash, path []byte, hash common.Hash, node []byte, scheme string) {
+	switch scheme {
+	case HashScheme:
+		WriteTrieNode(db, hash, node)
+	case PathScheme:
+		WriteTrieNodeWithPath(db, owner, path, node)
+	default:
+		panic(fmt.Sprintf("Unknown scheme %v", scheme))
+	}
+}
+
+// ReadPersistentStateID retrieves the id of the persistent state from the database.
+func ReadPersistentStateID(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(persistentStateIDKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// HasPersistentStateID reports whether the persistent state id is present,
+// which is the marker of a path-based state database.
+func HasPersistentStateID(db ethdb.KeyValueReader) bool {
+	ok, _ := db.Has(persistentStateIDKey)
+	return ok
+}
+
+// WritePersistentStateID stores the id of the persistent state into database.
+func WritePersistentStateID(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(persistentStateIDKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the persistent state ID", "err", err)
+	}
+}
+
+// ReadStateID retrieves the state id with the provided state root.
+func ReadStateID(db ethdb.KeyValueReader, root common.Hash) *uint64 {
+	data, err := db.Get(stateIDKey(root))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteStateID writes the provided state lookup to database.
+func WriteStateID(db ethdb.KeyValueWriter, root common.Hash, id uint64) {
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], id)
+	if err := db.Put(stateIDKey(root), buff[:]); err != nil {
+		log.Crit("Failed to store state ID", "err", err)
+	}
+}
+
+// DeleteStateID deletes the specified state lookup from the database.
+func DeleteStateID(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Delete(stateIDKey(root)); err != nil {
+		log.Crit("Failed to delete state ID", "err", err)
+	}
+}
+
+// ReadTrieJournal retrieves the serialized in-memory trie node layers saved at
+// the last shutdown. The blob is expected to be max a few 10s of megabytes.
+func ReadTrieJournal(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(trieJournalKey)
+	return data
+}
+
+// WriteTrieJournal stores the serialized in-memory trie node layers to save at
+// shutdown. The blob is expected to be max a few 10s of megabytes.
+func WriteTrieJournal(db ethdb.KeyValueWriter, journal []byte) {
+	if err := db.Put(trieJournalKey, journal); err != nil {
+		log.Crit("Failed to store tries journal", "err", err)
+	}
+}
+
+// DeleteTrieJournal deletes the serialized in-memory trie node layers saved at
+// the last shutdown.
+func DeleteTrieJournal(db ethdb.KeyValueWriter) {
+	if err := db.Delete(trieJournalKey); err != nil {
+		log.Crit("Failed to remove tries journal", "err", err)
+	}
+}
+
+// ReadStateHistory retrieves the state history with the provided id from the
+// state freezer. The id of the first state history is 1, stored as the item 0.
+func ReadStateHistory(db ethdb.AncientReaderOp, id uint64) []byte {
+	blob, err := db.Ancient(stateHistoryTable, id-1)
+	if err != nil {
+		return nil
+	}
+	return blob
+}
+
+// WriteStateHistory writes the provided state history into the state freezer.
+func WriteStateHistory(db ethdb.AncientWriter, id uint64, blob []byte) error {
+	_, err := db.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		return op.AppendRaw(stateHistoryTable, id-1, blob)
+	})
+	return err
+}
+
+// ReadStateScheme reads the state scheme of persistent state, or none
+// if the state is not present in database.
+func ReadStateScheme(db ethdb.Reader) string {
+	// Check if state in path-based scheme is present
+	if HasPersistentStateID(db) {
+		return PathScheme
+	}
+	if blob, _ := ReadAccountTrieNode(db, nil); len(blob) != 0 {
+		return PathScheme
+	}
+	// In a hash-based scheme, the genesis state is consistently stored
+	// on the disk. To assess the scheme of the persistent state, it
+	// suffices to inspect the scheme of the genesis state.
+	header := ReadHeader(db, ReadCanonicalHash(db, 0), 0)
+	if header == nil {
+		return "" // empty datadir
+	}
+	if !HasTrieNode(db, header.Root) {
+		return "" // no state in disk
+	}
+	return HashScheme
+}
+
+// ParseStateScheme checks if the specified state scheme is compatible with
+// the stored state. If the user didn't explicitly specify a scheme, the
+// scheme of the stored state is used, falling back to the hash scheme for
+// empty databases.
+func ParseStateScheme(provided string, disk ethdb.Database) (string, error) {
+	if provided != "" && provided != HashScheme && provided != PathScheme {
+		return "", fmt.Errorf("invalid state scheme %q, allowed %q or %q", provided, HashScheme, PathScheme)
+	}
+	stored := ReadStateScheme(disk)
+	if provided == "" {
+		if stored == "" {
+			log.Info("State scheme set to default", "scheme", HashScheme)
+			return HashScheme, nil // use default scheme for empty database
+		}
+		log.Info("State scheme set to already existing", "scheme", stored)
+		return stored, nil // reuse scheme of persistent scheme
+	}
+	// If state scheme is specified, ensure it's compatible with persistent state.
+	if stored == "" || provided == stored {
+		log.Info("State scheme set by user", "scheme", provided)
+		return provided, nil
+	}
+	return "", fmt.Errorf("incompatible state scheme, stored: %s, provided: %s", stored, provided)
+}
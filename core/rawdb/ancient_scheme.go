@@ -16,7 +16,10 @@
 
 package rawdb
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+)
 
 // The list of table names of chain freezer.
 const (
@@ -36,23 +39,45 @@ const (
 	chainFreezerDifficultyTable = "diffs"
 )
 
-// chainFreezerNoSnappy configures whether compression is disabled for the ancient-tables.
-// Hashes and difficulties don't compress well.
-var chainFreezerNoSnappy = map[string]bool{
-	chainFreezerHeaderTable:     false,
-	chainFreezerHashTable:       true,
-	chainFreezerBodiesTable:     false,
-	chainFreezerReceiptTable:    false,
-	chainFreezerDifficultyTable: true,
+// chainFreezerTableConfigs configures the settings for tables in the chain freezer.
+// Hashes and difficulties don't compress well. Bodies and receipts can be pruned
+// from the tail, the headers, hashes and difficulties are always retained.
+var chainFreezerTableConfigs = map[string]freezerTableConfig{
+	chainFreezerHeaderTable:     {noSnappy: false, prunable: false},
+	chainFreezerHashTable:       {noSnappy: true, prunable: false},
+	chainFreezerBodiesTable:     {noSnappy: false, prunable: true},
+	chainFreezerReceiptTable:    {noSnappy: false, prunable: true},
+	chainFreezerDifficultyTable: {noSnappy: true, prunable: false},
+}
+
+const (
+	// stateHistoryTable indicates the name of the freezer state history table.
+	stateHistoryTable = "history"
+)
+
+// stateFreezerTableConfigs configures the settings for tables in the state freezer.
+var stateFreezerTableConfigs = map[string]freezerTableConfig{
+	stateHistoryTable: {noSnappy: false, prunable: true},
 }
 
 // The list of identifiers of ancient stores.
 var (
 	chainFreezerName = "chain" // the folder name of chain segment ancient store.
+	stateFreezerName = "state" // the folder name of reverse diff ancient store.
 )
 
+// eraArchiveName is the folder name under the root ancient directory holding
+// the Era1 archives which serve the pruned chain history.
+const eraArchiveName = "era"
+
 // freezers the collections of all builtin freezers.
-var freezers = []string{chainFreezerName}
+var freezers = []string{chainFreezerName, stateFreezerName}
+
+// NewStateFreezer initializes the freezer for state history, which lives in
+// the "state" folder under the root ancient directory.
+func NewStateFreezer(ancientDir string, readOnly bool) (*Freezer, error) {
+	return NewFreezer(filepath.Join(ancientDir, stateFreezerName), "eth/db/state", readOnly, freezerTableSize, stateFreezerTableConfigs)
+}
 
 // InspectFreezerTable dumps out the index of a specific freezer table. The passed
 // ancient indicates the path of root ancient directory where the chain freezer can
@@ -61,15 +86,17 @@ var freezers = []string{chainFreezerName}
 func InspectFreezerTable(ancient string, freezerName string, tableName string, start, end int64) error {
 	var (
 		path   string
-		tables map[string]bool
+		tables map[string]freezerTableConfig
 	)
 	switch freezerName {
 	case chainFreezerName:
-		path, tables = resolveChainFreezerDir(ancient), chainFreezerNoSnappy
+		path, tables = resolveChainFreezerDir(ancient), chainFreezerTableConfigs
+	case stateFreezerName:
+		path, tables = filepath.Join(ancient, stateFreezerName), stateFreezerTableConfigs
 	default:
 		return fmt.Errorf("unknown freezer, supported ones: %v", freezers)
 	}
-	noSnappy, exist := tables[tableName]
+	config, exist := tables[tableName]
 	if !exist {
 		var names []string
 		for name := range tables {
@@ -77,7 +104,7 @@ func InspectFreezerTable(ancient string, freezerName string, tableName string, s
 		}
 		return fmt.Errorf("unknown table, supported ones: %v", names)
 	}
-	table, err := newFreezerTable(path, tableName, noSnappy, true)
+	table, err := newFreezerTable(path, tableName, config.noSnappy, true)
 	if err != nil {
 		return err
 	}
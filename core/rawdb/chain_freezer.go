@@ -49,19 +49,29 @@ type chainFreezer struct {
 	threshold uint64 // Number of recent blocks not to freeze (params.FullImmutabilityThreshold apart from tests)
 
 	*Freezer
+	era     *eraStore // Era1 archives serving the pruned history, nil if none
 	quit    chan struct{}
 	wg      sync.WaitGroup
 	trigger chan chan struct{} // Manual blocking freeze trigger, test determinism
 }
 
-// newChainFreezer initializes the freezer for ancient chain data.
-func newChainFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*chainFreezer, error) {
+// newChainFreezer initializes the freezer for ancient chain data. The pruned
+// bodies and receipts are served from the Era1 archives in eradir, if any.
+func newChainFreezer(datadir string, eradir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]freezerTableConfig) (*chainFreezer, error) {
 	freezer, err := NewFreezer(datadir, namespace, readonly, maxTableSize, tables)
 	if err != nil {
 		return nil, err
 	}
+	var archives *eraStore
+	if eradir != "" {
+		if archives, err = newEraStore(eradir); err != nil {
+			freezer.Close()
+			return nil, err
+		}
+	}
 	return &chainFreezer{
 		Freezer:   freezer,
+		era:       archives,
 		threshold: params.FullImmutabilityThreshold,
 		quit:      make(chan struct{}),
 		trigger:   make(chan chan struct{}),
@@ -71,6 +81,9 @@ func newChainFreezer(datadir string, namespace string, readonly bool, maxTableSi
 // Close closes the chain freezer instance and terminates the background thread.
 func (f *chainFreezer) Close() error {
 	err := f.Freezer.Close()
+	if f.era != nil {
+		f.era.close()
+	}
 	select {
 	case <-f.quit:
 	default:
@@ -80,6 +93,95 @@ func (f *chainFreezer) Close() error {
 	return err
 }
 
+// archived reports whether the given item was pruned from the freezer and
+// should be served from the era archives instead.
+func (f *chainFreezer) archived(kind string, number uint64) bool {
+	if f.era == nil || (kind != chainFreezerBodiesTable && kind != chainFreezerReceiptTable) {
+		return false
+	}
+	return number < atomic.LoadUint64(&f.tail) && number < atomic.LoadUint64(&f.frozen)
+}
+
+// archivedItem retrieves a pruned item from the era archives.
+func (f *chainFreezer) archivedItem(kind string, number uint64) ([]byte, error) {
+	hash, err := f.Freezer.Ancient(chainFreezerHashTable, number)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.era.retrieve(kind, number, common.BytesToHash(hash))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errOutOfBounds
+	}
+	return data, nil
+}
+
+// HasAncient returns an indicator whether the specified ancient data exists
+// in the freezer or in the era archives.
+func (f *chainFreezer) HasAncient(kind string, number uint64) (bool, error) {
+	if f.archived(kind, number) {
+		_, err := f.archivedItem(kind, number)
+		return err == nil, nil
+	}
+	return f.Freezer.HasAncient(kind, number)
+}
+
+// Ancient retrieves an ancient binary blob from the freezer, falling back to
+// the era archives for the pruned history.
+func (f *chainFreezer) Ancient(kind string, number uint64) ([]byte, error) {
+	if f.archived(kind, number) {
+		return f.archivedItem(kind, number)
+	}
+	return f.Freezer.Ancient(kind, number)
+}
+
+// AncientRange retrieves multiple items in sequence, starting from the index
+// 'start'. The items below the freezer tail are served from the era archives.
+func (f *chainFreezer) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	var (
+		items [][]byte
+		size  uint64
+	)
+	for ; count > 0 && f.archived(kind, start); start, count = start+1, count-1 {
+		item, err := f.archivedItem(kind, start)
+		if err != nil {
+			return nil, err
+		}
+		// Always return at least one item, stop before exceeding the limit
+		if len(items) > 0 && maxBytes != 0 && size+uint64(len(item)) > maxBytes {
+			return items, nil
+		}
+		items = append(items, item)
+		size += uint64(len(item))
+	}
+	if count == 0 {
+		return items, nil
+	}
+	if maxBytes != 0 {
+		if size >= maxBytes {
+			return items, nil
+		}
+		maxBytes -= size
+	}
+	rest, err := f.Freezer.AncientRange(kind, start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return append(items, rest...), nil
+}
+
+// ReadAncients runs the given read operation while ensuring that no writes take
+// place on the underlying freezer. The pruned history is served from the era
+// archives within the operation.
+func (f *chainFreezer) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err error) {
+	f.writeLock.RLock()
+	defer f.writeLock.RUnlock()
+
+	return fn(f)
+}
+
 // freeze is a background thread that periodically checks the blockchain for any
 // import progress and moves ancient data from the fast database into the freezer.
 //
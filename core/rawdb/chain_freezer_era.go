@@ -0,0 +1,159 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// eraOpenFiles is the maximum number of archives kept open at the same time.
+const eraOpenFiles = 16
+
+// errEraMismatch is returned if an archived block doesn't match the canonical
+// chain stored in the freezer.
+var errEraMismatch = errors.New("archived block mismatches canonical chain")
+
+// eraRange is the block range of an archive file.
+type eraRange struct {
+	path  string
+	start uint64
+	count uint64
+}
+
+// eraStore serves the pruned chain history from a directory of Era1 archives.
+// Only the bodies and receipts are retrieved from the archives, the headers,
+// hashes and total difficulties are always retained in the freezer.
+type eraStore struct {
+	ranges []eraRange // Block ranges of the archives, sorted by start
+	files  *lru.Cache // Open archive handles, keyed by path
+	lock   sync.Mutex // Lock serializing the archive reads
+}
+
+// newEraStore scans the given directory for Era1 archives. A missing directory
+// is not an error, nil is returned as there is nothing to serve.
+func newEraStore(dir string) (*eraStore, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ranges []eraRange
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".era1") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		e, err := era.Open(path)
+		if err != nil {
+			log.Warn("Skipping invalid era archive", "path", path, "err", err)
+			continue
+		}
+		ranges = append(ranges, eraRange{path: path, start: e.Start(), count: e.Count()})
+		e.Close()
+	}
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+
+	files, _ := lru.NewWithEvict(eraOpenFiles, func(key, value interface{}) {
+		value.(*era.Era).Close()
+	})
+	log.Info("Opened era archives", "dir", dir, "files", len(ranges))
+	return &eraStore{ranges: ranges, files: files}, nil
+}
+
+// archive returns the opened archive containing the given block, or nil if the
+// block is not archived. The caller must hold the lock.
+func (s *eraStore) archive(number uint64) (*era.Era, error) {
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return s.ranges[i].start+s.ranges[i].count > number
+	})
+	if i == len(s.ranges) || s.ranges[i].start > number {
+		return nil, nil
+	}
+	path := s.ranges[i].path
+	if e, ok := s.files.Get(path); ok {
+		return e.(*era.Era), nil
+	}
+	e, err := era.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	s.files.Add(path, e)
+	return e, nil
+}
+
+// retrieve reads an archived body or receipts item, converted to the freezer
+// encoding. The header of the archived block must match the given canonical
+// hash, ensuring the archive belongs to the local chain.
+func (s *eraStore) retrieve(kind string, number uint64, hash common.Hash) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, err := s.archive(number)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	header, err := e.GetRawHeaderByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.Keccak256Hash(header) != hash {
+		return nil, errEraMismatch
+	}
+	switch kind {
+	case chainFreezerBodiesTable:
+		return e.GetRawBodyByNumber(number)
+	case chainFreezerReceiptTable:
+		// The archives hold the consensus encoding of the receipts, the
+		// freezer holds the storage encoding.
+		receipts, err := e.GetReceiptsByNumber(number)
+		if err != nil {
+			return nil, err
+		}
+		stored := make([]*types.ReceiptForStorage, len(receipts))
+		for i, receipt := range receipts {
+			stored[i] = (*types.ReceiptForStorage)(receipt)
+		}
+		return rlp.EncodeToBytes(stored)
+	}
+	return nil, errUnknownTable
+}
+
+// close closes all the open archives.
+func (s *eraStore) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.files.Purge()
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadHistoryTail retrieves the number of the first block whose body and
+// receipts are retained in the ancient store. All the canonical blocks below
+// have been pruned, apart from the genesis. Zero is returned if the history
+// was never pruned or the database has no ancient store.
+func ReadHistoryTail(db ethdb.AncientReader) uint64 {
+	tail, err := db.Tail()
+	if err != nil {
+		return 0
+	}
+	return tail
+}
+
+// PruneChainHistory deletes the bodies and receipts of the canonical blocks
+// below the given number from the ancient store, retaining the headers, hashes
+// and total difficulties. Only frozen blocks can be pruned, the cutoff is capped
+// to the number of ancient items.
+//
+// The transaction indices of the pruned blocks are retained, so that lookups
+// can report the transactions as pruned instead of unknown. The genesis body
+// and receipts are moved into the key-value store as they're required by the
+// node at startup.
+func PruneChainHistory(db ethdb.Database, cutoff uint64) error {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	if cutoff > frozen {
+		cutoff = frozen
+	}
+	tail, err := db.Tail()
+	if err != nil {
+		return err
+	}
+	if cutoff <= tail {
+		return nil
+	}
+	start := time.Now()
+
+	// Retain the genesis in the key-value store if it's the first pruning
+	if tail == 0 {
+		hash := ReadCanonicalHash(db, 0)
+		if hash == (common.Hash{}) {
+			return fmt.Errorf("genesis is not found")
+		}
+		body, receipts := ReadBodyRLP(db, hash, 0), ReadReceiptsRLP(db, hash, 0)
+		if len(body) == 0 || len(receipts) == 0 {
+			return fmt.Errorf("genesis body or receipts are not found")
+		}
+		batch := db.NewBatch()
+		WriteBodyRLP(batch, hash, 0, body)
+		if err := batch.Put(blockReceiptsKey(0, hash), receipts); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	if err := db.TruncateTail(cutoff); err != nil {
+		return err
+	}
+	log.Info("Pruned chain history", "from", tail, "to", cutoff, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
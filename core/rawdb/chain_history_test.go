@@ -0,0 +1,162 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+)
+
+// Tests that the chain history pruning deletes the bodies and receipts below
+// the cutoff, retaining the headers and the genesis.
+func TestPruneChainHistory(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	blocks := makeTestBlocks(10, 1)
+	receipts := make([]types.Receipts, len(blocks))
+	for i := range receipts {
+		receipts[i] = types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{}}}
+	}
+	if _, err := WriteAncientBlocks(db, blocks, receipts, big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	WriteTxLookupEntriesByBlock(db, blocks[2])
+
+	if err := PruneChainHistory(db, 5); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail := ReadHistoryTail(db); tail != 5 {
+		t.Fatalf("history tail mismatch: have %d, want 5", tail)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if ReadHeader(db, hash, number) == nil {
+			t.Fatalf("block %d: header is missing", i)
+		}
+		pruned := i > 0 && i < 5
+		if body := ReadBody(db, hash, number); (body == nil) != pruned {
+			t.Fatalf("block %d: body mismatch, pruned %v, body %v", i, pruned, body)
+		}
+		if receipts := ReadRawReceipts(db, hash, number); (receipts == nil) != pruned {
+			t.Fatalf("block %d: receipts mismatch, pruned %v, receipts %v", i, pruned, receipts)
+		}
+	}
+	// The lookups of the pruned transactions are retained
+	txHash := blocks[2].Transactions()[0].Hash()
+	if ReadTxLookupEntry(db, txHash) == nil {
+		t.Fatal("transaction lookup is missing")
+	}
+	if tx, _, _, _ := ReadTransaction(db, txHash); tx != nil {
+		t.Fatal("pruned transaction is retrievable")
+	}
+	// Pruning is capped to the ancient store
+	if err := PruneChainHistory(db, 20); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail := ReadHistoryTail(db); tail != 10 {
+		t.Fatalf("history tail mismatch: have %d, want 10", tail)
+	}
+	if ReadBlock(db, blocks[0].Hash(), 0) == nil {
+		t.Fatal("genesis is pruned")
+	}
+	if have := len(ReadRawReceipts(db, blocks[0].Hash(), 0)); have != 1 {
+		t.Fatalf("genesis receipts mismatch: have %d, want 1", have)
+	}
+}
+
+// Tests that the pruned history is served from the era archives placed in the
+// ancient directory, as long as they match the canonical chain.
+func TestEraHistoryServing(t *testing.T) {
+	ancient := t.TempDir()
+
+	blocks := makeTestBlocks(10, 1)
+	receipts := make([]types.Receipts, len(blocks))
+	for i := range receipts {
+		receipts[i] = types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000 + uint64(i), Logs: []*types.Log{}}}
+	}
+	// Archive the first half of the chain, along with a forked block
+	if err := os.MkdirAll(filepath.Join(ancient, eraArchiveName), 0755); err != nil {
+		t.Fatalf("failed to create era directory: %v", err)
+	}
+	writeEra := func(name string, blocks []*types.Block, receipts []types.Receipts) {
+		f, err := os.Create(filepath.Join(ancient, eraArchiveName, name))
+		if err != nil {
+			t.Fatalf("failed to create era file: %v", err)
+		}
+		defer f.Close()
+
+		b := era.NewBuilder(f)
+		for i, block := range blocks {
+			if err := b.Add(block, receipts[i], big.NewInt(int64(i+1))); err != nil {
+				t.Fatalf("failed to add block: %v", err)
+			}
+		}
+		if _, err := b.Finalize(); err != nil {
+			t.Fatalf("failed to finalize era: %v", err)
+		}
+	}
+	writeEra("test-00000-00000000.era1", blocks[:3], receipts[:3])
+
+	fork := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(3), Extra: []byte("fork")})
+	writeEra("test-00001-00000000.era1", []*types.Block{fork}, []types.Receipts{nil})
+
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), ancient, "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := WriteAncientBlocks(db, blocks, receipts, big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	if err := PruneChainHistory(db, 5); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+
+		available := i < 3 || i >= 5
+		body := ReadBody(db, hash, number)
+		if (body != nil) != available {
+			t.Fatalf("block %d: body mismatch, available %v, body %v", i, available, body)
+		}
+		if body != nil && body.Transactions[0].Hash() != block.Transactions()[0].Hash() {
+			t.Fatalf("block %d: transactions mismatch", i)
+		}
+		have := ReadRawReceipts(db, hash, number)
+		if (have != nil) != available {
+			t.Fatalf("block %d: receipts mismatch, available %v, receipts %v", i, available, have)
+		}
+		if have != nil && have[0].CumulativeGasUsed != receipts[i][0].CumulativeGasUsed {
+			t.Fatalf("block %d: receipt mismatch", i)
+		}
+	}
+	// The archived transactions are retrievable again
+	WriteTxLookupEntriesByBlock(db, blocks[2])
+	if tx, _, _, _ := ReadTransaction(db, blocks[2].Transactions()[0].Hash()); tx == nil {
+		t.Fatal("archived transaction is not retrievable")
+	}
+}
@@ -178,6 +178,10 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
 func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+	// skip the blocks whose bodies are pruned
+	if tail := ReadHistoryTail(db); from < tail {
+		from = tail
+	}
 	// short circuit for invalid range
 	if from >= to {
 		return
@@ -270,6 +274,10 @@ func indexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, inte
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
 func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+	// skip the blocks whose bodies are pruned
+	if tail := ReadHistoryTail(db); from < tail {
+		from = tail
+	}
 	// short circuit for invalid range
 	if from >= to {
 		return
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 
@@ -198,7 +199,7 @@ func resolveChainFreezerDir(ancient string) string {
 // where the chain freezer can be opened.
 func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace string, readonly bool) (ethdb.Database, error) {
 	// Create the idle freezer instance
-	frdb, err := newChainFreezer(resolveChainFreezerDir(ancient), namespace, readonly, freezerTableSize, chainFreezerNoSnappy)
+	frdb, err := newChainFreezer(resolveChainFreezerDir(ancient), filepath.Join(ancient, eraArchiveName), namespace, readonly, freezerTableSize, chainFreezerTableConfigs)
 	if err != nil {
 		return nil, err
 	}
@@ -295,25 +296,111 @@ func NewMemoryDatabaseWithCap(size int) ethdb.Database {
 }
 
 // NewLevelDBDatabase creates a persistent key-value database without a freezer
-// moving immutable chain segments into cold storage.
+// moving immutable chain segments into cold storage. If a pebble database is
+// already present at the given location, it is opened instead.
 func NewLevelDBDatabase(file string, cache int, handles int, namespace string, readonly bool) (ethdb.Database, error) {
-	db, err := leveldb.New(file, cache, handles, namespace, readonly)
-	if err != nil {
-		return nil, err
-	}
-	return NewDatabase(db), nil
+	return Open(OpenOptions{
+		Directory: file,
+		Namespace: namespace,
+		Cache:     cache,
+		Handles:   handles,
+		ReadOnly:  readonly,
+	})
 }
 
 // NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
 // freezer moving immutable chain segments into cold storage. The passed ancient
 // indicates the path of root ancient directory where the chain freezer can be
-// opened.
+// opened. If a pebble database is already present at the given location, it
+// is opened instead.
 func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, ancient string, namespace string, readonly bool) (ethdb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, namespace, readonly)
+	return Open(OpenOptions{
+		Directory:         file,
+		AncientsDirectory: ancient,
+		Namespace:         namespace,
+		Cache:             cache,
+		Handles:           handles,
+		ReadOnly:          readonly,
+	})
+}
+
+const (
+	// DBLeveldb is the database engine name of the LevelDB backend.
+	DBLeveldb = "leveldb"
+
+	// DBPebble is the database engine name of the pebble backend.
+	DBPebble = "pebble"
+)
+
+// PreexistingDatabase checks the given data directory whether a database is
+// already instantiated at that location, and if so, returns the engine of the
+// database (or the empty string). The engine is recorded implicitly in the
+// on-disk layout: pebble always writes OPTIONS files, leveldb never does.
+func PreexistingDatabase(path string) string {
+	if matches, err := filepath.Glob(filepath.Join(path, "OPTIONS*")); err == nil && len(matches) > 0 {
+		return DBPebble
+	}
+	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err == nil {
+		return DBLeveldb
+	}
+	return "" // No pre-existing db
+}
+
+// OpenOptions contains the options to apply when opening a database.
+type OpenOptions struct {
+	Type              string // "leveldb" | "pebble", empty to detect or fall back to leveldb
+	Directory         string // the datadir
+	AncientsDirectory string // the ancients-dir, empty to open without a freezer
+	Namespace         string // the namespace for database relevant metrics
+	Cache             int    // the capacity(in megabytes) of the data caching
+	Handles           int    // number of files to be open simultaneously
+	ReadOnly          bool
+}
+
+// openKeyValueDatabase opens a disk-based key-value database, e.g. leveldb or pebble.
+//
+//	                      type == null          type != null
+//	                   +----------------------------------------
+//	db is non-existent |  leveldb default  |  specified type
+//	db is existent     |  from db          |  specified type (if compatible)
+func openKeyValueDatabase(o OpenOptions) (ethdb.Database, error) {
+	existingDb := PreexistingDatabase(o.Directory)
+	if len(existingDb) != 0 && len(o.Type) != 0 && o.Type != existingDb {
+		return nil, fmt.Errorf("db.engine choice was %v but found pre-existing %v database in specified data directory", o.Type, existingDb)
+	}
+	dbType := o.Type
+	if len(existingDb) != 0 {
+		dbType = existingDb
+	}
+	switch dbType {
+	case DBPebble:
+		log.Info("Using pebble as the backing database")
+		return NewPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
+	case DBLeveldb, "":
+		log.Info("Using leveldb as the backing database")
+		db, err := leveldb.New(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
+		if err != nil {
+			return nil, err
+		}
+		return NewDatabase(db), nil
+	default:
+		return nil, fmt.Errorf("unknown db.engine %q", dbType)
+	}
+}
+
+// Open opens both a disk-based key-value database such as leveldb or pebble,
+// and, if an ancients directory is configured, a freezer moving immutable
+// chain segments into cold storage. The engine of a pre-existing database is
+// always respected, a conflicting explicit choice is rejected.
+func Open(o OpenOptions) (ethdb.Database, error) {
+	kvdb, err := openKeyValueDatabase(o)
 	if err != nil {
 		return nil, err
 	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, ancient, namespace, readonly)
+	if len(o.AncientsDirectory) == 0 {
+		return kvdb, nil
+	}
+	frdb, err := NewDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -370,6 +457,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		numHashPairings stat
 		hashNumPairings stat
 		tries           stat
+		accountTries    stat
+		storageTries    stat
+		stateLookups    stat
 		codes           stat
 		txLookups       stat
 		accountSnaps    stat
@@ -378,6 +468,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		bloomBits       stat
 		beaconHeaders   stat
 		cliqueSnaps     stat
+		blockTraces     stat
 
 		// Ancient store statistics
 		ancientHeadersSize  common.StorageSize
@@ -417,6 +508,12 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			numHashPairings.Add(size)
 		case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
 			hashNumPairings.Add(size)
+		case IsAccountTrieNode(key):
+			accountTries.Add(size)
+		case IsStorageTrieNode(key):
+			storageTries.Add(size)
+		case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
+			stateLookups.Add(size)
 		case len(key) == common.HashLength:
 			tries.Add(size)
 		case bytes.HasPrefix(key, CodePrefix) && len(key) == len(CodePrefix)+common.HashLength:
@@ -433,12 +530,16 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			metadata.Add(size)
 		case bytes.HasPrefix(key, genesisPrefix) && len(key) == (len(genesisPrefix)+common.HashLength):
 			metadata.Add(size)
+		case bytes.HasPrefix(key, statePruningLogPrefix) && len(key) == (len(statePruningLogPrefix)+8):
+			metadata.Add(size)
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBits.Add(size)
 		case bytes.HasPrefix(key, BloomBitsIndexPrefix):
 			bloomBits.Add(size)
 		case bytes.HasPrefix(key, skeletonHeaderPrefix) && len(key) == (len(skeletonHeaderPrefix)+8):
 			beaconHeaders.Add(size)
+		case bytes.HasPrefix(key, blockTracesPrefix) && len(key) == (len(blockTracesPrefix)+8+2*common.HashLength):
+			blockTraces.Add(size)
 		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
 			cliqueSnaps.Add(size)
 		case bytes.HasPrefix(key, []byte("cht-")) ||
@@ -456,6 +557,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				lastPivotKey, fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
 				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
+				persistentStateIDKey, trieJournalKey, statePruningKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -498,11 +600,15 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
+		{"Key-Value store", "Path trie account nodes", accountTries.Size(), accountTries.Count()},
+		{"Key-Value store", "Path trie storage nodes", storageTries.Size(), storageTries.Count()},
+		{"Key-Value store", "Path trie state lookups", stateLookups.Size(), stateLookups.Count()},
 		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
 		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
 		{"Key-Value store", "Beacon sync headers", beaconHeaders.Size(), beaconHeaders.Count()},
 		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
+		{"Key-Value store", "Block traces", blockTraces.Size(), blockTraces.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
 		{"Ancient store", "Headers", ancientHeadersSize.String(), ancients.String()},
 		{"Ancient store", "Bodies", ancientBodiesSize.String(), ancients.String()},
@@ -0,0 +1,67 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build arm64 || amd64
+// +build arm64 amd64
+
+package rawdb
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// Tests that the database engine is detected from the data directory and that
+// a pre-existing database is always reopened with the engine it was created by.
+func TestOpenDatabaseEngine(t *testing.T) {
+	for _, engine := range []string{DBLeveldb, DBPebble} {
+		dir := filepath.Join(t.TempDir(), "chaindata")
+		if have := PreexistingDatabase(dir); have != "" {
+			t.Fatalf("%s: unexpected engine on empty directory: %q", engine, have)
+		}
+		db, err := Open(OpenOptions{Type: engine, Directory: dir, AncientsDirectory: filepath.Join(dir, "ancient")})
+		if err != nil {
+			t.Fatalf("%s: failed to create database: %v", engine, err)
+		}
+		if err := db.Put([]byte("key"), []byte("value")); err != nil {
+			t.Fatalf("%s: failed to write: %v", engine, err)
+		}
+		db.Close()
+
+		if have := PreexistingDatabase(dir); have != engine {
+			t.Fatalf("engine mismatch: have %q, want %q", have, engine)
+		}
+		// Opening without an explicit engine must pick up the existing one
+		db, err = NewLevelDBDatabaseWithFreezer(dir, 0, 0, filepath.Join(dir, "ancient"), "", false)
+		if err != nil {
+			t.Fatalf("%s: failed to reopen database: %v", engine, err)
+		}
+		if val, err := db.Get([]byte("key")); err != nil || !bytes.Equal(val, []byte("value")) {
+			t.Fatalf("%s: value mismatch: have %q (%v), want %q", engine, val, err, "value")
+		}
+		db.Close()
+
+		// Opening with a conflicting engine must be rejected
+		other := DBPebble
+		if engine == DBPebble {
+			other = DBLeveldb
+		}
+		if _, err := Open(OpenOptions{Type: other, Directory: dir}); err == nil {
+			t.Fatalf("%s: expected error opening with engine %s", engine, other)
+		}
+	}
+}
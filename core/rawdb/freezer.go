@@ -54,6 +54,12 @@ var (
 // freezerTableSize defines the maximum size of freezer data files.
 const freezerTableSize = 2 * 1000 * 1000 * 1000
 
+// freezerTableConfig contains the settings for a freezer table.
+type freezerTableConfig struct {
+	noSnappy bool // disables item compression
+	prunable bool // true for tables that can be pruned by TruncateTail
+}
+
 // Freezer is a memory mapped append-only database to store immutable ordered
 // data into flat files:
 //
@@ -66,7 +72,7 @@ type Freezer struct {
 	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
 	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
 	frozen uint64 // Number of blocks already frozen
-	tail   uint64 // Number of the first stored item in the freezer
+	tail   uint64 // Number of the first stored item in the prunable tables
 
 	// This lock synchronizes writers and the truncate operation, as well as
 	// the "atomic" (batched) read operations.
@@ -74,17 +80,19 @@ type Freezer struct {
 	writeBatch *freezerBatch
 
 	readonly     bool
-	tables       map[string]*freezerTable // Data tables for storing everything
-	instanceLock fileutil.Releaser        // File-system lock to prevent double opens
+	tables       map[string]*freezerTable      // Data tables for storing everything
+	configs      map[string]freezerTableConfig // Settings of the data tables
+	instanceLock fileutil.Releaser             // File-system lock to prevent double opens
 	closeOnce    sync.Once
 }
 
 // NewFreezer creates a freezer instance for maintaining immutable ordered
 // data according to the given parameters.
 //
-// The 'tables' argument defines the data tables. If the value of a map
-// entry is true, snappy compression is disabled for the table.
-func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+// The 'tables' argument defines the data tables along with their settings:
+// whether snappy compression is disabled and whether the table can be pruned
+// from the tail.
+func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]freezerTableConfig) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -108,12 +116,13 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 	freezer := &Freezer{
 		readonly:     readonly,
 		tables:       make(map[string]*freezerTable),
+		configs:      tables,
 		instanceLock: lock,
 	}
 
 	// Create the tables.
-	for name, disableSnappy := range tables {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly)
+	for name, config := range tables {
+		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, config.noSnappy, readonly)
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
@@ -203,7 +212,8 @@ func (f *Freezer) Ancients() (uint64, error) {
 	return atomic.LoadUint64(&f.frozen), nil
 }
 
-// Tail returns the number of first stored item in the freezer.
+// Tail returns the number of first stored item in the freezer. The items below
+// the tail are only retained in the tables which are not prunable.
 func (f *Freezer) Tail() (uint64, error) {
 	return atomic.LoadUint64(&f.tail), nil
 }
@@ -285,6 +295,7 @@ func (f *Freezer) TruncateHead(items uint64) error {
 }
 
 // TruncateTail discards any recent data below the provided threshold number.
+// Only the prunable tables are truncated, the others are left untouched.
 func (f *Freezer) TruncateTail(tail uint64) error {
 	if f.readonly {
 		return errReadOnly
@@ -295,7 +306,10 @@ func (f *Freezer) TruncateTail(tail uint64) error {
 	if atomic.LoadUint64(&f.tail) >= tail {
 		return nil
 	}
-	for _, table := range f.tables {
+	for name, table := range f.tables {
+		if !f.configs[name].prunable {
+			continue
+		}
 		if err := table.truncateTail(tail); err != nil {
 			return err
 		}
@@ -318,8 +332,8 @@ func (f *Freezer) Sync() error {
 	return nil
 }
 
-// validate checks that every table has the same length.
-// Used instead of `repair` in readonly mode.
+// validate checks that every table has the same length and that all prunable
+// tables share the same tail. Used instead of `repair` in readonly mode.
 func (f *Freezer) validate() error {
 	if len(f.tables) == 0 {
 		return nil
@@ -327,6 +341,10 @@ func (f *Freezer) validate() error {
 	var (
 		length uint64
 		name   string
+
+		tail      uint64
+		tailName  string
+		tailFound bool
 	)
 	// Hack to get length of any table
 	for kind, table := range f.tables {
@@ -340,31 +358,48 @@ func (f *Freezer) validate() error {
 		if length != items {
 			return fmt.Errorf("freezer tables %s and %s have differing lengths: %d != %d", kind, name, items, length)
 		}
+		if !f.configs[kind].prunable {
+			continue
+		}
+		hidden := atomic.LoadUint64(&table.itemHidden)
+		if !tailFound {
+			tail, tailName, tailFound = hidden, kind, true
+		} else if tail != hidden {
+			return fmt.Errorf("freezer tables %s and %s have differing tails: %d != %d", kind, tailName, hidden, tail)
+		}
 	}
 	atomic.StoreUint64(&f.frozen, length)
+	atomic.StoreUint64(&f.tail, tail)
 	return nil
 }
 
-// repair truncates all data tables to the same length.
+// repair truncates all data tables to the same length, and all prunable
+// tables to the same tail.
 func (f *Freezer) repair() error {
 	var (
 		head = uint64(math.MaxUint64)
 		tail = uint64(0)
 	)
-	for _, table := range f.tables {
+	for name, table := range f.tables {
 		items := atomic.LoadUint64(&table.items)
 		if head > items {
 			head = items
 		}
+		if !f.configs[name].prunable {
+			continue
+		}
 		hidden := atomic.LoadUint64(&table.itemHidden)
 		if hidden > tail {
 			tail = hidden
 		}
 	}
-	for _, table := range f.tables {
+	for name, table := range f.tables {
 		if err := table.truncateHead(head); err != nil {
 			return err
 		}
+		if !f.configs[name].prunable {
+			continue
+		}
 		if err := table.truncateTail(tail); err != nil {
 			return err
 		}
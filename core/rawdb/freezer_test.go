@@ -32,7 +32,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-var freezerTestTableDef = map[string]bool{"test": true}
+var freezerTestTableDef = map[string]freezerTableConfig{"test": {noSnappy: true, prunable: true}}
 
 func TestFreezerModify(t *testing.T) {
 	t.Parallel()
@@ -48,7 +48,7 @@ func TestFreezerModify(t *testing.T) {
 		valuesRLP = append(valuesRLP, iv)
 	}
 
-	tables := map[string]bool{"raw": true, "rlp": false}
+	tables := map[string]freezerTableConfig{"raw": {noSnappy: true}, "rlp": {noSnappy: false}}
 	f, _ := newFreezerForTesting(t, tables)
 	defer f.Close()
 
@@ -112,7 +112,7 @@ func TestFreezerModifyRollback(t *testing.T) {
 	f.Close()
 
 	// Reopen and check that the rolled-back data doesn't reappear.
-	tables := map[string]bool{"test": true}
+	tables := map[string]freezerTableConfig{"test": {noSnappy: true}}
 	f2, err := NewFreezer(dir, "", false, 2049, tables)
 	if err != nil {
 		t.Fatalf("can't reopen freezer after failed ModifyAncients: %v", err)
@@ -250,7 +250,7 @@ func TestFreezerConcurrentModifyTruncate(t *testing.T) {
 }
 
 func TestFreezerReadonlyValidate(t *testing.T) {
-	tables := map[string]bool{"a": true, "b": true}
+	tables := map[string]freezerTableConfig{"a": {noSnappy: true}, "b": {noSnappy: true}}
 	dir := t.TempDir()
 	// Open non-readonly freezer and fill individual tables
 	// with different amount of data.
@@ -283,7 +283,57 @@ func TestFreezerReadonlyValidate(t *testing.T) {
 	}
 }
 
-func newFreezerForTesting(t *testing.T, tables map[string]bool) (*Freezer, string) {
+func TestFreezerTruncateTailPrunable(t *testing.T) {
+	tables := map[string]freezerTableConfig{"a": {noSnappy: true, prunable: true}, "b": {noSnappy: true}}
+	f, dir := newFreezerForTesting(t, tables)
+
+	var item = make([]byte, 256)
+	_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			if err := op.AppendRaw("a", i, item); err != nil {
+				return err
+			}
+			if err := op.AppendRaw("b", i, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.TruncateTail(5))
+
+	check := func(f *Freezer) {
+		if tail, _ := f.Tail(); tail != 5 {
+			t.Fatalf("unexpected tail, want 5, got %d", tail)
+		}
+		if _, err := f.Ancient("a", 4); err == nil {
+			t.Fatal("pruned item is still retrievable")
+		}
+		if _, err := f.Ancient("a", 5); err != nil {
+			t.Fatalf("failed to retrieve item above tail: %v", err)
+		}
+		for i := uint64(0); i < 10; i++ {
+			if _, err := f.Ancient("b", i); err != nil {
+				t.Fatalf("failed to retrieve item %d from unprunable table: %v", i, err)
+			}
+		}
+	}
+	check(f)
+	require.NoError(t, f.Close())
+
+	// Reopen the freezer, the tail should be recovered from the prunable tables.
+	f, err = NewFreezer(dir, "", false, 2049, tables)
+	require.NoError(t, err)
+	check(f)
+	require.NoError(t, f.Close())
+
+	f, err = NewFreezer(dir, "", true, 2049, tables)
+	require.NoError(t, err)
+	check(f)
+	require.NoError(t, f.Close())
+}
+
+func newFreezerForTesting(t *testing.T, tables map[string]freezerTableConfig) (*Freezer, string) {
 	t.Helper()
 
 	dir := t.TempDir()
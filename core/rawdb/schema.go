@@ -81,6 +81,15 @@ var (
 	// uncleanShutdownKey tracks the list of local crashes
 	uncleanShutdownKey = []byte("unclean-shutdown") // config prefix for the db
 
+	// persistentStateIDKey tracks the id of latest stored state(for path-based only).
+	persistentStateIDKey = []byte("LastStateID")
+
+	// trieJournalKey tracks the in-memory trie node layers across restarts.
+	trieJournalKey = []byte("TrieJournal")
+
+	// statePruningKey tracks the online state pruning progress across restarts.
+	statePruningKey = []byte("StatePruning")
+
 	// transitionStatusKey tracks the eth2 transition status.
 	transitionStatusKey = []byte("eth2-transition")
 
@@ -99,10 +108,17 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 	skeletonHeaderPrefix  = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
+	blockTracesPrefix     = []byte("T") // blockTracesPrefix + num (uint64 big endian) + hash + tracer config hash -> compressed block traces
+
+	// Path-based storage scheme of merkle patricia trie.
+	TrieNodeAccountPrefix = []byte("A") // TrieNodeAccountPrefix + hexPath -> trie node
+	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
+	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
-	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
-	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
-	genesisPrefix  = []byte("ethereum-genesis-") // genesis state prefix for the db
+	PreimagePrefix        = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
+	configPrefix          = []byte("ethereum-config-")  // config prefix for the db
+	genesisPrefix         = []byte("ethereum-genesis-") // genesis state prefix for the db
+	statePruningLogPrefix = []byte("state-pruning-")    // statePruningLogPrefix + seq (uint64 big endian) -> hashes of trie nodes flushed during online pruning
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
@@ -196,6 +212,16 @@ func skeletonHeaderKey(number uint64) []byte {
 	return append(skeletonHeaderPrefix, encodeBlockNumber(number)...)
 }
 
+// blockTracesKeyPrefix = blockTracesPrefix + num (uint64 big endian)
+func blockTracesKeyPrefix(number uint64) []byte {
+	return append(blockTracesPrefix, encodeBlockNumber(number)...)
+}
+
+// blockTracesKey = blockTracesPrefix + num (uint64 big endian) + hash + tracer config hash
+func blockTracesKey(number uint64, hash common.Hash, config common.Hash) []byte {
+	return append(append(blockTracesKeyPrefix(number), hash.Bytes()...), config.Bytes()...)
+}
+
 // preimageKey = PreimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(PreimagePrefix, hash.Bytes()...)
@@ -215,6 +241,66 @@ func IsCodeKey(key []byte) (bool, []byte) {
 	return false, nil
 }
 
+// accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
+func accountTrieNodeKey(path []byte) []byte {
+	return append(TrieNodeAccountPrefix, path...)
+}
+
+// storageTrieNodeKey = TrieNodeStoragePrefix + accountHash + nodePath.
+func storageTrieNodeKey(accountHash common.Hash, path []byte) []byte {
+	buf := make([]byte, len(TrieNodeStoragePrefix)+common.HashLength+len(path))
+	n := copy(buf, TrieNodeStoragePrefix)
+	n += copy(buf[n:], accountHash.Bytes())
+	copy(buf[n:], path)
+	return buf
+}
+
+// IsAccountTrieNode reports whether a provided database entry is an account
+// trie node in path-based state scheme.
+func IsAccountTrieNode(key []byte) bool {
+	if !bytes.HasPrefix(key, TrieNodeAccountPrefix) {
+		return false
+	}
+	// The remaining key should only consist a hex node path.
+	return isHexPath(key[len(TrieNodeAccountPrefix):])
+}
+
+// IsStorageTrieNode reports whether a provided database entry is a storage
+// trie node in path-based state scheme.
+func IsStorageTrieNode(key []byte) bool {
+	if !bytes.HasPrefix(key, TrieNodeStoragePrefix) {
+		return false
+	}
+	if len(key) < len(TrieNodeStoragePrefix)+common.HashLength {
+		return false
+	}
+	return isHexPath(key[len(TrieNodeStoragePrefix)+common.HashLength:])
+}
+
+// isHexPath reports whether the given path is a valid hex node path, not longer
+// than a full key and consisting of nibbles only.
+func isHexPath(path []byte) bool {
+	if len(path) > 2*common.HashLength {
+		return false
+	}
+	for _, b := range path {
+		if b >= 16 {
+			return false
+		}
+	}
+	return true
+}
+
+// stateIDKey = stateIDPrefix + root (32 bytes)
+func stateIDKey(root common.Hash) []byte {
+	return append(stateIDPrefix, root.Bytes()...)
+}
+
+// statePruningLogKey = statePruningLogPrefix + seq (uint64 big endian)
+func statePruningLogKey(seq uint64) []byte {
+	return append(append([]byte{}, statePruningLogPrefix...), encodeBlockNumber(seq)...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
@@ -139,6 +139,18 @@ func NewDatabaseWithConfig(db ethdb.Database, config *trie.Config) Database {
 	}
 }
 
+// NewDatabaseWithNodeDB creates a state database with an already initialized
+// trie database.
+func NewDatabaseWithNodeDB(db ethdb.Database, triedb *trie.Database) Database {
+	csc, _ := lru.New(codeSizeCacheSize)
+	return &cachingDB{
+		db:            triedb,
+		disk:          db,
+		codeSizeCache: csc,
+		codeCache:     fastcache.New(codeCacheSize),
+	}
+}
+
 type cachingDB struct {
 	db            *trie.Database
 	disk          ethdb.KeyValueStore
@@ -0,0 +1,767 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// onlineBloomFilePrefix is the filename prefix of the state bloom filter
+	// used by the online pruning.
+	onlineBloomFilePrefix = "onlinebloom"
+
+	// onlineRecentLayers is the number of recent states retained by the online
+	// pruning, aligned with the number of in-memory states of the blockchain.
+	onlineRecentLayers = 128
+
+	// onlineCheckInterval is the number of marked trie nodes after which the
+	// interruption signal is checked.
+	onlineCheckInterval = 10000
+)
+
+// Phases of the online state pruning.
+const (
+	PhaseIdle     = "idle"     // No pruning is running
+	PhaseMarking  = "marking"  // The live state is being marked
+	PhaseSweeping = "sweeping" // The unmarked trie nodes are being deleted
+)
+
+var (
+	onlinePhaseGauge     = metrics.NewRegisteredGauge("state/pruning/phase", nil)
+	onlineProgressGauge  = metrics.NewRegisteredGauge("state/pruning/progress", nil)
+	onlineMarkedMeter    = metrics.NewRegisteredMeter("state/pruning/marked", nil)
+	onlineTrackedMeter   = metrics.NewRegisteredMeter("state/pruning/tracked", nil)
+	onlineSweptMeter     = metrics.NewRegisteredMeter("state/pruning/swept/nodes", nil)
+	onlineSweptSizeMeter = metrics.NewRegisteredMeter("state/pruning/swept/size", nil)
+)
+
+var (
+	// errPruningRunning is returned if the online pruning is requested to start
+	// while it's already running.
+	errPruningRunning = errors.New("state pruning is already running")
+
+	// errPruningNotRunning is returned if the online pruning is requested to stop
+	// while it isn't running.
+	errPruningNotRunning = errors.New("state pruning is not running")
+
+	// errPruningStopped is returned by the internal procedures if the pruning is
+	// interrupted.
+	errPruningStopped = errors.New("state pruning stopped")
+)
+
+// OnlineConfig contains the settings of the online state pruning.
+type OnlineConfig struct {
+	BloomSize uint64        // Megabytes of memory allocated to the bloom filter
+	Throttle  time.Duration // Pause between two consecutive deletion batches
+}
+
+// DefaultOnlineConfig contains the default settings of the online state pruning.
+var DefaultOnlineConfig = OnlineConfig{
+	BloomSize: 2048,
+	Throttle:  10 * time.Millisecond,
+}
+
+// Chain defines the blockchain functionalities required by the online pruning.
+type Chain interface {
+	// CurrentBlock retrieves the current head block of the canonical chain.
+	CurrentBlock() *types.Block
+
+	// Genesis retrieves the chain's genesis block.
+	Genesis() *types.Block
+
+	// Snapshots returns the state snapshot tree, nil if it's disabled.
+	Snapshots() *snapshot.Tree
+
+	// StateCache returns the caching database underpinning the blockchain.
+	StateCache() state.Database
+
+	// PersistState flushes the state with the given root into disk.
+	PersistState(root common.Hash) error
+}
+
+// OnlineStatus is the progress report of the online state pruning.
+type OnlineStatus struct {
+	Phase     string             `json:"phase"`     // Current phase of the pruning
+	Root      common.Hash        `json:"root"`      // Root of the pruning target state
+	Marked    uint64             `json:"marked"`    // Number of trie nodes marked as live
+	Tracked   uint64             `json:"tracked"`   // Number of trie nodes flushed during the pruning
+	Swept     uint64             `json:"swept"`     // Number of trie nodes deleted
+	SweptSize common.StorageSize `json:"sweptSize"` // Total size of the deleted trie nodes
+	Cursor    hexutil.Bytes      `json:"cursor"`    // Database key the sweeping has reached
+	Progress  float64            `json:"progress"`  // Percentage of the swept database key space
+	Running   bool               `json:"running"`   // Whether the pruning procedure is active
+}
+
+// onlineProgress is the persisted progress of the sweeping phase, used to
+// resume the pruning after restarts.
+type onlineProgress struct {
+	Root   common.Hash
+	Cursor []byte
+	Swept  uint64
+	Size   uint64
+}
+
+// OnlinePruner deletes the stale trie nodes in the background while the node
+// keeps importing blocks. The workflow is:
+//
+//   - select the state of the bottom-most snapshot diff layer as the target and
+//     persist it, start tracking all the trie nodes flushed into disk
+//   - mark the recent in-memory states and the entire target state, as well as
+//     the genesis state, in a bloom filter
+//   - iterate the database, delete the trie nodes neither marked nor tracked in
+//     throttled batches
+//
+// The trie nodes flushed during the pruning are logged in the database along
+// with the nodes themselves, so that the sweeping can be resumed after restarts
+// with the persisted bloom filter. Contract codes are never pruned. It's only
+// applicable in the hash-based state scheme.
+type OnlinePruner struct {
+	// Statistics, accessed atomically, kept at the top for 64-bit alignment.
+	marked  uint64
+	tracked uint64
+	swept   uint64
+	size    uint64
+
+	db      ethdb.Database
+	chain   Chain
+	datadir string
+	config  OnlineConfig
+
+	bloom  *stateBloom   // Bloom filter of the live trie nodes, nil if idle
+	root   common.Hash   // Root of the pruning target state
+	phase  string        // Current phase of the pruning
+	cursor []byte        // Database key the sweeping has reached
+	seq    uint64        // Sequence number of the next tracking log
+	quit   chan struct{} // Quit channel to interrupt the running procedure
+	done   chan struct{} // Channel closed when the running procedure exits
+
+	lock      sync.Mutex // Lock protecting the pruning status
+	trackLock sync.Mutex // Lock serializing node tracking and deletion
+}
+
+// NewOnlinePruner creates the online state pruner. If an interrupted pruning is
+// found in the database, the node tracking is restored immediately and the
+// sweeping is resumed in the background.
+func NewOnlinePruner(db ethdb.Database, chain Chain, datadir string, config OnlineConfig) *OnlinePruner {
+	if config.BloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", config.BloomSize, "updated(MB)", 256)
+		config.BloomSize = 256
+	}
+	p := &OnlinePruner{
+		db:      db,
+		chain:   chain,
+		datadir: datadir,
+		config:  config,
+		phase:   PhaseIdle,
+	}
+	blob := rawdb.ReadStatePruningStatus(db)
+	if len(blob) == 0 {
+		// Drop the leftovers of the pruning interrupted during marking
+		p.cleanup()
+		return p
+	}
+	var progress onlineProgress
+	if err := rlp.DecodeBytes(blob, &progress); err != nil {
+		log.Error("Failed to decode state pruning status", "err", err)
+		p.cleanup()
+		return p
+	}
+	if err := p.restore(&progress); err != nil {
+		// Nothing live has been deleted so far, it's safe to abort the pruning
+		log.Error("Failed to resume state pruning, aborting", "err", err)
+		p.cleanup()
+		return p
+	}
+	log.Info("Resuming state pruning", "root", p.root, "swept", p.swept, "cursor", hexutil.Encode(p.cursor))
+	p.quit, p.done = make(chan struct{}), make(chan struct{})
+	go p.run(nil)
+	return p
+}
+
+// restore loads the persisted bloom filter and the tracking logs, and installs
+// the tracking hook into the trie database.
+func (p *OnlinePruner) restore(progress *onlineProgress) error {
+	bloom, err := NewStateBloomFromDisk(onlineBloomName(p.datadir, progress.Root))
+	if err != nil {
+		return err
+	}
+	var tracked uint64
+	if err := rawdb.ReadStatePruningLogs(p.db, func(seq uint64, hashes []byte) error {
+		if len(hashes)%common.HashLength != 0 {
+			return fmt.Errorf("invalid state pruning log %d", seq)
+		}
+		for i := 0; i < len(hashes); i += common.HashLength {
+			bloom.Put(hashes[i:i+common.HashLength], nil)
+		}
+		tracked += uint64(len(hashes) / common.HashLength)
+		p.seq = seq + 1
+		return nil
+	}); err != nil {
+		return err
+	}
+	p.bloom, p.root, p.phase, p.cursor = bloom, progress.Root, PhaseSweeping, progress.Cursor
+	p.tracked, p.swept, p.size = tracked, progress.Swept, progress.Size
+	onlinePhaseGauge.Update(2)
+
+	p.chain.StateCache().TrieDB().SetFlushHook(p.track)
+	return nil
+}
+
+// Start launches the online pruning in the background, or resumes the paused
+// sweeping if there is any.
+func (p *OnlinePruner) Start() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.running() {
+		return errPruningRunning
+	}
+	triedb := p.chain.StateCache().TrieDB()
+	if triedb.Scheme() == rawdb.PathScheme {
+		return errors.New("state pruning is not required in path scheme")
+	}
+	// Resume the paused sweeping if there is any
+	if p.phase == PhaseSweeping {
+		log.Info("Resuming state pruning", "root", p.root, "swept", atomic.LoadUint64(&p.swept))
+		p.quit, p.done = make(chan struct{}), make(chan struct{})
+		go p.run(nil)
+		return nil
+	}
+	// Select the bottom-most snapshot diff layer as the target, the chance of
+	// being reorged out is quite low.
+	snaptree := p.chain.Snapshots()
+	if snaptree == nil {
+		return errors.New("state pruning requires the snapshot")
+	}
+	layers := snaptree.Snapshots(p.chain.CurrentBlock().Root(), onlineRecentLayers, true)
+	if len(layers) != onlineRecentLayers {
+		return fmt.Errorf("snapshot not old enough yet: need %d more blocks", onlineRecentLayers-len(layers))
+	}
+	bloom, err := newStateBloomWithSize(p.config.BloomSize)
+	if err != nil {
+		return err
+	}
+	p.cleanup()
+
+	p.bloom, p.root, p.phase, p.cursor, p.seq = bloom, layers[len(layers)-1].Root(), PhaseMarking, nil, 0
+	atomic.StoreUint64(&p.marked, 0)
+	atomic.StoreUint64(&p.tracked, 0)
+	atomic.StoreUint64(&p.swept, 0)
+	atomic.StoreUint64(&p.size, 0)
+	onlinePhaseGauge.Update(1)
+
+	// Track the flushed nodes before persisting the target state, all the nodes
+	// written into disk from now on are treated as live.
+	triedb.SetFlushHook(p.track)
+	if err := p.chain.PersistState(p.root); err != nil {
+		p.reset()
+		return err
+	}
+	// Collect the recent states above the target, from the oldest to newest
+	var recent []common.Hash
+	for i := len(layers) - 2; i >= 0; i-- {
+		recent = append(recent, layers[i].Root())
+	}
+	log.Info("Started state pruning", "root", p.root, "bloom", common.StorageSize(p.config.BloomSize*1024*1024))
+
+	p.quit, p.done = make(chan struct{}), make(chan struct{})
+	go p.run(recent)
+	return nil
+}
+
+// Stop interrupts the running pruning. The sweeping is paused and can be resumed
+// later, while the marking is aborted entirely.
+func (p *OnlinePruner) Stop() error {
+	p.lock.Lock()
+	if !p.running() {
+		p.lock.Unlock()
+		return errPruningNotRunning
+	}
+	select {
+	case <-p.quit:
+		// Already stopping
+	default:
+		close(p.quit)
+	}
+	done := p.done
+	p.lock.Unlock()
+
+	<-done
+	return nil
+}
+
+// Close terminates the running pruning, keeping the node tracking active until
+// the blockchain is stopped. The sweeping is resumed on the next startup.
+func (p *OnlinePruner) Close() {
+	p.Stop()
+}
+
+// Status returns the progress report of the online pruning.
+func (p *OnlinePruner) Status() *OnlineStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return &OnlineStatus{
+		Phase:     p.phase,
+		Root:      p.root,
+		Marked:    atomic.LoadUint64(&p.marked),
+		Tracked:   atomic.LoadUint64(&p.tracked),
+		Swept:     atomic.LoadUint64(&p.swept),
+		SweptSize: common.StorageSize(atomic.LoadUint64(&p.size)),
+		Cursor:    common.CopyBytes(p.cursor),
+		Progress:  sweepProgress(p.cursor),
+		Running:   p.running(),
+	}
+}
+
+// running reports whether the pruning procedure is active. The caller must hold
+// the lock.
+func (p *OnlinePruner) running() bool {
+	if p.done == nil {
+		return false
+	}
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// run executes the pruning procedure, marking the live state first if it's
+// a fresh pruning.
+func (p *OnlinePruner) run(recent []common.Hash) {
+	defer close(p.done)
+
+	p.lock.Lock()
+	phase := p.phase
+	p.lock.Unlock()
+
+	var err error
+	if phase == PhaseMarking {
+		if err = p.mark(recent); err == nil {
+			err = p.commit()
+		}
+		if err != nil {
+			// Nothing is deleted yet, drop the entire pruning
+			if errors.Is(err, errPruningStopped) {
+				log.Info("State pruning aborted during marking")
+			} else {
+				log.Error("Failed to mark live state", "err", err)
+			}
+			p.lock.Lock()
+			p.reset()
+			p.lock.Unlock()
+			return
+		}
+	}
+	if err = p.sweep(); err != nil {
+		if errors.Is(err, errPruningStopped) {
+			log.Info("State pruning paused", "swept", atomic.LoadUint64(&p.swept))
+		} else {
+			log.Error("Failed to sweep stale state", "err", err)
+		}
+		return
+	}
+	p.lock.Lock()
+	root := p.root
+	p.reset()
+	p.lock.Unlock()
+
+	log.Info("State pruning successful", "root", root, "nodes", atomic.LoadUint64(&p.swept), "size", common.StorageSize(atomic.LoadUint64(&p.size)))
+}
+
+// reset uninstalls the tracking hook and drops all the pruning leftovers. The
+// caller must hold the lock, or be the only accessor.
+func (p *OnlinePruner) reset() {
+	triedb := p.chain.StateCache().TrieDB()
+	triedb.SetFlushHook(nil)
+
+	p.trackLock.Lock()
+	p.bloom, p.seq = nil, 0
+	p.trackLock.Unlock()
+
+	p.cleanup()
+	p.root, p.phase, p.cursor = common.Hash{}, PhaseIdle, nil
+	onlinePhaseGauge.Update(0)
+
+	// The deleted nodes might still be cached, drop them from the cache.
+	triedb.ResetCleans()
+}
+
+// cleanup deletes the persisted pruning status, the tracking logs and the bloom
+// filters from the disk.
+func (p *OnlinePruner) cleanup() {
+	rawdb.DeleteStatePruningStatus(p.db)
+	rawdb.DeleteStatePruningLogs(p.db)
+
+	files, _ := filepath.Glob(filepath.Join(p.datadir, onlineBloomFilePrefix+".*"))
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// track is the flush hook installed into the trie database, marking all the
+// flushed trie nodes as live and logging them for resuming.
+func (p *OnlinePruner) track(batch ethdb.Batch) error {
+	var collector nodeCollector
+	if err := batch.Replay(&collector); err != nil {
+		return err
+	}
+	if len(collector) == 0 {
+		return nil
+	}
+	p.trackLock.Lock()
+	defer p.trackLock.Unlock()
+
+	if p.bloom == nil {
+		return nil // The pruning is just finished
+	}
+	for i := 0; i < len(collector); i += common.HashLength {
+		p.bloom.Put(collector[i:i+common.HashLength], nil)
+	}
+	rawdb.WriteStatePruningLog(batch, p.seq, collector)
+	p.seq++
+
+	n := uint64(len(collector) / common.HashLength)
+	atomic.AddUint64(&p.tracked, n)
+	onlineTrackedMeter.Mark(int64(n))
+	return nil
+}
+
+// nodeCollector is a batch replayer collecting the hashes of the written trie
+// nodes.
+type nodeCollector []byte
+
+// Put implements ethdb.KeyValueWriter, collecting the trie node keys.
+func (c *nodeCollector) Put(key []byte, value []byte) error {
+	if len(key) == common.HashLength {
+		*c = append(*c, key...)
+	}
+	return nil
+}
+
+// Delete implements ethdb.KeyValueWriter, ignoring the deletions.
+func (c *nodeCollector) Delete(key []byte) error {
+	return nil
+}
+
+// mark marks the recent states as well as the target and genesis states as live
+// in the bloom filter.
+func (p *OnlinePruner) mark(recent []common.Hash) error {
+	var (
+		start  = time.Now()
+		triedb = p.chain.StateCache().TrieDB()
+		base   = p.root
+	)
+	// Mark the recent states first, before they are dropped from memory. Only
+	// the difference with the previous state is iterated, the states which are
+	// already unavailable are skipped since they are not live anymore.
+	for _, root := range recent {
+		if root == base {
+			continue
+		}
+		if err := p.markState(triedb, root, base); err != nil {
+			if errors.Is(err, errPruningStopped) {
+				return err
+			}
+			log.Debug("Skipping unavailable recent state", "root", root, "err", err)
+			continue
+		}
+		base = root
+	}
+	log.Info("Marked recent states", "states", len(recent), "nodes", atomic.LoadUint64(&p.marked), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Mark the target state and the genesis state entirely
+	if err := p.markState(triedb, p.root, common.Hash{}); err != nil {
+		return err
+	}
+	if genesis := p.chain.Genesis().Root(); rawdb.HasTrieNode(p.db, genesis) {
+		if err := p.markState(triedb, genesis, common.Hash{}); err != nil {
+			return err
+		}
+	}
+	log.Info("Marked live state", "root", p.root, "nodes", atomic.LoadUint64(&p.marked), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// markState marks the trie nodes of the state with the given root, along with
+// the storage tries and contract codes. If the base is specified, only the
+// difference with the base state is marked.
+func (p *OnlinePruner) markState(triedb *trie.Database, root, base common.Hash) error {
+	if root == emptyRoot {
+		return nil
+	}
+	it, baseTrie, err := p.nodeIterator(triedb, common.Hash{}, root, base)
+	if err != nil {
+		return err
+	}
+	for it.Next(true) {
+		if err := p.markNode(it.Hash()); err != nil {
+			return err
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		prev := emptyRoot
+		if baseTrie != nil {
+			blob, err := baseTrie.TryGet(it.LeafKey())
+			if err != nil {
+				return err
+			}
+			if len(blob) != 0 {
+				var old types.StateAccount
+				if err := rlp.DecodeBytes(blob, &old); err != nil {
+					return err
+				}
+				prev = old.Root
+			}
+		}
+		if acc.Root != emptyRoot && acc.Root != prev {
+			if err := p.markStorage(triedb, common.BytesToHash(it.LeafKey()), acc.Root, prev); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(acc.CodeHash, emptyCode) {
+			p.bloom.Put(acc.CodeHash, nil)
+		}
+	}
+	return it.Error()
+}
+
+// markStorage marks the trie nodes of the storage trie with the given root. If
+// the base is a non-empty trie, only the difference is marked.
+func (p *OnlinePruner) markStorage(triedb *trie.Database, owner, root, base common.Hash) error {
+	if base == emptyRoot {
+		base = common.Hash{}
+	}
+	it, _, err := p.nodeIterator(triedb, owner, root, base)
+	if err != nil {
+		return err
+	}
+	for it.Next(true) {
+		if err := p.markNode(it.Hash()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// nodeIterator creates an iterator over the trie with the given root, or over
+// its difference with the base trie if it's specified.
+func (p *OnlinePruner) nodeIterator(triedb *trie.Database, owner, root, base common.Hash) (trie.NodeIterator, *trie.Trie, error) {
+	tr, err := trie.New(owner, root, triedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if base == (common.Hash{}) {
+		return tr.NodeIterator(nil), nil, nil
+	}
+	baseTrie, err := trie.New(owner, base, triedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	it, _ := trie.NewDifferenceIterator(baseTrie.NodeIterator(nil), tr.NodeIterator(nil))
+	return it, baseTrie, nil
+}
+
+// markNode marks the trie node with the given hash as live, embedded nodes
+// without hash are skipped.
+func (p *OnlinePruner) markNode(hash common.Hash) error {
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	p.bloom.Put(hash.Bytes(), nil)
+	onlineMarkedMeter.Mark(1)
+
+	if atomic.AddUint64(&p.marked, 1)%onlineCheckInterval == 0 {
+		select {
+		case <-p.quit:
+			return errPruningStopped
+		default:
+		}
+	}
+	return nil
+}
+
+// commit persists the bloom filter and the pruning status, after which the
+// sweeping is resumable across restarts.
+func (p *OnlinePruner) commit() error {
+	name := onlineBloomName(p.datadir, p.root)
+	log.Info("Writing state bloom to disk", "name", name)
+	if err := p.bloom.Commit(name, name+stateBloomFileTempSuffix); err != nil {
+		return err
+	}
+	blob, err := rlp.EncodeToBytes(&onlineProgress{Root: p.root})
+	if err != nil {
+		return err
+	}
+	rawdb.WriteStatePruningStatus(p.db, blob)
+
+	p.lock.Lock()
+	p.phase = PhaseSweeping
+	p.lock.Unlock()
+	onlinePhaseGauge.Update(2)
+	return nil
+}
+
+// sweep iterates the database from the persisted cursor, deleting the trie
+// nodes neither marked nor tracked in throttled batches.
+func (p *OnlinePruner) sweep() error {
+	p.lock.Lock()
+	cursor := common.CopyBytes(p.cursor)
+	p.lock.Unlock()
+
+	var (
+		nodes  []staleNode
+		logged = time.Now()
+		iter   = p.db.NewIterator(nil, cursor)
+	)
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		if ok, err := p.bloom.Contain(key); err != nil {
+			iter.Release()
+			return err
+		} else if ok {
+			continue
+		}
+		nodes = append(nodes, staleNode{key: common.CopyBytes(key), size: len(key) + len(iter.Value())})
+		if len(nodes)*common.HashLength < ethdb.IdealBatchSize {
+			continue
+		}
+		// Recreate the iterator after every batch commit in order to allow the
+		// underlying compactor to delete the entries, throttle the deletion to
+		// leave room for the block processing.
+		iter.Release()
+		if err := p.deleteNodes(nodes, key); err != nil {
+			return err
+		}
+		nodes = nodes[:0]
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data", "nodes", atomic.LoadUint64(&p.swept), "size", common.StorageSize(atomic.LoadUint64(&p.size)), "progress", fmt.Sprintf("%.2f%%", sweepProgress(key)))
+			logged = time.Now()
+		}
+		select {
+		case <-p.quit:
+			return errPruningStopped
+		case <-time.After(p.config.Throttle):
+		}
+		iter = p.db.NewIterator(nil, key)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return p.deleteNodes(nodes, nil)
+}
+
+// staleNode is a trie node picked for deletion.
+type staleNode struct {
+	key  []byte
+	size int
+}
+
+// deleteNodes deletes the given trie nodes unless they are tracked meanwhile,
+// and persists the sweeping progress atomically.
+func (p *OnlinePruner) deleteNodes(nodes []staleNode, cursor []byte) error {
+	p.trackLock.Lock()
+	defer p.trackLock.Unlock()
+
+	var (
+		batch = p.db.NewBatch()
+		count uint64
+		size  uint64
+	)
+	for _, node := range nodes {
+		// The node might be flushed again since it's checked, skip it.
+		if ok, err := p.bloom.Contain(node.key); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		batch.Delete(node.key)
+		count, size = count+1, size+uint64(node.size)
+	}
+	swept, total := atomic.AddUint64(&p.swept, count), atomic.AddUint64(&p.size, size)
+
+	blob, err := rlp.EncodeToBytes(&onlineProgress{
+		Root:   p.root,
+		Cursor: cursor,
+		Swept:  swept,
+		Size:   total,
+	})
+	if err != nil {
+		return err
+	}
+	rawdb.WriteStatePruningStatus(batch, blob)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	p.lock.Lock()
+	p.cursor = common.CopyBytes(cursor)
+	p.lock.Unlock()
+
+	onlineSweptMeter.Mark(int64(count))
+	onlineSweptSizeMeter.Mark(int64(size))
+	onlineProgressGauge.Update(int64(sweepProgress(cursor)))
+	return nil
+}
+
+// sweepProgress returns the percentage of the database key space which has
+// been swept, estimated by the first 8 bytes of the cursor.
+func sweepProgress(cursor []byte) float64 {
+	if len(cursor) == 0 {
+		return 0
+	}
+	var prefix [8]byte
+	copy(prefix[:], cursor)
+	return float64(binary.BigEndian.Uint64(prefix[:])) / math.MaxUint64 * 100
+}
+
+func onlineBloomName(datadir string, hash common.Hash) string {
+	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", onlineBloomFilePrefix, hash.Hex(), stateBloomFileSuffix))
+}
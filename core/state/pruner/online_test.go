@@ -0,0 +1,190 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newPruningTestChain creates an archive chain accumulating lots of stale trie
+// nodes on disk, returning the chain and the blocks to be imported later on.
+func newPruningTestChain(t *testing.T, n int, later int) (ethdb.Database, *core.BlockChain, []*types.Block) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), n+later, func(i int, block *core.BlockGen) {
+		for j := 0; j < 20; j++ {
+			var to common.Address
+			binary.BigEndian.PutUint64(to[:], uint64(i*20+j))
+
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), to, big.NewInt(1000), params.TxGas, block.BaseFee(), nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{
+		TrieCleanLimit:    256,
+		TrieDirtyDisabled: true,
+		SnapshotLimit:     256,
+		SnapshotWait:      true,
+	}, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks[:n]); err != nil {
+		t.Fatalf("Failed to insert block %d: %v", n, err)
+	}
+	return db, chain, blocks[n:]
+}
+
+// waitPruning waits until the running state pruning is terminated.
+func waitPruning(t *testing.T, p *OnlinePruner, until func(status *OnlineStatus) bool) *OnlineStatus {
+	for start := time.Now(); time.Since(start) < time.Minute; time.Sleep(10 * time.Millisecond) {
+		if status := p.Status(); until(status) {
+			return status
+		}
+	}
+	t.Fatal("State pruning timed out")
+	return nil
+}
+
+// checkState iterates the entire state with the given root, ensuring all the
+// trie nodes are present.
+func checkState(t *testing.T, chain *core.BlockChain, root common.Hash) {
+	statedb, err := state.New(root, chain.StateCache(), nil)
+	if err != nil {
+		t.Fatalf("State %x is missing: %v", root, err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("State %x is incomplete: %v", root, it.Error)
+	}
+}
+
+// countNodes returns the number of trie nodes stored in the database.
+func countNodes(db ethdb.Database) int {
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	var count int
+	for it.Next() {
+		if len(it.Key()) == common.HashLength {
+			count++
+		}
+	}
+	return count
+}
+
+// Tests that the online pruning deletes the stale trie nodes while the chain is
+// being extended, retaining all the recent states.
+func TestOnlinePruning(t *testing.T) {
+	db, chain, later := newPruningTestChain(t, 300, 20)
+	defer chain.Stop()
+
+	before := countNodes(db)
+	pruner := NewOnlinePruner(db, chain, t.TempDir(), OnlineConfig{BloomSize: 256})
+	if err := pruner.Start(); err != nil {
+		t.Fatalf("Failed to start pruning: %v", err)
+	}
+	if err := pruner.Start(); err != errPruningRunning {
+		t.Fatalf("Unexpected error for restarting pruning: %v", err)
+	}
+	// Keep importing blocks while pruning
+	if n, err := chain.InsertChain(later); err != nil {
+		t.Fatalf("Failed to insert block %d: %v", n, err)
+	}
+	status := waitPruning(t, pruner, func(status *OnlineStatus) bool { return !status.Running })
+	if status.Phase != PhaseIdle {
+		t.Fatalf("Pruning is not finished, phase %s", status.Phase)
+	}
+	if after := countNodes(db); after >= before {
+		t.Fatalf("Stale nodes are not deleted, before %d, after %d", before, after)
+	}
+	head := chain.CurrentBlock().NumberU64()
+	for number := head - onlineRecentLayers + 1; number <= head; number++ {
+		checkState(t, chain, chain.GetBlockByNumber(number).Root())
+	}
+	if rawdb.HasTrieNode(db, chain.GetBlockByNumber(10).Root()) {
+		t.Fatal("Stale state root is not deleted")
+	}
+	if len(rawdb.ReadStatePruningStatus(db)) != 0 {
+		t.Fatal("Pruning status is not deleted")
+	}
+}
+
+// Tests that the paused sweeping is resumed after restart, retaining the trie
+// nodes flushed in the meantime.
+func TestOnlinePruningResume(t *testing.T) {
+	db, chain, later := newPruningTestChain(t, 300, 20)
+	defer chain.Stop()
+
+	// Start the pruning with a huge throttle, it's stuck after the first batch
+	datadir := t.TempDir()
+	pruner := NewOnlinePruner(db, chain, datadir, OnlineConfig{BloomSize: 256, Throttle: time.Hour})
+	if err := pruner.Start(); err != nil {
+		t.Fatalf("Failed to start pruning: %v", err)
+	}
+	waitPruning(t, pruner, func(status *OnlineStatus) bool { return status.Swept > 0 })
+	pruner.Close()
+
+	if status := pruner.Status(); status.Phase != PhaseSweeping || status.Running {
+		t.Fatalf("Unexpected pruning status, phase %s, running %v", status.Phase, status.Running)
+	}
+	// Import blocks while the pruning is paused, the flushed nodes are tracked
+	if n, err := chain.InsertChain(later); err != nil {
+		t.Fatalf("Failed to insert block %d: %v", n, err)
+	}
+	// Restart the pruner, the sweeping should be resumed automatically
+	pruner = NewOnlinePruner(db, chain, datadir, OnlineConfig{BloomSize: 256})
+	status := waitPruning(t, pruner, func(status *OnlineStatus) bool { return !status.Running })
+	if status.Phase != PhaseIdle {
+		t.Fatalf("Pruning is not finished, phase %s", status.Phase)
+	}
+	if status.Tracked == 0 {
+		t.Fatal("Flushed nodes are not tracked")
+	}
+	head := chain.CurrentBlock().NumberU64()
+	for number := head - onlineRecentLayers + 1; number <= head; number++ {
+		checkState(t, chain, chain.GetBlockByNumber(number).Root())
+	}
+}
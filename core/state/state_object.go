@@ -92,6 +92,11 @@ type stateObject struct {
 	dirtyCode bool // true if the code was updated
 	suicided  bool
 	deleted   bool
+
+	// Storage root of the account in the last committed state, used to wipe
+	// the stale storage of destructed or re-created accounts.
+	originRoot common.Hash
+	recreated  bool // true if the account replaced an existing one since the last commit
 }
 
 // empty returns whether the account is considered empty.
@@ -115,6 +120,7 @@ func newObject(db *StateDB, address common.Address, data types.StateAccount) *st
 		address:        address,
 		addrHash:       crypto.Keccak256Hash(address[:]),
 		data:           data,
+		originRoot:     data.Root,
 		originStorage:  make(Storage),
 		pendingStorage: make(Storage),
 		dirtyStorage:   make(Storage),
@@ -442,6 +448,8 @@ func (s *stateObject) deepCopy(db *StateDB) *stateObject {
 	stateObject.suicided = s.suicided
 	stateObject.dirtyCode = s.dirtyCode
 	stateObject.deleted = s.deleted
+	stateObject.originRoot = s.originRoot
+	stateObject.recreated = s.recreated
 	return stateObject
 }
 
@@ -582,6 +582,9 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 		}
 	}
 	newobj = newObject(s, addr, types.StateAccount{})
+	if prev != nil {
+		newobj.originRoot, newobj.recreated = prev.originRoot, true
+	}
 	if prev == nil {
 		s.journal.append(createObjectChange{account: &addr})
 	} else {
@@ -910,25 +913,41 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 	)
 	codeWriter := s.db.DiskDB().NewBatch()
 	for addr := range s.stateObjectsDirty {
-		if obj := s.stateObjects[addr]; !obj.deleted {
+		var (
+			obj = s.stateObjects[addr]
+			set *trie.NodeSet
+			err error
+		)
+		if !obj.deleted {
 			// Write any contract code associated with the state object
 			if obj.code != nil && obj.dirtyCode {
 				rawdb.WriteCode(codeWriter, common.BytesToHash(obj.CodeHash()), obj.code)
 				obj.dirtyCode = false
 			}
 			// Write any storage changes in the state object to its storage trie
-			set, err := obj.CommitTrie(s.db)
-			if err != nil {
+			if set, err = obj.CommitTrie(s.db); err != nil {
 				return common.Hash{}, err
 			}
-			// Merge the dirty nodes of storage trie into global set
-			if set != nil {
-				if err := nodes.Merge(set); err != nil {
-					return common.Hash{}, err
-				}
-				storageTrieNodes += set.Len()
+		}
+		// Wipe the storage left behind by destructed or re-created accounts
+		if obj.deleted || obj.recreated {
+			if set, err = s.wipeStorage(obj, set); err != nil {
+				return common.Hash{}, err
 			}
 		}
+		// Merge the dirty nodes of storage trie into global set
+		if set != nil {
+			if err := nodes.Merge(set); err != nil {
+				return common.Hash{}, err
+			}
+			storageTrieNodes += set.Len()
+		}
+		if obj.deleted {
+			obj.originRoot = emptyRoot
+		} else {
+			obj.originRoot = obj.data.Root
+		}
+		obj.recreated = false
 	}
 	if len(s.stateObjectsDirty) > 0 {
 		s.stateObjectsDirty = make(map[common.Address]struct{})
@@ -986,13 +1005,41 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 		}
 		s.snap, s.snapDestructs, s.snapAccounts, s.snapStorage = nil, nil, nil, nil
 	}
-	if err := s.db.TrieDB().Update(nodes); err != nil {
+	if err := s.db.TrieDB().UpdateState(root, s.originalRoot, nodes); err != nil {
 		return common.Hash{}, err
 	}
 	s.originalRoot = root
 	return root, err
 }
 
+// wipeStorage marks all the nodes of the last committed storage trie of the
+// given account as deleted in the node set, which is created if nil. It's only
+// needed by the path-based scheme, where the nodes of the old storage trie
+// would otherwise remain on disk under the paths of the re-created account.
+func (s *StateDB) wipeStorage(obj *stateObject, set *trie.NodeSet) (*trie.NodeSet, error) {
+	if s.db.TrieDB().Scheme() != rawdb.PathScheme || obj.originRoot == emptyRoot {
+		return set, nil
+	}
+	tr, err := s.db.OpenStorageTrie(obj.addrHash, obj.originRoot)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		set = trie.NewNodeSet(obj.addrHash)
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+		// Embedded nodes are not stored on their own
+		if it.Hash() != (common.Hash{}) {
+			set.MarkDeleted(it.Path())
+		}
+	}
+	if it.Error() != nil {
+		return nil, fmt.Errorf("failed to wipe storage of %x: %w", obj.address, it.Error())
+	}
+	return set, nil
+}
+
 // PrepareAccessList handles the preparatory steps for executing a state transition with
 // regards to both EIP-2929 and EIP-2930:
 //
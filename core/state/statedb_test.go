@@ -32,6 +32,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -954,3 +956,100 @@ func TestFlushOrderDataLoss(t *testing.T) {
 		}
 	}
 }
+
+// Tests that in the path-based scheme the storage of destructed accounts is
+// wiped from disk, both if the account stays deleted and if it's re-created,
+// and that the wiped storage is restored when reverting the state.
+func TestDestructStorageWipe(t *testing.T) {
+	for _, recreate := range []bool{false, true} {
+		testDestructStorageWipe(t, recreate)
+	}
+}
+
+func testDestructStorageWipe(t *testing.T, recreate bool) {
+	diskdb, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer diskdb.Close()
+
+	var (
+		sdb   = NewDatabaseWithConfig(diskdb, &trie.Config{Scheme: rawdb.PathScheme})
+		addr  = common.Address{0x01}
+		owner = crypto.Keccak256Hash(addr.Bytes())
+	)
+	defer sdb.TrieDB().Close()
+
+	countStorageNodes := func() int {
+		it := diskdb.NewIterator(append(rawdb.TrieNodeStoragePrefix, owner.Bytes()...), nil)
+		defer it.Release()
+
+		var count int
+		for it.Next() {
+			count++
+		}
+		return count
+	}
+	// Create an account with a storage trie of multiple nodes and persist it
+	state, _ := New(common.Hash{}, sdb, nil)
+	state.SetNonce(addr, 1)
+	for i := byte(1); i <= 16; i++ {
+		state.SetState(addr, common.Hash{i}, common.Hash{i})
+	}
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().CapLayers(root, 0); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	nodes := countStorageNodes()
+	if nodes <= 1 {
+		t.Fatalf("storage trie too small: %d nodes", nodes)
+	}
+	// Destruct the account, optionally re-creating it with a new storage
+	state, _ = New(root, sdb, nil)
+	state.Suicide(addr)
+	state.Finalise(true)
+	if recreate {
+		state.CreateAccount(addr)
+		state.SetNonce(addr, 1)
+		state.SetState(addr, common.Hash{0xff}, common.Hash{0xff})
+	}
+	next, err := state.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().CapLayers(next, 0); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	want := 0
+	if recreate {
+		want = 1 // Single slot, stored as the root node
+	}
+	if have := countStorageNodes(); have != want {
+		t.Fatalf("recreate %v: stale storage nodes: have %d, want %d", recreate, have, want)
+	}
+	state, _ = New(next, sdb, nil)
+	if have := state.GetState(addr, common.Hash{0x01}); have != (common.Hash{}) {
+		t.Fatalf("recreate %v: destructed slot still present: %x", recreate, have)
+	}
+	if recreate {
+		if have := state.GetState(addr, common.Hash{0xff}); have != (common.Hash{0xff}) {
+			t.Fatalf("new slot mismatch: have %x", have)
+		}
+	}
+	// Revert the destruction and ensure the original storage is restored
+	if err := sdb.TrieDB().Recover(root); err != nil {
+		t.Fatalf("failed to revert state: %v", err)
+	}
+	if have := countStorageNodes(); have != nodes {
+		t.Fatalf("recreate %v: restored storage nodes: have %d, want %d", recreate, have, nodes)
+	}
+	state, _ = New(root, sdb, nil)
+	for i := byte(1); i <= 16; i++ {
+		if have := state.GetState(addr, common.Hash{i}); have != (common.Hash{i}) {
+			t.Fatalf("recreate %v: restored slot %d mismatch: have %x", recreate, i, have)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	cmath "github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -80,6 +81,9 @@ type Message interface {
 	IsFake() bool
 	Data() []byte
 	AccessList() types.AccessList
+
+	DataGasFeeCap() *big.Int
+	DataHashes() []common.Hash
 }
 
 // ExecutionResult includes all output after executing given evm
@@ -192,14 +196,30 @@ func (st *StateTransition) to() common.Address {
 	return *st.msg.To()
 }
 
+// dataGas returns the data gas consumed by the blobs of the message.
+func (st *StateTransition) dataGas() uint64 {
+	return uint64(len(st.msg.DataHashes())) * params.DataGasPerBlob
+}
+
 func (st *StateTransition) buyGas() error {
 	mgval := new(big.Int).SetUint64(st.msg.Gas())
 	mgval = mgval.Mul(mgval, st.gasPrice)
+	if dataGas := st.dataGas(); dataGas > 0 {
+		// The data gas is paid for upfront and is burned in its entirety
+		dataFee := new(big.Int).SetUint64(dataGas)
+		dataFee.Mul(dataFee, misc.CalcDataGasPrice(st.evm.Context.ExcessDataGas))
+		mgval.Add(mgval, dataFee)
+	}
 	balanceCheck := mgval
 	if st.gasFeeCap != nil {
 		balanceCheck = new(big.Int).SetUint64(st.msg.Gas())
 		balanceCheck = balanceCheck.Mul(balanceCheck, st.gasFeeCap)
 		balanceCheck.Add(balanceCheck, st.value)
+		if dataGas := st.dataGas(); dataGas > 0 {
+			dataFeeCap := new(big.Int).SetUint64(dataGas)
+			dataFeeCap.Mul(dataFeeCap, st.msg.DataGasFeeCap())
+			balanceCheck.Add(balanceCheck, dataFeeCap)
+		}
 	}
 	if have, want := st.state.GetBalance(st.msg.From()), balanceCheck; have.Cmp(want) < 0 {
 		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, st.msg.From().Hex(), have, want)
@@ -259,6 +279,18 @@ func (st *StateTransition) preCheck() error {
 			}
 		}
 	}
+	// Make sure that the data gas fee cap of blob transactions covers the data
+	// gas price of the block (post cancun)
+	if st.dataGas() > 0 {
+		if !st.evm.ChainConfig().IsCancun(st.evm.Context.BlockNumber) {
+			return fmt.Errorf("%w: address %v, blob transaction before cancun", ErrTxTypeNotSupported,
+				st.msg.From().Hex())
+		}
+		if price := misc.CalcDataGasPrice(st.evm.Context.ExcessDataGas); st.msg.DataGasFeeCap().Cmp(price) < 0 {
+			return fmt.Errorf("%w: address %v, maxFeePerDataGas: %s dataGasPrice: %s", ErrDataFeeCapTooLow,
+				st.msg.From().Hex(), st.msg.DataGasFeeCap(), price)
+		}
+	}
 	return st.buyGas()
 }
 
@@ -0,0 +1,126 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// ErrMissingBlobSidecar is returned if a blob transaction is submitted to
+	// the pool without the blobs backing its versioned hashes.
+	ErrMissingBlobSidecar = errors.New("missing blob sidecar")
+
+	// ErrBlobPoolOverflow is returned if the blob sidecar store of the pool is
+	// full and can't accept the blobs of another transaction.
+	ErrBlobPoolOverflow = errors.New("blob pool is full")
+)
+
+var blobsGauge = metrics.NewRegisteredGauge("txpool/blobs", nil)
+
+// txBlobStore keeps the sidecars of the blob transactions tracked by the pool.
+// The pool itself only ever holds blob transactions stripped of their sidecars,
+// which are reattached when a transaction is retrieved for propagation. The
+// store is capped by the number of blobs it holds, not transactions.
+//
+// Note, the store is not thread safe, it relies on the pool lock.
+type txBlobStore struct {
+	sidecars map[common.Hash]*types.BlobTxSidecar
+	blobs    int    // Number of blobs currently stored
+	limit    uint64 // Maximum number of blobs to store
+}
+
+// newTxBlobStore creates a blob sidecar store holding at most limit blobs.
+func newTxBlobStore(limit uint64) *txBlobStore {
+	return &txBlobStore{
+		sidecars: make(map[common.Hash]*types.BlobTxSidecar),
+		limit:    limit,
+	}
+}
+
+// has returns whether there is room to store the given number of extra blobs.
+func (s *txBlobStore) has(blobs int) bool {
+	return uint64(s.blobs+blobs) <= s.limit
+}
+
+// get retrieves the sidecar of the blob transaction with the given hash.
+func (s *txBlobStore) get(hash common.Hash) *types.BlobTxSidecar {
+	return s.sidecars[hash]
+}
+
+// add stores the sidecar of the blob transaction with the given hash.
+func (s *txBlobStore) add(hash common.Hash, sidecar *types.BlobTxSidecar) {
+	if _, ok := s.sidecars[hash]; ok {
+		return
+	}
+	s.sidecars[hash] = sidecar
+	s.blobs += len(sidecar.Blobs)
+	blobsGauge.Update(int64(s.blobs))
+}
+
+// remove deletes the sidecar of the blob transaction with the given hash.
+func (s *txBlobStore) remove(hash common.Hash) {
+	sidecar, ok := s.sidecars[hash]
+	if !ok {
+		return
+	}
+	delete(s.sidecars, hash)
+	s.blobs -= len(sidecar.Blobs)
+	blobsGauge.Update(int64(s.blobs))
+}
+
+// prune deletes the sidecars of all the transactions no longer tracked by the
+// given lookup.
+func (s *txBlobStore) prune(all *txLookup) {
+	for hash := range s.sidecars {
+		if all.Get(hash) == nil {
+			s.remove(hash)
+		}
+	}
+}
+
+// validateBlobTx checks that a blob transaction can be accepted into the pool,
+// verifying its sidecar against the versioned hashes it commits to.
+func (pool *TxPool) validateBlobTx(tx *types.Transaction) error {
+	if !pool.eip4844 {
+		return ErrTxTypeNotSupported
+	}
+	if err := types.ValidateBlobTx(tx); err != nil {
+		return err
+	}
+	sidecar := tx.BlobTxSidecar()
+	if sidecar == nil {
+		// Transactions reinjected after a reorg may still have their sidecar around
+		if sidecar = pool.blobs.get(tx.Hash()); sidecar == nil {
+			return ErrMissingBlobSidecar
+		}
+	}
+	return sidecar.Validate(tx.DataHashes())
+}
+
+// BlobSidecar returns the sidecar of the blob transaction with the given hash,
+// or nil if the pool does not know about it.
+func (pool *TxPool) BlobSidecar(hash common.Hash) *types.BlobTxSidecar {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.blobs.get(hash)
+}
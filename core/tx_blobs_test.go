@@ -0,0 +1,163 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// kzgSetupOnce guards the loading of the insecure kzg test setup.
+var (
+	kzgSetupOnce sync.Once
+	kzgSetupErr  error
+)
+
+// loadTestKZGSetup loads the insecure trusted setup used by the kzg tests, as
+// commitments and proofs are refused without a setup.
+func loadTestKZGSetup(t *testing.T) {
+	kzgSetupOnce.Do(func() {
+		kzgSetupErr = kzg.LoadTrustedSetup(filepath.Join("..", "crypto", "kzg", "testdata", "dev_setup.json"))
+	})
+	if kzgSetupErr != nil {
+		t.Fatalf("failed to load kzg setup: %v", kzgSetupErr)
+	}
+}
+
+// blobTx creates a signed blob transaction carrying a single blob derived from
+// the given seed.
+func blobTx(t *testing.T, nonce uint64, seed byte, key *ecdsa.PrivateKey) *types.Transaction {
+	loadTestKZGSetup(t)
+
+	var blob kzg.Blob
+	for i := 0; i < kzg.FieldElementsPerBlob; i++ {
+		blob[i*kzg.BytesPerFieldElement+30] = byte(i)
+		blob[i*kzg.BytesPerFieldElement+31] = seed
+	}
+	commitment, err := kzg.BlobToCommitment(&blob)
+	if err != nil {
+		t.Fatalf("failed to commit to blob: %v", err)
+	}
+	proof, err := kzg.ComputeBlobProof(&blob, commitment)
+	if err != nil {
+		t.Fatalf("failed to compute blob proof: %v", err)
+	}
+	sidecar := &types.BlobTxSidecar{
+		Blobs:       []kzg.Blob{blob},
+		Commitments: []kzg.Commitment{commitment},
+		Proofs:      []kzg.Proof{proof},
+	}
+	tx, _ := types.SignNewTx(key, types.NewCancunSigner(params.TestChainConfig.ChainID), &types.BlobTx{
+		ChainID:       params.TestChainConfig.ChainID,
+		Nonce:         nonce,
+		GasTipCap:     big.NewInt(1),
+		GasFeeCap:     big.NewInt(1),
+		Gas:           21000,
+		To:            common.Address{},
+		Value:         big.NewInt(100),
+		DataGasFeeCap: big.NewInt(1),
+		BlobHashes:    sidecar.BlobHashes(),
+		Sidecar:       sidecar,
+	})
+	return tx
+}
+
+// Tests that blob transactions are only accepted along with their sidecars,
+// which are kept apart from the transactions and capped by the blob slots.
+func TestBlobTransactions(t *testing.T) {
+	t.Parallel()
+
+	cpy := *eip1559Config
+	config := &cpy
+	config.CancunBlock = common.Big0
+
+	poolConfig := testTxPoolConfig
+	poolConfig.BlobSlots = 1
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed)}
+
+	pool := NewTxPool(poolConfig, config, blockchain)
+	defer pool.Stop()
+	<-pool.initDoneCh
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	first, second := blobTx(t, 0, 1, key), blobTx(t, 1, 2, key)
+
+	// Blob transactions without sidecars must be rejected
+	if err := pool.AddRemote(first.WithoutBlobTxSidecar()); err != ErrMissingBlobSidecar {
+		t.Fatalf("missing sidecar error mismatch: have %v, want %v", err, ErrMissingBlobSidecar)
+	}
+	if err := pool.AddRemote(first); err != nil {
+		t.Fatalf("failed to add blob transaction: %v", err)
+	}
+	// The pool should only track the stripped transaction, reattaching the
+	// sidecar on retrieval
+	pool.mu.RLock()
+	tracked := pool.all.Get(first.Hash())
+	pool.mu.RUnlock()
+	if tracked == nil || tracked.BlobTxSidecar() != nil {
+		t.Fatalf("tracked transaction mismatch: have %v", tracked)
+	}
+	if tx := pool.Get(first.Hash()); tx == nil || tx.BlobTxSidecar() == nil {
+		t.Fatalf("retrieved transaction missing sidecar")
+	}
+	if pool.BlobSidecar(first.Hash()) == nil {
+		t.Fatalf("blob sidecar not stored")
+	}
+	// The blob store is full, any further blobs must be rejected
+	if err := pool.AddRemote(second); err != ErrBlobPoolOverflow {
+		t.Fatalf("blob overflow error mismatch: have %v, want %v", err, ErrBlobPoolOverflow)
+	}
+	// Removing the transaction should release its blobs
+	pool.mu.Lock()
+	pool.removeTx(first.Hash(), true)
+	pool.mu.Unlock()
+
+	if pool.BlobSidecar(first.Hash()) != nil {
+		t.Fatalf("blob sidecar not released")
+	}
+	if err := pool.AddRemote(second); err != nil {
+		t.Fatalf("failed to add blob transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Blob transactions must be rejected if Cancun is not configured, the pool
+	// signer fails to recover their sender before any further validation
+	legacy, _ := setupTxPoolWithConfig(eip1559Config)
+	defer legacy.Stop()
+
+	testAddBalance(legacy, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	if err := legacy.AddRemote(first); err != ErrInvalidSender {
+		t.Fatalf("pre-cancun error mismatch: have %v, want %v", err, ErrInvalidSender)
+	}
+}
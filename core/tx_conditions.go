@@ -0,0 +1,238 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxConditionCost is the maximum number of storage roots and slots a single
+// conditional transaction may require to be checked.
+const maxConditionCost = 1000
+
+var (
+	// ErrTxConditionsTooLarge is returned if a conditional transaction requests
+	// more state preconditions to be checked than permitted.
+	ErrTxConditionsTooLarge = errors.New("too many transaction preconditions")
+
+	// ErrTxConditionsInvalid is returned if a conditional transaction has an empty
+	// block number or timestamp range.
+	ErrTxConditionsInvalid = errors.New("invalid transaction precondition range")
+
+	// ErrTxBlockRange is returned if a conditional transaction cannot be included
+	// into a block with the given number.
+	ErrTxBlockRange = errors.New("block number out of conditional range")
+
+	// ErrTxTimestampRange is returned if a conditional transaction cannot be
+	// included into a block with the given timestamp.
+	ErrTxTimestampRange = errors.New("timestamp out of conditional range")
+
+	// ErrTxStorageCondition is returned if the state of an account known by a
+	// conditional transaction doesn't match the expected one.
+	ErrTxStorageCondition = errors.New("storage precondition failed")
+)
+
+// KnownAccount is the expected storage of an account, required to hold for a
+// conditional transaction to be included. Either the entire storage root or a
+// set of individual slots may be specified.
+type KnownAccount struct {
+	StorageRoot  *common.Hash                // Expected storage root of the account
+	StorageSlots map[common.Hash]common.Hash // Expected values of individual storage slots
+}
+
+// TxConditions is the set of preconditions a conditional transaction may only
+// be included into a block under. Unset limits are not enforced.
+type TxConditions struct {
+	BlockNumberMin *uint64 // Minimum number of the including block
+	BlockNumberMax *uint64 // Maximum number of the including block
+	TimestampMin   *uint64 // Minimum timestamp of the including block
+	TimestampMax   *uint64 // Maximum timestamp of the including block
+
+	KnownAccounts map[common.Address]*KnownAccount // Expected storage of accounts before execution
+}
+
+// validate checks that the conditions are well formed and cheap enough to check.
+func (c *TxConditions) validate() error {
+	if c.BlockNumberMin != nil && c.BlockNumberMax != nil && *c.BlockNumberMin > *c.BlockNumberMax {
+		return fmt.Errorf("%w: block number min %d above max %d", ErrTxConditionsInvalid, *c.BlockNumberMin, *c.BlockNumberMax)
+	}
+	if c.TimestampMin != nil && c.TimestampMax != nil && *c.TimestampMin > *c.TimestampMax {
+		return fmt.Errorf("%w: timestamp min %d above max %d", ErrTxConditionsInvalid, *c.TimestampMin, *c.TimestampMax)
+	}
+	cost := 0
+	for _, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			cost++
+		}
+		cost += len(account.StorageSlots)
+	}
+	if cost > maxConditionCost {
+		return fmt.Errorf("%w: have %d, max %d", ErrTxConditionsTooLarge, cost, maxConditionCost)
+	}
+	return nil
+}
+
+// CheckBlock verifies the block number and timestamp preconditions against a
+// block with the given number and timestamp.
+func (c *TxConditions) CheckBlock(number uint64, timestamp uint64) error {
+	if c.BlockNumberMin != nil && number < *c.BlockNumberMin {
+		return fmt.Errorf("%w: have %d, min %d", ErrTxBlockRange, number, *c.BlockNumberMin)
+	}
+	if c.BlockNumberMax != nil && number > *c.BlockNumberMax {
+		return fmt.Errorf("%w: have %d, max %d", ErrTxBlockRange, number, *c.BlockNumberMax)
+	}
+	if c.TimestampMin != nil && timestamp < *c.TimestampMin {
+		return fmt.Errorf("%w: have %d, min %d", ErrTxTimestampRange, timestamp, *c.TimestampMin)
+	}
+	if c.TimestampMax != nil && timestamp > *c.TimestampMax {
+		return fmt.Errorf("%w: have %d, max %d", ErrTxTimestampRange, timestamp, *c.TimestampMax)
+	}
+	return nil
+}
+
+// CheckState verifies the storage preconditions against the given state.
+func (c *TxConditions) CheckState(statedb *state.StateDB) error {
+	for addr, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			root := types.EmptyRootHash
+			if trie := statedb.StorageTrie(addr); trie != nil {
+				root = trie.Hash()
+			}
+			if root != *account.StorageRoot {
+				return fmt.Errorf("%w: account %v storage root %v, want %v", ErrTxStorageCondition, addr, root, *account.StorageRoot)
+			}
+		}
+		for slot, want := range account.StorageSlots {
+			if have := statedb.GetState(addr, slot); have != want {
+				return fmt.Errorf("%w: account %v slot %v value %v, want %v", ErrTxStorageCondition, addr, slot, have, want)
+			}
+		}
+	}
+	return nil
+}
+
+// expired returns whether the block number or timestamp range of the conditions
+// ended before any block built on top of the given head.
+func (c *TxConditions) expired(head *types.Header) bool {
+	if c.BlockNumberMax != nil && *c.BlockNumberMax <= head.Number.Uint64() {
+		return true
+	}
+	if c.TimestampMax != nil && *c.TimestampMax <= head.Time {
+		return true
+	}
+	return false
+}
+
+// AddConditional enqueues a single remote transaction into the pool, which is
+// only permitted to be included into blocks satisfying the given preconditions.
+// The preconditions are verified against the current head on admission and
+// rechecked on every new head, dropping the transaction once they fail. Like
+// private transactions, conditional ones are never propagated to the network.
+func (pool *TxPool) AddConditional(tx *types.Transaction, conditions *TxConditions) error {
+	if err := conditions.validate(); err != nil {
+		return err
+	}
+	if _, err := types.Sender(pool.signer, tx); err != nil {
+		invalidTxMeter.Mark(1)
+		return ErrInvalidSender
+	}
+	hash := tx.Hash()
+
+	pool.mu.Lock()
+	if pool.all.Get(hash) != nil {
+		pool.mu.Unlock()
+		knownTxMeter.Mark(1)
+		return ErrAlreadyKnown
+	}
+	if err := pool.checkConditions(conditions); err != nil {
+		pool.mu.Unlock()
+		return err
+	}
+	// Track the conditions before admission so the transaction is never
+	// observable without them
+	pool.conditions[hash] = conditions
+	errs, dirty := pool.addTxsLocked([]*types.Transaction{tx}, false)
+	if errs[0] != nil {
+		delete(pool.conditions, hash)
+	}
+	pool.mu.Unlock()
+	pool.flushLifecycle()
+
+	pool.requestPromoteExecutables(dirty)
+	return errs[0]
+}
+
+// Conditions retrieves the inclusion preconditions of all the conditional
+// transactions currently in the pool, keyed by transaction hash.
+func (pool *TxPool) Conditions() map[common.Hash]*TxConditions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	conditions := make(map[common.Hash]*TxConditions, len(pool.conditions))
+	for hash, cond := range pool.conditions {
+		conditions[hash] = cond
+	}
+	return conditions
+}
+
+// IsConditional returns whether the transaction with the given hash was
+// submitted with inclusion preconditions. Conditional transactions must not be
+// propagated to the network, as remote builders would not check them.
+func (pool *TxPool) IsConditional(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.conditions[hash]
+	return ok
+}
+
+// checkConditions verifies whether the given preconditions may still be met by
+// a block built on top of the current head. The pool lock must be held.
+func (pool *TxPool) checkConditions(conditions *TxConditions) error {
+	if conditions.expired(pool.currentHead) {
+		if conditions.BlockNumberMax != nil && *conditions.BlockNumberMax <= pool.currentHead.Number.Uint64() {
+			return fmt.Errorf("%w: head %d, max %d", ErrTxBlockRange, pool.currentHead.Number, *conditions.BlockNumberMax)
+		}
+		return fmt.Errorf("%w: head %d, max %d", ErrTxTimestampRange, pool.currentHead.Time, *conditions.TimestampMax)
+	}
+	return conditions.CheckState(pool.currentState)
+}
+
+// recheckConditions drops all the conditional transactions whose preconditions
+// can no longer be met after a new head was set, and forgets the preconditions
+// of transactions already gone from the pool. The pool lock must be held.
+func (pool *TxPool) recheckConditions() {
+	for hash, conditions := range pool.conditions {
+		tx := pool.all.Get(hash)
+		if tx == nil {
+			delete(pool.conditions, hash)
+			continue
+		}
+		if err := pool.checkConditions(conditions); err != nil {
+			log.Trace("Dropping conditional transaction", "hash", hash, "err", err)
+			pool.removeTx(hash, true)
+			pool.lifecycle.dropped(err, tx)
+			delete(pool.conditions, hash)
+		}
+	}
+}
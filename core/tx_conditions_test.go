@@ -0,0 +1,146 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that conditional transactions are only admitted if their preconditions
+// may still hold, and that they are dropped once a new head invalidates them.
+func TestConditionalTransactions(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	var (
+		from     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0ffee")
+		slot     = common.HexToHash("0x01")
+		value    = common.HexToHash("0x02")
+	)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+	pool.mu.Lock()
+	pool.currentState.SetState(contract, slot, value)
+	pool.mu.Unlock()
+
+	uint64ptr := func(n uint64) *uint64 { return &n }
+	head := func(number uint64, time uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), Time: time, GasLimit: 10000000, BaseFee: big.NewInt(1)}
+	}
+	<-pool.requestReset(nil, head(10, 100))
+
+	// Malformed, expired and failing preconditions should be rejected
+	tx := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.AddConditional(tx, &TxConditions{BlockNumberMin: uint64ptr(20), BlockNumberMax: uint64ptr(15)}); !errors.Is(err, ErrTxConditionsInvalid) {
+		t.Fatalf("invalid range error mismatch: have %v, want %v", err, ErrTxConditionsInvalid)
+	}
+	if err := pool.AddConditional(tx, &TxConditions{BlockNumberMax: uint64ptr(10)}); !errors.Is(err, ErrTxBlockRange) {
+		t.Fatalf("expired block range error mismatch: have %v, want %v", err, ErrTxBlockRange)
+	}
+	if err := pool.AddConditional(tx, &TxConditions{TimestampMax: uint64ptr(100)}); !errors.Is(err, ErrTxTimestampRange) {
+		t.Fatalf("expired timestamp range error mismatch: have %v, want %v", err, ErrTxTimestampRange)
+	}
+	mismatch := map[common.Address]*KnownAccount{contract: {StorageSlots: map[common.Hash]common.Hash{slot: {}}}}
+	if err := pool.AddConditional(tx, &TxConditions{KnownAccounts: mismatch}); !errors.Is(err, ErrTxStorageCondition) {
+		t.Fatalf("storage condition error mismatch: have %v, want %v", err, ErrTxStorageCondition)
+	}
+	// Valid preconditions should be admitted and tracked
+	match := map[common.Address]*KnownAccount{contract: {StorageSlots: map[common.Hash]common.Hash{slot: value}}}
+	if err := pool.AddConditional(tx, &TxConditions{BlockNumberMax: uint64ptr(12), KnownAccounts: match}); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer, from))
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", pending, 1)
+	}
+	if cond := pool.Conditions()[tx.Hash()]; cond == nil || cond.CheckBlock(13, 0) == nil {
+		t.Fatalf("conditions not tracked: %v", cond)
+	}
+	// A new head within range should keep the transaction, past it drop it
+	<-pool.requestReset(nil, head(11, 110))
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", pending, 1)
+	}
+	<-pool.requestReset(nil, head(12, 120))
+	if pending, _ := pool.Stats(); pending != 0 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", pending, 0)
+	}
+	if len(pool.Conditions()) != 0 {
+		t.Fatalf("conditions of dropped transaction retained")
+	}
+	// Changing the known storage should drop the transaction on the next head
+	if err := pool.AddConditional(tx, &TxConditions{KnownAccounts: match}); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	pool.mu.Lock()
+	pool.currentState.SetState(contract, slot, common.Hash{})
+	pool.mu.Unlock()
+
+	<-pool.requestReset(nil, head(13, 130))
+	if pending, queued := pool.Stats(); pending+queued != 0 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 0, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that conditional transactions are kept local, never being emitted to
+// the subsystems propagating transactions to the network.
+func TestConditionalTransactionsNotAnnounced(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	events := make(chan NewTxsEvent, 32)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	other, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000000))
+
+	conditional := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.AddConditional(conditional, &TxConditions{}); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	if !pool.IsConditional(conditional.Hash()) {
+		t.Fatalf("transaction not marked conditional")
+	}
+	public := pricedTransaction(0, 100000, big.NewInt(1), other)
+	if err := pool.addRemoteSync(public); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if pool.IsConditional(public.Hash()) {
+		t.Fatalf("remote transaction marked conditional")
+	}
+	if err := validateEvents(events, 1); err != nil {
+		t.Fatalf("event firing failed: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", pending, 2)
+	}
+}
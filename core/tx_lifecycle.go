@@ -0,0 +1,147 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+var (
+	// ErrTxExpired is the drop reason of non-executable transactions that were
+	// queued for longer than the configured pool lifetime.
+	ErrTxExpired = errors.New("transaction expired")
+
+	// ErrTxUnexecutable is the drop reason of transactions that the sender can
+	// no longer pay for, or that exceed the block gas limit.
+	ErrTxUnexecutable = errors.New("insufficient funds or exceeds block gas limit")
+)
+
+// TxLifecycle is the kind of a state change a transaction went through within
+// the transaction pool.
+type TxLifecycle uint8
+
+const (
+	TxLifecycleAdded    TxLifecycle = iota // Transaction entered the pool
+	TxLifecyclePromoted                    // Transaction became executable (pending)
+	TxLifecycleDemoted                     // Transaction became non-executable (queued)
+	TxLifecycleReplaced                    // Transaction was replaced by one with a higher fee
+	TxLifecycleDropped                     // Transaction was evicted from the pool
+	TxLifecycleIncluded                    // Transaction was included into the chain
+)
+
+// String implements fmt.Stringer.
+func (l TxLifecycle) String() string {
+	switch l {
+	case TxLifecycleAdded:
+		return "added"
+	case TxLifecyclePromoted:
+		return "promoted"
+	case TxLifecycleDemoted:
+		return "demoted"
+	case TxLifecycleReplaced:
+		return "replaced"
+	case TxLifecycleDropped:
+		return "dropped"
+	case TxLifecycleIncluded:
+		return "included"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(l))
+	}
+}
+
+// TxLifecycleChange is a single state change of a pooled transaction.
+type TxLifecycleChange struct {
+	Kind        TxLifecycle        // Kind of the state change
+	Tx          *types.Transaction // Transaction changing its state
+	Replacement common.Hash        // Hash of the replacing transaction, if replaced
+	Reason      error              // Reason of the eviction, if dropped
+}
+
+// TxLifecycleEvent is posted when a batch of transactions change their state
+// within the transaction pool.
+type TxLifecycleEvent struct{ Changes []*TxLifecycleChange }
+
+// txLifecycleTracker accumulates the state changes of pooled transactions until
+// they are flushed to the subscribers. Changes are tracked while the pool lock
+// is held, but delivered only after it's released to avoid blocking the pool
+// on slow subscribers.
+type txLifecycleTracker struct {
+	feed    event.Feed
+	changes []*TxLifecycleChange
+	flushMu sync.Mutex // Ensures batches are delivered in the order they were taken
+}
+
+// track records a new transaction state change. The pool lock must be held.
+func (t *txLifecycleTracker) track(kind TxLifecycle, tx *types.Transaction) {
+	t.changes = append(t.changes, &TxLifecycleChange{Kind: kind, Tx: tx})
+}
+
+// trackAll records the same state change for a batch of transactions. The pool
+// lock must be held.
+func (t *txLifecycleTracker) trackAll(kind TxLifecycle, txs types.Transactions) {
+	for _, tx := range txs {
+		t.track(kind, tx)
+	}
+}
+
+// replaced records the replacement of a transaction. The pool lock must be held.
+func (t *txLifecycleTracker) replaced(old *types.Transaction, replacement *types.Transaction) {
+	t.changes = append(t.changes, &TxLifecycleChange{Kind: TxLifecycleReplaced, Tx: old, Replacement: replacement.Hash()})
+}
+
+// dropped records the eviction of a batch of transactions for the given reason.
+// The pool lock must be held.
+func (t *txLifecycleTracker) dropped(reason error, txs ...*types.Transaction) {
+	for _, tx := range txs {
+		t.changes = append(t.changes, &TxLifecycleChange{Kind: TxLifecycleDropped, Tx: tx, Reason: reason})
+	}
+}
+
+// take retrieves and resets the accumulated state changes. The pool lock must
+// be held.
+func (t *txLifecycleTracker) take() []*TxLifecycleChange {
+	changes := t.changes
+	t.changes = nil
+	return changes
+}
+
+// SubscribeTxLifecycleEvent registers a subscription of TxLifecycleEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxLifecycleEvent(ch chan<- TxLifecycleEvent) event.Subscription {
+	return pool.scope.Track(pool.lifecycle.feed.Subscribe(ch))
+}
+
+// flushLifecycle delivers the accumulated transaction state changes to the
+// subscribers. It must be called without holding the pool lock.
+func (pool *TxPool) flushLifecycle() {
+	pool.lifecycle.flushMu.Lock()
+	defer pool.lifecycle.flushMu.Unlock()
+
+	pool.mu.Lock()
+	changes := pool.lifecycle.take()
+	pool.mu.Unlock()
+
+	if len(changes) > 0 {
+		pool.lifecycle.feed.Send(TxLifecycleEvent{Changes: changes})
+	}
+}
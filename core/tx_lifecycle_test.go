@@ -0,0 +1,148 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the transaction pool reports the state changes of the pooled
+// transactions through the lifecycle feed.
+func TestTransactionLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	events := make(chan TxLifecycleEvent, 32)
+	sub := pool.SubscribeTxLifecycleEvent(events)
+	defer sub.Unsubscribe()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// Collect the lifecycle changes of the next operation, waiting for the
+	// asynchronous reorg to finish
+	expect := func(want ...TxLifecycle) []*TxLifecycleChange {
+		t.Helper()
+
+		var changes []*TxLifecycleChange
+		timeout := time.After(time.Second)
+		for len(changes) < len(want) {
+			select {
+			case ev := <-events:
+				changes = append(changes, ev.Changes...)
+			case <-timeout:
+				t.Fatalf("timed out waiting for lifecycle changes: have %d, want %d", len(changes), len(want))
+			}
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("lifecycle change count mismatch: have %d, want %d", len(changes), len(want))
+		}
+		for i, change := range changes {
+			if change.Kind != want[i] {
+				t.Fatalf("lifecycle change %d mismatch: have %v, want %v", i, change.Kind, want[i])
+			}
+		}
+		return changes
+	}
+	// A gapped transaction should only be added, and promoted once the gap fills
+	gapped := pricedTransaction(1, 100000, big.NewInt(1), key)
+	if err := pool.addRemoteSync(gapped); err != nil {
+		t.Fatalf("failed to add gapped transaction: %v", err)
+	}
+	expect(TxLifecycleAdded)
+
+	first := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.addRemoteSync(first); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	expect(TxLifecycleAdded, TxLifecyclePromoted, TxLifecyclePromoted)
+
+	// Replacing a pending transaction should report both sides of the swap
+	replacement := pricedTransaction(0, 100000, big.NewInt(2), key)
+	if err := pool.addRemoteSync(replacement); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	changes := expect(TxLifecycleReplaced, TxLifecycleAdded, TxLifecyclePromoted)
+	if changes[0].Tx.Hash() != first.Hash() || changes[0].Replacement != replacement.Hash() {
+		t.Fatalf("replacement mismatch: have %x -> %x", changes[0].Tx.Hash(), changes[0].Replacement)
+	}
+	// Raising the minimum gas price should drop the underpriced gapped remote
+	pool.SetGasPrice(big.NewInt(2))
+	changes = expect(TxLifecycleDropped)
+	if changes[0].Tx.Hash() != gapped.Hash() || changes[0].Reason != ErrUnderpriced {
+		t.Fatalf("drop mismatch: have %x (%v)", changes[0].Tx.Hash(), changes[0].Reason)
+	}
+	// Invalidating the replacement without including it should report it as a
+	// dropped nonce too low transaction
+	pool.mu.Lock()
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 1)
+	pool.mu.Unlock()
+	<-pool.requestReset(nil, nil)
+
+	changes = expect(TxLifecycleDropped)
+	if changes[0].Tx.Hash() != replacement.Hash() || changes[0].Reason != ErrNonceTooLow {
+		t.Fatalf("invalidated transaction mismatch: have %x (%v), want %x", changes[0].Tx.Hash(), changes[0].Reason, replacement.Hash())
+	}
+	// Including a transaction into the chain should report it as included
+	next := pricedTransaction(1, 100000, big.NewInt(2), key)
+	if err := pool.addRemoteSync(next); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	expect(TxLifecycleAdded, TxLifecyclePromoted)
+
+	pool.mu.Lock()
+	pool.chain = &testIncludingChain{pool.chain.(*testBlockChain), types.Transactions{next}}
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 2)
+	pool.mu.Unlock()
+	<-pool.requestReset(nil, nil)
+
+	changes = expect(TxLifecycleIncluded)
+	if changes[0].Tx.Hash() != next.Hash() {
+		t.Fatalf("included transaction mismatch: have %x, want %x", changes[0].Tx.Hash(), next.Hash())
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected lifecycle event: %v", ev.Changes)
+	default:
+	}
+}
+
+// testIncludingChain is a test blockchain whose head block includes the given
+// transactions.
+type testIncludingChain struct {
+	*testBlockChain
+	txs types.Transactions
+}
+
+func (bc *testIncludingChain) CurrentBlock() *types.Block {
+	return types.NewBlock(&types.Header{
+		GasLimit: atomic.LoadUint64(&bc.gasLimit),
+	}, bc.txs, nil, nil, trie.NewStackTrie(nil))
+}
+
+func (bc *testIncludingChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.CurrentBlock()
+}
@@ -0,0 +1,282 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrSenderNotAllowed is returned by the address list policy if the sender
+	// of a transaction is not contained in a non-empty sender allowlist.
+	ErrSenderNotAllowed = errors.New("sender not allowed")
+
+	// ErrSenderDenied is returned by the address list policy if the sender of a
+	// transaction is contained in the sender denylist.
+	ErrSenderDenied = errors.New("sender denied")
+
+	// ErrRecipientNotAllowed is returned by the address list policy if the
+	// recipient of a transaction is not contained in a non-empty recipient
+	// allowlist. Contract creations never match a recipient allowlist.
+	ErrRecipientNotAllowed = errors.New("recipient not allowed")
+
+	// ErrRecipientDenied is returned by the address list policy if the recipient
+	// of a transaction is contained in the recipient denylist.
+	ErrRecipientDenied = errors.New("recipient denied")
+
+	// ErrMethodDenied is returned by the method filter policy if a transaction
+	// calls a contract method that is filtered out.
+	ErrMethodDenied = errors.New("contract method denied")
+
+	// ErrFeeBelowPolicy is returned by the fee floor policy if the fee cap of a
+	// transaction is below the configured minimum.
+	ErrFeeBelowPolicy = errors.New("fee cap below policy minimum")
+)
+
+// errcodeTxPolicyRejected is the JSON-RPC error code returned for transactions
+// rejected by an admission policy. It matches the "transaction rejected" code
+// of EIP-1474.
+const errcodeTxPolicyRejected = -32003
+
+// TxPolicy is an admission rule consulted by the transaction pool for every new
+// transaction, after all the built-in consensus and sanity checks have passed.
+// Policies are invoked with the pool lock held, so they must not call back into
+// the pool and should be cheap to evaluate.
+type TxPolicy interface {
+	// Name returns a short identifier of the policy, used in rejection errors.
+	Name() string
+
+	// Check returns a non-nil error if the transaction must not be admitted
+	// into the pool. The sender is already recovered and the local flag is
+	// set if the transaction was submitted locally or by a local account.
+	Check(tx *types.Transaction, from common.Address, local bool) error
+}
+
+// TxPolicyError is returned by the transaction pool if a transaction was
+// rejected by one of the registered admission policies. It implements the rpc
+// error interfaces so that the rejection reason is surfaced to API clients.
+type TxPolicyError struct {
+	Policy string // Name of the policy rejecting the transaction
+	Reason error  // Reason reported by the policy
+}
+
+// Error implements error.
+func (e *TxPolicyError) Error() string {
+	return fmt.Sprintf("rejected by txpool policy %s: %v", e.Policy, e.Reason)
+}
+
+// Unwrap returns the reason reported by the policy.
+func (e *TxPolicyError) Unwrap() error {
+	return e.Reason
+}
+
+// ErrorCode returns the JSON-RPC error code of a policy rejection.
+func (e *TxPolicyError) ErrorCode() int {
+	return errcodeTxPolicyRejected
+}
+
+// ErrorData returns the policy name and rejection reason as JSON-RPC error data.
+func (e *TxPolicyError) ErrorData() interface{} {
+	return map[string]string{
+		"policy": e.Policy,
+		"reason": e.Reason.Error(),
+	}
+}
+
+// AddressListPolicy is a transaction admission policy filtering transactions
+// based on their sender and recipient. Denylists take precedence over allowlists,
+// and an empty allowlist admits every address.
+type AddressListPolicy struct {
+	allowSenders    map[common.Address]struct{}
+	denySenders     map[common.Address]struct{}
+	allowRecipients map[common.Address]struct{}
+	denyRecipients  map[common.Address]struct{}
+}
+
+// NewAddressListPolicy creates an address list policy from the given allow-
+// and denylists.
+func NewAddressListPolicy(allowSenders, denySenders, allowRecipients, denyRecipients []common.Address) *AddressListPolicy {
+	return &AddressListPolicy{
+		allowSenders:    addressSet(allowSenders),
+		denySenders:     addressSet(denySenders),
+		allowRecipients: addressSet(allowRecipients),
+		denyRecipients:  addressSet(denyRecipients),
+	}
+}
+
+// addressSet converts a list of addresses into a lookup set.
+func addressSet(addrs []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// Name implements TxPolicy.
+func (p *AddressListPolicy) Name() string { return "addresslist" }
+
+// Check implements TxPolicy.
+func (p *AddressListPolicy) Check(tx *types.Transaction, from common.Address, local bool) error {
+	if _, ok := p.denySenders[from]; ok {
+		return ErrSenderDenied
+	}
+	if len(p.allowSenders) > 0 {
+		if _, ok := p.allowSenders[from]; !ok {
+			return ErrSenderNotAllowed
+		}
+	}
+	to := tx.To()
+	if to != nil {
+		if _, ok := p.denyRecipients[*to]; ok {
+			return ErrRecipientDenied
+		}
+	}
+	if len(p.allowRecipients) > 0 {
+		if to == nil {
+			return ErrRecipientNotAllowed
+		}
+		if _, ok := p.allowRecipients[*to]; !ok {
+			return ErrRecipientNotAllowed
+		}
+	}
+	return nil
+}
+
+// TxMethodRule identifies a contract method by the address of the contract and
+// the 4 byte selector of the method.
+type TxMethodRule struct {
+	Contract common.Address
+	Selector [4]byte
+}
+
+// ParseTxMethodRule parses a method rule in the "<contract>:<selector>" format,
+// where both parts are hex encoded, e.g. "0x6b17...1d0f:0xa9059cbb".
+func ParseTxMethodRule(s string) (TxMethodRule, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return TxMethodRule{}, fmt.Errorf("invalid method rule %q, want <contract>:<selector>", s)
+	}
+	if !common.IsHexAddress(parts[0]) {
+		return TxMethodRule{}, fmt.Errorf("invalid contract address %q in method rule", parts[0])
+	}
+	selector, err := hexutil.Decode(parts[1])
+	if err != nil || len(selector) != 4 {
+		return TxMethodRule{}, fmt.Errorf("invalid method selector %q in method rule", parts[1])
+	}
+	rule := TxMethodRule{Contract: common.HexToAddress(parts[0])}
+	copy(rule.Selector[:], selector)
+	return rule, nil
+}
+
+// String implements fmt.Stringer.
+func (r TxMethodRule) String() string {
+	return fmt.Sprintf("%s:%s", r.Contract.Hex(), hexutil.Encode(r.Selector[:]))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r TxMethodRule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *TxMethodRule) UnmarshalText(input []byte) error {
+	rule, err := ParseTxMethodRule(string(input))
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+// MethodFilterPolicy is a transaction admission policy rejecting calls to a set
+// of contract methods.
+type MethodFilterPolicy struct {
+	deny map[TxMethodRule]struct{}
+}
+
+// NewMethodFilterPolicy creates a policy rejecting calls to the given methods.
+func NewMethodFilterPolicy(deny []TxMethodRule) *MethodFilterPolicy {
+	set := make(map[TxMethodRule]struct{}, len(deny))
+	for _, rule := range deny {
+		set[rule] = struct{}{}
+	}
+	return &MethodFilterPolicy{deny: set}
+}
+
+// Name implements TxPolicy.
+func (p *MethodFilterPolicy) Name() string { return "methodfilter" }
+
+// Check implements TxPolicy.
+func (p *MethodFilterPolicy) Check(tx *types.Transaction, from common.Address, local bool) error {
+	if tx.To() == nil || len(tx.Data()) < 4 {
+		return nil
+	}
+	rule := TxMethodRule{Contract: *tx.To()}
+	copy(rule.Selector[:], tx.Data()[:4])
+	if _, ok := p.deny[rule]; ok {
+		return fmt.Errorf("%w: %v", ErrMethodDenied, rule)
+	}
+	return nil
+}
+
+// FeeFloorPolicy is a transaction admission policy enforcing a minimum fee cap.
+// Contrary to the pool's price limit, it applies to local transactions too.
+type FeeFloorPolicy struct {
+	minFeeCap *big.Int
+}
+
+// NewFeeFloorPolicy creates a policy rejecting transactions with a fee cap (or
+// gas price for legacy transactions) below the given minimum.
+func NewFeeFloorPolicy(minFeeCap *big.Int) *FeeFloorPolicy {
+	return &FeeFloorPolicy{minFeeCap: new(big.Int).Set(minFeeCap)}
+}
+
+// Name implements TxPolicy.
+func (p *FeeFloorPolicy) Name() string { return "feefloor" }
+
+// Check implements TxPolicy.
+func (p *FeeFloorPolicy) Check(tx *types.Transaction, from common.Address, local bool) error {
+	if tx.GasFeeCapIntCmp(p.minFeeCap) < 0 {
+		return fmt.Errorf("%w: have %v, want %v", ErrFeeBelowPolicy, tx.GasFeeCap(), p.minFeeCap)
+	}
+	return nil
+}
+
+// policies assembles the admission policies configured for the pool: the ones
+// derived from the declarative config fields first, followed by any custom
+// policies.
+func (config *TxPoolConfig) policies() []TxPolicy {
+	var policies []TxPolicy
+	if len(config.AllowSenders) > 0 || len(config.DenySenders) > 0 || len(config.AllowRecipients) > 0 || len(config.DenyRecipients) > 0 {
+		policies = append(policies, NewAddressListPolicy(config.AllowSenders, config.DenySenders, config.AllowRecipients, config.DenyRecipients))
+	}
+	if len(config.DenyMethods) > 0 {
+		policies = append(policies, NewMethodFilterPolicy(config.DenyMethods))
+	}
+	if config.MinFeeCap > 0 {
+		policies = append(policies, NewFeeFloorPolicy(new(big.Int).SetUint64(config.MinFeeCap)))
+	}
+	return append(policies, config.Policies...)
+}
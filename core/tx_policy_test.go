@@ -0,0 +1,130 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the method rules round trip through their textual representation
+// and that malformed rules are rejected.
+func TestParseTxMethodRule(t *testing.T) {
+	rule, err := ParseTxMethodRule("0x0000000000000000000000000000000000000001:0xa9059cbb")
+	if err != nil {
+		t.Fatalf("failed to parse method rule: %v", err)
+	}
+	if rule.Contract != common.HexToAddress("0x01") || rule.Selector != [4]byte{0xa9, 0x05, 0x9c, 0xbb} {
+		t.Fatalf("method rule mismatch: have %v", rule)
+	}
+	var decoded TxMethodRule
+	blob, _ := rule.MarshalText()
+	if err := decoded.UnmarshalText(blob); err != nil {
+		t.Fatalf("failed to decode method rule: %v", err)
+	}
+	if decoded != rule {
+		t.Fatalf("method rule round trip mismatch: have %v, want %v", decoded, rule)
+	}
+	for _, invalid := range []string{"", "0x01", "0xzz:0xa9059cbb", "0x0000000000000000000000000000000000000001:0xa905"} {
+		if _, err := ParseTxMethodRule(invalid); err == nil {
+			t.Errorf("invalid method rule %q accepted", invalid)
+		}
+	}
+}
+
+// Tests that the admission policies configured for the pool are consulted for
+// both local and remote transactions, and that rejections are reported with
+// the typed policy error.
+func TestTransactionPolicies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		allowed, _ = crypto.GenerateKey()
+		denied, _  = crypto.GenerateKey()
+		stranger   = common.HexToAddress("0xdead")
+		contract   = common.HexToAddress("0xc0de")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AllowSenders = []common.Address{crypto.PubkeyToAddress(allowed.PublicKey)}
+	config.DenyRecipients = []common.Address{stranger}
+	config.DenyMethods = []TxMethodRule{{Contract: contract, Selector: [4]byte{0xa9, 0x05, 0x9c, 0xbb}}}
+	config.MinFeeCap = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(allowed.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(denied.PublicKey), big.NewInt(1000000000))
+
+	signed := func(nonce uint64, to common.Address, price int64, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), 100000, big.NewInt(price), data), types.HomesteadSigner{}, key)
+		return tx
+	}
+	tests := []struct {
+		tx     *types.Transaction
+		local  bool
+		policy string
+		reason error
+	}{
+		{signed(0, common.Address{}, 2, nil, denied), false, "addresslist", ErrSenderNotAllowed},
+		{signed(0, common.Address{}, 2, nil, denied), true, "addresslist", ErrSenderNotAllowed},
+		{signed(0, stranger, 2, nil, allowed), false, "addresslist", ErrRecipientDenied},
+		{signed(0, contract, 2, []byte{0xa9, 0x05, 0x9c, 0xbb, 0x00}, allowed), false, "methodfilter", ErrMethodDenied},
+		{signed(0, common.Address{}, 1, nil, allowed), true, "feefloor", ErrFeeBelowPolicy},
+		{signed(0, contract, 2, []byte{0x09, 0x5e, 0xa7, 0xb3}, allowed), false, "", nil},
+	}
+	for i, tt := range tests {
+		var err error
+		if tt.local {
+			err = pool.AddLocal(tt.tx)
+		} else {
+			err = pool.AddRemote(tt.tx)
+		}
+		if tt.reason == nil {
+			if err != nil {
+				t.Errorf("test %d: transaction rejected: %v", i, err)
+			}
+			continue
+		}
+		var perr *TxPolicyError
+		if !errors.As(err, &perr) {
+			t.Errorf("test %d: error type mismatch: have %v, want policy error", i, err)
+			continue
+		}
+		if perr.Policy != tt.policy || !errors.Is(err, tt.reason) {
+			t.Errorf("test %d: rejection mismatch: have %s/%v, want %s/%v", i, perr.Policy, perr.Reason, tt.policy, tt.reason)
+		}
+	}
+	// Register a custom policy at runtime and ensure it's consulted
+	pool.AddPolicy(NewAddressListPolicy(nil, []common.Address{crypto.PubkeyToAddress(allowed.PublicKey)}, nil, nil))
+	if err := pool.AddRemote(signed(1, common.Address{}, 2, nil, allowed)); !errors.Is(err, ErrSenderDenied) {
+		t.Errorf("custom policy error mismatch: have %v, want %v", err, ErrSenderDenied)
+	}
+}
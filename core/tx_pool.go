@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -112,6 +113,7 @@ var (
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
 	overflowedTxMeter  = metrics.NewRegisteredMeter("txpool/overflowed", nil)
+	policyRejectMeter  = metrics.NewRegisteredMeter("txpool/policy/reject", nil)
 	// throttleTxMeter counts how many transactions are rejected due to too-many-changes between
 	// txpool reorgs.
 	throttleTxMeter = metrics.NewRegisteredMeter("txpool/throttle", nil)
@@ -156,6 +158,11 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	Snapshot   string        // Snapshot of all pooled transactions to survive node restarts (empty = disabled)
+	Resnapshot time.Duration // Time interval to regenerate the pool snapshot
+
+	Record string // File to record the incoming transaction stream into for offline replays (empty = disabled)
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -165,6 +172,26 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	PrivateLifetime uint64 // Number of blocks private transactions are kept for before being dropped
+
+	BlobSlots uint64 // Maximum number of blobs whose sidecars are kept by the pool
+
+	AccountRate  float64 // Remote transactions replenished per second per sender (0 = unlimited)
+	AccountBurst uint64  // Maximum number of remote transactions a sender may submit at once
+	GlobalRate   float64 // Remote transactions replenished per second for all senders (0 = unlimited)
+	GlobalBurst  uint64  // Maximum number of remote transactions all senders may submit at once
+	PeerRate     float64 // Transactions replenished per second per origin peer (0 = unlimited)
+	PeerBurst    uint64  // Maximum number of transactions an origin peer may deliver at once
+
+	AllowSenders    []common.Address // Senders exclusively admitted into the pool (empty = all)
+	DenySenders     []common.Address // Senders whose transactions are always rejected
+	AllowRecipients []common.Address // Recipients exclusively admitted into the pool (empty = all)
+	DenyRecipients  []common.Address // Recipients whose transactions are always rejected
+	DenyMethods     []TxMethodRule   // Contract methods whose invocations are always rejected
+	MinFeeCap       uint64           // Minimum fee cap enforced on all transactions, locals included
+
+	Policies []TxPolicy `toml:"-"` // Custom admission policies consulted after the built-in ones
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -173,6 +200,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	Resnapshot: 10 * time.Minute,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -182,6 +211,14 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	PrivateLifetime: 25,
+
+	BlobSlots: 256,
+
+	AccountBurst: 16,
+	GlobalBurst:  1024,
+	PeerBurst:    4096,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -192,6 +229,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.Resnapshot < time.Second {
+		log.Warn("Sanitizing invalid txpool snapshot time", "provided", conf.Resnapshot, "updated", time.Second)
+		conf.Resnapshot = time.Second
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -220,6 +261,26 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.PrivateLifetime < 1 {
+		log.Warn("Sanitizing invalid txpool private lifetime", "provided", conf.PrivateLifetime, "updated", DefaultTxPoolConfig.PrivateLifetime)
+		conf.PrivateLifetime = DefaultTxPoolConfig.PrivateLifetime
+	}
+	if conf.BlobSlots < 1 {
+		log.Warn("Sanitizing invalid txpool blob slots", "provided", conf.BlobSlots, "updated", DefaultTxPoolConfig.BlobSlots)
+		conf.BlobSlots = DefaultTxPoolConfig.BlobSlots
+	}
+	if conf.AccountRate > 0 && conf.AccountBurst < 1 {
+		log.Warn("Sanitizing invalid txpool account burst", "provided", conf.AccountBurst, "updated", DefaultTxPoolConfig.AccountBurst)
+		conf.AccountBurst = DefaultTxPoolConfig.AccountBurst
+	}
+	if conf.GlobalRate > 0 && conf.GlobalBurst < 1 {
+		log.Warn("Sanitizing invalid txpool global burst", "provided", conf.GlobalBurst, "updated", DefaultTxPoolConfig.GlobalBurst)
+		conf.GlobalBurst = DefaultTxPoolConfig.GlobalBurst
+	}
+	if conf.PeerRate > 0 && conf.PeerBurst < 1 {
+		log.Warn("Sanitizing invalid txpool peer burst", "provided", conf.PeerBurst, "updated", DefaultTxPoolConfig.PeerBurst)
+		conf.PeerBurst = DefaultTxPoolConfig.PeerBurst
+	}
 	return conf
 }
 
@@ -236,26 +297,40 @@ type TxPool struct {
 	chain       blockChain
 	gasPrice    *big.Int
 	txFeed      event.Feed
+	lifecycle   txLifecycleTracker
 	scope       event.SubscriptionScope
 	signer      types.Signer
+	policies    []TxPolicy
+	limiter     *txRateLimiter // Token buckets throttling remote submissions (nil = unlimited)
+	clock       mclock.Clock   // Time source of account heartbeats, simulated during replays
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
 	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
+	eip4844  bool // Fork indicator whether we are using EIP-4844 blob transactions.
 
+	currentHead   *types.Header  // Current head of the blockchain
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals   *accountSet // Set of local transaction to exempt from eviction rules
+	journal  *txJournal  // Journal of local transaction to back up to disk
+	snap     *txSnapshot // Snapshot of all transactions to back up to disk
+	recorder *txRecorder // Recorder of the incoming transaction stream for offline replays
+
+	pending map[common.Address]*txList        // All currently processable transactions
+	queue   map[common.Address]*txList        // Queued but non-processable transactions
+	beats   map[common.Address]mclock.AbsTime // Last heartbeat from each known account
+	all     *txLookup                         // All transactions to allow lookups
+	priced  *txPricedList                     // All transactions sorted by price
 
-	pending map[common.Address]*txList   // All currently processable transactions
-	queue   map[common.Address]*txList   // Queued but non-processable transactions
-	beats   map[common.Address]time.Time // Last heartbeat from each known account
-	all     *txLookup                    // All transactions to allow lookups
-	priced  *txPricedList                // All transactions sorted by price
+	included map[common.Hash]struct{} // Transactions included by the chain since the last reset
+
+	conditions map[common.Hash]*TxConditions // Inclusion preconditions of conditional transactions
+	private    map[common.Hash]uint64        // Private transactions and the block they expire at
+	blobs      *txBlobStore                  // Sidecars of the blob transactions, kept apart from the transactions
 
 	chainHeadCh     chan ChainHeadEvent
 	chainHeadSub    event.Subscription
@@ -277,6 +352,11 @@ type txpoolResetRequest struct {
 // NewTxPool creates a new transaction pool to gather, sort and filter inbound
 // transactions from the network.
 func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain blockChain) *TxPool {
+	return newTxPool(config, chainconfig, chain, mclock.System{})
+}
+
+// newTxPool creates a new transaction pool running on the given clock.
+func newTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain blockChain, clock mclock.Clock) *TxPool {
 	// Sanitize the input to ensure no vulnerable gas prices are set
 	config = (&config).sanitize()
 
@@ -286,9 +366,15 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		chainconfig:     chainconfig,
 		chain:           chain,
 		signer:          types.LatestSigner(chainconfig),
+		policies:        config.policies(),
+		limiter:         newTxRateLimiter(&config),
+		clock:           clock,
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
-		beats:           make(map[common.Address]time.Time),
+		beats:           make(map[common.Address]mclock.AbsTime),
+		conditions:      make(map[common.Hash]*TxConditions),
+		private:         make(map[common.Hash]uint64),
+		blobs:           newTxBlobStore(config.BlobSlots),
 		all:             newTxLookup(),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
@@ -305,6 +391,16 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pool.locals.add(addr)
 	}
 	pool.priced = newTxPricedList(pool.all)
+
+	// If recording is enabled, start capturing before the pool is first populated
+	if config.Record != "" {
+		recorder, err := newTxRecorder(config.Record, chainconfig)
+		if err != nil {
+			log.Warn("Failed to start transaction pool recording", "err", err)
+		} else {
+			pool.recorder = recorder
+		}
+	}
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
@@ -318,10 +414,18 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		if err := pool.journal.load(pool.AddLocals); err != nil {
 			log.Warn("Failed to load transaction journal", "err", err)
 		}
-		if err := pool.journal.rotate(pool.local()); err != nil {
+		if err := pool.journal.rotate(pool.journaled()); err != nil {
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If pool snapshotting is enabled, restore all the previously pooled transactions
+	if config.Snapshot != "" {
+		pool.snap = newTxSnapshot(config.Snapshot)
+
+		if err := pool.snap.load(pool); err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+	}
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -343,12 +447,14 @@ func (pool *TxPool) loop() {
 		report  = time.NewTicker(statsReportInterval)
 		evict   = time.NewTicker(evictionInterval)
 		journal = time.NewTicker(pool.config.Rejournal)
+		snap    = time.NewTicker(pool.config.Resnapshot)
 		// Track the previous head headers for transaction reorgs
 		head = pool.chain.CurrentBlock()
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
+	defer snap.Stop()
 
 	// Notify tests that the init phase is done
 	close(pool.initDoneCh)
@@ -380,34 +486,62 @@ func (pool *TxPool) loop() {
 
 		// Handle inactive account transaction eviction
 		case <-evict.C:
-			pool.mu.Lock()
-			for addr := range pool.queue {
-				// Skip local transactions from the eviction mechanism
-				if pool.locals.contains(addr) {
-					continue
-				}
-				// Any non-locals old enough should be removed
-				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
-					list := pool.queue[addr].Flatten()
-					for _, tx := range list {
-						pool.removeTx(tx.Hash(), true)
-					}
-					queuedEvictionMeter.Mark(int64(len(list)))
-				}
-			}
-			pool.mu.Unlock()
+			pool.evictExpired()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
 			if pool.journal != nil {
 				pool.mu.Lock()
-				if err := pool.journal.rotate(pool.local()); err != nil {
+				if err := pool.journal.rotate(pool.journaled()); err != nil {
 					log.Warn("Failed to rotate local tx journal", "err", err)
 				}
 				pool.mu.Unlock()
 			}
+
+		// Handle full pool snapshot regeneration
+		case <-snap.C:
+			if pool.snap != nil {
+				if err := pool.snap.save(pool); err != nil {
+					log.Warn("Failed to save tx pool snapshot", "err", err)
+				}
+			}
+		}
+	}
+}
+
+// evictExpired drops the queued transactions of all remote accounts that have
+// been inactive for longer than the configured lifetime.
+func (pool *TxPool) evictExpired() {
+	pool.mu.Lock()
+	now := pool.clock.Now()
+	for addr := range pool.queue {
+		// Skip local transactions from the eviction mechanism
+		if pool.locals.contains(addr) {
+			continue
+		}
+		// Any non-locals old enough should be removed
+		if now.Sub(pool.beats[addr]) > pool.config.Lifetime {
+			list := pool.queue[addr].Flatten()
+			for _, tx := range list {
+				pool.removeTx(tx.Hash(), true)
+			}
+			pool.lifecycle.dropped(ErrTxExpired, list...)
+			queuedEvictionMeter.Mark(int64(len(list)))
 		}
 	}
+	pool.mu.Unlock()
+	pool.flushLifecycle()
+
+	if pool.limiter != nil {
+		pool.limiter.prune(pool.limiterTime())
+	}
+}
+
+// limiterTime returns the current time of the pool clock in the form expected
+// by the rate limiter. The token buckets only care about elapsed durations, so
+// the arbitrary epoch of the clock is irrelevant.
+func (pool *TxPool) limiterTime() time.Time {
+	return time.Unix(0, int64(pool.clock.Now()))
 }
 
 // Stop terminates the transaction pool.
@@ -419,9 +553,17 @@ func (pool *TxPool) Stop() {
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
 
+	if pool.snap != nil {
+		if err := pool.snap.save(pool); err != nil {
+			log.Warn("Failed to save tx pool snapshot", "err", err)
+		}
+	}
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.recorder != nil {
+		pool.recorder.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -442,6 +584,8 @@ func (pool *TxPool) GasPrice() *big.Int {
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
+	defer pool.flushLifecycle()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -454,12 +598,24 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false)
 		}
+		pool.lifecycle.dropped(ErrUnderpriced, drop...)
 		pool.priced.Removed(len(drop))
 	}
 
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
+// AddPolicy registers a new admission policy with the transaction pool. The
+// policy is only consulted for transactions arriving after its registration,
+// transactions already in the pool are not re-validated.
+func (pool *TxPool) AddPolicy(policy TxPolicy) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.policies = append(pool.policies, policy)
+	log.Info("Transaction pool policy registered", "name", policy.Name())
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *TxPool) Nonce(addr common.Address) uint64 {
@@ -589,8 +745,14 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
 		return ErrTxTypeNotSupported
 	}
+	// Blob transactions must carry their sidecar, which is validated separately
+	if tx.Type() == types.BlobTxType {
+		if err := pool.validateBlobTx(tx); err != nil {
+			return err
+		}
+	}
 	// Reject transactions over defined size to prevent DOS attacks
-	if uint64(tx.Size()) > txMaxSize {
+	if uint64(tx.WithoutBlobTxSidecar().Size()) > txMaxSize {
 		return ErrOversizedData
 	}
 	// Transactions can't be negative. This may never happen using RLP decoded
@@ -639,6 +801,13 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
+	// Ensure the transaction is accepted by all the admission policies
+	for _, policy := range pool.policies {
+		if err := policy.Check(tx, from, local); err != nil {
+			policyRejectMeter.Mark(1)
+			return &TxPolicyError{Policy: policy.Name(), Reason: err}
+		}
+	}
 	return nil
 }
 
@@ -667,6 +836,24 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// Blob sidecars are kept apart, the pool only ever tracks the bare transaction
+	if tx.Type() == types.BlobTxType {
+		sidecar := tx.BlobTxSidecar()
+		if sidecar == nil {
+			sidecar = pool.blobs.get(hash)
+		}
+		if pool.blobs.get(hash) == nil && !pool.blobs.has(len(sidecar.Blobs)) {
+			log.Trace("Discarding overflown blob transaction", "hash", hash)
+			overflowedTxMeter.Mark(1)
+			return false, ErrBlobPoolOverflow
+		}
+		tx = tx.WithoutBlobTxSidecar()
+		defer func() {
+			if err == nil {
+				pool.blobs.add(hash, sidecar)
+			}
+		}()
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -703,6 +890,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			underpricedTxMeter.Mark(1)
 			pool.removeTx(tx.Hash(), false)
 		}
+		pool.lifecycle.dropped(ErrUnderpriced, drop...)
 	}
 	// Try to replace an existing transaction in the pending pool
 	from, _ := types.Sender(pool.signer, tx) // already validated
@@ -718,15 +906,18 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.lifecycle.replaced(old, tx)
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
+		pool.lifecycle.track(TxLifecycleAdded, tx)
+		pool.lifecycle.track(TxLifecyclePromoted, tx)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
-		pool.beats[from] = time.Now()
+		pool.beats[from] = pool.clock.Now()
 		return old != nil, nil
 	}
 	// New transaction isn't replacing a pending one, push into queue
@@ -734,6 +925,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	pool.lifecycle.track(TxLifecycleAdded, tx)
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -769,6 +961,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.lifecycle.replaced(old, tx)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -784,7 +977,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 	}
 	// If we never record the heartbeat, do it right now.
 	if _, exist := pool.beats[from]; !exist {
-		pool.beats[from] = time.Now()
+		pool.beats[from] = pool.clock.Now()
 	}
 	return old != nil, nil
 }
@@ -792,8 +985,12 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
-	// Only journal if it's enabled and the transaction is local
-	if pool.journal == nil || !pool.locals.contains(from) {
+	// Only journal if it's enabled and the transaction is local and public.
+	// Blob transactions are not journaled as their sidecars aren't persisted.
+	if pool.journal == nil || !pool.locals.contains(from) || tx.Type() == types.BlobTxType {
+		return
+	}
+	if _, ok := pool.private[tx.Hash()]; ok {
 		return
 	}
 	if err := pool.journal.insert(tx); err != nil {
@@ -825,15 +1022,17 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.lifecycle.replaced(old, tx)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
 	}
+	pool.lifecycle.track(TxLifecyclePromoted, tx)
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
 
 	// Successful promotion, bump the heartbeat
-	pool.beats[addr] = time.Now()
+	pool.beats[addr] = pool.clock.Now()
 	return true
 }
 
@@ -914,8 +1113,12 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 
 	// Process all the new transaction and merge any errors into the original slice
 	pool.mu.Lock()
+	if pool.recorder != nil {
+		pool.recorder.txs(news, local, pool.signer, pool.currentState)
+	}
 	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
 	pool.mu.Unlock()
+	pool.flushLifecycle()
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -938,7 +1141,19 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
 	dirty := newAccountSet(pool.signer)
 	errs := make([]error, len(txs))
+	now := pool.limiterTime()
 	for i, tx := range txs {
+		// Throttle remote submissions, exempting the senders tracked as locals
+		if pool.limiter != nil && !local {
+			from, _ := types.Sender(pool.signer, tx) // already validated
+			if !pool.locals.contains(from) {
+				if err := pool.limiter.allow(from, now); err != nil {
+					log.Trace("Rate limited transaction", "hash", tx.Hash(), "from", from, "err", err)
+					errs[i] = err
+					continue
+				}
+			}
+		}
 		replaced, err := pool.add(tx, local)
 		errs[i] = err
 		if err == nil && !replaced {
@@ -954,7 +1169,7 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error,
 func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 	status := make([]TxStatus, len(hashes))
 	for i, hash := range hashes {
-		tx := pool.Get(hash)
+		tx := pool.all.Get(hash)
 		if tx == nil {
 			continue
 		}
@@ -973,8 +1188,16 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 }
 
 // Get returns a transaction if it is contained in the pool and nil otherwise.
+// Blob transactions are returned along with their sidecar.
 func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
-	return pool.all.Get(hash)
+	tx := pool.all.Get(hash)
+	if tx == nil || tx.Type() != types.BlobTxType {
+		return tx
+	}
+	if sidecar := pool.BlobSidecar(hash); sidecar != nil {
+		return tx.WithBlobTxSidecar(sidecar)
+	}
+	return tx
 }
 
 // Has returns an indicator whether txpool has a transaction cached with the
@@ -995,6 +1218,7 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 
 	// Remove it from the list of known transactions
 	pool.all.Remove(hash)
+	pool.blobs.remove(hash)
 	if outofbound {
 		pool.priced.Removed(1)
 	}
@@ -1013,6 +1237,7 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(tx.Hash(), tx, false, false)
 			}
+			pool.lifecycle.trackAll(TxLifecycleDemoted, invalids)
 			// Update the account nonce if needed
 			pool.pendingNonces.setIfLower(addr, tx.Nonce())
 			// Reduce the pending counter
@@ -1186,14 +1411,38 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 			nonces[addr] = highestPending.Nonce() + 1
 		}
 		pool.pendingNonces.setAll(nonces)
+		pool.included = nil
 	}
 	// Ensure pool.queue and pool.pending sizes stay within the configured limits.
 	pool.truncatePending()
 	pool.truncateQueue()
 
+	// Drop the sidecars of the blob transactions no longer in the pool
+	pool.blobs.prune(pool.all)
+
 	dropBetweenReorgHistogram.Update(int64(pool.changesSinceReorg))
 	pool.changesSinceReorg = 0 // Reset change counter
+
+	// Conditional transactions are only ever included by the local miner,
+	// keep them out of the events feeding the network
+	if len(pool.conditions) > 0 {
+		conditional := func(tx *types.Transaction) bool {
+			_, ok := pool.conditions[tx.Hash()]
+			return ok
+		}
+		public := promoted[:0]
+		for _, tx := range promoted {
+			if !conditional(tx) {
+				public = append(public, tx)
+			}
+		}
+		promoted = public
+		for _, set := range events {
+			set.Filter(conditional)
+		}
+	}
 	pool.mu.Unlock()
+	pool.flushLifecycle()
 
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
@@ -1203,11 +1452,11 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		}
 		events[addr].Put(tx)
 	}
-	if len(events) > 0 {
-		var txs []*types.Transaction
-		for _, set := range events {
-			txs = append(txs, set.Flatten()...)
-		}
+	var txs []*types.Transaction
+	for _, set := range events {
+		txs = append(txs, set.Flatten()...)
+	}
+	if len(txs) > 0 {
 		pool.txFeed.Send(NewTxsEvent{txs})
 	}
 }
@@ -1216,7 +1465,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
-	var reinject types.Transactions
+	var reinject, included types.Transactions
 
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
@@ -1227,7 +1476,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 			log.Debug("Skipping deep transaction reorg", "depth", depth)
 		} else {
 			// Reorg seems shallow enough to pull in all transactions into memory
-			var discarded, included types.Transactions
+			var discarded types.Transactions
 			var (
 				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number.Uint64())
 				add = pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64())
@@ -1282,15 +1531,32 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	if newHead == nil {
 		newHead = pool.chain.CurrentBlock().Header() // Special case during testing
 	}
+	// Gather the transactions included by the new chain segment, to tell them
+	// apart from the ones invalidated by a different transaction of the sender
+	if included == nil {
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			included = block.Transactions()
+		}
+	}
+	pool.included = make(map[common.Hash]struct{}, len(included))
+	for _, tx := range included {
+		pool.included[tx.Hash()] = struct{}{}
+	}
 	statedb, err := pool.chain.StateAt(newHead.Root)
 	if err != nil {
 		log.Error("Failed to reset txpool state", "err", err)
 		return
 	}
+	pool.currentHead = newHead
 	pool.currentState = statedb
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
+	// Drop any conditional transactions that can no longer be included and any
+	// private ones that weren't included in time
+	pool.recheckConditions()
+	pool.expirePrivate(newHead)
+
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	senderCacher.recover(pool.signer, reinject)
@@ -1301,6 +1567,26 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
 	pool.eip2718 = pool.chainconfig.IsBerlin(next)
 	pool.eip1559 = pool.chainconfig.IsLondon(next)
+	pool.eip4844 = pool.chainconfig.IsCancun(next)
+
+	if pool.recorder != nil {
+		addrs := make([]common.Address, 0, len(pool.pending)+len(pool.queue))
+		for addr := range pool.pending {
+			addrs = append(addrs, addr)
+		}
+		for addr := range pool.queue {
+			if _, ok := pool.pending[addr]; !ok {
+				addrs = append(addrs, addr)
+			}
+		}
+		var hashes []common.Hash
+		for hash := range pool.included {
+			if pool.all.Get(hash) != nil {
+				hashes = append(hashes, hash)
+			}
+		}
+		pool.recorder.head(newHead, pool.currentState, addrs, hashes)
+	}
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1322,6 +1608,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.trackStale(forwards)
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1329,6 +1616,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.lifecycle.dropped(ErrTxUnexecutable, drops...)
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
@@ -1352,6 +1640,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
+			pool.lifecycle.dropped(ErrTxPoolOverflow, caps...)
 			queuedRateLimitMeter.Mark(int64(len(caps)))
 		}
 		// Mark all the items dropped as removed
@@ -1417,6 +1706,7 @@ func (pool *TxPool) truncatePending() {
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
+					pool.lifecycle.dropped(ErrTxPoolOverflow, caps...)
 					pool.priced.Removed(len(caps))
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
@@ -1444,6 +1734,7 @@ func (pool *TxPool) truncatePending() {
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
+				pool.lifecycle.dropped(ErrTxPoolOverflow, caps...)
 				pool.priced.Removed(len(caps))
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
@@ -1484,9 +1775,11 @@ func (pool *TxPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true)
 			}
+			pool.lifecycle.dropped(ErrTxPoolOverflow, txs...)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
 			continue
@@ -1495,12 +1788,27 @@ func (pool *TxPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.lifecycle.dropped(ErrTxPoolOverflow, txs[i])
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
 	}
 }
 
+// trackStale records the removal of transactions whose nonce fell below the
+// account nonce. Only the ones included by the chain since the last reset are
+// reported as included, the rest were invalidated by a different transaction
+// of the same sender and are reported as dropped.
+func (pool *TxPool) trackStale(txs types.Transactions) {
+	for _, tx := range txs {
+		if _, ok := pool.included[tx.Hash()]; ok {
+			pool.lifecycle.track(TxLifecycleIncluded, tx)
+		} else {
+			pool.lifecycle.dropped(ErrNonceTooLow, tx)
+		}
+	}
+}
+
 // demoteUnexecutables removes invalid and processed transactions from the pools
 // executable/pending queue and any subsequent transactions that become unexecutable
 // are moved back into the future queue.
@@ -1520,6 +1828,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
+		pool.trackStale(olds)
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
@@ -1527,6 +1836,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 		}
+		pool.lifecycle.dropped(ErrTxUnexecutable, drops...)
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
 		for _, tx := range invalids {
@@ -1536,6 +1846,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			// Internal shuffle shouldn't touch the lookup set.
 			pool.enqueueTx(hash, tx, false, false)
 		}
+		pool.lifecycle.trackAll(TxLifecycleDemoted, invalids)
 		pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
 		if pool.locals.contains(addr) {
 			localGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
@@ -1550,6 +1861,7 @@ func (pool *TxPool) demoteUnexecutables() {
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(hash, tx, false, false)
 			}
+			pool.lifecycle.trackAll(TxLifecycleDemoted, gapped)
 			pendingGauge.Dec(int64(len(gapped)))
 			// This might happen in a reorg, so log it to the metering
 			blockReorgInvalidatedTx.Mark(int64(len(gapped)))
@@ -1564,13 +1876,13 @@ func (pool *TxPool) demoteUnexecutables() {
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
-	heartbeat time.Time
+	heartbeat mclock.AbsTime
 }
 
 type addressesByHeartbeat []addressByHeartbeat
 
 func (a addressesByHeartbeat) Len() int           { return len(a) }
-func (a addressesByHeartbeat) Less(i, j int) bool { return a[i].heartbeat.Before(a[j].heartbeat) }
+func (a addressesByHeartbeat) Less(i, j int) bool { return a[i].heartbeat < a[j].heartbeat }
 func (a addressesByHeartbeat) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
 // accountSet is simply a set of addresses to check for existence, and a signer
@@ -0,0 +1,112 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// ErrTxPrivateExpired is the drop reason of private transactions that were not
+// included within the configured number of blocks.
+var ErrTxPrivateExpired = errors.New("private transaction expired")
+
+var privateExpiredMeter = metrics.NewRegisteredMeter("txpool/private/expired", nil)
+
+// AddPrivate enqueues a single local transaction into the pool which must never
+// be propagated to the network, only included by the local miner. Private
+// transactions are neither journaled nor snapshotted, and are dropped if they
+// are not included within the configured number of blocks.
+func (pool *TxPool) AddPrivate(tx *types.Transaction) error {
+	if _, err := types.Sender(pool.signer, tx); err != nil {
+		invalidTxMeter.Mark(1)
+		return ErrInvalidSender
+	}
+	hash := tx.Hash()
+
+	pool.mu.Lock()
+	if pool.all.Get(hash) != nil {
+		pool.mu.Unlock()
+		knownTxMeter.Mark(1)
+		return ErrAlreadyKnown
+	}
+	// Mark the transaction private before admission so it's never announced
+	pool.private[hash] = pool.currentHead.Number.Uint64() + pool.config.PrivateLifetime
+	errs, dirty := pool.addTxsLocked([]*types.Transaction{tx}, !pool.config.NoLocals)
+	if errs[0] != nil {
+		delete(pool.private, hash)
+	}
+	pool.mu.Unlock()
+	pool.flushLifecycle()
+
+	<-pool.requestPromoteExecutables(dirty)
+	return errs[0]
+}
+
+// IsPrivate returns whether the transaction with the given hash was submitted
+// privately and must not be propagated to the network.
+func (pool *TxPool) IsPrivate(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	_, ok := pool.private[hash]
+	return ok
+}
+
+// journaled retrieves all currently known local transactions eligible to be
+// persisted in the journal, omitting the private ones and the blob ones whose
+// sidecars are never persisted.
+func (pool *TxPool) journaled() map[common.Address]types.Transactions {
+	txs := pool.local()
+	for addr, list := range txs {
+		public := list[:0]
+		for _, tx := range list {
+			if tx.Type() == types.BlobTxType {
+				continue
+			}
+			if _, ok := pool.private[tx.Hash()]; !ok {
+				public = append(public, tx)
+			}
+		}
+		txs[addr] = public
+	}
+	return txs
+}
+
+// expirePrivate drops all the private transactions which were not included up
+// to and including the given block, and forgets the ones already gone from the
+// pool. The pool lock must be held.
+func (pool *TxPool) expirePrivate(head *types.Header) {
+	for hash, deadline := range pool.private {
+		tx := pool.all.Get(hash)
+		if tx == nil {
+			delete(pool.private, hash)
+			continue
+		}
+		if head.Number.Uint64() >= deadline {
+			log.Trace("Dropping expired private transaction", "hash", hash, "deadline", deadline)
+			pool.removeTx(hash, true)
+			pool.lifecycle.dropped(ErrTxPrivateExpired, tx)
+			delete(pool.private, hash)
+			privateExpiredMeter.Mark(1)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that private transactions are kept out of the journal and snapshots,
+// and are dropped if not included within the configured number of blocks.
+func TestPrivateTransactions(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	head := func(number uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), GasLimit: 10000000, BaseFee: big.NewInt(1)}
+	}
+	<-pool.requestReset(nil, head(10))
+
+	private := pricedTransaction(0, 100000, big.NewInt(1), key)
+	if err := pool.AddPrivate(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if err := pool.AddPrivate(private); err != ErrAlreadyKnown {
+		t.Fatalf("duplicate private transaction error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	public := pricedTransaction(1, 100000, big.NewInt(1), key)
+	if err := pool.AddLocal(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	if !pool.IsPrivate(private.Hash()) || pool.IsPrivate(public.Hash()) {
+		t.Fatalf("privacy mismatch: private %v, public %v", pool.IsPrivate(private.Hash()), pool.IsPrivate(public.Hash()))
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", pending, 2)
+	}
+	// Only the public transaction may be persisted
+	pool.mu.RLock()
+	journaled := pool.journaled()
+	pool.mu.RUnlock()
+	for _, txs := range journaled {
+		if len(txs) != 1 || txs[0].Hash() != public.Hash() {
+			t.Fatalf("journaled transactions mismatch: have %v", txs)
+		}
+	}
+	var snapshot bytes.Buffer
+	if exported, err := pool.Export(&snapshot); err != nil || exported != 1 {
+		t.Fatalf("exported transaction count mismatch: have %d (%v), want %d", exported, err, 1)
+	}
+	// The private transaction should survive until its lifetime runs out
+	<-pool.requestReset(nil, head(10+testTxPoolConfig.PrivateLifetime-1))
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", pending, 2)
+	}
+	<-pool.requestReset(nil, head(10+testTxPoolConfig.PrivateLifetime))
+	if pending, queued := pool.Stats(); pending != 0 || queued != 1 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 0, 1)
+	}
+	if pool.IsPrivate(private.Hash()) {
+		t.Fatalf("expired private transaction still tracked")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
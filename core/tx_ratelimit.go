@@ -0,0 +1,121 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrAccountRateLimited is returned if a remote transaction is rejected because
+	// its sender exceeded the configured per-account submission rate.
+	ErrAccountRateLimited = errors.New("sender exceeded transaction rate limit")
+
+	// ErrGlobalRateLimited is returned if a remote transaction is rejected because
+	// the pool exceeded the configured global remote submission rate.
+	ErrGlobalRateLimited = errors.New("transaction pool exceeded remote transaction rate limit")
+)
+
+var (
+	accountLimitedMeter = metrics.NewRegisteredMeter("txpool/limited/account", nil)
+	globalLimitedMeter  = metrics.NewRegisteredMeter("txpool/limited/global", nil)
+)
+
+// txRateLimiter is a set of token buckets throttling the rate at which remote
+// transactions are accepted into the pool, both per sender and globally.
+type txRateLimiter struct {
+	rate  rate.Limit // Number of transactions replenished per second per account
+	burst int        // Maximum number of transactions an account may submit at once
+
+	accounts map[common.Address]*accountLimiter // Token buckets of the recently active senders
+	global   *rate.Limiter                      // Token bucket shared by all the remote senders (nil = unlimited)
+	lock     sync.Mutex
+}
+
+// accountLimiter is the token bucket of a single sender, along with the time it
+// was last used to decide when it can be discarded.
+type accountLimiter struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+// newTxRateLimiter creates a rate limiter according to the given pool config,
+// or nil if neither per-account nor global limits are configured.
+func newTxRateLimiter(config *TxPoolConfig) *txRateLimiter {
+	if config.AccountRate <= 0 && config.GlobalRate <= 0 {
+		return nil
+	}
+	limiter := &txRateLimiter{
+		rate:     rate.Inf,
+		accounts: make(map[common.Address]*accountLimiter),
+	}
+	if config.AccountRate > 0 {
+		limiter.rate, limiter.burst = rate.Limit(config.AccountRate), int(config.AccountBurst)
+	}
+	if config.GlobalRate > 0 {
+		limiter.global = rate.NewLimiter(rate.Limit(config.GlobalRate), int(config.GlobalBurst))
+	}
+	return limiter
+}
+
+// allow consumes a token from the bucket of the given sender and from the global
+// one, returning an error if either of them is exhausted.
+func (l *txRateLimiter) allow(from common.Address, now time.Time) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate != rate.Inf {
+		account := l.accounts[from]
+		if account == nil {
+			account = &accountLimiter{limiter: rate.NewLimiter(l.rate, l.burst)}
+			l.accounts[from] = account
+		}
+		account.used = now
+		if !account.limiter.AllowN(now, 1) {
+			accountLimitedMeter.Mark(1)
+			return ErrAccountRateLimited
+		}
+	}
+	if l.global != nil && !l.global.AllowN(now, 1) {
+		globalLimitedMeter.Mark(1)
+		return ErrGlobalRateLimited
+	}
+	return nil
+}
+
+// prune discards the token buckets of senders that have been idle long enough
+// for their buckets to fully refill, as they are equivalent to fresh ones.
+func (l *txRateLimiter) prune(now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate == rate.Inf {
+		return
+	}
+	refill := time.Duration(float64(l.burst) / float64(l.rate) * float64(time.Second))
+	for addr, account := range l.accounts {
+		if now.Sub(account.used) > refill {
+			delete(l.accounts, addr)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that remote transactions are throttled per sender and globally, while
+// local ones bypass the limits.
+func TestTransactionRateLimiting(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AccountRate, config.AccountBurst = 0.001, 2
+	config.GlobalRate, config.GlobalBurst = 0.001, 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// The first sender may only push its burst before being throttled
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(1), keys[0])); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	if err := pool.addRemoteSync(pricedTransaction(2, 100000, big.NewInt(1), keys[0])); !errors.Is(err, ErrAccountRateLimited) {
+		t.Fatalf("account limit error mismatch: have %v, want %v", err, ErrAccountRateLimited)
+	}
+	// Known transactions should be rejected without consuming the allowance
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[0])); !errors.Is(err, ErrAlreadyKnown) {
+		t.Fatalf("known transaction error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	// A different sender should only be able to use up the global allowance
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[1])); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[2])); !errors.Is(err, ErrGlobalRateLimited) {
+		t.Fatalf("global limit error mismatch: have %v, want %v", err, ErrGlobalRateLimited)
+	}
+	// Local transactions must never be throttled
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), keys[2])); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(1), keys[2])); err != nil {
+		t.Fatalf("failed to add transaction from local account: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 5 || queued != 0 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 5, 0)
+	}
+	// Idle senders should be dropped from the limiter once their buckets refill
+	pool.limiter.prune(time.Now().Add(time.Hour))
+	if len(pool.limiter.accounts) != 0 {
+		t.Fatalf("idle account limiters not pruned: have %d", len(pool.limiter.accounts))
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Kinds of the entries in a transaction pool recording.
+const (
+	txRecordConfig uint8 = iota // Chain configuration of the recording node
+	txRecordHead                // New chain head the pool was reset to
+	txRecordTxs                 // Batch of transactions fed into the pool
+)
+
+// txRecord is a single entry of a transaction pool recording.
+type txRecord struct {
+	Kind    uint8        // Kind of the entry, determining the payload format
+	Time    uint64       // Unix time the entry was recorded at, in nanoseconds
+	Payload rlp.RawValue // Kind specific content of the entry
+}
+
+// txRecordAccount is the state of an account as seen by the pool when an entry
+// was recorded.
+type txRecordAccount struct {
+	Address common.Address
+	Nonce   uint64
+	Balance *big.Int
+}
+
+// txRecordHead is the payload of a head entry, along with the accounts tracked
+// by the pool whose state changed with the new head and the pooled transactions
+// included by it.
+type txRecordHeadPayload struct {
+	Header   *types.Header
+	Accounts []txRecordAccount
+	Included []common.Hash `rlp:"optional"`
+}
+
+// txRecordTxsPayload is the payload of a transaction batch entry, along with
+// the senders whose state was not yet recorded.
+type txRecordTxsPayload struct {
+	Local    bool
+	Txs      []*types.Transaction
+	Accounts []txRecordAccount
+}
+
+// txRecorder writes the stream of transactions and chain heads fed into the
+// pool to disk, along with just enough account state to reconstruct the view
+// of the pool during an offline replay.
+//
+// Note, the recorder is not thread safe, it relies on the pool lock.
+type txRecorder struct {
+	path   string                             // Filesystem path to record into
+	output *os.File                           // Output stream to write new entries into
+	known  map[common.Address]txRecordAccount // Last recorded state of the tracked accounts
+}
+
+// newTxRecorder creates a new transaction pool recording at the given path,
+// overwriting any previous one, and starts it with the chain configuration.
+func newTxRecorder(path string, config *params.ChainConfig) (*txRecorder, error) {
+	output, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	recorder := &txRecorder{
+		path:   path,
+		output: output,
+		known:  make(map[common.Address]txRecordAccount),
+	}
+	blob, err := json.Marshal(config)
+	if err != nil {
+		output.Close()
+		return nil, err
+	}
+	if err := recorder.write(txRecordConfig, blob); err != nil {
+		output.Close()
+		return nil, err
+	}
+	log.Info("Recording transaction pool", "path", path)
+	return recorder, nil
+}
+
+// write appends a new entry with the given payload to the recording.
+func (r *txRecorder) write(kind uint8, payload interface{}) error {
+	blob, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(r.output, &txRecord{
+		Kind:    kind,
+		Time:    uint64(time.Now().UnixNano()),
+		Payload: blob,
+	})
+}
+
+// diff returns the state of the given accounts that changed since they were
+// last recorded.
+func (r *txRecorder) diff(statedb *state.StateDB, addrs []common.Address) []txRecordAccount {
+	var accounts []txRecordAccount
+	for _, addr := range addrs {
+		account := txRecordAccount{
+			Address: addr,
+			Nonce:   statedb.GetNonce(addr),
+			Balance: statedb.GetBalance(addr),
+		}
+		if old, ok := r.known[addr]; ok && old.Nonce == account.Nonce && old.Balance.Cmp(account.Balance) == 0 {
+			continue
+		}
+		r.known[addr] = account
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// head records a new chain head along with the state changes of the accounts
+// tracked by the pool and the pooled transactions it included. Accounts no
+// longer tracked are forgotten and will be recorded anew if they return.
+func (r *txRecorder) head(header *types.Header, statedb *state.StateDB, addrs []common.Address, included []common.Hash) {
+	tracked := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		tracked[addr] = struct{}{}
+	}
+	for addr := range r.known {
+		if _, ok := tracked[addr]; !ok {
+			delete(r.known, addr)
+		}
+	}
+	payload := &txRecordHeadPayload{
+		Header:   header,
+		Accounts: r.diff(statedb, addrs),
+		Included: included,
+	}
+	if err := r.write(txRecordHead, payload); err != nil {
+		log.Warn("Failed to record transaction pool head", "err", err)
+	}
+}
+
+// txs records a batch of transactions fed into the pool, along with the state
+// of their senders if not yet known.
+func (r *txRecorder) txs(txs []*types.Transaction, local bool, signer types.Signer, statedb *state.StateDB) {
+	var senders []common.Address
+	for _, tx := range txs {
+		from, _ := types.Sender(signer, tx) // already validated
+		if _, ok := r.known[from]; !ok {
+			senders = append(senders, from)
+		}
+	}
+	payload := &txRecordTxsPayload{
+		Local:    local,
+		Txs:      txs,
+		Accounts: r.diff(statedb, senders),
+	}
+	if err := r.write(txRecordTxs, payload); err != nil {
+		log.Warn("Failed to record transactions", "err", err)
+	}
+}
+
+// close flushes the recording contents to disk and closes the file.
+func (r *txRecorder) close() error {
+	return r.output.Close()
+}
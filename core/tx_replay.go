@@ -0,0 +1,350 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// errInvalidRecording is returned if a transaction pool recording does not
+// start with the chain configuration and the initial head.
+var errInvalidRecording = errors.New("invalid transaction pool recording")
+
+// TxPoolReplayStats is a snapshot of the transaction pool activity during the
+// replay of a recording. Counters are cumulative since the start of the replay.
+type TxPoolReplayStats struct {
+	Time     time.Time // Recording time the stats were taken at
+	Head     uint64    // Number of the current chain head
+	Received int       // Transactions fed into the pool
+	Admitted int       // Transactions accepted into the pool, replacements included
+	Rejected int       // Transactions refused by the pool
+	Replaced int       // Pooled transactions replaced by ones paying more
+	Evicted  int       // Pooled transactions dropped before being included
+	Included int       // Pooled transactions included into the chain
+	Pending  int       // Executable transactions currently pooled
+	Queued   int       // Non-executable transactions currently pooled
+
+	Rejections map[string]int // Rejected transactions by reason
+	Evictions  map[string]int // Evicted transactions by reason
+}
+
+// copy creates a deep copy of the replay stats.
+func (s *TxPoolReplayStats) copy() *TxPoolReplayStats {
+	cpy := *s
+	cpy.Rejections = make(map[string]int, len(s.Rejections))
+	for reason, count := range s.Rejections {
+		cpy.Rejections[reason] = count
+	}
+	cpy.Evictions = make(map[string]int, len(s.Evictions))
+	for reason, count := range s.Evictions {
+		cpy.Evictions[reason] = count
+	}
+	return &cpy
+}
+
+// replayChain is an in-memory blockchain feeding the recorded heads and account
+// states into a replayed transaction pool.
+type replayChain struct {
+	head    *types.Header
+	txs     types.Transactions // Pooled transactions included by the head
+	statedb *state.StateDB
+	feed    event.Feed
+	lock    sync.Mutex
+}
+
+// newReplayChain creates an in-memory chain with an empty state.
+func newReplayChain(head *types.Header) *replayChain {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	return &replayChain{head: head, statedb: statedb}
+}
+
+func (c *replayChain) CurrentBlock() *types.Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return types.NewBlockWithHeader(c.head).WithBody(c.txs, nil)
+}
+
+func (c *replayChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if block := c.CurrentBlock(); block.Hash() == hash {
+		return block
+	}
+	return nil
+}
+
+func (c *replayChain) StateAt(common.Hash) (*state.StateDB, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.statedb.Copy(), nil
+}
+
+func (c *replayChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// apply updates the in-memory state with the recorded accounts.
+func (c *replayChain) apply(accounts []txRecordAccount) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, account := range accounts {
+		c.statedb.SetNonce(account.Address, account.Nonce)
+		c.statedb.SetBalance(account.Address, account.Balance)
+	}
+}
+
+// setHead updates the head of the in-memory chain, along with the pooled
+// transactions it included.
+func (c *replayChain) setHead(head *types.Header, txs types.Transactions) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.head, c.txs = head, txs
+}
+
+// ReplayTxPool feeds a recorded stream of transactions and chain heads into a
+// fresh transaction pool with the given configuration, backed by an in-memory
+// chain. The pool runs on a simulated clock following the recording, so that
+// transaction lifetimes are honored. The report callback is invoked after every
+// interval of recorded time (never if zero), and once more at the end.
+//
+// As only the chain heads are recorded, transactions dropped by reorgs are not
+// reinjected during the replay.
+func ReplayTxPool(input io.Reader, config TxPoolConfig, interval time.Duration, report func(*TxPoolReplayStats)) error {
+	stream := rlp.NewStream(input, 0)
+
+	// Recordings always start with the chain config and the initial head
+	var (
+		record   txRecord
+		blob     []byte
+		initial  txRecordHeadPayload
+		chainCfg = new(params.ChainConfig)
+	)
+	if err := stream.Decode(&record); err != nil {
+		return err
+	}
+	if record.Kind != txRecordConfig {
+		return fmt.Errorf("%w: missing chain config", errInvalidRecording)
+	}
+	if err := rlp.DecodeBytes(record.Payload, &blob); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(blob, chainCfg); err != nil {
+		return err
+	}
+	if err := stream.Decode(&record); err != nil {
+		return err
+	}
+	if record.Kind != txRecordHead {
+		return fmt.Errorf("%w: missing initial head", errInvalidRecording)
+	}
+	if err := rlp.DecodeBytes(record.Payload, &initial); err != nil {
+		return err
+	}
+	chain := newReplayChain(initial.Header)
+	chain.apply(initial.Accounts)
+
+	// Create a pool without any disk side effects, running on a simulated clock
+	config.Journal, config.Snapshot, config.Record = "", "", ""
+
+	clock := new(mclock.Simulated)
+	pool := newTxPool(config, chainCfg, chain, clock)
+	defer pool.Stop()
+
+	// Track the state changes of the pooled transactions
+	var (
+		start   = record.Time
+		elapsed time.Duration
+		stats   = &TxPoolReplayStats{
+			Head:       initial.Header.Number.Uint64(),
+			Rejections: make(map[string]int),
+			Evictions:  make(map[string]int),
+		}
+		events  = make(chan TxLifecycleEvent)
+		sub     = pool.SubscribeTxLifecycleEvent(events)
+		updates = make(chan func(*TxPoolReplayStats))
+		reqs    = make(chan chan *TxPoolReplayStats)
+		quit    = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	defer sub.Unsubscribe()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case ev := <-events:
+				for _, change := range ev.Changes {
+					switch change.Kind {
+					case TxLifecycleAdded:
+						stats.Admitted++
+					case TxLifecycleReplaced:
+						stats.Replaced++
+					case TxLifecycleDropped:
+						stats.Evicted++
+						stats.Evictions[change.Reason.Error()]++
+					case TxLifecycleIncluded:
+						stats.Included++
+					}
+				}
+			case update := <-updates:
+				update(stats)
+			case req := <-reqs:
+				// Lifecycle events are delivered synchronously, so all changes
+				// caused by the requester were already accounted for
+				req <- stats.copy()
+			case <-quit:
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+
+	snapshot := func() *TxPoolReplayStats {
+		req := make(chan *TxPoolReplayStats)
+		reqs <- req
+		res := <-req
+		res.Time = time.Unix(0, int64(start)).Add(elapsed)
+		res.Pending, res.Queued = pool.Stats()
+		return res
+	}
+	// Advance the simulated clock to a recorded timestamp, running evictions
+	// and reports on the way
+	var (
+		nextEvict  = evictionInterval
+		nextReport = interval
+	)
+	advance := func(to time.Duration) {
+		for {
+			next := to
+			if nextEvict < next {
+				next = nextEvict
+			}
+			if interval > 0 && nextReport < next {
+				next = nextReport
+			}
+			if next > elapsed {
+				clock.Run(next - elapsed)
+				elapsed = next
+			}
+			if elapsed == nextEvict {
+				pool.evictExpired()
+				nextEvict += evictionInterval
+				continue
+			}
+			if interval > 0 && elapsed == nextReport {
+				report(snapshot())
+				nextReport += interval
+				continue
+			}
+			return
+		}
+	}
+	// Feed all the recorded entries into the pool
+	for {
+		if err := stream.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if record.Time > start {
+			advance(time.Duration(record.Time - start))
+		}
+		switch record.Kind {
+		case txRecordHead:
+			var payload txRecordHeadPayload
+			if err := rlp.DecodeBytes(record.Payload, &payload); err != nil {
+				return err
+			}
+			var included types.Transactions
+			for _, hash := range payload.Included {
+				if tx := pool.Get(hash); tx != nil {
+					included = append(included, tx)
+				}
+			}
+			chain.apply(payload.Accounts)
+			chain.setHead(payload.Header, included)
+			<-pool.requestReset(nil, payload.Header)
+
+			updates <- func(stats *TxPoolReplayStats) {
+				stats.Head = payload.Header.Number.Uint64()
+			}
+
+		case txRecordTxs:
+			var payload txRecordTxsPayload
+			if err := rlp.DecodeBytes(record.Payload, &payload); err != nil {
+				return err
+			}
+			chain.apply(payload.Accounts)
+			pool.applyRecordedAccounts(payload.Accounts)
+
+			errs := pool.addTxs(payload.Txs, payload.Local && !pool.config.NoLocals, true)
+			updates <- func(stats *TxPoolReplayStats) {
+				stats.Received += len(errs)
+				for _, err := range errs {
+					if err != nil {
+						stats.Rejected++
+						stats.Rejections[err.Error()]++
+					}
+				}
+			}
+
+		default:
+			return fmt.Errorf("%w: unexpected entry kind %d", errInvalidRecording, record.Kind)
+		}
+	}
+	report(snapshot())
+	return nil
+}
+
+// applyRecordedAccounts injects the state of newly seen senders into the current
+// pool state, which is otherwise only refreshed on new heads.
+func (pool *TxPool) applyRecordedAccounts(accounts []txRecordAccount) {
+	if len(accounts) == 0 {
+		return
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.pendingNonces.lock.Lock()
+	defer pool.pendingNonces.lock.Unlock()
+
+	for _, account := range accounts {
+		pool.currentState.SetNonce(account.Address, account.Nonce)
+		pool.currentState.SetBalance(account.Address, account.Balance)
+		pool.pendingNonces.fallback.SetNonce(account.Address, account.Nonce)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the transaction stream recorded on a live pool can be replayed
+// into a simulated one, reproducing the pool behavior under different settings.
+func TestTxPoolRecordReplay(t *testing.T) {
+	t.Parallel()
+
+	// Record a short transaction stream on a live pool
+	path := filepath.Join(t.TempDir(), "txpool.rec")
+
+	config := testTxPoolConfig
+	config.Record = path
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed)}
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	<-pool.initDoneCh
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000000))
+
+	head := func(number uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), GasLimit: 10000000, BaseFee: big.NewInt(1)}
+	}
+	<-pool.requestReset(nil, head(1))
+
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), key),
+		pricedTransaction(1, 100000, big.NewInt(1), key),
+		pricedTransaction(3, 100000, big.NewInt(1), key),
+		pricedTransaction(1, 100000, big.NewInt(2), key),
+	}
+	for i, tx := range txs {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// Include the first transaction into the chain
+	pool.mu.Lock()
+	pool.chain = &testIncludingChain{blockchain, types.Transactions{txs[0]}}
+	pool.mu.Unlock()
+	statedb.SetNonce(addr, 1)
+	<-pool.requestReset(nil, head(2))
+	pool.Stop()
+
+	// Replay the recording with the same settings and ensure the outcome matches
+	replay := func(config TxPoolConfig) *TxPoolReplayStats {
+		input, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open recording: %v", err)
+		}
+		defer input.Close()
+
+		var stats *TxPoolReplayStats
+		if err := ReplayTxPool(input, config, time.Hour, func(s *TxPoolReplayStats) { stats = s }); err != nil {
+			t.Fatalf("failed to replay recording: %v", err)
+		}
+		return stats
+	}
+	stats := replay(testTxPoolConfig)
+	if stats.Head != 2 {
+		t.Errorf("head mismatch: have %d, want %d", stats.Head, 2)
+	}
+	if stats.Received != 4 || stats.Admitted != 4 || stats.Rejected != 0 {
+		t.Errorf("admission mismatch: have %d/%d/%d received/admitted/rejected, want %d/%d/%d", stats.Received, stats.Admitted, stats.Rejected, 4, 4, 0)
+	}
+	if stats.Replaced != 1 || stats.Included != 1 || stats.Evicted != 0 {
+		t.Errorf("outcome mismatch: have %d/%d/%d replaced/included/evicted, want %d/%d/%d", stats.Replaced, stats.Included, stats.Evicted, 1, 1, 0)
+	}
+	if stats.Pending != 1 || stats.Queued != 1 {
+		t.Errorf("pool size mismatch: have %d/%d, want %d/%d", stats.Pending, stats.Queued, 1, 1)
+	}
+	// Replay with a higher price bump, the replacement should be rejected
+	config = testTxPoolConfig
+	config.PriceBump = 200
+
+	stats = replay(config)
+	if stats.Rejected != 1 || stats.Rejections[ErrReplaceUnderpriced.Error()] != 1 {
+		t.Errorf("rejection mismatch: have %d (%v), want %d", stats.Rejected, stats.Rejections, 1)
+	}
+	if stats.Replaced != 0 {
+		t.Errorf("replacement count mismatch: have %d, want %d", stats.Replaced, 0)
+	}
+}
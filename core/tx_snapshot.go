@@ -0,0 +1,196 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// txSnapshotEntry is the RLP encoding of a single transaction in a pool snapshot,
+// retaining whether the transaction was tracked as a local one.
+type txSnapshotEntry struct {
+	Tx    *types.Transaction
+	Local bool
+}
+
+// Export writes all the transactions currently contained in the pool, pending
+// and queued alike, into the given writer. Transactions are grouped by sender
+// and ordered by nonce, so they can be imported back without reordering. The
+// number of exported transactions is returned.
+func (pool *TxPool) Export(w io.Writer) (int, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	// Gather the senders in a deterministic order to keep exports stable
+	addrs := make([]common.Address, 0, len(pool.pending)+len(pool.queue))
+	for addr := range pool.pending {
+		addrs = append(addrs, addr)
+	}
+	for addr := range pool.queue {
+		if _, ok := pool.pending[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+	exported := 0
+	for _, addr := range addrs {
+		var txs types.Transactions
+		if list := pool.pending[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+		if list := pool.queue[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+		for _, tx := range txs {
+			// Skip conditional transactions, they cannot be restored safely
+			// without their preconditions, blob ones whose sidecars are not
+			// exported, and private ones which must not leave the node
+			if pool.conditions[tx.Hash()] != nil || tx.Type() == types.BlobTxType {
+				continue
+			}
+			if _, ok := pool.private[tx.Hash()]; ok {
+				continue
+			}
+			entry := &txSnapshotEntry{Tx: tx, Local: pool.all.GetLocal(tx.Hash()) != nil}
+			if err := rlp.Encode(w, entry); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+	}
+	return exported, nil
+}
+
+// Import parses a pool export from the given reader and injects its contents
+// into the pool. Every transaction is validated against the current head as if
+// it was freshly received. The local origin of the exported transactions is only
+// retained if locals is set, otherwise all of them are imported as remote ones.
+// The number of transactions imported and dropped during validation is returned.
+func (pool *TxPool) Import(r io.Reader, locals bool) (int, int, error) {
+	var (
+		stream            = rlp.NewStream(r, 0)
+		imported, dropped int
+		local, remote     types.Transactions
+	)
+	// Create a method to inject a limited batch of transactions and bump the
+	// appropriate progress counters.
+	flush := func(txs types.Transactions, local bool) {
+		if len(txs) == 0 {
+			return
+		}
+		for _, err := range pool.addTxs(txs, local && !pool.config.NoLocals, true) {
+			if err != nil {
+				log.Trace("Failed to import pooled transaction", "err", err)
+				dropped++
+			} else {
+				imported++
+			}
+		}
+	}
+	for {
+		entry := new(txSnapshotEntry)
+		if err := stream.Decode(entry); err != nil {
+			flush(local, true)
+			flush(remote, false)
+			if err == io.EOF {
+				err = nil
+			}
+			return imported, dropped, err
+		}
+		if entry.Local && locals {
+			if local = append(local, entry.Tx); len(local) > 1024 {
+				flush(local, true)
+				local = local[:0]
+			}
+		} else {
+			if remote = append(remote, entry.Tx); len(remote) > 1024 {
+				flush(remote, false)
+				remote = remote[:0]
+			}
+		}
+	}
+}
+
+// txSnapshot is a periodically regenerated dump of the entire transaction pool,
+// with the aim of letting remote transactions survive node restarts too.
+type txSnapshot struct {
+	path string // Filesystem path to store the transactions at
+}
+
+// newTxSnapshot creates a new transaction pool snapshot backed by the given file.
+func newTxSnapshot(path string) *txSnapshot {
+	return &txSnapshot{
+		path: path,
+	}
+}
+
+// load parses the pool snapshot from disk, importing its contents into the pool.
+func (snap *txSnapshot) load(pool *TxPool) error {
+	input, err := os.Open(snap.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Skip the parsing if the snapshot file doesn't exist at all
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	imported, dropped, err := pool.Import(bufio.NewReader(input), true)
+	log.Info("Loaded transaction pool snapshot", "transactions", imported, "dropped", dropped)
+	return err
+}
+
+// save regenerates the pool snapshot on disk from the current pool contents. The
+// new snapshot is written to a temporary file first and atomically moved over
+// the old one to avoid leaving a truncated snapshot behind on crashes.
+func (snap *txSnapshot) save(pool *TxPool) error {
+	replacement, err := os.OpenFile(snap.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	buffer := bufio.NewWriter(replacement)
+	exported, err := pool.Export(buffer)
+	if err == nil {
+		err = buffer.Flush()
+	}
+	if err != nil {
+		replacement.Close()
+		os.Remove(snap.path + ".new")
+		return err
+	}
+	if err = replacement.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(snap.path+".new", snap.path); err != nil {
+		return err
+	}
+	log.Info("Saved transaction pool snapshot", "transactions", exported)
+	return nil
+}
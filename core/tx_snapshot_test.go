@@ -0,0 +1,120 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the full pool snapshot is written out on shutdown and that on the
+// next startup both local and remote transactions are restored, revalidated
+// against the new chain head.
+func TestTransactionSnapshotting(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Snapshot = filepath.Join(t.TempDir(), "txpool.rlp")
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	// Add a couple pending and queued transactions from both accounts
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddLocal(pricedTransaction(2, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	for _, nonce := range []uint64{0, 1, 3} {
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(1), remote)); err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 2 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 3, 2)
+	}
+	pool.Stop()
+
+	// Include the first remote transaction and restart the pool on top
+	statedb.SetNonce(crypto.PubkeyToAddress(remote.PublicKey), 1)
+	blockchain = &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 2 {
+		t.Fatalf("restored pool size mismatch: have %d/%d, want %d/%d", pending, queued, 2, 2)
+	}
+	if locals := pool.Locals(); len(locals) != 1 || locals[0] != crypto.PubkeyToAddress(local.PublicKey) {
+		t.Fatalf("restored locals mismatch: have %v", locals)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that importing an export without trusting its origins adds every
+// transaction as a remote one, even if it was exported as local.
+func TestTransactionImportUntrusted(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	var buf bytes.Buffer
+	if n, err := pool.Export(&buf); err != nil || n != 1 {
+		t.Fatalf("failed to export pool: %d, %v", n, err)
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	fresh := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	defer fresh.Stop()
+
+	testAddBalance(fresh, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	if imported, dropped, err := fresh.Import(bytes.NewReader(buf.Bytes()), false); err != nil || imported != 1 || dropped != 0 {
+		t.Fatalf("failed to import pool: imported %d, dropped %d, err %v", imported, dropped, err)
+	}
+	if pending, _ := fresh.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if locals := fresh.Locals(); len(locals) != 0 {
+		t.Fatalf("untrusted import added locals: %v", locals)
+	}
+}
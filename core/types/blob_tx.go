@@ -0,0 +1,203 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	ErrMissingBlobHashes = errors.New("blob transaction missing blob hashes")
+	ErrTooManyBlobs      = errors.New("blob transaction has too many blobs")
+	ErrBlobHashVersion   = errors.New("blob hash version not supported")
+	ErrBlobSidecar       = errors.New("invalid blob sidecar")
+)
+
+// BlobTx represents an EIP-4844 transaction carrying data blobs. The blobs are
+// not part of the transaction itself, only their versioned hashes. The blobs
+// along with the commitments and proofs backing the hashes form the sidecar,
+// which is only present while the transaction propagates through the network.
+type BlobTx struct {
+	ChainID       *big.Int
+	Nonce         uint64
+	GasTipCap     *big.Int // a.k.a. maxPriorityFeePerGas
+	GasFeeCap     *big.Int // a.k.a. maxFeePerGas
+	Gas           uint64
+	To            common.Address
+	Value         *big.Int
+	Data          []byte
+	AccessList    AccessList
+	DataGasFeeCap *big.Int // a.k.a. maxFeePerDataGas
+	BlobHashes    []common.Hash
+
+	// A blob transaction can optionally contain blobs. This field must be set
+	// when the transaction is gossiped, but it is never part of a block.
+	Sidecar *BlobTxSidecar `rlp:"-"`
+
+	// Signature values
+	V *big.Int `json:"v" gencodec:"required"`
+	R *big.Int `json:"r" gencodec:"required"`
+	S *big.Int `json:"s" gencodec:"required"`
+}
+
+// BlobTxSidecar contains the blobs of a blob transaction along with the KZG
+// commitments and proofs verifying them against the versioned hashes.
+type BlobTxSidecar struct {
+	Blobs       []kzg.Blob       // Blobs needed by the blob pool
+	Commitments []kzg.Commitment // Commitments needed by the blob pool
+	Proofs      []kzg.Proof      // Proofs needed by the blob pool
+}
+
+// BlobHashes computes the versioned hashes of the blobs in the sidecar.
+func (sc *BlobTxSidecar) BlobHashes() []common.Hash {
+	hashes := make([]common.Hash, len(sc.Commitments))
+	for i, commitment := range sc.Commitments {
+		hashes[i] = kzg.VersionedHash(commitment)
+	}
+	return hashes
+}
+
+// Validate checks that the sidecar contains the blobs referenced by the given
+// versioned hashes, and that the proofs verify each blob against its commitment.
+func (sc *BlobTxSidecar) Validate(hashes []common.Hash) error {
+	if len(sc.Blobs) != len(hashes) || len(sc.Commitments) != len(hashes) || len(sc.Proofs) != len(hashes) {
+		return fmt.Errorf("%w: %d hashes, %d blobs, %d commitments, %d proofs", ErrBlobSidecar, len(hashes), len(sc.Blobs), len(sc.Commitments), len(sc.Proofs))
+	}
+	for i, hash := range sc.BlobHashes() {
+		if hash != hashes[i] {
+			return fmt.Errorf("%w: blob %d commitment hash %x mismatch, want %x", ErrBlobSidecar, i, hash, hashes[i])
+		}
+	}
+	for i := range sc.Blobs {
+		if err := kzg.VerifyBlobProof(&sc.Blobs[i], sc.Commitments[i], sc.Proofs[i]); err != nil {
+			return fmt.Errorf("%w: blob %d: %v", ErrBlobSidecar, i, err)
+		}
+	}
+	return nil
+}
+
+// blobTxWithBlobs is the network representation of a blob transaction, which
+// carries its sidecar along.
+type blobTxWithBlobs struct {
+	BlobTx      *BlobTx
+	Blobs       []kzg.Blob
+	Commitments []kzg.Commitment
+	Proofs      []kzg.Proof
+}
+
+// copy creates a deep copy of the transaction data and initializes all fields.
+// The sidecar is shared as it is never modified.
+func (tx *BlobTx) copy() TxData {
+	cpy := &BlobTx{
+		Nonce:   tx.Nonce,
+		To:      tx.To,
+		Data:    common.CopyBytes(tx.Data),
+		Gas:     tx.Gas,
+		Sidecar: tx.Sidecar,
+		// These are copied below.
+		AccessList:    make(AccessList, len(tx.AccessList)),
+		BlobHashes:    make([]common.Hash, len(tx.BlobHashes)),
+		Value:         new(big.Int),
+		ChainID:       new(big.Int),
+		GasTipCap:     new(big.Int),
+		GasFeeCap:     new(big.Int),
+		DataGasFeeCap: new(big.Int),
+		V:             new(big.Int),
+		R:             new(big.Int),
+		S:             new(big.Int),
+	}
+	copy(cpy.AccessList, tx.AccessList)
+	copy(cpy.BlobHashes, tx.BlobHashes)
+	if tx.Value != nil {
+		cpy.Value.Set(tx.Value)
+	}
+	if tx.ChainID != nil {
+		cpy.ChainID.Set(tx.ChainID)
+	}
+	if tx.GasTipCap != nil {
+		cpy.GasTipCap.Set(tx.GasTipCap)
+	}
+	if tx.GasFeeCap != nil {
+		cpy.GasFeeCap.Set(tx.GasFeeCap)
+	}
+	if tx.DataGasFeeCap != nil {
+		cpy.DataGasFeeCap.Set(tx.DataGasFeeCap)
+	}
+	if tx.V != nil {
+		cpy.V.Set(tx.V)
+	}
+	if tx.R != nil {
+		cpy.R.Set(tx.R)
+	}
+	if tx.S != nil {
+		cpy.S.Set(tx.S)
+	}
+	return cpy
+}
+
+// accessors for innerTx.
+func (tx *BlobTx) txType() byte           { return BlobTxType }
+func (tx *BlobTx) chainID() *big.Int      { return tx.ChainID }
+func (tx *BlobTx) accessList() AccessList { return tx.AccessList }
+func (tx *BlobTx) data() []byte           { return tx.Data }
+func (tx *BlobTx) gas() uint64            { return tx.Gas }
+func (tx *BlobTx) gasFeeCap() *big.Int    { return tx.GasFeeCap }
+func (tx *BlobTx) gasTipCap() *big.Int    { return tx.GasTipCap }
+func (tx *BlobTx) gasPrice() *big.Int     { return tx.GasFeeCap }
+func (tx *BlobTx) value() *big.Int        { return tx.Value }
+func (tx *BlobTx) nonce() uint64          { return tx.Nonce }
+func (tx *BlobTx) to() *common.Address    { tmp := tx.To; return &tmp }
+
+func (tx *BlobTx) rawSignatureValues() (v, r, s *big.Int) {
+	return tx.V, tx.R, tx.S
+}
+
+func (tx *BlobTx) setSignatureValues(chainID, v, r, s *big.Int) {
+	tx.ChainID, tx.V, tx.R, tx.S = chainID, v, r, s
+}
+
+// withoutSidecar returns a shallow copy of the transaction data without the
+// blob sidecar.
+func (tx *BlobTx) withoutSidecar() *BlobTx {
+	cpy := *tx
+	cpy.Sidecar = nil
+	return &cpy
+}
+
+// ValidateBlobTx performs the stateless sanity checks of a blob transaction,
+// ensuring it references a valid number of properly versioned blobs.
+func ValidateBlobTx(tx *Transaction) error {
+	hashes := tx.DataHashes()
+	if len(hashes) == 0 {
+		return ErrMissingBlobHashes
+	}
+	if len(hashes) > params.MaxBlobsPerBlock {
+		return fmt.Errorf("%w: have %d, max %d", ErrTooManyBlobs, len(hashes), params.MaxBlobsPerBlock)
+	}
+	for i, hash := range hashes {
+		if hash[0] != kzg.BlobCommitmentVersionKZG {
+			return fmt.Errorf("%w: blob %d version %d", ErrBlobHashVersion, i, hash[0])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// kzgSetupOnce guards the loading of the insecure kzg test setup.
+var (
+	kzgSetupOnce sync.Once
+	kzgSetupErr  error
+)
+
+// loadTestKZGSetup loads the insecure trusted setup used by the kzg tests, as
+// commitments and proofs are refused without a setup.
+func loadTestKZGSetup(t *testing.T) {
+	kzgSetupOnce.Do(func() {
+		kzgSetupErr = kzg.LoadTrustedSetup(filepath.Join("..", "..", "crypto", "kzg", "testdata", "dev_setup.json"))
+	})
+	if kzgSetupErr != nil {
+		t.Fatalf("failed to load kzg setup: %v", kzgSetupErr)
+	}
+}
+
+// makeSidecar creates a valid sidecar for a single blob derived from the given seed.
+func makeSidecar(t *testing.T, seed byte) *BlobTxSidecar {
+	loadTestKZGSetup(t)
+
+	var blob kzg.Blob
+	for i := 0; i < kzg.FieldElementsPerBlob; i++ {
+		blob[i*kzg.BytesPerFieldElement+30] = byte(i)
+		blob[i*kzg.BytesPerFieldElement+31] = seed
+	}
+	commitment, err := kzg.BlobToCommitment(&blob)
+	if err != nil {
+		t.Fatalf("failed to commit to blob: %v", err)
+	}
+	proof, err := kzg.ComputeBlobProof(&blob, commitment)
+	if err != nil {
+		t.Fatalf("failed to compute blob proof: %v", err)
+	}
+	return &BlobTxSidecar{
+		Blobs:       []kzg.Blob{blob},
+		Commitments: []kzg.Commitment{commitment},
+		Proofs:      []kzg.Proof{proof},
+	}
+}
+
+func TestBlobTxEncoding(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := NewCancunSigner(big.NewInt(1))
+
+	sidecar := makeSidecar(t, 1)
+	tx, err := SignNewTx(key, signer, &BlobTx{
+		ChainID:       big.NewInt(1),
+		Nonce:         1,
+		GasTipCap:     big.NewInt(1),
+		GasFeeCap:     big.NewInt(10),
+		Gas:           21000,
+		To:            common.Address{0x01},
+		Value:         big.NewInt(1),
+		DataGasFeeCap: big.NewInt(5),
+		BlobHashes:    sidecar.BlobHashes(),
+		Sidecar:       sidecar,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign blob tx: %v", err)
+	}
+	if err := ValidateBlobTx(tx); err != nil {
+		t.Fatalf("failed to validate blob tx: %v", err)
+	}
+	if have, want := tx.DataGas(), uint64(131072); have != want {
+		t.Fatalf("data gas mismatch: have %d, want %d", have, want)
+	}
+	stripped := tx.WithoutBlobTxSidecar()
+	if stripped.BlobTxSidecar() != nil {
+		t.Fatalf("sidecar not stripped")
+	}
+	if tx.Hash() != stripped.Hash() {
+		t.Fatalf("sidecar changed the transaction hash")
+	}
+	// The network encoding carries the sidecar along
+	full, err := encodeDecodeBinary(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertEqual(tx, full); err != nil {
+		t.Fatal(err)
+	}
+	if full.BlobTxSidecar() == nil {
+		t.Fatalf("sidecar lost in network encoding")
+	}
+	if err := full.BlobTxSidecar().Validate(full.DataHashes()); err != nil {
+		t.Fatalf("decoded sidecar invalid: %v", err)
+	}
+	if from, err := Sender(signer, full); err != nil || from != addr {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	// The canonical encoding does not
+	bare, err := encodeDecodeBinary(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := assertEqual(tx, bare); err != nil {
+		t.Fatal(err)
+	}
+	if bare.BlobTxSidecar() != nil {
+		t.Fatalf("sidecar present in canonical encoding")
+	}
+	// Block bodies must always contain the canonical encoding
+	var buf bytes.Buffer
+	Transactions{tx}.EncodeIndex(0, &buf)
+	if want, _ := stripped.MarshalBinary(); !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("indexed encoding mismatch: have %x, want %x", buf.Bytes(), want)
+	}
+	if got, err := encodeDecodeJSON(tx); err != nil {
+		t.Fatal(err)
+	} else if err := assertEqual(tx, got); err != nil {
+		t.Fatal(err)
+	}
+	// Ensure a transaction can be wrapped in RLP lists as part of a message
+	enc, err := rlp.EncodeToBytes([]*Transaction{tx})
+	if err != nil {
+		t.Fatalf("failed to encode tx list: %v", err)
+	}
+	var txs []*Transaction
+	if err := rlp.DecodeBytes(enc, &txs); err != nil {
+		t.Fatalf("failed to decode tx list: %v", err)
+	}
+	if len(txs) != 1 || txs[0].BlobTxSidecar() == nil {
+		t.Fatalf("sidecar lost in tx list encoding")
+	}
+}
+
+func TestBlobTxValidation(t *testing.T) {
+	sidecar := makeSidecar(t, 1)
+
+	tests := []struct {
+		hashes []common.Hash
+		err    error
+	}{
+		{hashes: nil, err: ErrMissingBlobHashes},
+		{hashes: make([]common.Hash, 5), err: ErrTooManyBlobs},
+		{hashes: []common.Hash{{0x02}}, err: ErrBlobHashVersion},
+		{hashes: sidecar.BlobHashes(), err: nil},
+	}
+	for i, tt := range tests {
+		tx := NewTx(&BlobTx{BlobHashes: tt.hashes})
+		if err := ValidateBlobTx(tx); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Ensure sidecars not matching the hashes or the proofs are rejected
+	other := makeSidecar(t, 2)
+	if err := sidecar.Validate(other.BlobHashes()); !errors.Is(err, ErrBlobSidecar) {
+		t.Errorf("mismatching hashes error mismatch: have %v, want %v", err, ErrBlobSidecar)
+	}
+	invalid := &BlobTxSidecar{
+		Blobs:       sidecar.Blobs,
+		Commitments: sidecar.Commitments,
+		Proofs:      other.Proofs,
+	}
+	if err := invalid.Validate(sidecar.BlobHashes()); !errors.Is(err, ErrBlobSidecar) {
+		t.Errorf("invalid proof error mismatch: have %v, want %v", err, ErrBlobSidecar)
+	}
+}
@@ -194,6 +194,48 @@ func TestEIP2718BlockEncoding(t *testing.T) {
 	}
 }
 
+func TestWithdrawalsBlockEncoding(t *testing.T) {
+	header := &Header{
+		Difficulty: common.Big0,
+		Number:     big.NewInt(1),
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	for _, withdrawals := range [][]*Withdrawal{
+		{},
+		{
+			{Index: 0, Validator: 1, Address: common.HexToAddress("0xaaaa"), Amount: 32},
+			{Index: 1, Validator: 2, Address: common.HexToAddress("0xbbbb"), Amount: 1},
+		},
+	} {
+		block := NewBlockWithWithdrawals(header, nil, nil, nil, withdrawals, newHasher())
+		if block.Header().WithdrawalsHash == nil {
+			t.Fatalf("missing withdrawals root")
+		}
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			t.Fatal("encode error: ", err)
+		}
+		var dec Block
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatal("decode error: ", err)
+		}
+		if dec.Hash() != block.Hash() {
+			t.Errorf("hash mismatch: have %x, want %x", dec.Hash(), block.Hash())
+		}
+		if dec.Withdrawals() == nil {
+			t.Errorf("decoded withdrawals missing")
+		}
+		if !reflect.DeepEqual([]*Withdrawal(dec.Withdrawals()), withdrawals) {
+			t.Errorf("withdrawals mismatch: have %v, want %v", dec.Withdrawals(), withdrawals)
+		}
+	}
+	// Blocks without withdrawals must retain their legacy encoding
+	block := NewBlockWithWithdrawals(header, nil, nil, nil, nil, newHasher())
+	if block.Header().WithdrawalsHash != nil || block.Withdrawals() != nil {
+		t.Errorf("unexpected withdrawals in legacy block")
+	}
+}
+
 func TestUncleHash(t *testing.T) {
 	uncles := make([]*Header, 0)
 	h := CalcUncleHash(uncles)
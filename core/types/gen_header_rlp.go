@@ -41,7 +41,9 @@ func (obj *Header) EncodeRLP(_w io.Writer) error {
 	w.WriteBytes(obj.MixDigest[:])
 	w.WriteBytes(obj.Nonce[:])
 	_tmp1 := obj.BaseFee != nil
-	if _tmp1 {
+	_tmp2 := obj.WithdrawalsHash != nil
+	_tmp3 := obj.ExcessDataGas != nil
+	if _tmp1 || _tmp2 || _tmp3 {
 		if obj.BaseFee == nil {
 			w.Write(rlp.EmptyString)
 		} else {
@@ -51,6 +53,23 @@ func (obj *Header) EncodeRLP(_w io.Writer) error {
 			w.WriteBigInt(obj.BaseFee)
 		}
 	}
+	if _tmp2 || _tmp3 {
+		if obj.WithdrawalsHash == nil {
+			w.Write([]byte{0x80})
+		} else {
+			w.WriteBytes(obj.WithdrawalsHash[:])
+		}
+	}
+	if _tmp3 {
+		if obj.ExcessDataGas == nil {
+			w.Write(rlp.EmptyString)
+		} else {
+			if obj.ExcessDataGas.Sign() == -1 {
+				return rlp.ErrNegativeBigInt
+			}
+			w.WriteBigInt(obj.ExcessDataGas)
+		}
+	}
 	w.ListEnd(_tmp0)
 	return w.Flush()
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -45,6 +46,7 @@ const (
 	LegacyTxType = iota
 	AccessListTxType
 	DynamicFeeTxType
+	BlobTxType
 )
 
 // Transaction is an Ethereum transaction.
@@ -67,7 +69,7 @@ func NewTx(inner TxData) *Transaction {
 
 // TxData is the underlying data of a transaction.
 //
-// This is implemented by DynamicFeeTx, LegacyTx and AccessListTx.
+// This is implemented by BlobTx, DynamicFeeTx, LegacyTx and AccessListTx.
 type TxData interface {
 	txType() byte // returns the type ID
 	copy() TxData // creates a deep copy and initializes all fields
@@ -102,15 +104,25 @@ func (tx *Transaction) EncodeRLP(w io.Writer) error {
 	return rlp.Encode(w, buf.Bytes())
 }
 
-// encodeTyped writes the canonical encoding of a typed transaction to w.
+// encodeTyped writes the encoding of a typed transaction to w. Blob transactions
+// carrying a sidecar are encoded in their network representation.
 func (tx *Transaction) encodeTyped(w *bytes.Buffer) error {
 	w.WriteByte(tx.Type())
+	if inner, ok := tx.inner.(*BlobTx); ok && inner.Sidecar != nil {
+		return rlp.Encode(w, &blobTxWithBlobs{
+			BlobTx:      inner,
+			Blobs:       inner.Sidecar.Blobs,
+			Commitments: inner.Sidecar.Commitments,
+			Proofs:      inner.Sidecar.Proofs,
+		})
+	}
 	return rlp.Encode(w, tx.inner)
 }
 
 // MarshalBinary returns the canonical encoding of the transaction.
 // For legacy transactions, it returns the RLP encoding. For EIP-2718 typed
-// transactions, it returns the type and payload.
+// transactions, it returns the type and payload. Blob transactions carrying
+// a sidecar are encoded along with their blobs.
 func (tx *Transaction) MarshalBinary() ([]byte, error) {
 	if tx.Type() == LegacyTxType {
 		return rlp.EncodeToBytes(tx.inner)
@@ -184,11 +196,42 @@ func (tx *Transaction) decodeTyped(b []byte) (TxData, error) {
 		var inner DynamicFeeTx
 		err := rlp.DecodeBytes(b[1:], &inner)
 		return &inner, err
+	case BlobTxType:
+		return decodeBlobTx(b[1:])
 	default:
 		return nil, ErrTxTypeNotSupported
 	}
 }
 
+// decodeBlobTx decodes a blob transaction, either in its canonical form or in
+// the network representation carrying the sidecar.
+func decodeBlobTx(b []byte) (TxData, error) {
+	_, content, _, err := rlp.Split(b)
+	if err != nil {
+		return nil, err
+	}
+	kind, _, _, err := rlp.Split(content)
+	if err != nil {
+		return nil, err
+	}
+	if kind != rlp.List {
+		var inner BlobTx
+		err := rlp.DecodeBytes(b, &inner)
+		return &inner, err
+	}
+	var wrapped blobTxWithBlobs
+	if err := rlp.DecodeBytes(b, &wrapped); err != nil {
+		return nil, err
+	}
+	inner := wrapped.BlobTx
+	inner.Sidecar = &BlobTxSidecar{
+		Blobs:       wrapped.Blobs,
+		Commitments: wrapped.Commitments,
+		Proofs:      wrapped.Proofs,
+	}
+	return inner, nil
+}
+
 // setDecoded sets the inner transaction and size after decoding.
 func (tx *Transaction) setDecoded(inner TxData, size int) {
 	tx.inner = inner
@@ -285,9 +328,85 @@ func (tx *Transaction) To() *common.Address {
 	return copyAddressPtr(tx.inner.to())
 }
 
-// Cost returns gas * gasPrice + value.
+// Time returns the time the transaction was first seen locally, which for the
+// transactions in the pool is their arrival time.
+func (tx *Transaction) Time() time.Time { return tx.time }
+
+// DataGas returns the data gas consumed by the blobs of the transaction.
+func (tx *Transaction) DataGas() uint64 {
+	return uint64(len(tx.DataHashes())) * params.DataGasPerBlob
+}
+
+// DataGasFeeCap returns the data gas fee cap of the transaction, or nil for
+// non-blob transactions.
+func (tx *Transaction) DataGasFeeCap() *big.Int {
+	if inner, ok := tx.inner.(*BlobTx); ok {
+		return new(big.Int).Set(inner.DataGasFeeCap)
+	}
+	return nil
+}
+
+// DataHashes returns the versioned hashes of the blobs of the transaction.
+func (tx *Transaction) DataHashes() []common.Hash {
+	if inner, ok := tx.inner.(*BlobTx); ok {
+		return inner.BlobHashes
+	}
+	return nil
+}
+
+// BlobTxSidecar returns the sidecar of a blob transaction, or nil if the
+// transaction carries none.
+func (tx *Transaction) BlobTxSidecar() *BlobTxSidecar {
+	if inner, ok := tx.inner.(*BlobTx); ok {
+		return inner.Sidecar
+	}
+	return nil
+}
+
+// WithoutBlobTxSidecar returns a copy of the transaction without the blob
+// sidecar, or the transaction itself if it carries none.
+func (tx *Transaction) WithoutBlobTxSidecar() *Transaction {
+	inner, ok := tx.inner.(*BlobTx)
+	if !ok || inner.Sidecar == nil {
+		return tx
+	}
+	cpy := &Transaction{inner: inner.withoutSidecar(), time: tx.time}
+	if h := tx.hash.Load(); h != nil {
+		cpy.hash.Store(h)
+	}
+	if f := tx.from.Load(); f != nil {
+		cpy.from.Store(f)
+	}
+	return cpy
+}
+
+// WithBlobTxSidecar returns a copy of the blob transaction with the given
+// sidecar attached.
+func (tx *Transaction) WithBlobTxSidecar(sidecar *BlobTxSidecar) *Transaction {
+	inner, ok := tx.inner.(*BlobTx)
+	if !ok {
+		return tx
+	}
+	blobtx := inner.withoutSidecar()
+	blobtx.Sidecar = sidecar
+
+	cpy := &Transaction{inner: blobtx, time: tx.time}
+	if h := tx.hash.Load(); h != nil {
+		cpy.hash.Store(h)
+	}
+	if f := tx.from.Load(); f != nil {
+		cpy.from.Store(f)
+	}
+	return cpy
+}
+
+// Cost returns gas * gasPrice + value, plus dataGas * dataGasFeeCap for blob
+// transactions.
 func (tx *Transaction) Cost() *big.Int {
 	total := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+	if feeCap := tx.DataGasFeeCap(); feeCap != nil {
+		total.Add(total, new(big.Int).Mul(feeCap, new(big.Int).SetUint64(tx.DataGas())))
+	}
 	total.Add(total, tx.Value())
 	return total
 }
@@ -410,7 +529,9 @@ func (s Transactions) EncodeIndex(i int, w *bytes.Buffer) {
 	if tx.Type() == LegacyTxType {
 		rlp.Encode(w, tx.inner)
 	} else {
-		tx.encodeTyped(w)
+		// Blob sidecars are never part of the consensus encoding
+		w.WriteByte(tx.Type())
+		rlp.Encode(w, tx.inner)
 	}
 }
 
@@ -590,6 +711,9 @@ type Message struct {
 	data       []byte
 	accessList AccessList
 	isFake     bool
+
+	dataGasFeeCap *big.Int
+	dataHashes    []common.Hash
 }
 
 func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList, isFake bool) Message {
@@ -621,6 +745,9 @@ func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
 		data:       tx.Data(),
 		accessList: tx.AccessList(),
 		isFake:     false,
+
+		dataGasFeeCap: tx.DataGasFeeCap(),
+		dataHashes:    tx.DataHashes(),
 	}
 	// If baseFee provided, set gasPrice to effectiveGasPrice.
 	if baseFee != nil {
@@ -643,6 +770,9 @@ func (m Message) Data() []byte           { return m.data }
 func (m Message) AccessList() AccessList { return m.accessList }
 func (m Message) IsFake() bool           { return m.isFake }
 
+func (m Message) DataGasFeeCap() *big.Int   { return m.dataGasFeeCap }
+func (m Message) DataHashes() []common.Hash { return m.dataHashes }
+
 // copyAddressPtr copies an address.
 func copyAddressPtr(a *common.Address) *common.Address {
 	if a == nil {
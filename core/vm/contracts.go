@@ -20,6 +20,7 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -28,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/blake2b"
 	"github.com/ethereum/go-ethereum/crypto/bls12381"
 	"github.com/ethereum/go-ethereum/crypto/bn256"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
 	"github.com/ethereum/go-ethereum/params"
 	"golang.org/x/crypto/ripemd160"
 )
@@ -90,6 +92,21 @@ var PrecompiledContractsBerlin = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{9}): &blake2F{},
 }
 
+// PrecompiledContractsCancun contains the default set of pre-compiled Ethereum
+// contracts used in the Cancun release.
+var PrecompiledContractsCancun = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1}):  &ecrecover{},
+	common.BytesToAddress([]byte{2}):  &sha256hash{},
+	common.BytesToAddress([]byte{3}):  &ripemd160hash{},
+	common.BytesToAddress([]byte{4}):  &dataCopy{},
+	common.BytesToAddress([]byte{5}):  &bigModExp{eip2565: true},
+	common.BytesToAddress([]byte{6}):  &bn256AddIstanbul{},
+	common.BytesToAddress([]byte{7}):  &bn256ScalarMulIstanbul{},
+	common.BytesToAddress([]byte{8}):  &bn256PairingIstanbul{},
+	common.BytesToAddress([]byte{9}):  &blake2F{},
+	common.BytesToAddress([]byte{10}): &pointEvaluation{},
+}
+
 // PrecompiledContractsBLS contains the set of pre-compiled Ethereum
 // contracts specified in EIP-2537. These are exported for testing purposes.
 var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
@@ -105,6 +122,7 @@ var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
 }
 
 var (
+	PrecompiledAddressesCancun    []common.Address
 	PrecompiledAddressesBerlin    []common.Address
 	PrecompiledAddressesIstanbul  []common.Address
 	PrecompiledAddressesByzantium []common.Address
@@ -124,11 +142,16 @@ func init() {
 	for k := range PrecompiledContractsBerlin {
 		PrecompiledAddressesBerlin = append(PrecompiledAddressesBerlin, k)
 	}
+	for k := range PrecompiledContractsCancun {
+		PrecompiledAddressesCancun = append(PrecompiledAddressesCancun, k)
+	}
 }
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
 	switch {
+	case rules.IsCancun:
+		return PrecompiledAddressesCancun
 	case rules.IsBerlin:
 		return PrecompiledAddressesBerlin
 	case rules.IsIstanbul:
@@ -1041,3 +1064,54 @@ func (c *bls12381MapG2) Run(input []byte) ([]byte, error) {
 	// Encode the G2 point to 256 bytes
 	return g.EncodePoint(r), nil
 }
+
+var (
+	errPointEvaluationInputLength   = errors.New("invalid input length")
+	errPointEvaluationVersionedHash = errors.New("mismatched versioned hash")
+	errPointEvaluationVerifyProof   = errors.New("error verifying kzg proof")
+)
+
+// pointEvaluationReturnValue is the result of a successful point evaluation,
+// the number of field elements per blob followed by the BLS scalar modulus.
+var pointEvaluationReturnValue = func() []byte {
+	ret := make([]byte, 64)
+	new(big.Int).SetUint64(kzg.FieldElementsPerBlob).FillBytes(ret[:32])
+	kzg.BLSModulus().FillBytes(ret[32:])
+	return ret
+}()
+
+// pointEvaluation implements the EIP-4844 point evaluation precompile.
+type pointEvaluation struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *pointEvaluation) RequiredGas(input []byte) uint64 {
+	return params.BlobTxPointEvaluationPrecompileGas
+}
+
+func (c *pointEvaluation) Run(input []byte) ([]byte, error) {
+	// The input is the versioned hash of the blob, the evaluation point, the
+	// claimed value at that point, the commitment and the proof:
+	// > versioned_hash (32) | z (32) | y (32) | commitment (48) | proof (48)
+	if len(input) != 192 {
+		return nil, errPointEvaluationInputLength
+	}
+	var (
+		point      kzg.Point
+		claim      kzg.Claim
+		commitment kzg.Commitment
+		proof      kzg.Proof
+	)
+	versionedHash := common.BytesToHash(input[:32])
+	copy(point[:], input[32:64])
+	copy(claim[:], input[64:96])
+	copy(commitment[:], input[96:144])
+	copy(proof[:], input[144:192])
+
+	if kzg.VersionedHash(commitment) != versionedHash {
+		return nil, errPointEvaluationVersionedHash
+	}
+	if err := kzg.VerifyProof(commitment, point, claim, proof); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPointEvaluationVerifyProof, err)
+	}
+	return common.CopyBytes(pointEvaluationReturnValue), nil
+}
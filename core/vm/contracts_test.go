@@ -19,12 +19,16 @@ package vm
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -272,6 +276,75 @@ func TestPrecompileBlake2FMalformedInput(t *testing.T) {
 
 func TestPrecompiledEcrecover(t *testing.T) { testJson("ecRecover", "01", t) }
 
+// kzgSetupOnce guards the loading of the insecure kzg test setup.
+var (
+	kzgSetupOnce sync.Once
+	kzgSetupErr  error
+)
+
+// loadTestKZGSetup loads the insecure trusted setup used by the kzg tests, as
+// commitments and proofs are refused without a setup.
+func loadTestKZGSetup(t *testing.T) {
+	kzgSetupOnce.Do(func() {
+		kzgSetupErr = kzg.LoadTrustedSetup(filepath.Join("..", "..", "crypto", "kzg", "testdata", "dev_setup.json"))
+	})
+	if kzgSetupErr != nil {
+		t.Fatalf("failed to load kzg setup: %v", kzgSetupErr)
+	}
+}
+
+func TestPrecompiledPointEvaluation(t *testing.T) {
+	loadTestKZGSetup(t)
+
+	var blob kzg.Blob
+	for i := 0; i < kzg.FieldElementsPerBlob; i++ {
+		blob[i*kzg.BytesPerFieldElement+31] = byte(i)
+	}
+	commitment, err := kzg.BlobToCommitment(&blob)
+	if err != nil {
+		t.Fatalf("failed to commit to blob: %v", err)
+	}
+	point := kzg.Point{31: 0x2a}
+	proof, claim, err := kzg.ComputeProof(&blob, point)
+	if err != nil {
+		t.Fatalf("failed to compute proof: %v", err)
+	}
+	hash := kzg.VersionedHash(commitment)
+
+	input := make([]byte, 0, 192)
+	input = append(input, hash[:]...)
+	input = append(input, point[:]...)
+	input = append(input, claim[:]...)
+	input = append(input, commitment[:]...)
+	input = append(input, proof[:]...)
+
+	p := &pointEvaluation{}
+	if gas := p.RequiredGas(input); gas != 50000 {
+		t.Fatalf("gas mismatch: have %d, want %d", gas, 50000)
+	}
+	res, err := p.Run(input)
+	if err != nil {
+		t.Fatalf("failed to evaluate valid point: %v", err)
+	}
+	if !bytes.Equal(res, pointEvaluationReturnValue) {
+		t.Fatalf("result mismatch: have %x, want %x", res, pointEvaluationReturnValue)
+	}
+	// Ensure invalid inputs are rejected
+	if _, err := p.Run(input[:191]); err != errPointEvaluationInputLength {
+		t.Errorf("short input error mismatch: have %v, want %v", err, errPointEvaluationInputLength)
+	}
+	bad := common.CopyBytes(input)
+	bad[0] = 0x02
+	if _, err := p.Run(bad); err != errPointEvaluationVersionedHash {
+		t.Errorf("versioned hash error mismatch: have %v, want %v", err, errPointEvaluationVersionedHash)
+	}
+	bad = common.CopyBytes(input)
+	bad[95]++
+	if _, err := p.Run(bad); !errors.Is(err, errPointEvaluationVerifyProof) {
+		t.Errorf("invalid claim error mismatch: have %v, want %v", err, errPointEvaluationVerifyProof)
+	}
+}
+
 func testJson(name, addr string, t *testing.T) {
 	tests, err := loadJson(name)
 	if err != nil {
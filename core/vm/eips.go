@@ -26,6 +26,7 @@ import (
 
 var activators = map[int]func(*JumpTable){
 	3855: enable3855,
+	4844: enable4844,
 	3529: enable3529,
 	3198: enable3198,
 	2929: enable2929,
@@ -192,3 +193,26 @@ func opPush0(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 	scope.Stack.push(new(uint256.Int))
 	return nil, nil
 }
+
+// enable4844 applies EIP-4844 (DATAHASH opcode)
+func enable4844(jt *JumpTable) {
+	// New opcode
+	jt[DATAHASH] = &operation{
+		execute:     opDataHash,
+		constantGas: GasFastestStep,
+		minStack:    minStack(1, 1),
+		maxStack:    maxStack(1, 1),
+	}
+}
+
+// opDataHash implements the DATAHASH opcode
+func opDataHash(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	index := scope.Stack.peek()
+	if index.LtUint64(uint64(len(interpreter.evm.TxContext.DataHashes))) {
+		hash := interpreter.evm.TxContext.DataHashes[index.Uint64()]
+		index.SetBytes32(hash[:])
+	} else {
+		index.Clear()
+	}
+	return nil, nil
+}
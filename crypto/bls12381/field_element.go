@@ -176,6 +176,14 @@ func (e *fe) sign() bool {
 	return r[0]&1 == 0
 }
 
+// isLexicographicallyLargest returns whether the canonical form of the element
+// is larger than its negation, as required by the compressed point encoding.
+func (e *fe) isLexicographicallyLargest() bool {
+	r := new(fe)
+	fromMont(r, e)
+	return r.cmp(halfModulus) > 0
+}
+
 func (fe *fe) div2(e uint64) {
 	fe[0] = fe[0]>>1 | fe[1]<<63
 	fe[1] = fe[1]>>1 | fe[2]<<63
@@ -226,6 +234,15 @@ func (e *fe2) rand(r io.Reader) (*fe2, error) {
 	return &fe2{*a0, *a1}, nil
 }
 
+// isLexicographicallyLargest returns whether the element is larger than its
+// negation, comparing the imaginary parts first.
+func (e *fe2) isLexicographicallyLargest() bool {
+	if !e[1].isZero() {
+		return e[1].isLexicographicallyLargest()
+	}
+	return e[0].isLexicographicallyLargest()
+}
+
 func (e *fe2) isOne() bool {
 	return e[0].isOne() && e[1].isZero()
 }
@@ -112,6 +112,65 @@ func (g *G1) FromBytes(in []byte) (*PointG1, error) {
 	return p, nil
 }
 
+// FromCompressed constructs a new point given compressed byte input in the
+// zcash serialization format. The point is checked to be on the curve and in
+// the correct subgroup.
+func (g *G1) FromCompressed(compressed []byte) (*PointG1, error) {
+	if len(compressed) != 48 {
+		return nil, errors.New("input string should be equal 48 bytes")
+	}
+	if compressed[0]&compressionFlag == 0 {
+		return nil, errors.New("compression flag must be set")
+	}
+	in := make([]byte, 48)
+	copy(in, compressed)
+	in[0] &= ^byte(compressionFlag | infinityFlag | signFlag)
+
+	if compressed[0]&infinityFlag != 0 {
+		if compressed[0]&signFlag != 0 || !isZeroBytes(in) {
+			return nil, errors.New("invalid point at infinity encoding")
+		}
+		return g.Zero(), nil
+	}
+	x, err := fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	// Recover y from the curve equation y^2 = x^3 + b
+	y, y2 := new(fe), new(fe)
+	square(y2, x)
+	mul(y2, y2, x)
+	add(y2, y2, b)
+	if !sqrt(y, y2) {
+		return nil, errors.New("point is not on curve")
+	}
+	if y.isLexicographicallyLargest() != (compressed[0]&signFlag != 0) {
+		neg(y, y)
+	}
+	p := &PointG1{*x, *y, *new(fe).one()}
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not in correct subgroup")
+	}
+	return p, nil
+}
+
+// ToCompressed serializes a point into 48 bytes in the compressed zcash
+// serialization format.
+func (g *G1) ToCompressed(p *PointG1) []byte {
+	out := make([]byte, 48)
+	if g.IsZero(p) {
+		out[0] = compressionFlag | infinityFlag
+		return out
+	}
+	g.Affine(p)
+	copy(out, toBytes(&p[0]))
+	out[0] |= compressionFlag
+	if p[1].isLexicographicallyLargest() {
+		out[0] |= signFlag
+	}
+	return out
+}
+
 // DecodePoint given encoded (x, y) coordinates in 128 bytes returns a valid G1 Point.
 func (g *G1) DecodePoint(in []byte) (*PointG1, error) {
 	if len(in) != 128 {
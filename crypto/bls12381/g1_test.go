@@ -53,6 +53,30 @@ func TestG1Serialization(t *testing.T) {
 	}
 }
 
+func TestG1Compression(t *testing.T) {
+	g1 := NewG1()
+	for i := 0; i < fuz; i++ {
+		a := g1.rand()
+		b, err := g1.FromCompressed(g1.ToCompressed(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g1.Equal(a, b) {
+			t.Fatal("bad compression from/to")
+		}
+	}
+	one := common.FromHex("0x97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb")
+	if enc := g1.ToCompressed(g1.One()); !bytes.Equal(enc, one) {
+		t.Fatalf("bad generator compression: have %x, want %x", enc, one)
+	}
+	if p, err := g1.FromCompressed(g1.ToCompressed(g1.Zero())); err != nil || !g1.IsZero(p) {
+		t.Fatal("bad infinity compression")
+	}
+	if _, err := g1.FromCompressed(g1.ToBytes(g1.One())[:48]); err == nil {
+		t.Fatal("uncompressed input accepted")
+	}
+}
+
 func TestG1IsOnCurve(t *testing.T) {
 	g := NewG1()
 	zero := g.Zero()
@@ -164,6 +164,65 @@ func (g *G2) ToBytes(p *PointG2) []byte {
 	return out
 }
 
+// FromCompressed constructs a new point given compressed byte input in the
+// zcash serialization format. The point is checked to be on the curve and in
+// the correct subgroup.
+func (g *G2) FromCompressed(compressed []byte) (*PointG2, error) {
+	if len(compressed) != 96 {
+		return nil, errors.New("input string should be equal 96 bytes")
+	}
+	if compressed[0]&compressionFlag == 0 {
+		return nil, errors.New("compression flag must be set")
+	}
+	in := make([]byte, 96)
+	copy(in, compressed)
+	in[0] &= ^byte(compressionFlag | infinityFlag | signFlag)
+
+	if compressed[0]&infinityFlag != 0 {
+		if compressed[0]&signFlag != 0 || !isZeroBytes(in) {
+			return nil, errors.New("invalid point at infinity encoding")
+		}
+		return g.Zero(), nil
+	}
+	x, err := g.f.fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	// Recover y from the curve equation y^2 = x^3 + b
+	y, y2 := new(fe2), new(fe2)
+	g.f.square(y2, x)
+	g.f.mul(y2, y2, x)
+	g.f.add(y2, y2, b2)
+	if !g.f.sqrt(y, y2) {
+		return nil, errors.New("point is not on curve")
+	}
+	if y.isLexicographicallyLargest() != (compressed[0]&signFlag != 0) {
+		g.f.neg(y, y)
+	}
+	p := &PointG2{*x, *y, *new(fe2).one()}
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not in correct subgroup")
+	}
+	return p, nil
+}
+
+// ToCompressed serializes a point into 96 bytes in the compressed zcash
+// serialization format.
+func (g *G2) ToCompressed(p *PointG2) []byte {
+	out := make([]byte, 96)
+	if g.IsZero(p) {
+		out[0] = compressionFlag | infinityFlag
+		return out
+	}
+	g.Affine(p)
+	copy(out, g.f.toBytes(&p[0]))
+	out[0] |= compressionFlag
+	if p[1].isLexicographicallyLargest() {
+		out[0] |= signFlag
+	}
+	return out
+}
+
 // EncodePoint encodes a point into 256 bytes.
 func (g *G2) EncodePoint(p *PointG2) []byte {
 	// outRaw is 96 bytes
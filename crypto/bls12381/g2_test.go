@@ -55,6 +55,27 @@ func TestG2Serialization(t *testing.T) {
 	}
 }
 
+func TestG2Compression(t *testing.T) {
+	g2 := NewG2()
+	for i := 0; i < fuz; i++ {
+		a := g2.rand()
+		b, err := g2.FromCompressed(g2.ToCompressed(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g2.Equal(a, b) {
+			t.Fatal("bad compression from/to")
+		}
+	}
+	one := common.FromHex("0x93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8")
+	if enc := g2.ToCompressed(g2.One()); !bytes.Equal(enc, one) {
+		t.Fatalf("bad generator compression: have %x, want %x", enc, one)
+	}
+	if p, err := g2.FromCompressed(g2.ToCompressed(g2.Zero())); err != nil || !g2.IsZero(p) {
+		t.Fatal("bad infinity compression")
+	}
+}
+
 func TestG2IsOnCurve(t *testing.T) {
 	g := NewG2()
 	zero := g.Zero()
@@ -43,3 +43,20 @@ func decodeFieldElement(in []byte) ([]byte, error) {
 	copy(out[:], in[16:])
 	return out, nil
 }
+
+// Flags encoded into the most significant bits of compressed points.
+const (
+	compressionFlag = 1 << 7
+	infinityFlag    = 1 << 6
+	signFlag        = 1 << 5
+)
+
+// isZeroBytes returns whether all bytes of the input are zero.
+func isZeroBytes(in []byte) bool {
+	for _, b := range in {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,309 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package kzg implements the KZG polynomial commitment scheme over BLS12-381
+// used by the blob transactions of EIP-4844.
+package kzg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+const (
+	// FieldElementsPerBlob is the number of field elements a blob consists of.
+	FieldElementsPerBlob = 4096
+
+	// BytesPerFieldElement is the size of a serialized field element.
+	BytesPerFieldElement = 32
+
+	// BlobSize is the size of a serialized blob.
+	BlobSize = FieldElementsPerBlob * BytesPerFieldElement
+
+	// BlobCommitmentVersionKZG is the version byte of the versioned hashes of
+	// KZG commitments.
+	BlobCommitmentVersionKZG = 0x01
+)
+
+// fiatShamirDomain is the domain separator of the blob proof challenges.
+var fiatShamirDomain = []byte("FSBLOBVERIFY_V1_")
+
+var (
+	errInvalidFieldElement = errors.New("field element not in canonical form")
+	errInvalidProof        = errors.New("invalid kzg proof")
+)
+
+// Blob is the data the commitments are computed over, a sequence of big-endian
+// encoded field elements.
+type Blob [BlobSize]byte
+
+// Commitment is a compressed G1 point committing to a blob.
+type Commitment [48]byte
+
+// Proof is a compressed G1 point proving the evaluation of a blob at a point.
+type Proof [48]byte
+
+// Point is a big-endian encoded field element a blob is evaluated at.
+type Point [32]byte
+
+// Claim is a big-endian encoded field element a blob evaluates to at a point.
+type Claim [32]byte
+
+// BLSModulus returns the order of the BLS12-381 scalar field the elements of
+// the blobs are defined over.
+func BLSModulus() *big.Int {
+	return new(big.Int).Set(modulus)
+}
+
+// VersionedHash computes the versioned hash of a commitment, which is how blob
+// transactions reference their blobs.
+func VersionedHash(commitment Commitment) common.Hash {
+	hash := sha256.Sum256(commitment[:])
+	hash[0] = BlobCommitmentVersionKZG
+	return hash
+}
+
+// BlobToCommitment computes the commitment of a blob.
+func BlobToCommitment(blob *Blob) (Commitment, error) {
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return Commitment{}, err
+	}
+	setup, err := currentSetup()
+	if err != nil {
+		return Commitment{}, err
+	}
+	g1 := bls12381.NewG1()
+	point, err := g1.MultiExp(g1.New(), setup.lagrange, poly)
+	if err != nil {
+		return Commitment{}, err
+	}
+	var commitment Commitment
+	copy(commitment[:], g1.ToCompressed(point))
+	return commitment, nil
+}
+
+// ComputeProof computes the proof of evaluating a blob at the given point,
+// returning the proof along with the evaluation.
+func ComputeProof(blob *Blob, point Point) (Proof, Claim, error) {
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return Proof{}, Claim{}, err
+	}
+	z, err := bytesToFieldElement(point[:])
+	if err != nil {
+		return Proof{}, Claim{}, err
+	}
+	proof, y, err := computeProof(poly, z)
+	if err != nil {
+		return Proof{}, Claim{}, err
+	}
+	var claim Claim
+	y.FillBytes(claim[:])
+	return proof, claim, nil
+}
+
+// VerifyProof verifies that the blob committed to evaluates to the claimed
+// value at the given point.
+func VerifyProof(commitment Commitment, point Point, claim Claim, proof Proof) error {
+	z, err := bytesToFieldElement(point[:])
+	if err != nil {
+		return err
+	}
+	y, err := bytesToFieldElement(claim[:])
+	if err != nil {
+		return err
+	}
+	return verifyProof(commitment, z, y, proof)
+}
+
+// ComputeBlobProof computes the proof verifying a blob against its commitment,
+// evaluating the blob at a challenge derived from both.
+func ComputeBlobProof(blob *Blob, commitment Commitment) (Proof, error) {
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return Proof{}, err
+	}
+	proof, _, err := computeProof(poly, computeChallenge(blob, commitment))
+	return proof, err
+}
+
+// VerifyBlobProof verifies that the commitment belongs to the blob.
+func VerifyBlobProof(blob *Blob, commitment Commitment, proof Proof) error {
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return err
+	}
+	z := computeChallenge(blob, commitment)
+	return verifyProof(commitment, z, evaluatePolynomial(poly, z), proof)
+}
+
+// computeProof computes the proof of evaluating the polynomial given in
+// evaluation form at the point z.
+func computeProof(poly []*big.Int, z *big.Int) (Proof, *big.Int, error) {
+	var (
+		domain   = currentDomain()
+		y        = evaluatePolynomial(poly, z)
+		quotient = make([]*big.Int, FieldElementsPerBlob)
+		inDomain = -1
+	)
+	// The quotient polynomial q(X) = (p(X) - y) / (X - z) in evaluation form
+	for i, w := range domain {
+		if w.Cmp(z) == 0 {
+			inDomain = i
+			quotient[i] = new(big.Int)
+			continue
+		}
+		q := new(big.Int).Sub(poly[i], y)
+		q.Mul(q, inverse(new(big.Int).Sub(w, z)))
+		quotient[i] = q.Mod(q, modulus)
+	}
+	// If z is a root of unity, the quotient at that point is computed as
+	// sum((p_i - y) * w_i / (z * (z - w_i)))
+	if inDomain >= 0 {
+		sum := new(big.Int)
+		for i, w := range domain {
+			if i == inDomain {
+				continue
+			}
+			num := new(big.Int).Sub(poly[i], y)
+			num.Mul(num, w)
+			den := new(big.Int).Sub(z, w)
+			den.Mul(den, z)
+			num.Mul(num, inverse(den))
+			sum.Add(sum, num)
+		}
+		quotient[inDomain] = sum.Mod(sum, modulus)
+	}
+	setup, err := currentSetup()
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	g1 := bls12381.NewG1()
+	point, err := g1.MultiExp(g1.New(), setup.lagrange, quotient)
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	var proof Proof
+	copy(proof[:], g1.ToCompressed(point))
+	return proof, y, nil
+}
+
+// verifyProof checks the pairing equation e(C - [y]G1, G2) = e(π, [s]G2 - [z]G2).
+func verifyProof(commitment Commitment, z, y *big.Int, proof Proof) error {
+	setup, err := currentSetup()
+	if err != nil {
+		return err
+	}
+	g1 := bls12381.NewG1()
+	c, err := g1.FromCompressed(commitment[:])
+	if err != nil {
+		return err
+	}
+	pi, err := g1.FromCompressed(proof[:])
+	if err != nil {
+		return err
+	}
+	g2 := bls12381.NewG2()
+	sz := g2.MulScalar(g2.New(), g2.One(), z)
+	g2.Sub(sz, setup.secretG2, sz)
+
+	cy := g1.MulScalar(g1.New(), g1.One(), y)
+	g1.Sub(cy, c, cy)
+
+	engine := bls12381.NewPairingEngine()
+	engine.AddPair(cy, g2.One())
+	engine.AddPairInv(pi, sz)
+	if !engine.Check() {
+		return errInvalidProof
+	}
+	return nil
+}
+
+// evaluatePolynomial evaluates the polynomial given in evaluation form at the
+// point z using the barycentric formula.
+func evaluatePolynomial(poly []*big.Int, z *big.Int) *big.Int {
+	var (
+		domain = currentDomain()
+		sum    = new(big.Int)
+	)
+	for i, w := range domain {
+		if w.Cmp(z) == 0 {
+			return new(big.Int).Set(poly[i])
+		}
+		term := new(big.Int).Mul(poly[i], w)
+		term.Mul(term, inverse(new(big.Int).Sub(z, w)))
+		sum.Add(sum, term)
+	}
+	// Multiply by (z^N - 1) / N
+	n := big.NewInt(FieldElementsPerBlob)
+	factor := new(big.Int).Exp(z, n, modulus)
+	factor.Sub(factor, common.Big1)
+	factor.Mul(factor, inverse(n))
+
+	sum.Mul(sum, factor)
+	return sum.Mod(sum, modulus)
+}
+
+// computeChallenge derives the Fiat-Shamir challenge a blob is evaluated at
+// when proving it against its commitment.
+func computeChallenge(blob *Blob, commitment Commitment) *big.Int {
+	var degree [16]byte
+	binary.BigEndian.PutUint64(degree[8:], FieldElementsPerBlob)
+
+	hasher := sha256.New()
+	hasher.Write(fiatShamirDomain)
+	hasher.Write(degree[:])
+	hasher.Write(blob[:])
+	hasher.Write(commitment[:])
+
+	challenge := new(big.Int).SetBytes(hasher.Sum(nil))
+	return challenge.Mod(challenge, modulus)
+}
+
+// blobToPolynomial converts a blob into a polynomial in evaluation form,
+// ensuring all field elements are canonical.
+func blobToPolynomial(blob *Blob) ([]*big.Int, error) {
+	poly := make([]*big.Int, FieldElementsPerBlob)
+	for i := range poly {
+		elem, err := bytesToFieldElement(blob[i*BytesPerFieldElement : (i+1)*BytesPerFieldElement])
+		if err != nil {
+			return nil, err
+		}
+		poly[i] = elem
+	}
+	return poly, nil
+}
+
+// bytesToFieldElement decodes a big-endian field element, rejecting values
+// not less than the field modulus.
+func bytesToFieldElement(b []byte) (*big.Int, error) {
+	elem := new(big.Int).SetBytes(b)
+	if elem.Cmp(modulus) >= 0 {
+		return nil, errInvalidFieldElement
+	}
+	return elem, nil
+}
+
+// inverse computes the multiplicative inverse of x in the scalar field.
+func inverse(x *big.Int) *big.Int {
+	return new(big.Int).ModInverse(new(big.Int).Mod(x, modulus), modulus)
+}
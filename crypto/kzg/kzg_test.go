@@ -0,0 +1,202 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package kzg
+
+import (
+	"encoding/json"
+	"flag"
+	"math/big"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// devSetupSeed is the seed the secret of the insecure setup used by the tests
+// is derived from. The setup is stored in testdata/dev_setup.json, rerun the
+// tests with -regen-setup to regenerate it.
+var (
+	devSetupSeed = []byte("go-ethereum insecure kzg development setup")
+	devSetupPath = "testdata/dev_setup.json"
+	regenSetup   = flag.Bool("regen-setup", false, "regenerate the insecure test setup")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *regenSetup {
+		secret := new(big.Int).SetBytes(crypto.Keccak256(devSetupSeed))
+		if err := writeSetup(newSetup(secret.Mod(secret, modulus)), devSetupPath); err != nil {
+			panic(err)
+		}
+	}
+	if err := LoadTrustedSetup(devSetupPath); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newSetup generates a trusted setup from a known secret. The result is only
+// suitable for testing and development networks, as knowledge of the secret
+// allows forging proofs.
+func newSetup(secret *big.Int) *trustedSetup {
+	var (
+		g1  = bls12381.NewG1()
+		g2  = bls12381.NewG2()
+		set = &trustedSetup{
+			lagrange: make([]*bls12381.PointG1, FieldElementsPerBlob),
+			secretG2: g2.MulScalar(g2.New(), g2.One(), secret),
+		}
+	)
+	// The lagrange polynomial L_i(s) = w_i * (s^N - 1) / (N * (s - w_i))
+	n := big.NewInt(FieldElementsPerBlob)
+	factor := new(big.Int).Exp(secret, n, modulus)
+	factor.Sub(factor, common.Big1)
+	factor.Mul(factor, inverse(n))
+
+	for i, w := range currentDomain() {
+		scalar := new(big.Int).Mul(factor, w)
+		scalar.Mul(scalar, inverse(new(big.Int).Sub(secret, w)))
+		scalar.Mod(scalar, modulus)
+
+		set.lagrange[i] = g1.MulScalar(g1.New(), g1.One(), scalar)
+		g1.Affine(set.lagrange[i])
+	}
+	return set
+}
+
+// writeSetup stores the trusted setup in the ceremony output format.
+func writeSetup(set *trustedSetup, path string) error {
+	var (
+		g1  = bls12381.NewG1()
+		g2  = bls12381.NewG2()
+		enc trustedSetupJSON
+	)
+	for _, point := range set.lagrange {
+		enc.G1Lagrange = append(enc.G1Lagrange, g1.ToCompressed(point))
+	}
+	enc.G2Monomial = []hexutil.Bytes{g2.ToCompressed(g2.One()), g2.ToCompressed(set.secretG2)}
+
+	blob, err := json.MarshalIndent(enc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, blob, 0644)
+}
+
+// randBlob creates a blob filled with random canonical field elements.
+func randBlob(r *rand.Rand) *Blob {
+	var blob Blob
+	for i := 0; i < FieldElementsPerBlob; i++ {
+		elem := new(big.Int).Rand(r, modulus)
+		elem.FillBytes(blob[i*BytesPerFieldElement : (i+1)*BytesPerFieldElement])
+	}
+	return &blob
+}
+
+func TestBlobProof(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	blob := randBlob(r)
+	commitment, err := BlobToCommitment(blob)
+	if err != nil {
+		t.Fatalf("failed to commit to blob: %v", err)
+	}
+	proof, err := ComputeBlobProof(blob, commitment)
+	if err != nil {
+		t.Fatalf("failed to compute blob proof: %v", err)
+	}
+	if err := VerifyBlobProof(blob, commitment, proof); err != nil {
+		t.Fatalf("failed to verify blob proof: %v", err)
+	}
+	// Ensure the proof does not verify against a different blob
+	other := randBlob(r)
+	if err := VerifyBlobProof(other, commitment, proof); err == nil {
+		t.Fatalf("proof verified against a different blob")
+	}
+	// Ensure non-canonical field elements are rejected
+	for i := 0; i < BytesPerFieldElement; i++ {
+		other[i] = 0xff
+	}
+	if _, err := BlobToCommitment(other); err != errInvalidFieldElement {
+		t.Fatalf("non-canonical blob error mismatch: have %v, want %v", err, errInvalidFieldElement)
+	}
+}
+
+func TestPointProof(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	blob := randBlob(r)
+	commitment, err := BlobToCommitment(blob)
+	if err != nil {
+		t.Fatalf("failed to commit to blob: %v", err)
+	}
+	// Evaluate both inside and outside of the evaluation domain
+	var inside, outside Point
+	currentDomain()[3].FillBytes(inside[:])
+	new(big.Int).Rand(r, modulus).FillBytes(outside[:])
+
+	for _, point := range []Point{inside, outside} {
+		proof, claim, err := ComputeProof(blob, point)
+		if err != nil {
+			t.Fatalf("failed to compute proof: %v", err)
+		}
+		if err := VerifyProof(commitment, point, claim, proof); err != nil {
+			t.Fatalf("failed to verify proof: %v", err)
+		}
+		claim[31] ^= 1
+		if err := VerifyProof(commitment, point, claim, proof); err == nil {
+			t.Fatalf("invalid claim verified")
+		}
+	}
+	// The evaluation inside the domain must be the blob element itself
+	_, claim, _ := ComputeProof(blob, inside)
+	if want := blob[3*BytesPerFieldElement : 4*BytesPerFieldElement]; string(claim[:]) != string(want) {
+		t.Fatalf("claim mismatch: have %x, want %x", claim, want)
+	}
+}
+
+// Tests that commitments and proofs are refused without a trusted setup.
+func TestNoTrustedSetup(t *testing.T) {
+	setupLock.Lock()
+	loaded := setup
+	setup = nil
+	setupLock.Unlock()
+
+	defer func() {
+		setupLock.Lock()
+		setup = loaded
+		setupLock.Unlock()
+	}()
+	var blob Blob
+	if _, err := BlobToCommitment(&blob); err != errNoTrustedSetup {
+		t.Fatalf("commitment error mismatch: have %v, want %v", err, errNoTrustedSetup)
+	}
+	if err := VerifyProof(Commitment{}, Point{}, Claim{}, Proof{}); err != errNoTrustedSetup {
+		t.Fatalf("verification error mismatch: have %v, want %v", err, errNoTrustedSetup)
+	}
+}
+
+func TestVersionedHash(t *testing.T) {
+	hash := VersionedHash(Commitment{})
+	if hash[0] != BlobCommitmentVersionKZG {
+		t.Fatalf("version mismatch: have %d, want %d", hash[0], BlobCommitmentVersionKZG)
+	}
+}
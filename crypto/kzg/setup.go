@@ -0,0 +1,147 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package kzg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+var (
+	// modulus is the order of the BLS12-381 scalar field.
+	modulus, _ = new(big.Int).SetString("52435875175126190479447740508185965837690552500527637822603658699938581184513", 10)
+
+	// primitiveRoot is a generator of the multiplicative group of the scalar
+	// field, used to derive the roots of unity of the evaluation domain.
+	primitiveRoot = big.NewInt(7)
+
+	// errNoTrustedSetup is returned if commitments or proofs are computed or
+	// verified before a trusted setup was loaded.
+	errNoTrustedSetup = errors.New("kzg trusted setup not loaded")
+)
+
+// trustedSetup is the output of a powers-of-tau ceremony needed to compute and
+// verify commitments.
+type trustedSetup struct {
+	lagrange []*bls12381.PointG1 // Lagrange basis over the evaluation domain, in bit-reversed order
+	secretG2 *bls12381.PointG2   // The ceremony secret s as [s]G2
+}
+
+// trustedSetupJSON is the file format of the ceremony output.
+type trustedSetupJSON struct {
+	G1Lagrange []hexutil.Bytes `json:"g1_lagrange"`
+	G2Monomial []hexutil.Bytes `json:"g2_monomial"`
+}
+
+var (
+	setup     *trustedSetup
+	setupLock sync.Mutex
+
+	domain     []*big.Int
+	domainOnce sync.Once
+)
+
+// LoadTrustedSetup replaces the trusted setup used by the package with the one
+// stored in the given JSON file, in the format published by the ceremony.
+func LoadTrustedSetup(path string) error {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var enc trustedSetupJSON
+	if err := json.Unmarshal(blob, &enc); err != nil {
+		return err
+	}
+	if len(enc.G1Lagrange) != FieldElementsPerBlob {
+		return fmt.Errorf("invalid number of lagrange points: have %d, want %d", len(enc.G1Lagrange), FieldElementsPerBlob)
+	}
+	if len(enc.G2Monomial) < 2 {
+		return fmt.Errorf("missing G2 points: have %d, want at least 2", len(enc.G2Monomial))
+	}
+	var (
+		g1  = bls12381.NewG1()
+		g2  = bls12381.NewG2()
+		set = &trustedSetup{lagrange: make([]*bls12381.PointG1, FieldElementsPerBlob)}
+	)
+	for i, enc := range enc.G1Lagrange {
+		if set.lagrange[i], err = g1.FromCompressed(enc); err != nil {
+			return fmt.Errorf("invalid lagrange point %d: %v", i, err)
+		}
+	}
+	if set.secretG2, err = g2.FromCompressed(enc.G2Monomial[1]); err != nil {
+		return fmt.Errorf("invalid G2 point: %v", err)
+	}
+	setupLock.Lock()
+	setup = set
+	setupLock.Unlock()
+	return nil
+}
+
+// TrustedSetupLoaded reports whether a trusted setup was loaded, without which
+// blob transactions and the point evaluation precompile can't be verified.
+func TrustedSetupLoaded() bool {
+	setupLock.Lock()
+	defer setupLock.Unlock()
+
+	return setup != nil
+}
+
+// currentSetup returns the trusted setup in use. Commitments and proofs are
+// refused until a setup was loaded, as a setup with a known secret would allow
+// forging proofs.
+func currentSetup() (*trustedSetup, error) {
+	setupLock.Lock()
+	defer setupLock.Unlock()
+
+	if setup == nil {
+		return nil, errNoTrustedSetup
+	}
+	return setup, nil
+}
+
+// currentDomain returns the roots of unity the blob polynomials are evaluated
+// over, in bit-reversed order.
+func currentDomain() []*big.Int {
+	domainOnce.Do(func() {
+		exp := new(big.Int).Sub(modulus, common.Big1)
+		exp.Div(exp, big.NewInt(FieldElementsPerBlob))
+		root := new(big.Int).Exp(primitiveRoot, exp, modulus)
+
+		roots := make([]*big.Int, FieldElementsPerBlob)
+		roots[0] = big.NewInt(1)
+		for i := 1; i < len(roots); i++ {
+			roots[i] = new(big.Int).Mul(roots[i-1], root)
+			roots[i].Mod(roots[i], modulus)
+		}
+		shift := 64 - bits.Len64(FieldElementsPerBlob-1)
+
+		domain = make([]*big.Int, FieldElementsPerBlob)
+		for i := range roots {
+			domain[bits.Reverse64(uint64(i))>>shift] = roots[i]
+		}
+	})
+	return domain
+}
@@ -17,8 +17,10 @@
 package eth
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -33,9 +35,12 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -71,6 +76,237 @@ func (api *EthereumAPI) Mining() bool {
 	return api.e.IsMining()
 }
 
+// TxLifecycleFilter restricts a transaction lifecycle subscription to a set of
+// transactions or senders. An empty filter matches every transaction.
+type TxLifecycleFilter struct {
+	Hashes  []common.Hash    `json:"hashes"`
+	Senders []common.Address `json:"senders"`
+}
+
+// RPCTxLifecycleChange is the notification sent to transaction lifecycle
+// subscribers when a pooled transaction changes its state.
+type RPCTxLifecycleChange struct {
+	Hash        common.Hash    `json:"hash"`
+	From        common.Address `json:"from"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Status      string         `json:"status"`
+	Replacement *common.Hash   `json:"replacedBy,omitempty"`
+	Reason      string         `json:"reason,omitempty"`
+}
+
+// TxLifecycle creates a subscription that is notified each time a transaction
+// matching the filter is added to the transaction pool, promoted, demoted,
+// replaced, dropped or included into the chain.
+func (api *EthereumAPI) TxLifecycle(ctx context.Context, filter *TxLifecycleFilter) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		hashes  = make(map[common.Hash]struct{})
+		senders = make(map[common.Address]struct{})
+	)
+	if filter != nil {
+		for _, hash := range filter.Hashes {
+			hashes[hash] = struct{}{}
+		}
+		for _, sender := range filter.Senders {
+			senders[sender] = struct{}{}
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			events = make(chan core.TxLifecycleEvent, 128)
+			sub    = api.e.TxPool().SubscribeTxLifecycleEvent(events)
+			signer = types.LatestSigner(api.e.BlockChain().Config())
+		)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				for _, change := range ev.Changes {
+					from, _ := types.Sender(signer, change.Tx)
+					if len(hashes) > 0 || len(senders) > 0 {
+						_, hashOk := hashes[change.Tx.Hash()]
+						_, senderOk := senders[from]
+						if !hashOk && !senderOk {
+							continue
+						}
+					}
+					notification := &RPCTxLifecycleChange{
+						Hash:   change.Tx.Hash(),
+						From:   from,
+						Nonce:  hexutil.Uint64(change.Tx.Nonce()),
+						Status: change.Kind.String(),
+					}
+					if change.Kind == core.TxLifecycleReplaced {
+						replacement := change.Replacement
+						notification.Replacement = &replacement
+					}
+					if change.Reason != nil {
+						notification.Reason = change.Reason.Error()
+					}
+					notifier.Notify(rpcSub.ID, notification)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// SendBundleArgs represents the arguments of a bundle submission.
+type SendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp      *hexutil.Uint64 `json:"minTimestamp"`
+	MaxTimestamp      *hexutil.Uint64 `json:"maxTimestamp"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes"`
+}
+
+// SendBundle submits an ordered group of signed transactions to be included
+// atomically at the top of the given block, or not at all. Bundles are picked
+// by the local miner based on the revenue they generate, as simulated against
+// the pending state. The hash identifying the bundle is returned.
+func (api *EthereumAPI) SendBundle(args SendBundleArgs) (common.Hash, error) {
+	if len(args.Txs) == 0 {
+		return common.Hash{}, errors.New("bundle missing txs")
+	}
+	if args.BlockNumber == 0 {
+		return common.Hash{}, errors.New("bundle missing blockNumber")
+	}
+	var (
+		signer = types.LatestSigner(api.e.BlockChain().Config())
+		bundle = &miner.Bundle{
+			BlockNumber:       uint64(args.BlockNumber),
+			RevertingTxHashes: args.RevertingTxHashes,
+		}
+	)
+	for i, encoded := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encoded); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid bundle tx %d: %v", i, err)
+		}
+		if _, err := types.Sender(signer, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid bundle tx %d: %v", i, err)
+		}
+		bundle.Txs = append(bundle.Txs, tx)
+	}
+	if args.MinTimestamp != nil {
+		bundle.MinTimestamp = uint64(*args.MinTimestamp)
+	}
+	if args.MaxTimestamp != nil {
+		bundle.MaxTimestamp = uint64(*args.MaxTimestamp)
+	}
+	if err := api.e.Miner().AddBundle(bundle); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
+// KnownAccountArgs is the expected storage of an account required by a
+// conditional transaction, given either as a storage root hash or as a map of
+// individual storage slot values.
+type KnownAccountArgs struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+// UnmarshalJSON parses either a storage root hash or a map of slot values.
+func (args *KnownAccountArgs) UnmarshalJSON(input []byte) error {
+	var root common.Hash
+	if err := json.Unmarshal(input, &root); err == nil {
+		args.StorageRoot = &root
+		return nil
+	}
+	return json.Unmarshal(input, &args.StorageSlots)
+}
+
+// TxConditionsArgs represents the preconditions of a conditional transaction.
+type TxConditionsArgs struct {
+	BlockNumberMin *hexutil.Uint64                      `json:"blockNumberMin"`
+	BlockNumberMax *hexutil.Uint64                      `json:"blockNumberMax"`
+	TimestampMin   *hexutil.Uint64                      `json:"timestampMin"`
+	TimestampMax   *hexutil.Uint64                      `json:"timestampMax"`
+	KnownAccounts  map[common.Address]*KnownAccountArgs `json:"knownAccounts"`
+}
+
+// toConditions converts the arguments into the pool representation.
+func (args *TxConditionsArgs) toConditions() *core.TxConditions {
+	conditions := &core.TxConditions{
+		BlockNumberMin: (*uint64)(args.BlockNumberMin),
+		BlockNumberMax: (*uint64)(args.BlockNumberMax),
+		TimestampMin:   (*uint64)(args.TimestampMin),
+		TimestampMax:   (*uint64)(args.TimestampMax),
+		KnownAccounts:  make(map[common.Address]*core.KnownAccount, len(args.KnownAccounts)),
+	}
+	for addr, account := range args.KnownAccounts {
+		if account == nil {
+			continue
+		}
+		conditions.KnownAccounts[addr] = &core.KnownAccount{
+			StorageRoot:  account.StorageRoot,
+			StorageSlots: account.StorageSlots,
+		}
+	}
+	return conditions
+}
+
+// SendRawTransactionConditional submits a signed transaction into the pool that
+// may only be included into blocks satisfying the given preconditions. The
+// transaction is dropped once the preconditions can no longer be met.
+func (api *EthereumAPI) SendRawTransactionConditional(input hexutil.Bytes, args TxConditionsArgs) (common.Hash, error) {
+	tx, err := api.decodeRawTransaction(input)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := api.e.TxPool().AddConditional(tx, args.toConditions()); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted conditional transaction", "hash", tx.Hash(), "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value())
+	return tx.Hash(), nil
+}
+
+// SendPrivateRawTransaction submits a signed transaction into the pool that is
+// never propagated to the network, only included by the local miner. The
+// transaction is dropped if not included within the configured number of blocks.
+func (api *EthereumAPI) SendPrivateRawTransaction(input hexutil.Bytes) (common.Hash, error) {
+	tx, err := api.decodeRawTransaction(input)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := api.e.TxPool().AddPrivate(tx); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted private transaction", "hash", tx.Hash(), "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value())
+	return tx.Hash(), nil
+}
+
+// decodeRawTransaction parses a signed transaction submitted over RPC, applying
+// the same sanity checks as for regular raw transactions.
+func (api *EthereumAPI) decodeRawTransaction(input hexutil.Bytes) (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return nil, err
+	}
+	if cap := api.e.APIBackend.RPCTxFeeCap(); cap != 0 {
+		fee := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))), new(big.Float).SetInt(big.NewInt(params.Ether)))
+		if feeFloat, _ := fee.Float64(); feeFloat > cap {
+			return nil, fmt.Errorf("tx fee (%.2f ether) exceeds the configured cap (%.2f ether)", feeFloat, cap)
+		}
+	}
+	if !api.e.APIBackend.UnprotectedAllowed() && !tx.Protected() {
+		return nil, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+	return tx, nil
+}
+
 // MinerAPI provides an API to control the miner.
 type MinerAPI struct {
 	e *Ethereum
@@ -134,6 +370,46 @@ func (api *MinerAPI) SetRecommitInterval(interval int) {
 	api.e.Miner().SetRecommitInterval(time.Duration(interval) * time.Millisecond)
 }
 
+// TxPoolAPI is the collection of transaction pool related APIs exposed by full
+// nodes, complementing the read only ones offered by the shared API backend.
+type TxPoolAPI struct {
+	eth *Ethereum
+}
+
+// NewTxPoolAPI creates a new API definition for the full node transaction pool
+// methods of the Ethereum service.
+func NewTxPoolAPI(eth *Ethereum) *TxPoolAPI {
+	return &TxPoolAPI{eth: eth}
+}
+
+// TxPoolImportResult is the result of a transaction pool import.
+type TxPoolImportResult struct {
+	Imported hexutil.Uint `json:"imported"`
+	Dropped  hexutil.Uint `json:"dropped"`
+}
+
+// Export returns all the transactions contained in the transaction pool, along
+// with their local or remote origin, in the binary format of the pool snapshot.
+func (api *TxPoolAPI) Export() (hexutil.Bytes, error) {
+	var buf bytes.Buffer
+	if _, err := api.eth.TxPool().Export(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Import injects the transactions of a previous pool export into the transaction
+// pool, validating each of them against the current chain head. All transactions
+// are imported as remote ones, the local origin recorded in the export is not
+// honoured as it would let any RPC user bypass the pricing and eviction rules.
+func (api *TxPoolAPI) Import(blob hexutil.Bytes) (*TxPoolImportResult, error) {
+	imported, dropped, err := api.eth.TxPool().Import(bytes.NewReader(blob), false)
+	if err != nil {
+		return nil, err
+	}
+	return &TxPoolImportResult{Imported: hexutil.Uint(imported), Dropped: hexutil.Uint(dropped)}, nil
+}
+
 // AdminAPI is the collection of Ethereum full node related APIs for node
 // administration.
 type AdminAPI struct {
@@ -590,3 +866,32 @@ func (api *DebugAPI) GetAccessibleState(from, to rpc.BlockNumber) (uint64, error
 	}
 	return 0, errors.New("no state found")
 }
+
+// StartStatePruning starts deleting the stale state in the background while the
+// node keeps importing blocks, or resumes the paused one.
+func (api *DebugAPI) StartStatePruning() error {
+	if api.eth.statePruner == nil {
+		return errors.New("state pruning is not supported in path scheme")
+	}
+	if !api.eth.Synced() {
+		return errors.New("state pruning is not available during sync")
+	}
+	return api.eth.statePruner.Start()
+}
+
+// StopStatePruning interrupts the running state pruning. The deletion can be
+// resumed later by StartStatePruning, while the marking has to be redone.
+func (api *DebugAPI) StopStatePruning() error {
+	if api.eth.statePruner == nil {
+		return errors.New("state pruning is not supported in path scheme")
+	}
+	return api.eth.statePruner.Stop()
+}
+
+// StatePruningStatus returns the progress of the state pruning.
+func (api *DebugAPI) StatePruningStatus() (*pruner.OnlineStatus, error) {
+	if api.eth.statePruner == nil {
+		return nil, errors.New("state pruning is not supported in path scheme")
+	}
+	return api.eth.statePruner.Status(), nil
+}
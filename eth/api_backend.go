@@ -127,11 +127,19 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	if number == rpc.SafeBlockNumber {
 		return b.eth.blockchain.CurrentSafeBlock(), nil
 	}
-	return b.eth.blockchain.GetBlockByNumber(uint64(number)), nil
+	block := b.eth.blockchain.GetBlockByNumber(uint64(number))
+	if block == nil && b.eth.blockchain.IsHistoryPruned(b.eth.blockchain.GetCanonicalHash(uint64(number))) {
+		return nil, core.ErrHistoryPruned
+	}
+	return block, nil
 }
 
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.eth.blockchain.GetBlockByHash(hash), nil
+	block := b.eth.blockchain.GetBlockByHash(hash)
+	if block == nil && b.eth.blockchain.IsHistoryPruned(hash) {
+		return nil, core.ErrHistoryPruned
+	}
+	return block, nil
 }
 
 func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
@@ -148,6 +156,9 @@ func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 		}
 		block := b.eth.blockchain.GetBlock(hash, header.Number.Uint64())
 		if block == nil {
+			if b.eth.blockchain.IsHistoryPruned(hash) {
+				return nil, core.ErrHistoryPruned
+			}
 			return nil, errors.New("header found, but block body is missing")
 		}
 		return block, nil
@@ -199,11 +210,19 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	return b.eth.blockchain.GetReceiptsByHash(hash), nil
+	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
+	if receipts == nil && b.eth.blockchain.IsHistoryPruned(hash) {
+		return nil, core.ErrHistoryPruned
+	}
+	return receipts, nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
-	return rawdb.ReadLogs(b.eth.chainDb, hash, number, b.ChainConfig()), nil
+	logs := rawdb.ReadLogs(b.eth.chainDb, hash, number, b.ChainConfig())
+	if logs == nil && b.eth.blockchain.IsHistoryPruned(hash) {
+		return nil, core.ErrHistoryPruned
+	}
+	return logs, nil
 }
 
 func (b *EthAPIBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
@@ -266,6 +285,14 @@ func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction
 
 func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.eth.ChainDb(), txHash)
+	if tx == nil {
+		// The lookup entries of the pruned transactions are retained
+		if number := rawdb.ReadTxLookupEntry(b.eth.ChainDb(), txHash); number != nil {
+			if b.eth.blockchain.IsHistoryPruned(b.eth.blockchain.GetCanonicalHash(*number)) {
+				return nil, common.Hash{}, 0, 0, core.ErrHistoryPruned
+			}
+		}
+	}
 	return tx, blockHash, blockNumber, index, nil
 }
 
@@ -301,10 +328,18 @@ func (b *EthAPIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 	return b.gpo.SuggestTipCap(ctx)
 }
 
+func (b *EthAPIBackend) SuggestGasTipCapWithUrgency(ctx context.Context, urgency gasprice.Urgency) (*big.Int, error) {
+	return b.gpo.SuggestTipCapWithUrgency(ctx, urgency)
+}
+
 func (b *EthAPIBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (firstBlock *big.Int, reward [][]*big.Int, baseFee []*big.Int, gasUsedRatio []float64, err error) {
 	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
 }
 
+func (b *EthAPIBackend) EstimateFees(ctx context.Context) (*gasprice.FeeEstimate, error) {
+	return b.gpo.EstimateFees(ctx)
+}
+
 func (b *EthAPIBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
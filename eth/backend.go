@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto/kzg"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -84,6 +85,8 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	statePruner *pruner.OnlinePruner // Background state pruner, nil in the path-based scheme
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -130,8 +133,15 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
-		log.Error("Failed to recover state", "error", err)
+	scheme, err := rawdb.ParseStateScheme(config.StateScheme, chainDb)
+	if err != nil {
+		return nil, err
+	}
+	// Offline pruning is only available in the hash-based scheme
+	if scheme == rawdb.HashScheme {
+		if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
+			log.Error("Failed to recover state", "error", err)
+		}
 	}
 	// Transfer mining-related config to the ethash config.
 	ethashConfig := config.Ethash
@@ -190,6 +200,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
+			StateScheme:         scheme,
+			StateHistory:        config.StateHistory,
+			HistoryRetain:       config.HistoryRetain,
 		}
 	)
 	// Override the chain config with provided settings.
@@ -204,11 +217,28 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Blobs can't be verified without a trusted setup, refuse to follow a chain
+	// scheduling Cancun rather than forking off it.
+	if eth.blockchain.Config().CancunBlock != nil && !kzg.TrustedSetupLoaded() {
+		eth.blockchain.Stop()
+		return nil, errors.New("cancun scheduled without a kzg trusted setup, use --kzg.trustedsetup")
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	// The stale state is only accumulated in the hash-based scheme
+	if scheme == rawdb.HashScheme {
+		eth.statePruner = pruner.NewOnlinePruner(chainDb, eth.blockchain, stack.ResolvePath(""), pruner.OnlineConfig{
+			BloomSize: config.PruningBloomSize,
+			Throttle:  pruner.DefaultOnlineConfig.Throttle,
+		})
+	}
+
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = stack.ResolvePath(config.TxPool.Snapshot)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.blockchain.Config(), eth.blockchain)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -228,6 +258,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		EventMux:       eth.eventMux,
 		Checkpoint:     checkpoint,
 		RequiredBlocks: config.RequiredBlocks,
+		TxPeerRate:     config.TxPool.PeerRate,
+		TxPeerBurst:    int(config.TxPool.PeerBurst),
 	}); err != nil {
 		return nil, err
 	}
@@ -306,6 +338,9 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Service:   downloader.NewDownloaderAPI(s.handler.downloader, s.eventMux),
+		}, {
+			Namespace: "txpool",
+			Service:   NewTxPoolAPI(s),
 		}, {
 			Namespace: "admin",
 			Service:   NewAdminAPI(s),
@@ -545,6 +580,9 @@ func (s *Ethereum) Stop() error {
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
+	if s.statePruner != nil {
+		s.statePruner.Close()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 
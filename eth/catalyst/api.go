@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -156,6 +157,41 @@ func NewConsensusAPI(eth *eth.Ethereum) *ConsensusAPI {
 // If there are payloadAttributes: we try to assemble a block with the payloadAttributes
 // and return its payloadID.
 func (api *ConsensusAPI) ForkchoiceUpdatedV1(update beacon.ForkchoiceStateV1, payloadAttributes *beacon.PayloadAttributesV1) (beacon.ForkChoiceResponse, error) {
+	if payloadAttributes != nil && payloadAttributes.Withdrawals != nil {
+		return beacon.STATUS_INVALID, beacon.InvalidParams.With(errors.New("withdrawals not supported in V1"))
+	}
+	return api.forkchoiceUpdated(update, payloadAttributes)
+}
+
+// ForkchoiceUpdatedV2 is equivalent to V1 with the addition of withdrawals in
+// the payload attributes, which must be present if and only if the payload
+// is built on top of a Shanghai block.
+func (api *ConsensusAPI) ForkchoiceUpdatedV2(update beacon.ForkchoiceStateV1, payloadAttributes *beacon.PayloadAttributesV1) (beacon.ForkChoiceResponse, error) {
+	if payloadAttributes != nil {
+		if head := api.eth.BlockChain().GetHeaderByHash(update.HeadBlockHash); head != nil {
+			number := new(big.Int).Add(head.Number, common.Big1)
+			if err := api.checkWithdrawals(number, payloadAttributes.Withdrawals); err != nil {
+				return beacon.STATUS_INVALID, beacon.InvalidParams.With(err)
+			}
+		}
+	}
+	return api.forkchoiceUpdated(update, payloadAttributes)
+}
+
+// checkWithdrawals verifies that a withdrawal list is provided if and only if
+// the block with the given number is a Shanghai block.
+func (api *ConsensusAPI) checkWithdrawals(number *big.Int, withdrawals []*types.Withdrawal) error {
+	if api.eth.BlockChain().Config().IsShanghai(number) {
+		if withdrawals == nil {
+			return errors.New("missing withdrawals list")
+		}
+	} else if withdrawals != nil {
+		return errors.New("withdrawals before shanghai")
+	}
+	return nil
+}
+
+func (api *ConsensusAPI) forkchoiceUpdated(update beacon.ForkchoiceStateV1, payloadAttributes *beacon.PayloadAttributesV1) (beacon.ForkChoiceResponse, error) {
 	api.forkchoiceLock.Lock()
 	defer api.forkchoiceLock.Unlock()
 
@@ -281,20 +317,20 @@ func (api *ConsensusAPI) ForkchoiceUpdatedV1(update beacon.ForkchoiceStateV1, pa
 	// might replace it arbitrarily many times in between.
 	if payloadAttributes != nil {
 		// Create an empty block first which can be used as a fallback
-		empty, err := api.eth.Miner().GetSealingBlockSync(update.HeadBlockHash, payloadAttributes.Timestamp, payloadAttributes.SuggestedFeeRecipient, payloadAttributes.Random, true)
+		empty, err := api.eth.Miner().GetSealingBlockSync(update.HeadBlockHash, payloadAttributes.Timestamp, payloadAttributes.SuggestedFeeRecipient, payloadAttributes.Random, payloadAttributes.Withdrawals, true)
 		if err != nil {
 			log.Error("Failed to create empty sealing payload", "err", err)
 			return valid(nil), beacon.InvalidPayloadAttributes.With(err)
 		}
 		// Send a request to generate a full block in the background.
 		// The result can be obtained via the returned channel.
-		resCh, err := api.eth.Miner().GetSealingBlockAsync(update.HeadBlockHash, payloadAttributes.Timestamp, payloadAttributes.SuggestedFeeRecipient, payloadAttributes.Random, false)
+		resCh, err := api.eth.Miner().GetSealingBlockAsync(update.HeadBlockHash, payloadAttributes.Timestamp, payloadAttributes.SuggestedFeeRecipient, payloadAttributes.Random, payloadAttributes.Withdrawals, false)
 		if err != nil {
 			log.Error("Failed to create async sealing payload", "err", err)
 			return valid(nil), beacon.InvalidPayloadAttributes.With(err)
 		}
 		id := computePayloadId(update.HeadBlockHash, payloadAttributes)
-		api.localBlocks.put(id, &payload{empty: empty, result: resCh})
+		api.localBlocks.put(id, &payload{empty: empty.Block, result: resCh})
 		return valid(&id), nil
 	}
 	return valid(nil), nil
@@ -332,6 +368,21 @@ func (api *ConsensusAPI) ExchangeTransitionConfigurationV1(config beacon.Transit
 
 // GetPayloadV1 returns a cached payload by id.
 func (api *ConsensusAPI) GetPayloadV1(payloadID beacon.PayloadID) (*beacon.ExecutableDataV1, error) {
+	data, err := api.GetPayloadV3(payloadID)
+	if err != nil {
+		return nil, err
+	}
+	return data.ExecutionPayload, nil
+}
+
+// GetPayloadV2 returns a cached payload by id, including its withdrawals.
+func (api *ConsensusAPI) GetPayloadV2(payloadID beacon.PayloadID) (*beacon.ExecutableDataV1, error) {
+	return api.GetPayloadV1(payloadID)
+}
+
+// GetPayloadV3 returns a cached payload by id, along with the blobs, commitments
+// and proofs of the blob transactions it includes.
+func (api *ConsensusAPI) GetPayloadV3(payloadID beacon.PayloadID) (*beacon.ExecutionPayloadEnvelope, error) {
 	log.Trace("Engine API request received", "method", "GetPayload", "id", payloadID)
 	data := api.localBlocks.get(payloadID)
 	if data == nil {
@@ -342,6 +393,65 @@ func (api *ConsensusAPI) GetPayloadV1(payloadID beacon.PayloadID) (*beacon.Execu
 
 // NewPayloadV1 creates an Eth1 block, inserts it in the chain, and returns the status of the chain.
 func (api *ConsensusAPI) NewPayloadV1(params beacon.ExecutableDataV1) (beacon.PayloadStatusV1, error) {
+	if params.Withdrawals != nil {
+		return beacon.PayloadStatusV1{Status: beacon.INVALID}, beacon.InvalidParams.With(errors.New("withdrawals not supported in V1"))
+	}
+	return api.newPayload(params)
+}
+
+// NewPayloadV2 creates an Eth1 block, inserts it in the chain, and returns the
+// status of the chain. The payload must carry withdrawals if and only if it is
+// a Shanghai block.
+func (api *ConsensusAPI) NewPayloadV2(params beacon.ExecutableDataV1) (beacon.PayloadStatusV1, error) {
+	if err := api.checkWithdrawals(new(big.Int).SetUint64(params.Number), params.Withdrawals); err != nil {
+		return beacon.PayloadStatusV1{Status: beacon.INVALID}, beacon.InvalidParams.With(err)
+	}
+	return api.newPayload(params)
+}
+
+// NewPayloadV3 is equivalent to V2 with the addition of the versioned hashes of
+// the blobs the payload's transactions commit to, which are cross checked with
+// the transactions before the payload is executed.
+func (api *ConsensusAPI) NewPayloadV3(params beacon.ExecutableDataV1, versionedHashes []common.Hash) (beacon.PayloadStatusV1, error) {
+	number := new(big.Int).SetUint64(params.Number)
+	if err := api.checkWithdrawals(number, params.Withdrawals); err != nil {
+		return beacon.PayloadStatusV1{Status: beacon.INVALID}, beacon.InvalidParams.With(err)
+	}
+	if !api.eth.BlockChain().Config().IsCancun(number) {
+		return beacon.PayloadStatusV1{Status: beacon.INVALID}, beacon.InvalidParams.With(errors.New("blob payload before cancun"))
+	}
+	if params.ExcessDataGas == nil {
+		return beacon.PayloadStatusV1{Status: beacon.INVALID}, beacon.InvalidParams.With(errors.New("missing excessDataGas"))
+	}
+	if err := checkBlobHashes(params.Transactions, versionedHashes); err != nil {
+		return api.invalid(err, nil), nil
+	}
+	return api.newPayload(params)
+}
+
+// checkBlobHashes verifies that the versioned hashes referenced by the blob
+// transactions of a payload match the expected ones, in order.
+func checkBlobHashes(txs [][]byte, versionedHashes []common.Hash) error {
+	var hashes []common.Hash
+	for i, enc := range txs {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		hashes = append(hashes, tx.DataHashes()...)
+	}
+	if len(hashes) != len(versionedHashes) {
+		return fmt.Errorf("invalid number of versioned hashes: have %d, want %d", len(versionedHashes), len(hashes))
+	}
+	for i := range hashes {
+		if hashes[i] != versionedHashes[i] {
+			return fmt.Errorf("invalid versioned hash %d: have %x, want %x", i, versionedHashes[i], hashes[i])
+		}
+	}
+	return nil
+}
+
+func (api *ConsensusAPI) newPayload(params beacon.ExecutableDataV1) (beacon.PayloadStatusV1, error) {
 	log.Trace("Engine API request received", "method", "ExecutePayload", "number", params.Number, "hash", params.BlockHash)
 	block, err := beacon.ExecutableDataToBlock(params)
 	if err != nil {
@@ -435,6 +545,9 @@ func computePayloadId(headBlockHash common.Hash, params *beacon.PayloadAttribute
 	binary.Write(hasher, binary.BigEndian, params.Timestamp)
 	hasher.Write(params.Random[:])
 	hasher.Write(params.SuggestedFeeRecipient[:])
+	if params.Withdrawals != nil {
+		rlp.Encode(hasher, params.Withdrawals)
+	}
 	var out beacon.PayloadID
 	copy(out[:], hasher.Sum(nil)[:8])
 	return out
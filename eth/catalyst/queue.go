@@ -23,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/beacon"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/miner"
 )
 
 // maxTrackedPayloads is the maximum number of prepared payloads the execution
@@ -41,13 +42,14 @@ type payload struct {
 	lock   sync.Mutex
 	done   bool
 	empty  *types.Block
-	block  *types.Block
-	result chan *types.Block
+	full   *miner.SealingResult
+	result chan *miner.SealingResult
 }
 
 // resolve extracts the generated full block from the given channel if possible
-// or fallback to empty block as an alternative.
-func (req *payload) resolve() *beacon.ExecutableDataV1 {
+// or fallback to empty block as an alternative. The blobs of the included blob
+// transactions are returned along with the payload.
+func (req *payload) resolve() *beacon.ExecutionPayloadEnvelope {
 	// this function can be called concurrently, prevent any
 	// concurrency issue in the first place.
 	req.lock.Lock()
@@ -61,7 +63,7 @@ func (req *payload) resolve() *beacon.ExecutableDataV1 {
 		defer timeout.Stop()
 
 		select {
-		case req.block = <-req.result:
+		case req.full = <-req.result:
 			req.done = true
 		case <-timeout.C:
 			// TODO(rjl49345642, Marius), should we keep this
@@ -70,10 +72,16 @@ func (req *payload) resolve() *beacon.ExecutableDataV1 {
 		}
 	}
 
-	if req.block != nil {
-		return beacon.BlockToExecutableData(req.block)
+	if req.full != nil {
+		return &beacon.ExecutionPayloadEnvelope{
+			ExecutionPayload: beacon.BlockToExecutableData(req.full.Block),
+			BlobsBundle:      beacon.SidecarsToBlobsBundle(req.full.Sidecars),
+		}
+	}
+	return &beacon.ExecutionPayloadEnvelope{
+		ExecutionPayload: beacon.BlockToExecutableData(req.empty),
+		BlobsBundle:      beacon.SidecarsToBlobsBundle(nil),
 	}
-	return beacon.BlockToExecutableData(req.empty)
 }
 
 // payloadQueueItem represents an id->payload tuple to store until it's retrieved
@@ -111,7 +119,7 @@ func (q *payloadQueue) put(id beacon.PayloadID, data *payload) {
 }
 
 // get retrieves a previously stored payload item or nil if it does not exist.
-func (q *payloadQueue) get(id beacon.PayloadID) *beacon.ExecutableDataV1 {
+func (q *payloadQueue) get(id beacon.PayloadID) *beacon.ExecutionPayloadEnvelope {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
 
@@ -74,6 +74,7 @@ var Defaults = Config{
 	},
 	NetworkId:               1,
 	TxLookupLimit:           2350000,
+	StateHistory:            params.FullImmutabilityThreshold,
 	LightPeers:              100,
 	UltraLightFraction:      75,
 	DatabaseCache:           512,
@@ -83,11 +84,13 @@ var Defaults = Config{
 	TrieDirtyCache:          256,
 	TrieTimeout:             60 * time.Minute,
 	SnapshotCache:           102,
+	PruningBloomSize:        2048,
 	FilterLogCacheSize:      32,
 	Miner: miner.Config{
 		GasCeil:  30000000,
 		GasPrice: big.NewInt(params.GWei),
 		Recommit: 3 * time.Second,
+		Ordering: "greedy",
 	},
 	TxPool:        core.DefaultTxPoolConfig,
 	RPCGasCap:     50000000,
@@ -138,6 +141,9 @@ type Config struct {
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
+	StateHistory  uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved (path scheme only).
+	StateScheme   string `toml:",omitempty"` // State scheme used to store ethereum states and merkle tree nodes on top (hash or path).
+	HistoryRetain uint64 `toml:",omitempty"` // The number of blocks from head whose bodies and receipts are retained (0 = entire chain).
 
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes geth verify the
@@ -171,6 +177,7 @@ type Config struct {
 	TrieTimeout             time.Duration
 	SnapshotCache           int
 	Preimages               bool
+	PruningBloomSize        uint64 `toml:",omitempty"` // Megabytes of memory allocated to bloom-filter for online state pruning
 
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
@@ -203,6 +210,13 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// TraceIndex is the name of the tracer run on every imported block to store
+	// its results for serving block traces from disk. Disabled if empty.
+	TraceIndex string `toml:",omitempty"`
+
+	// TraceIndexConfig is the json-encoded config of the trace index tracer.
+	TraceIndexConfig string `toml:",omitempty"`
+
 	// Checkpoint is a hardcoded checkpoint which can be nil.
 	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
 
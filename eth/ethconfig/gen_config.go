@@ -26,6 +26,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NoPruning                             bool
 		NoPrefetch                            bool
 		TxLookupLimit                         uint64                 `toml:",omitempty"`
+		StateHistory                          uint64                 `toml:",omitempty"`
+		StateScheme                           string                 `toml:",omitempty"`
+		HistoryRetain                         uint64                 `toml:",omitempty"`
 		RequiredBlocks                        map[uint64]common.Hash `toml:"-"`
 		LightServ                             int                    `toml:",omitempty"`
 		LightIngress                          int                    `toml:",omitempty"`
@@ -48,6 +51,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TrieTimeout                           time.Duration
 		SnapshotCache                         int
 		Preimages                             bool
+		PruningBloomSize                      uint64 `toml:",omitempty"`
 		FilterLogCacheSize                    int
 		Miner                                 miner.Config
 		Ethash                                ethash.Config
@@ -58,6 +62,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		RPCGasCap                             uint64
 		RPCEVMTimeout                         time.Duration
 		RPCTxFeeCap                           float64
+		TraceIndex                            string                         `toml:",omitempty"`
+		TraceIndexConfig                      string                         `toml:",omitempty"`
 		Checkpoint                            *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle                      *params.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideTerminalTotalDifficulty       *big.Int                       `toml:",omitempty"`
@@ -72,6 +78,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.StateHistory = c.StateHistory
+	enc.StateScheme = c.StateScheme
+	enc.HistoryRetain = c.HistoryRetain
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
@@ -94,6 +103,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieTimeout = c.TrieTimeout
 	enc.SnapshotCache = c.SnapshotCache
 	enc.Preimages = c.Preimages
+	enc.PruningBloomSize = c.PruningBloomSize
 	enc.FilterLogCacheSize = c.FilterLogCacheSize
 	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
@@ -104,6 +114,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.TraceIndex = c.TraceIndex
+	enc.TraceIndexConfig = c.TraceIndexConfig
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
 	enc.OverrideTerminalTotalDifficulty = c.OverrideTerminalTotalDifficulty
@@ -122,6 +134,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NoPruning                             *bool
 		NoPrefetch                            *bool
 		TxLookupLimit                         *uint64                `toml:",omitempty"`
+		StateHistory                          *uint64                `toml:",omitempty"`
+		StateScheme                           *string                `toml:",omitempty"`
+		HistoryRetain                         *uint64                `toml:",omitempty"`
 		RequiredBlocks                        map[uint64]common.Hash `toml:"-"`
 		LightServ                             *int                   `toml:",omitempty"`
 		LightIngress                          *int                   `toml:",omitempty"`
@@ -144,6 +159,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TrieTimeout                           *time.Duration
 		SnapshotCache                         *int
 		Preimages                             *bool
+		PruningBloomSize                      *uint64 `toml:",omitempty"`
 		FilterLogCacheSize                    *int
 		Miner                                 *miner.Config
 		Ethash                                *ethash.Config
@@ -154,6 +170,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		RPCGasCap                             *uint64
 		RPCEVMTimeout                         *time.Duration
 		RPCTxFeeCap                           *float64
+		TraceIndex                            *string                        `toml:",omitempty"`
+		TraceIndexConfig                      *string                        `toml:",omitempty"`
 		Checkpoint                            *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle                      *params.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideTerminalTotalDifficulty       *big.Int                       `toml:",omitempty"`
@@ -187,6 +205,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.StateHistory != nil {
+		c.StateHistory = *dec.StateHistory
+	}
+	if dec.StateScheme != nil {
+		c.StateScheme = *dec.StateScheme
+	}
+	if dec.HistoryRetain != nil {
+		c.HistoryRetain = *dec.HistoryRetain
+	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
@@ -253,6 +280,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Preimages != nil {
 		c.Preimages = *dec.Preimages
 	}
+	if dec.PruningBloomSize != nil {
+		c.PruningBloomSize = *dec.PruningBloomSize
+	}
 	if dec.FilterLogCacheSize != nil {
 		c.FilterLogCacheSize = *dec.FilterLogCacheSize
 	}
@@ -283,6 +313,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.TraceIndex != nil {
+		c.TraceIndex = *dec.TraceIndex
+	}
+	if dec.TraceIndexConfig != nil {
+		c.TraceIndexConfig = *dec.TraceIndexConfig
+	}
 	if dec.Checkpoint != nil {
 		c.Checkpoint = dec.Checkpoint
 	}
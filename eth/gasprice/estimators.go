@@ -0,0 +1,251 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tip estimation strategies selectable in the oracle config.
+const (
+	StrategyPercentile = "percentile" // Percentile of the tips paid in recent blocks
+	StrategyPool       = "pool"       // Tip outbidding the pending demand in the local pool
+	StrategyFeeHistory = "feehistory" // Median of the reward percentiles of recent blocks
+	StrategyBaseFee    = "basefee"    // Fixed tip on top of the base fee, for private chains
+)
+
+// DefaultFixedTip is the tip suggested by the basefee strategy if none is set.
+var DefaultFixedTip = big.NewInt(params.GWei)
+
+// Urgency expresses how quickly a transaction is wanted to be included.
+type Urgency uint8
+
+const (
+	UrgencyMedium Urgency = iota // Inclusion within a few blocks
+	UrgencyLow                   // Inclusion eventually, at a lower price
+	UrgencyHigh                  // Inclusion as soon as possible, at a higher price
+)
+
+// String implements fmt.Stringer.
+func (u Urgency) String() string {
+	switch u {
+	case UrgencyMedium:
+		return "medium"
+	case UrgencyLow:
+		return "low"
+	case UrgencyHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(u))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u Urgency) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *Urgency) UnmarshalText(input []byte) error {
+	switch string(input) {
+	case "medium":
+		*u = UrgencyMedium
+	case "low":
+		*u = UrgencyLow
+	case "high":
+		*u = UrgencyHigh
+	default:
+		return fmt.Errorf(`unknown urgency %q, want "low", "medium" or "high"`, input)
+	}
+	return nil
+}
+
+// percentile shifts the base sampling percentile according to the urgency:
+// halfway towards the cheapest samples for low urgency and halfway towards the
+// priciest ones for high urgency.
+func (u Urgency) percentile(base int) int {
+	switch u {
+	case UrgencyLow:
+		return base / 2
+	case UrgencyHigh:
+		return base + (100-base)/2
+	default:
+		return base
+	}
+}
+
+// Estimator is a strategy suggesting the tip of newly created transactions.
+type Estimator interface {
+	// SuggestTipCap returns a tip cap for a transaction to be included as
+	// quickly as the urgency demands.
+	SuggestTipCap(ctx context.Context, urgency Urgency) (*big.Int, error)
+}
+
+// estimatorFunc is an adapter to allow the use of ordinary functions as tip
+// estimators.
+type estimatorFunc func(ctx context.Context, urgency Urgency) (*big.Int, error)
+
+func (f estimatorFunc) SuggestTipCap(ctx context.Context, urgency Urgency) (*big.Int, error) {
+	return f(ctx, urgency)
+}
+
+// PoolBackend is implemented by oracle backends with access to a local
+// transaction pool, which is required by the pool strategy.
+type PoolBackend interface {
+	GetPoolTransactions() (types.Transactions, error)
+}
+
+// newEstimator creates the tip estimator of the configured strategy, falling
+// back to sampling recent blocks if the strategy is unknown or unsupported.
+func newEstimator(oracle *Oracle, config Config) Estimator {
+	switch config.Strategy {
+	case "", StrategyPercentile:
+	case StrategyPool:
+		if pool, ok := oracle.backend.(PoolBackend); ok {
+			return &poolEstimator{oracle: oracle, pool: pool}
+		}
+		log.Warn("Gasprice oracle backend has no transaction pool, using percentile strategy")
+	case StrategyFeeHistory:
+		return &feeHistoryEstimator{oracle: oracle}
+	case StrategyBaseFee:
+		tip := config.FixedTip
+		if tip == nil || tip.Sign() < 0 {
+			tip = DefaultFixedTip
+			log.Warn("Sanitizing invalid gasprice oracle fixed tip", "provided", config.FixedTip, "updated", tip)
+		}
+		return &baseFeeEstimator{tip: new(big.Int).Set(tip)}
+	default:
+		log.Warn("Unknown gasprice oracle strategy, using percentile", "strategy", config.Strategy)
+	}
+	return estimatorFunc(oracle.sampleTipCap)
+}
+
+// poolEstimator suggests the tip needed to outbid the pending demand in the
+// local transaction pool. Pending transactions are ordered by their effective
+// tip in the next block, and the suggestion is the tip of the one at which the
+// accumulated gas exceeds a target share of the block gas limit: half a block
+// for high urgency, one block for medium and two blocks for low. If the pool
+// demand is below the target, recent blocks are sampled instead.
+type poolEstimator struct {
+	oracle *Oracle
+	pool   PoolBackend
+}
+
+func (e *poolEstimator) SuggestTipCap(ctx context.Context, urgency Urgency) (*big.Int, error) {
+	head, err := e.oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	demands, err := e.oracle.poolDemand(e.pool, head)
+	if err != nil {
+		return nil, err
+	}
+	var target uint64
+	switch urgency {
+	case UrgencyLow:
+		target = 2 * head.GasLimit
+	case UrgencyHigh:
+		target = head.GasLimit / 2
+	default:
+		target = head.GasLimit
+	}
+	var gas uint64
+	for _, d := range demands {
+		if gas += d.gas; gas >= target {
+			return new(big.Int).Set(d.tip), nil
+		}
+	}
+	return e.oracle.sampleTipCap(ctx, urgency)
+}
+
+// tipDemand is the gas demand of a pooled transaction at its effective tip.
+type tipDemand struct {
+	tip *big.Int
+	gas uint64
+}
+
+// poolDemand returns the gas demand of the pooled transactions able to pay for
+// the block following head, ordered by effective tip, highest first.
+func (oracle *Oracle) poolDemand(pool PoolBackend, head *types.Header) ([]tipDemand, error) {
+	txs, err := pool.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	var baseFee *big.Int
+	if head.BaseFee != nil {
+		baseFee = misc.CalcBaseFee(oracle.backend.ChainConfig(), head)
+	}
+	demands := make([]tipDemand, 0, len(txs))
+	for _, tx := range txs {
+		tip, err := tx.EffectiveGasTip(baseFee)
+		if err != nil || tip.Cmp(oracle.ignorePrice) < 0 {
+			continue // can't pay the base fee or not worth considering
+		}
+		demands = append(demands, tipDemand{tip: tip, gas: tx.Gas()})
+	}
+	sort.SliceStable(demands, func(i, j int) bool {
+		return demands[i].tip.Cmp(demands[j].tip) > 0
+	})
+	return demands, nil
+}
+
+// feeHistoryEstimator suggests the median of the gas weighted reward percentile
+// of recent non-empty blocks, the percentile being shifted by the urgency. If
+// there are no such blocks, the last sampled suggestion is used.
+type feeHistoryEstimator struct {
+	oracle *Oracle
+}
+
+func (e *feeHistoryEstimator) SuggestTipCap(ctx context.Context, urgency Urgency) (*big.Int, error) {
+	percentile := float64(urgency.percentile(e.oracle.percentile))
+
+	_, rewards, _, ratios, err := e.oracle.FeeHistory(ctx, e.oracle.checkBlocks, rpc.LatestBlockNumber, []float64{percentile})
+	if err != nil {
+		return nil, err
+	}
+	var tips []*big.Int
+	for i, reward := range rewards {
+		if ratios[i] > 0 && len(reward) > 0 {
+			tips = append(tips, reward[0])
+		}
+	}
+	if len(tips) == 0 {
+		return e.oracle.sampleTipCap(ctx, urgency)
+	}
+	sort.Sort(bigIntArray(tips))
+	return new(big.Int).Set(tips[len(tips)/2]), nil
+}
+
+// baseFeeEstimator always suggests the same tip, making the suggested legacy gas
+// price the base fee plus a constant. It is meant for private chains where there
+// is no competition for block space.
+type baseFeeEstimator struct {
+	tip *big.Int
+}
+
+func (e *baseFeeEstimator) SuggestTipCap(ctx context.Context, urgency Urgency) (*big.Int, error) {
+	return new(big.Int).Set(e.tip), nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testPoolBackend is a test backend with access to a fake transaction pool.
+type testPoolBackend struct {
+	*testBackend
+	txs types.Transactions
+}
+
+func (b *testPoolBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.txs, nil
+}
+
+func TestUrgencyUnmarshal(t *testing.T) {
+	for _, want := range []Urgency{UrgencyLow, UrgencyMedium, UrgencyHigh} {
+		var have Urgency
+		if err := have.UnmarshalText([]byte(want.String())); err != nil {
+			t.Fatalf("failed to unmarshal %v: %v", want, err)
+		}
+		if have != want {
+			t.Errorf("urgency mismatch: have %v, want %v", have, want)
+		}
+	}
+	var u Urgency
+	if err := u.UnmarshalText([]byte("asap")); err == nil {
+		t.Errorf("unknown urgency accepted")
+	}
+}
+
+func TestEstimatorStrategies(t *testing.T) {
+	gwei := func(n int64) *big.Int { return big.NewInt(n * params.GWei) }
+
+	backend := newTestBackend(t, big.NewInt(0), false)
+	limit := backend.CurrentHeader().GasLimit
+
+	// Pending pool demand filling half a block at 8 gwei, a full one at 4 gwei
+	var txs types.Transactions
+	for _, tip := range []int64{10, 8, 6, 4} {
+		txs = append(txs, types.NewTx(&types.DynamicFeeTx{
+			To:        &common.Address{},
+			Gas:       limit / 4,
+			GasFeeCap: gwei(100),
+			GasTipCap: gwei(tip),
+		}))
+	}
+	tests := []struct {
+		config Config
+		low    *big.Int
+		medium *big.Int
+		high   *big.Int
+	}{
+		// Recent blocks pay 27-32 gwei tips
+		{Config{Blocks: 3, Percentile: 60}, gwei(28), gwei(30), gwei(31)},
+		// Pool demand doesn't fill two blocks, low urgency falls back to sampling
+		{Config{Blocks: 3, Percentile: 60, Strategy: StrategyPool}, gwei(28), gwei(4), gwei(8)},
+		// Each of the last three blocks has a single transaction, paying 30-32 gwei
+		{Config{Blocks: 3, Percentile: 60, MaxBlockHistory: 3, Strategy: StrategyFeeHistory}, gwei(31), gwei(31), gwei(31)},
+		// Fixed tip, capped by the maximum price
+		{Config{Blocks: 3, Percentile: 60, Strategy: StrategyBaseFee, FixedTip: gwei(3)}, gwei(3), gwei(3), gwei(3)},
+		{Config{Blocks: 3, Percentile: 60, Strategy: StrategyBaseFee, FixedTip: gwei(3), MaxPrice: gwei(2)}, gwei(2), gwei(2), gwei(2)},
+	}
+	for i, tt := range tests {
+		oracle := NewOracle(&testPoolBackend{testBackend: backend, txs: txs}, tt.config)
+		for urgency, want := range map[Urgency]*big.Int{UrgencyLow: tt.low, UrgencyMedium: tt.medium, UrgencyHigh: tt.high} {
+			have, err := oracle.SuggestTipCapWithUrgency(context.Background(), urgency)
+			if err != nil {
+				t.Fatalf("test %d, %v: failed to suggest tip: %v", i, urgency, err)
+			}
+			if have.Cmp(want) != 0 {
+				t.Errorf("test %d, %v: tip mismatch: have %v, want %v", i, urgency, have, want)
+			}
+		}
+	}
+}
+
+func TestEstimateFees(t *testing.T) {
+	gwei := func(n int64) *big.Int { return big.NewInt(n * params.GWei) }
+
+	backend := newTestBackend(t, big.NewInt(0), false)
+	head, _ := backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+
+	// Pending pool demand filling two blocks, paying more than recent blocks
+	var txs types.Transactions
+	for i := 0; i < 8; i++ {
+		txs = append(txs, types.NewTx(&types.DynamicFeeTx{
+			To:        &common.Address{},
+			Gas:       head.GasLimit / 4,
+			GasFeeCap: gwei(200),
+			GasTipCap: gwei(100),
+		}))
+	}
+	oracle := NewOracle(&testPoolBackend{testBackend: backend, txs: txs}, Config{Blocks: 3, Percentile: 60, MaxBlockHistory: 1024})
+
+	estimate, err := oracle.EstimateFees(context.Background())
+	if err != nil {
+		t.Fatalf("failed to estimate fees: %v", err)
+	}
+	if estimate.Number != testHead {
+		t.Errorf("head mismatch: have %d, want %d", estimate.Number, testHead)
+	}
+	baseFee := misc.CalcBaseFee(backend.ChainConfig(), head)
+	if estimate.BaseFee.Cmp(baseFee) != 0 {
+		t.Errorf("base fee mismatch: have %v, want %v", estimate.BaseFee, baseFee)
+	}
+	tips := []*big.Int{gwei(28), gwei(30), gwei(31)}
+	if len(estimate.Tiers) != len(tips) {
+		t.Fatalf("tier count mismatch: have %d, want %d", len(estimate.Tiers), len(tips))
+	}
+	for i, tier := range estimate.Tiers {
+		if tier.MaxPriorityFeePerGas.Cmp(tips[i]) != 0 {
+			t.Errorf("tier %d: tip mismatch: have %v, want %v", i, tier.MaxPriorityFeePerGas, tips[i])
+		}
+		maxFee := new(big.Int).Add(tips[i], new(big.Int).Mul(baseFee, big.NewInt(2)))
+		if tier.MaxFeePerGas.Cmp(maxFee) != 0 {
+			t.Errorf("tier %d: fee cap mismatch: have %v, want %v", i, tier.MaxFeePerGas, maxFee)
+		}
+		// Recent blocks are nowhere near full, but two blocks of pooled demand
+		// pay more than any tier
+		if tier.InclusionProbability != 1 {
+			t.Errorf("tier %d: inclusion probability mismatch: have %v, want %v", i, tier.InclusionProbability, 1)
+		}
+		if tier.ExpectedBlocks != 3 {
+			t.Errorf("tier %d: expected blocks mismatch: have %d, want %d", i, tier.ExpectedBlocks, 3)
+		}
+	}
+}
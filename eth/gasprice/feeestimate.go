@@ -0,0 +1,138 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// feeEstimateBlocks is the number of recent blocks whose inclusion data is
+	// used to estimate the inclusion chances of a tip.
+	feeEstimateBlocks = 20
+
+	// feeEstimatePercentile is the gas weighted reward percentile of a block
+	// taken as the lowest tip the block producer was willing to accept.
+	feeEstimatePercentile = 10
+
+	// feeEstimateFullRatio is the gas used ratio above which a block is deemed
+	// to have had no room left for transactions paying less than its lowest tip.
+	feeEstimateFullRatio = 0.9
+
+	// feeEstimateBaseFeeMultiplier is the factor of the next base fee included in
+	// the suggested fee caps, keeping transactions includable while the base fee
+	// rises for a few consecutive full blocks.
+	feeEstimateBaseFeeMultiplier = 2
+)
+
+// errNoFeeHistory is returned if there are no recent blocks to estimate fees from.
+var errNoFeeHistory = errors.New("no fee history available")
+
+// FeeTier is a fee suggestion for a given inclusion urgency.
+type FeeTier struct {
+	Urgency              Urgency
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+
+	// InclusionProbability is the share of recent blocks which would have
+	// included a transaction paying the suggested tip.
+	InclusionProbability float64
+
+	// ExpectedBlocks is the expected number of blocks until inclusion, taking
+	// into account the pooled transactions paying more. It is zero if none of
+	// the recent blocks would have included the transaction.
+	ExpectedBlocks uint64
+}
+
+// FeeEstimate is a set of fee suggestions, from the cheapest to the fastest.
+type FeeEstimate struct {
+	Number  uint64   // Number of the head block the estimate is based on
+	BaseFee *big.Int // Base fee of the next block, zero before London
+	Tiers   []FeeTier
+}
+
+// EstimateFees suggests fee caps for low, medium and high urgency transactions,
+// along with the expected number of blocks until their inclusion. The chance of
+// inclusion is measured on recent blocks: a block would have included a tip if
+// it was not full, or if the tip is above the lowest ones it included. Pooled
+// transactions paying more than a tip are queued ahead of it, delaying its
+// inclusion by the number of blocks they fill.
+func (oracle *Oracle) EstimateFees(ctx context.Context) (*FeeEstimate, error) {
+	oldest, rewards, baseFees, ratios, err := oracle.FeeHistory(ctx, feeEstimateBlocks, rpc.LatestBlockNumber, []float64{feeEstimatePercentile})
+	if err != nil {
+		return nil, err
+	}
+	if len(ratios) == 0 {
+		return nil, errNoFeeHistory
+	}
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	var demands []tipDemand
+	if pool, ok := oracle.backend.(PoolBackend); ok {
+		if demands, err = oracle.poolDemand(pool, head); err != nil {
+			return nil, err
+		}
+	}
+	estimate := &FeeEstimate{
+		Number:  oldest.Uint64() + uint64(len(ratios)) - 1,
+		BaseFee: new(big.Int).Set(baseFees[len(baseFees)-1]),
+	}
+	maxBaseFee := new(big.Int).Mul(estimate.BaseFee, big.NewInt(feeEstimateBaseFeeMultiplier))
+
+	for _, urgency := range []Urgency{UrgencyLow, UrgencyMedium, UrgencyHigh} {
+		tip, err := oracle.SuggestTipCapWithUrgency(ctx, urgency)
+		if err != nil {
+			return nil, err
+		}
+		// Measure the inclusion chances of the tip in recent blocks
+		var included int
+		for i, ratio := range ratios {
+			if ratio < feeEstimateFullRatio || len(rewards[i]) == 0 || tip.Cmp(rewards[i][0]) >= 0 {
+				included++
+			}
+		}
+		tier := FeeTier{
+			Urgency:              urgency,
+			MaxFeePerGas:         new(big.Int).Add(tip, maxBaseFee),
+			MaxPriorityFeePerGas: tip,
+			InclusionProbability: float64(included) / float64(len(ratios)),
+		}
+		// Queue the transaction behind the pooled ones paying more
+		if included > 0 {
+			var ahead uint64
+			for _, d := range demands {
+				if d.tip.Cmp(tip) <= 0 {
+					break
+				}
+				ahead += d.gas
+			}
+			tier.ExpectedBlocks = uint64(math.Ceil(1 / tier.InclusionProbability))
+			if head.GasLimit > 0 {
+				tier.ExpectedBlocks += ahead / head.GasLimit
+			}
+		}
+		estimate.Tiers = append(estimate.Tiers, tier)
+	}
+	return estimate, nil
+}
@@ -47,6 +47,8 @@ type Config struct {
 	Default          *big.Int `toml:",omitempty"`
 	MaxPrice         *big.Int `toml:",omitempty"`
 	IgnorePrice      *big.Int `toml:",omitempty"`
+	Strategy         string   `toml:",omitempty"` // Tip estimation strategy (percentile, pool, feehistory, basefee)
+	FixedTip         *big.Int `toml:",omitempty"` // Tip suggested by the basefee strategy
 }
 
 // OracleBackend includes all necessary background APIs for oracle.
@@ -63,8 +65,10 @@ type OracleBackend interface {
 // blocks. Suitable for both light and full clients.
 type Oracle struct {
 	backend     OracleBackend
+	estimator   Estimator
 	lastHead    common.Hash
 	lastPrice   *big.Int
+	lastSamples []*big.Int
 	maxPrice    *big.Int
 	ignorePrice *big.Int
 	cacheLock   sync.RWMutex
@@ -127,7 +131,7 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		}
 	}()
 
-	return &Oracle{
+	oracle := &Oracle{
 		backend:          backend,
 		lastPrice:        params.Default,
 		maxPrice:         maxPrice,
@@ -138,6 +142,8 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		maxBlockHistory:  maxBlockHistory,
 		historyCache:     cache,
 	}
+	oracle.estimator = newEstimator(oracle, params)
+	return oracle
 }
 
 // SuggestTipCap returns a tip cap so that newly created transaction can have a
@@ -147,25 +153,46 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 // necessary to add the basefee to the returned number to fall back to the legacy
 // behavior.
 func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	return oracle.SuggestTipCapWithUrgency(ctx, UrgencyMedium)
+}
+
+// SuggestTipCapWithUrgency returns a tip cap for a newly created transaction to
+// be included as quickly as the given urgency demands, using the configured
+// estimation strategy.
+func (oracle *Oracle) SuggestTipCapWithUrgency(ctx context.Context, urgency Urgency) (*big.Int, error) {
+	tip, err := oracle.estimator.SuggestTipCap(ctx, urgency)
+	if err != nil {
+		return tip, err
+	}
+	if tip.Cmp(oracle.maxPrice) > 0 {
+		tip = new(big.Int).Set(oracle.maxPrice)
+	}
+	return tip, nil
+}
+
+// sampleTipCap implements the percentile strategy, suggesting the configured
+// percentile of the lowest tips paid in recent blocks. Lower and higher urgency
+// levels shift the percentile towards the cheapest and the priciest samples.
+func (oracle *Oracle) sampleTipCap(ctx context.Context, urgency Urgency) (*big.Int, error) {
 	head, _ := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	headHash := head.Hash()
 
 	// If the latest gasprice is still available, return it.
 	oracle.cacheLock.RLock()
-	lastHead, lastPrice := oracle.lastHead, oracle.lastPrice
+	lastHead, lastPrice, lastSamples := oracle.lastHead, oracle.lastPrice, oracle.lastSamples
 	oracle.cacheLock.RUnlock()
 	if headHash == lastHead {
-		return new(big.Int).Set(lastPrice), nil
+		return oracle.pickSample(lastSamples, lastPrice, urgency), nil
 	}
 	oracle.fetchLock.Lock()
 	defer oracle.fetchLock.Unlock()
 
 	// Try checking the cache again, maybe the last fetch fetched what we need
 	oracle.cacheLock.RLock()
-	lastHead, lastPrice = oracle.lastHead, oracle.lastPrice
+	lastHead, lastPrice, lastSamples = oracle.lastHead, oracle.lastPrice, oracle.lastSamples
 	oracle.cacheLock.RUnlock()
 	if headHash == lastHead {
-		return new(big.Int).Set(lastPrice), nil
+		return oracle.pickSample(lastSamples, lastPrice, urgency), nil
 	}
 	var (
 		sent, exp int
@@ -216,9 +243,23 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 	oracle.cacheLock.Lock()
 	oracle.lastHead = headHash
 	oracle.lastPrice = price
+	oracle.lastSamples = results
 	oracle.cacheLock.Unlock()
 
-	return new(big.Int).Set(price), nil
+	return oracle.pickSample(results, price, urgency), nil
+}
+
+// pickSample selects the tip matching the urgency from the sorted samples of
+// recent blocks, where price is the one for the default urgency.
+func (oracle *Oracle) pickSample(samples []*big.Int, price *big.Int, urgency Urgency) *big.Int {
+	if urgency == UrgencyMedium || len(samples) == 0 {
+		return new(big.Int).Set(price)
+	}
+	sample := samples[(len(samples)-1)*urgency.percentile(oracle.percentile)/100]
+	if sample.Cmp(oracle.maxPrice) > 0 {
+		sample = oracle.maxPrice
+	}
+	return new(big.Int).Set(sample)
 }
 
 type results struct {
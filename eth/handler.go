@@ -39,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -65,6 +66,14 @@ type txPool interface {
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// IsPrivate returns whether the transaction with the given hash was
+	// submitted privately and must not be propagated to the network.
+	IsPrivate(hash common.Hash) bool
+
+	// IsConditional returns whether the transaction with the given hash was
+	// submitted with inclusion preconditions and must not be propagated.
+	IsConditional(hash common.Hash) bool
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending(enforceTips bool) map[common.Address]types.Transactions
@@ -87,6 +96,8 @@ type handlerConfig struct {
 	EventMux       *event.TypeMux            // Legacy event mux, deprecate for `feed`
 	Checkpoint     *params.TrustedCheckpoint // Hard coded checkpoint for sync challenges
 	RequiredBlocks map[uint64]common.Hash    // Hard coded map of required block hashes for sync challenges
+	TxPeerRate     float64                   // Transactions replenished per second per peer (0 = unlimited)
+	TxPeerBurst    int                       // Maximum number of transactions a peer may deliver at once
 }
 
 type handler struct {
@@ -117,6 +128,9 @@ type handler struct {
 
 	requiredBlocks map[uint64]common.Hash
 
+	txPeerRate  float64 // Transactions replenished per second per peer (0 = unlimited)
+	txPeerBurst int     // Maximum number of transactions a peer may deliver at once
+
 	// channels for fetcher, syncer, txsyncLoop
 	quitSync chan struct{}
 
@@ -141,6 +155,8 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		peers:          newPeerSet(),
 		merger:         config.Merger,
 		requiredBlocks: config.RequiredBlocks,
+		txPeerRate:     config.TxPeerRate,
+		txPeerBurst:    config.TxPeerBurst,
 		quitSync:       make(chan struct{}),
 	}
 	if config.Sync == downloader.FullSync {
@@ -366,6 +382,9 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 	if p == nil {
 		return errors.New("peer dropped during handling")
 	}
+	if h.txPeerRate > 0 {
+		p.txLimiter = rate.NewLimiter(rate.Limit(h.txPeerRate), h.txPeerBurst)
+	}
 	// Register the peer in the downloader. If the downloader considers it banned, we disconnect
 	if err := h.downloader.RegisterPeer(peer.ID(), peer.Version(), peer); err != nil {
 		peer.Log().Error("Failed to register peer in eth syncer", "err", err)
@@ -628,9 +647,17 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	)
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
+		// Never leak private or conditional transactions to the network
+		if h.txpool.IsPrivate(tx.Hash()) || h.txpool.IsConditional(tx.Hash()) {
+			continue
+		}
 		peers := h.peers.peersWithoutTransaction(tx.Hash())
-		// Send the tx unconditionally to a subset of our peers
+		// Send the tx unconditionally to a subset of our peers. Blob transactions
+		// are too heavy to be pushed, they are only ever announced.
 		numDirect := int(math.Sqrt(float64(len(peers))))
+		if tx.Type() == types.BlobTxType {
+			numDirect = 0
+		}
 		for _, peer := range peers[:numDirect] {
 			txset[peer] = append(txset[peer], tx.Hash())
 		}
@@ -26,15 +26,33 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
+// txPeerLimitedMeter counts the inbound transactions dropped because the origin
+// peer exceeded its transaction rate limit.
+var txPeerLimitedMeter = metrics.NewRegisteredMeter("eth/txs/limited/peer", nil)
+
 // ethHandler implements the eth.Backend interface to handle the various network
 // packets that are sent as replies or broadcasts.
 type ethHandler handler
 
 func (h *ethHandler) Chain() *core.BlockChain { return h.chain }
-func (h *ethHandler) TxPool() eth.TxPool      { return h.txpool }
+func (h *ethHandler) TxPool() eth.TxPool      { return (*publicTxPool)(h) }
+
+// publicTxPool is a view of the transaction pool hiding private and conditional
+// transactions, used to serve the transaction retrievals of remote peers.
+type publicTxPool handler
+
+// Get retrieves the transaction from the local txpool with the given hash,
+// unless it was submitted privately or with inclusion preconditions.
+func (p *publicTxPool) Get(hash common.Hash) *types.Transaction {
+	if p.txpool.IsPrivate(hash) || p.txpool.IsConditional(hash) {
+		return nil
+	}
+	return p.txpool.Get(hash)
+}
 
 // RunPeer is invoked when a peer joins on the `eth` protocol.
 func (h *ethHandler) RunPeer(peer *eth.Peer, hand eth.Handler) error {
@@ -71,16 +89,34 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.txFetcher.Notify(peer.ID(), *packet)
 
 	case *eth.TransactionsPacket:
-		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
+		return h.txFetcher.Enqueue(peer.ID(), h.throttleTransactions(peer, *packet), false)
 
 	case *eth.PooledTransactionsPacket:
-		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
+		return h.txFetcher.Enqueue(peer.ID(), h.throttleTransactions(peer, *packet), true)
 
 	default:
 		return fmt.Errorf("unexpected eth packet type: %T", packet)
 	}
 }
 
+// throttleTransactions consumes the transaction allowance of the origin peer,
+// dropping every transaction delivered beyond it.
+func (h *ethHandler) throttleTransactions(peer *eth.Peer, txs []*types.Transaction) []*types.Transaction {
+	p := h.peers.peer(peer.ID())
+	if p == nil || p.txLimiter == nil {
+		return txs
+	}
+	now := time.Now()
+	for i := range txs {
+		if !p.txLimiter.AllowN(now, 1) {
+			peer.Log().Debug("Dropping rate limited transactions", "count", len(txs)-i)
+			txPeerLimitedMeter.Mark(int64(len(txs) - i))
+			return txs[:i]
+		}
+	}
+	return txs
+}
+
 // handleBlockAnnounces is invoked from a peer's message handler when it transmits a
 // batch of block announcements for the local node to process.
 func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash, numbers []uint64) error {
@@ -91,6 +91,18 @@ func (p *testTxPool) AddRemotes(txs []*types.Transaction) []error {
 	return make([]error, len(txs))
 }
 
+// IsPrivate returns whether a transaction was submitted privately, which is
+// never the case for the mock pool.
+func (p *testTxPool) IsPrivate(hash common.Hash) bool {
+	return false
+}
+
+// IsConditional returns whether a transaction was submitted with preconditions,
+// which is never the case for the mock pool.
+func (p *testTxPool) IsConditional(hash common.Hash) bool {
+	return false
+}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending(enforceTips bool) map[common.Address]types.Transactions {
 	p.lock.RLock()
@@ -21,6 +21,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"golang.org/x/time/rate"
 )
 
 // ethPeerInfo represents a short summary of the `eth` sub-protocol metadata known
@@ -35,6 +36,8 @@ type ethPeerInfo struct {
 type ethPeer struct {
 	*eth.Peer
 	snapExt *snapPeer // Satellite `snap` connection
+
+	txLimiter *rate.Limiter // Token bucket throttling inbound transactions (nil = unlimited)
 }
 
 // info gathers and returns some `eth` protocol metadata known about a peer.
@@ -225,10 +225,18 @@ func ServiceGetBlockBodiesQuery(chain *core.BlockChain, query GetBlockBodiesPack
 			lookups >= 2*maxBodiesServe {
 			break
 		}
-		if data := chain.GetBodyRLP(hash); len(data) != 0 {
-			bodies = append(bodies, data)
-			bytes += len(data)
+		data := chain.GetBodyRLP(hash)
+		if len(data) == 0 {
+			// Stop at the pruned history, the response is cut short as
+			// the remaining bodies are most probably pruned too.
+			if chain.IsHistoryPruned(hash) {
+				log.Trace("Stopped serving block bodies", "hash", hash, "err", core.ErrHistoryPruned)
+				break
+			}
+			continue
 		}
+		bodies = append(bodies, data)
+		bytes += len(data)
 	}
 	return bodies
 }
@@ -296,7 +304,12 @@ func ServiceGetReceiptsQuery(chain *core.BlockChain, query GetReceiptsPacket) []
 		// Retrieve the requested block's receipts
 		results := chain.GetReceiptsByHash(hash)
 		if results == nil {
-			if header := chain.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
+			header := chain.GetHeaderByHash(hash)
+			if header != nil && header.ReceiptHash != types.EmptyRootHash && chain.IsHistoryPruned(hash) {
+				log.Trace("Stopped serving receipts", "hash", hash, "err", core.ErrHistoryPruned)
+				break
+			}
+			if header == nil || header.ReceiptHash != types.EmptyRootHash {
 				continue
 			}
 		}
@@ -379,15 +392,19 @@ func handleBlockBodies66(backend Backend, msg Decoder, peer *Peer) error {
 	}
 	metadata := func() interface{} {
 		var (
-			txsHashes   = make([]common.Hash, len(res.BlockBodiesPacket))
-			uncleHashes = make([]common.Hash, len(res.BlockBodiesPacket))
+			txsHashes        = make([]common.Hash, len(res.BlockBodiesPacket))
+			uncleHashes      = make([]common.Hash, len(res.BlockBodiesPacket))
+			withdrawalHashes = make([]common.Hash, len(res.BlockBodiesPacket))
 		)
 		hasher := trie.NewStackTrie(nil)
 		for i, body := range res.BlockBodiesPacket {
 			txsHashes[i] = types.DeriveSha(types.Transactions(body.Transactions), hasher)
 			uncleHashes[i] = types.CalcUncleHash(body.Uncles)
+			if body.Withdrawals != nil {
+				withdrawalHashes[i] = types.DeriveSha(types.Withdrawals(body.Withdrawals), hasher)
+			}
 		}
-		return [][]common.Hash{txsHashes, uncleHashes}
+		return [][]common.Hash{txsHashes, uncleHashes, withdrawalHashes}
 	}
 	return peer.dispatchResponse(&Response{
 		id:   res.RequestId,
@@ -184,6 +184,21 @@ type TraceCallConfig struct {
 	BlockOverrides *ethapi.BlockOverrides
 }
 
+// Bundle is a list of calls traced one after the other by traceCallMany,
+// optionally in a block context differing from the one of the state.
+type Bundle struct {
+	Transactions  []ethapi.TransactionArgs `json:"transactions"`
+	BlockOverride *ethapi.BlockOverrides   `json:"blockOverride"`
+}
+
+// StateContext selects the state traceCallMany starts from: the one of the
+// given block right before the transaction at the given index. An index of
+// -1 selects the state after the whole block.
+type StateContext struct {
+	BlockNumber      rpc.BlockNumberOrHash `json:"blockNumber"`
+	TransactionIndex int                   `json:"transactionIndex"`
+}
+
 // StdTraceConfig holds extra parameters to standard-json trace functions.
 type StdTraceConfig struct {
 	logger.Config
@@ -312,9 +327,10 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 				for i, tx := range task.block.Transactions() {
 					msg, _ := tx.AsMessage(signer, task.block.BaseFee())
 					txctx := &Context{
-						BlockHash: task.block.Hash(),
-						TxIndex:   i,
-						TxHash:    tx.Hash(),
+						BlockHash:   task.block.Hash(),
+						BlockNumber: task.block.Number(),
+						TxIndex:     i,
+						TxHash:      tx.Hash(),
 					}
 					res, err := api.traceTx(ctx, msg, txctx, blockCtx, task.statedb, config)
 					if err != nil {
@@ -598,6 +614,10 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
+	// Serve the traces from disk if the block was indexed with the same tracer
+	if results := api.indexedTraces(block, config); results != nil {
+		return results, nil
+	}
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
 		return nil, err
@@ -635,9 +655,10 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 			for task := range jobs {
 				msg, _ := txs[task.index].AsMessage(signer, block.BaseFee())
 				txctx := &Context{
-					BlockHash: blockHash,
-					TxIndex:   task.index,
-					TxHash:    txs[task.index].Hash(),
+					BlockHash:   blockHash,
+					BlockNumber: block.Number(),
+					TxIndex:     task.index,
+					TxHash:      txs[task.index].Hash(),
 				}
 				res, err := api.traceTx(ctx, msg, txctx, blockCtx, task.statedb, config)
 				if err != nil {
@@ -834,25 +855,97 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 	defer release()
 
 	txctx := &Context{
-		BlockHash: blockHash,
-		TxIndex:   int(index),
-		TxHash:    hash,
+		BlockHash:   blockHash,
+		BlockNumber: block.Number(),
+		TxIndex:     int(index),
+		TxHash:      hash,
 	}
 	return api.traceTx(ctx, msg, txctx, vmctx, statedb, config)
 }
 
-// TraceCall lets you trace a given eth_call. It collects the structured logs
-// created during the execution of EVM if the given transaction was added on
-// top of the provided block and returns them as a JSON object.
-func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
-	// Try to retrieve the specified block
+// TraceTransactionStream traces a transaction with the struct logger like
+// TraceTransaction, but delivers the struct logs as subscription notifications
+// while they are captured, never holding the entire trace in memory. The last
+// notification is the execution result with an empty struct log list, or an
+// object holding the error if the tracing failed.
+func (api *API) TraceTransactionStream(ctx context.Context, hash common.Hash, config *TraceConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if config == nil {
+		config = &TraceConfig{}
+	}
+	if config.Tracer != nil {
+		return nil, errors.New("only the struct logger can be streamed")
+	}
+	timeout := defaultTraceTimeout
+	if config.Timeout != nil {
+		var err error
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	_, blockHash, blockNumber, index, err := api.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if blockNumber == 0 {
+		return nil, errors.New("genesis is not traceable")
+	}
+	reexec := defaultTraceReexec
+	if config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	block, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(blockNumber), blockHash)
+	if err != nil {
+		return nil, err
+	}
+	msg, vmctx, statedb, release, err := api.backend.StateAtTransaction(ctx, block, int(index), reexec)
+	if err != nil {
+		return nil, err
+	}
 	var (
-		err   error
-		block *types.Block
+		sub   = notifier.CreateSubscription()
+		txctx = &Context{
+			BlockHash:   blockHash,
+			BlockNumber: block.Number(),
+			TxIndex:     int(index),
+			TxHash:      hash,
+		}
 	)
+	go func() {
+		defer release()
+
+		// Deliver the logs as they are captured, aborting if the subscriber
+		// is gone
+		tracer := logger.NewStreamingStructLogger(config.Config, func(log json.RawMessage) error {
+			select {
+			case err := <-sub.Err():
+				if err == nil {
+					err = errors.New("unsubscribed")
+				}
+				return err
+			default:
+			}
+			return notifier.Notify(sub.ID, log)
+		})
+		res, err := api.runTracer(context.Background(), tracer, timeout, msg, core.NewEVMTxContext(msg), txctx, vmctx, statedb)
+		if err != nil {
+			notifier.Notify(sub.ID, map[string]string{"error": err.Error()})
+			return
+		}
+		notifier.Notify(sub.ID, res)
+	}()
+	return sub, nil
+}
+
+// callBlock retrieves the block on top of which calls are traced.
+func (api *API) callBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
 	if hash, ok := blockNrOrHash.Hash(); ok {
-		block, err = api.blockByHash(ctx, hash)
-	} else if number, ok := blockNrOrHash.Number(); ok {
+		return api.blockByHash(ctx, hash)
+	}
+	if number, ok := blockNrOrHash.Number(); ok {
 		if number == rpc.PendingBlockNumber {
 			// We don't have access to the miner here. For tracing 'future' transactions,
 			// it can be done with block- and state-overrides instead, which offers
@@ -861,10 +954,17 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			// of what the next actual block is likely to contain.
 			return nil, errors.New("tracing on top of pending is not supported")
 		}
-		block, err = api.blockByNumber(ctx, number)
-	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return api.blockByNumber(ctx, number)
 	}
+	return nil, errors.New("invalid arguments; neither block nor hash specified")
+}
+
+// TraceCall lets you trace a given eth_call. It collects the structured logs
+// created during the execution of EVM if the given transaction was added on
+// top of the provided block and returns them as a JSON object.
+func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	// Try to retrieve the specified block
+	block, err := api.callBlock(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -905,6 +1005,82 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
 }
 
+// TraceCallMany traces bundles of calls, executed one after the other on top of
+// the same state. The state is the one at the given block and transaction
+// index, with the state overrides of the config applied once. Each bundle may
+// override the block context its calls are executed in. The result holds the
+// trace of every call, grouped by bundle.
+func (api *API) TraceCallMany(ctx context.Context, bundles []Bundle, stateContext StateContext, config *TraceCallConfig) ([][]interface{}, error) {
+	if len(bundles) == 0 {
+		return nil, errors.New("empty bundle list")
+	}
+	block, err := api.callBlock(ctx, stateContext.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	// Recompute the state right before the requested transaction, or after the
+	// whole block if none was selected
+	var (
+		statedb *state.StateDB
+		release StateReleaseFunc
+		txIndex = stateContext.TransactionIndex
+	)
+	if txIndex < 0 || txIndex >= len(block.Transactions()) {
+		statedb, release, err = api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
+		txIndex = len(block.Transactions())
+	} else {
+		_, _, statedb, release, err = api.backend.StateAtTransaction(ctx, block, txIndex, reexec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		traceConfig = &TraceConfig{
+			Config:       config.Config,
+			Tracer:       config.Tracer,
+			Timeout:      config.Timeout,
+			Reexec:       config.Reexec,
+			TracerConfig: config.TracerConfig,
+		}
+	}
+	var (
+		is158   = api.backend.ChainConfig().IsEIP158(block.Number())
+		results = make([][]interface{}, len(bundles))
+	)
+	for i, bundle := range bundles {
+		vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+		bundle.BlockOverride.Apply(&vmctx)
+
+		results[i] = make([]interface{}, len(bundle.Transactions))
+		for j, args := range bundle.Transactions {
+			msg, err := args.ToMessage(api.backend.RPCGasCap(), vmctx.BaseFee)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			txctx := &Context{BlockHash: block.Hash(), BlockNumber: block.Number(), TxIndex: txIndex}
+			res, err := api.traceTx(ctx, msg, txctx, vmctx, statedb, traceConfig)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			// Finalize the call so the next one sees its state changes
+			statedb.Finalise(is158)
+			results[i][j] = res
+			txIndex++
+		}
+	}
+	return results, nil
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
@@ -932,6 +1108,16 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 			return nil, err
 		}
 	}
+	res, err := api.runTracer(ctx, tracer, timeout, message, txContext, txctx, vmctx, statedb)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// runTracer executes the message with the given tracer attached, aborting the
+// execution if it doesn't finish within the timeout.
+func (api *API) runTracer(ctx context.Context, tracer Tracer, timeout time.Duration, message core.Message, txContext vm.TxContext, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB) (json.RawMessage, error) {
 	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 	go func() {
 		<-deadlineCtx.Done()
@@ -945,7 +1131,7 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 	vmenv := vm.NewEVM(vmctx, txContext, statedb, api.backend.ChainConfig(), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
 	// Call Prepare to clear out the statedb access list
 	statedb.Prepare(txctx.TxHash, txctx.TxIndex)
-	if _, err = core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas())); err != nil {
+	if _, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas())); err != nil {
 		return nil, fmt.Errorf("tracing failed: %w", err)
 	}
 	return tracer.GetResult()
@@ -959,5 +1145,9 @@ func APIs(backend Backend) []rpc.API {
 			Namespace: "debug",
 			Service:   NewAPI(backend),
 		},
+		{
+			Namespace: "trace",
+			Service:   NewTraceAPI(backend),
+		},
 	}
 }
@@ -42,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -153,6 +154,14 @@ func (b *testBackend) StateAtBlock(ctx context.Context, block *types.Block, reex
 	return statedb, release, nil
 }
 
+func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return b.chain.SubscribeChainEvent(ch)
+}
+
+func (b *testBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
+	return b.chain.SubscribeChainSideEvent(ch)
+}
+
 func (b *testBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, StateReleaseFunc, error) {
 	parent := b.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
 	if parent == nil {
@@ -316,6 +325,112 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[2].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	genBlocks := 10
+	signer := types.HomesteadSigner{}
+	api := NewAPI(newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {
+		// Transfer from account[0] to account[1]
+		//    value: 1000 wei
+		//    fee:   0 wei
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+	}))
+	// balanceOf returns a call pushing the balance of an account and the block
+	// number onto the stack
+	balanceOf := func(addr common.Address) ethapi.TransactionArgs {
+		input := append(append([]byte{0x73}, addr.Bytes()...), 0x31, 0x43, 0x00) // PUSH20 addr BALANCE NUMBER STOP
+		return ethapi.TransactionArgs{From: &accounts[0].addr, Input: (*hexutil.Bytes)(&input)}
+	}
+	// stackOf returns the stack at the end of a traced call
+	stackOf := func(result interface{}) []string {
+		var res *logger.ExecutionResult
+		if err := json.Unmarshal(result.(json.RawMessage), &res); err != nil {
+			t.Fatalf("failed to unmarshal result: %v", err)
+		}
+		if len(res.StructLogs) == 0 || res.StructLogs[len(res.StructLogs)-1].Stack == nil {
+			t.Fatalf("missing stack: %v", res)
+		}
+		return *res.StructLogs[len(res.StructLogs)-1].Stack
+	}
+	head := rpc.BlockNumber(genBlocks)
+	number := (*hexutil.Big)(big.NewInt(0x1337))
+
+	var testSuite = []struct {
+		txIndex int
+		bundles []Bundle
+		expect  [][][]string
+	}{
+		// State after the head block, with a transfer carried over to a later
+		// bundle executing in an overridden block context
+		{
+			txIndex: -1,
+			bundles: []Bundle{
+				{
+					Transactions: []ethapi.TransactionArgs{
+						balanceOf(accounts[2].addr),
+						{From: &accounts[0].addr, To: &accounts[2].addr, Value: (*hexutil.Big)(big.NewInt(1000))},
+					},
+				},
+				{
+					Transactions:  []ethapi.TransactionArgs{balanceOf(accounts[2].addr)},
+					BlockOverride: &ethapi.BlockOverrides{Number: number},
+				},
+			},
+			expect: [][][]string{
+				{{"0xde0b6b3a7640000", "0xa"}, nil},
+				{{"0xde0b6b3a76403e8", "0x1337"}},
+			},
+		},
+		// State before the only transaction of the head block
+		{
+			txIndex: 0,
+			bundles: []Bundle{
+				{Transactions: []ethapi.TransactionArgs{balanceOf(accounts[1].addr)}},
+			},
+			expect: [][][]string{
+				{{hexutil.EncodeBig(big.NewInt(params.Ether + 1000*int64(genBlocks-1))), "0xa"}},
+			},
+		},
+	}
+	for i, testspec := range testSuite {
+		results, err := api.TraceCallMany(context.Background(), testspec.bundles, StateContext{
+			BlockNumber:      rpc.BlockNumberOrHash{BlockNumber: &head},
+			TransactionIndex: testspec.txIndex,
+		}, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to trace: %v", i, err)
+		}
+		if len(results) != len(testspec.expect) {
+			t.Fatalf("test %d: bundle count mismatch: have %d, want %d", i, len(results), len(testspec.expect))
+		}
+		for j, bundle := range testspec.expect {
+			if len(results[j]) != len(bundle) {
+				t.Fatalf("test %d, bundle %d: trace count mismatch: have %d, want %d", i, j, len(results[j]), len(bundle))
+			}
+			for k, want := range bundle {
+				if want == nil {
+					continue
+				}
+				if have := stackOf(results[j][k]); !reflect.DeepEqual(have, want) {
+					t.Errorf("test %d, bundle %d, call %d: stack mismatch: have %v, want %v", i, j, k, have, want)
+				}
+			}
+		}
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -356,6 +471,73 @@ func TestTraceTransaction(t *testing.T) {
 	}
 }
 
+// Tests that the struct logs of a transaction are streamed as subscription
+// notifications, followed by the execution result.
+func TestTraceTransactionStream(t *testing.T) {
+	t.Parallel()
+
+	// Initialize a contract executing a few simple steps
+	accounts := newAccounts(1)
+	contract := common.HexToAddress("0xc0ffee")
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			contract:         {Balance: common.Big0, Code: common.FromHex("60016002015000")}, // PUSH1 1, PUSH1 2, ADD, POP, STOP
+		},
+	}
+	target := common.Hash{}
+	signer := types.HomesteadSigner{}
+	api := NewAPI(newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), contract, big.NewInt(0), 100000, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+		target = tx.Hash()
+	}))
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", api); err != nil {
+		t.Fatalf("failed to register api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	notifications := make(chan json.RawMessage, 16)
+	sub, err := client.Subscribe(context.Background(), "debug", notifications, "traceTransactionStream", target, &TraceConfig{Config: &logger.Config{Compact: true}})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	var ops []string
+	for {
+		var log struct {
+			Op          string
+			StructLogs  []json.RawMessage
+			ReturnValue *string
+		}
+		select {
+		case blob := <-notifications:
+			if err := json.Unmarshal(blob, &log); err != nil {
+				t.Fatalf("failed to decode notification %s: %v", blob, err)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for notification")
+		}
+		if log.ReturnValue != nil {
+			if len(log.StructLogs) != 0 {
+				t.Fatalf("result holds %d struct logs", len(log.StructLogs))
+			}
+			break
+		}
+		ops = append(ops, log.Op)
+	}
+	if want := []string{"PUSH1", "PUSH1", "ADD", "POP", "STOP"}; !reflect.DeepEqual(ops, want) {
+		t.Fatalf("streamed ops mismatch: have %v, want %v", ops, want)
+	}
+}
+
 func TestTraceBlock(t *testing.T) {
 	t.Parallel()
 
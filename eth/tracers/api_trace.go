@@ -0,0 +1,301 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// flatCallTracer is the native tracer producing the Parity-style traces.
+	flatCallTracer = "flatCallTracer"
+
+	// maxTraceFilterRange is the maximum number of blocks trace_filter scans.
+	maxTraceFilterRange = 1000
+)
+
+var errTraceFilterRange = fmt.Errorf("block range too large, at most %d blocks allowed", maxTraceFilterRange)
+
+// TraceAPI is the collection of Parity-style tracing APIs exposed over the trace
+// namespace. Call frames are reported as flat lists, built on top of the block
+// tracing machinery of the debug namespace.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the Parity-style tracing methods
+// of the Ethereum service.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// traceConfig returns the tracing config producing Parity-style traces.
+func (api *TraceAPI) traceConfig() *TraceConfig {
+	tracer := flatCallTracer
+	return &TraceConfig{
+		Tracer:       &tracer,
+		TracerConfig: json.RawMessage(`{"convertParityErrors":true}`),
+	}
+}
+
+// rewardAction is the input of a reward trace.
+type rewardAction struct {
+	Author     common.Address `json:"author"`
+	RewardType string         `json:"rewardType"`
+	Value      *hexutil.Big   `json:"value"`
+}
+
+// rewardTrace is a Parity-style trace of a mining reward.
+type rewardTrace struct {
+	Action              rewardAction `json:"action"`
+	BlockHash           common.Hash  `json:"blockHash"`
+	BlockNumber         uint64       `json:"blockNumber"`
+	Result              *struct{}    `json:"result"`
+	Subtraces           int          `json:"subtraces"`
+	TraceAddress        []int        `json:"traceAddress"`
+	TransactionHash     *common.Hash `json:"transactionHash"`
+	TransactionPosition *uint64      `json:"transactionPosition"`
+	Type                string       `json:"type"`
+}
+
+// flatTraceAddresses is the subset of a Parity-style trace used for filtering.
+type flatTraceAddresses struct {
+	Type   string `json:"type"`
+	Action struct {
+		From          *common.Address `json:"from"`
+		To            *common.Address `json:"to"`
+		Address       *common.Address `json:"address"`
+		RefundAddress *common.Address `json:"refundAddress"`
+		Author        *common.Address `json:"author"`
+	} `json:"action"`
+	Result *struct {
+		Address *common.Address `json:"address"`
+	} `json:"result"`
+}
+
+// addresses returns the sender and recipient of the traced action, if any.
+func (t *flatTraceAddresses) addresses() (from *common.Address, to *common.Address) {
+	switch t.Type {
+	case "create":
+		if t.Result != nil {
+			to = t.Result.Address
+		}
+		return t.Action.From, to
+	case "suicide":
+		return t.Action.Address, t.Action.RefundAddress
+	case "reward":
+		return nil, t.Action.Author
+	default:
+		return t.Action.From, t.Action.To
+	}
+}
+
+// Block returns the Parity-style traces of all the transactions in the given
+// block, followed by the reward traces of proof-of-work blocks.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.traceBlock(ctx, block)
+}
+
+// Transaction returns the Parity-style traces of the given transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]json.RawMessage, error) {
+	res, err := api.api.TraceTransaction(ctx, hash, api.traceConfig())
+	if err != nil {
+		return nil, err
+	}
+	var traces []json.RawMessage
+	if err := json.Unmarshal(res.(json.RawMessage), &traces); err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// TraceFilterArgs selects the traces returned by trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// Filter returns the Parity-style traces of a block range whose sender is in
+// the from addresses and whose recipient is in the to addresses. An empty
+// address list matches any address. Matching traces can be paginated by
+// skipping the first ones and limiting their count.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	// Resolve the block range to scan, defaulting to the head block
+	resolve := func(number *rpc.BlockNumber) (uint64, error) {
+		n := rpc.LatestBlockNumber
+		if number != nil {
+			n = *number
+		}
+		header, err := api.api.backend.HeaderByNumber(ctx, n)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, fmt.Errorf("block #%d not found", n)
+		}
+		return header.Number.Uint64(), nil
+	}
+	from, err := resolve(args.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolve(args.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("invalid block range")
+	}
+	if to-from >= maxTraceFilterRange {
+		return nil, errTraceFilterRange
+	}
+	// Trace the blocks one after the other, collecting the matching traces
+	var (
+		skip   uint64
+		traces = []json.RawMessage{}
+	)
+	if args.After != nil {
+		skip = *args.After
+	}
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if number == 0 {
+			continue // genesis is not traceable
+		}
+		block, err := api.api.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		results, err := api.traceBlock(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range results {
+			var fields flatTraceAddresses
+			if err := json.Unmarshal(trace, &fields); err != nil {
+				return nil, err
+			}
+			sender, recipient := fields.addresses()
+			if !containsAddress(args.FromAddress, sender) || !containsAddress(args.ToAddress, recipient) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			traces = append(traces, trace)
+			if args.Count != nil && uint64(len(traces)) >= *args.Count {
+				return traces, nil
+			}
+		}
+	}
+	return traces, nil
+}
+
+// traceBlock returns the Parity-style traces of all the transactions in the
+// given block, followed by the reward traces of proof-of-work blocks.
+func (api *TraceAPI) traceBlock(ctx context.Context, block *types.Block) ([]json.RawMessage, error) {
+	results, err := api.api.traceBlock(ctx, block, api.traceConfig())
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{}
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("tracing transaction %d failed: %s", i, res.Error)
+		}
+		var frames []json.RawMessage
+		if err := json.Unmarshal(res.Result.(json.RawMessage), &frames); err != nil {
+			return nil, err
+		}
+		traces = append(traces, frames...)
+	}
+	rewards, err := api.rewardTraces(block)
+	if err != nil {
+		return nil, err
+	}
+	return append(traces, rewards...), nil
+}
+
+// rewardTraces returns the traces of the block and uncle rewards credited by
+// the given block, if it was mined with proof-of-work.
+func (api *TraceAPI) rewardTraces(block *types.Block) ([]json.RawMessage, error) {
+	config := api.api.backend.ChainConfig()
+	if config.Ethash == nil || block.Difficulty().Sign() == 0 {
+		return nil, nil
+	}
+	reward, uncleRewards := ethash.BlockRewards(config, block.Header(), block.Uncles())
+
+	makeTrace := func(author common.Address, kind string, value *hexutil.Big) (json.RawMessage, error) {
+		return json.Marshal(&rewardTrace{
+			Action:       rewardAction{Author: author, RewardType: kind, Value: value},
+			BlockHash:    block.Hash(),
+			BlockNumber:  block.NumberU64(),
+			TraceAddress: []int{},
+			Type:         "reward",
+		})
+	}
+	trace, err := makeTrace(block.Coinbase(), "block", (*hexutil.Big)(reward))
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{trace}
+	for i, uncle := range block.Uncles() {
+		trace, err := makeTrace(uncle.Coinbase, "uncle", (*hexutil.Big)(uncleRewards[i]))
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, trace)
+	}
+	return traces, nil
+}
+
+// containsAddress returns whether the address is in the list, an empty list
+// containing every address.
+func containsAddress(list []common.Address, addr *common.Address) bool {
+	if len(list) == 0 {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	for _, a := range list {
+		if a == *addr {
+			return true
+		}
+	}
+	return false
+}
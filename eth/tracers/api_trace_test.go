@@ -0,0 +1,93 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that proof-of-work blocks report their mining rewards, and that the
+// reward traces can be filtered by their recipient.
+func TestTraceRewards(t *testing.T) {
+	t.Parallel()
+
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	miners := []common.Address{{0x01}, {0x02}, {0x01}}
+
+	api := NewTraceAPI(newTestBackend(t, len(miners), genesis, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(miners[i])
+	}))
+	traces, err := api.Block(context.Background(), rpc.BlockNumber(2))
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), 1)
+	}
+	var reward rewardTrace
+	if err := json.Unmarshal(traces[0], &reward); err != nil {
+		t.Fatalf("failed to decode reward trace: %v", err)
+	}
+	if reward.Type != "reward" || reward.Action.RewardType != "block" || reward.Action.Author != miners[1] || reward.BlockNumber != 2 {
+		t.Errorf("reward trace mismatch: %+v", reward)
+	}
+	if reward.Action.Value.ToInt().Cmp(ethash.ConstantinopleBlockReward) != 0 {
+		t.Errorf("reward mismatch: have %v, want %v", reward.Action.Value, ethash.ConstantinopleBlockReward)
+	}
+	// Filter the rewards of the first miner
+	from, to := rpc.BlockNumber(1), rpc.BlockNumber(3)
+	traces, err = api.Filter(context.Background(), TraceFilterArgs{
+		FromBlock: &from,
+		ToBlock:   &to,
+		ToAddress: []common.Address{miners[0]},
+	})
+	if err != nil {
+		t.Fatalf("failed to filter traces: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("filtered trace count mismatch: have %d, want %d", len(traces), 2)
+	}
+	// Paginate over them
+	after, count := uint64(1), uint64(1)
+	traces, err = api.Filter(context.Background(), TraceFilterArgs{
+		FromBlock: &from,
+		ToBlock:   &to,
+		ToAddress: []common.Address{miners[0]},
+		After:     &after,
+		Count:     &count,
+	})
+	if err != nil {
+		t.Fatalf("failed to filter traces: %v", err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("paginated trace count mismatch: have %d, want %d", len(traces), 1)
+	}
+	if err := json.Unmarshal(traces[0], &reward); err != nil {
+		t.Fatalf("failed to decode reward trace: %v", err)
+	}
+	if reward.BlockNumber != 3 {
+		t.Errorf("paginated trace block mismatch: have %d, want %d", reward.BlockNumber, 3)
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// traceIndexTimeout is the amount of time a single transaction can execute
+	// when being traced for the index. It is generous as the indexer runs in
+	// the background, and timed out blocks are not indexed.
+	traceIndexTimeout = time.Minute
+
+	// traceIndexQueue is the maximum number of imported blocks waiting to be
+	// traced. Older blocks are dropped from the index if it overflows.
+	traceIndexQueue = 1024
+
+	// chainEventChanSize is the size of the channel listening to ChainEvent.
+	chainEventChanSize = 64
+
+	// chainSideChanSize is the size of the channel listening to ChainSideEvent.
+	chainSideChanSize = 64
+)
+
+// IndexerBackend is the collection of methods required by the trace indexer.
+type IndexerBackend interface {
+	Backend
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+	SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription
+}
+
+// traceConfigHash returns the identifier under which the block traces produced
+// with the given config are indexed. Only the tracer and its config affect the
+// results, the config is compacted so that formatting differences don't matter.
+// False is returned for the struct logger, whose output is never indexed.
+func traceConfigHash(config *TraceConfig) (common.Hash, bool) {
+	if config == nil || config.Tracer == nil {
+		return common.Hash{}, false
+	}
+	buf := bytes.NewBufferString(*config.Tracer)
+	buf.WriteByte(0)
+	// Named JavaScript tracers can be modified without being renamed, identify
+	// them by their code too
+	if code, ok := Code(*config.Tracer); ok {
+		buf.Write(crypto.Keccak256([]byte(code)))
+	}
+	if len(config.TracerConfig) > 0 {
+		if err := json.Compact(buf, config.TracerConfig); err != nil {
+			return common.Hash{}, false
+		}
+	}
+	return crypto.Keccak256Hash(buf.Bytes()), true
+}
+
+// indexedTraces returns the traces of a block stored by the trace indexer for
+// the given config, or nil if the block was not indexed with it.
+func (api *API) indexedTraces(block *types.Block, config *TraceConfig) []*txTraceResult {
+	hash, ok := traceConfigHash(config)
+	if !ok {
+		return nil
+	}
+	blob := rawdb.ReadBlockTraces(api.backend.ChainDb(), block.NumberU64(), block.Hash(), hash)
+	if blob == nil {
+		return nil
+	}
+	var stored []struct {
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  string          `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(blob, &stored); err != nil {
+		log.Error("Invalid indexed block traces", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return nil
+	}
+	if len(stored) != len(block.Transactions()) {
+		log.Error("Indexed block traces mismatch", "number", block.NumberU64(), "hash", block.Hash(), "have", len(stored), "want", len(block.Transactions()))
+		return nil
+	}
+	results := make([]*txTraceResult, len(stored))
+	for i, res := range stored {
+		results[i] = &txTraceResult{Error: res.Error}
+		if res.Result != nil {
+			results[i].Result = res.Result
+		}
+	}
+	return results
+}
+
+// TraceIndexer runs a tracer on every block imported into the chain, storing
+// the compressed traces in the database. Block tracing requests using the same
+// tracer config are then served from disk, without re-executing the block or
+// needing its historical state.
+type TraceIndexer struct {
+	api        *API
+	backend    IndexerBackend
+	config     *TraceConfig
+	configHash common.Hash
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTraceIndexer creates a trace indexer running the given tracer with its
+// config on the imported blocks.
+func NewTraceIndexer(backend IndexerBackend, tracer string, tracerConfig json.RawMessage) (*TraceIndexer, error) {
+	// Ensure the tracer exists and accepts its config before indexing anything
+	if _, err := New(tracer, new(Context), tracerConfig); err != nil {
+		return nil, fmt.Errorf("invalid index tracer %s: %w", tracer, err)
+	}
+	timeout := traceIndexTimeout.String()
+	config := &TraceConfig{
+		Tracer:       &tracer,
+		TracerConfig: tracerConfig,
+		Timeout:      &timeout,
+	}
+	hash, ok := traceConfigHash(config)
+	if !ok {
+		return nil, fmt.Errorf("invalid index tracer config: %s", tracerConfig)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TraceIndexer{
+		api:        NewAPI(backend),
+		backend:    backend,
+		config:     config,
+		configHash: hash,
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+// Start implements node.Lifecycle, starting the background indexing of the
+// imported blocks.
+func (ix *TraceIndexer) Start() error {
+	ix.wg.Add(1)
+	go ix.loop()
+
+	log.Info("Started trace indexer", "tracer", *ix.config.Tracer, "config", string(ix.config.TracerConfig))
+	return nil
+}
+
+// Stop implements node.Lifecycle, aborting any running trace and terminating
+// the indexer.
+func (ix *TraceIndexer) Stop() error {
+	ix.cancel()
+	ix.wg.Wait()
+
+	log.Info("Stopped trace indexer")
+	return nil
+}
+
+// loop queues up the imported blocks and traces them one after the other. The
+// chain events are consumed independently of the tracing, so that slow traces
+// never block the chain import.
+func (ix *TraceIndexer) loop() {
+	defer ix.wg.Done()
+
+	var (
+		events  = make(chan core.ChainEvent, chainEventChanSize)
+		sub     = ix.backend.SubscribeChainEvent(events)
+		sides   = make(chan core.ChainSideEvent, chainSideChanSize)
+		sideSub = ix.backend.SubscribeChainSideEvent(sides)
+		queue   []*types.Block
+		done    chan struct{}
+	)
+	defer sub.Unsubscribe()
+	defer sideSub.Unsubscribe()
+
+	for {
+		// Start tracing the next block if the previous one finished
+		if done == nil && len(queue) > 0 {
+			done = make(chan struct{})
+			go func(block *types.Block, done chan struct{}) {
+				defer close(done)
+				ix.index(block)
+			}(queue[0], done)
+			queue = queue[1:]
+		}
+		select {
+		case ev := <-events:
+			if ev.Block.NumberU64() == 0 {
+				continue
+			}
+			if len(queue) >= traceIndexQueue {
+				log.Warn("Trace indexer overloaded, dropping block", "number", queue[0].NumberU64(), "hash", queue[0].Hash())
+				queue = queue[1:]
+			}
+			queue = append(queue, ev.Block)
+
+		case ev := <-sides:
+			ix.prune(ev.Block)
+
+		case <-done:
+			done = nil
+
+		case <-sideSub.Err():
+			if done != nil {
+				<-done
+			}
+			return
+
+		case <-sub.Err():
+			if done != nil {
+				<-done
+			}
+			return
+
+		case <-ix.ctx.Done():
+			if done != nil {
+				<-done
+			}
+			return
+		}
+	}
+}
+
+// index traces a single block and stores the results, unless the block was
+// already indexed.
+func (ix *TraceIndexer) index(block *types.Block) {
+	db := ix.backend.ChainDb()
+	if rawdb.HasBlockTraces(db, block.NumberU64(), block.Hash(), ix.configHash) {
+		return
+	}
+	// Skip the blocks reorged out of the chain while waiting in the queue
+	if rawdb.ReadCanonicalHash(db, block.NumberU64()) != block.Hash() {
+		return
+	}
+	start := time.Now()
+	results, err := ix.api.traceBlock(ix.ctx, block, ix.config)
+	if err != nil {
+		if ix.ctx.Err() == nil {
+			log.Warn("Failed to trace block for index", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		}
+		return
+	}
+	// Failures might be caused by timeouts or interruptions, don't persist them
+	for i, res := range results {
+		if res.Error != "" {
+			log.Warn("Failed to trace transaction for index", "number", block.NumberU64(), "hash", block.Hash(), "index", i, "err", res.Error)
+			return
+		}
+	}
+	blob, err := json.Marshal(results)
+	if err != nil {
+		log.Error("Failed to encode block traces", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return
+	}
+	rawdb.WriteBlockTraces(db, block.NumberU64(), block.Hash(), ix.configHash, blob)
+	log.Debug("Indexed block traces", "number", block.NumberU64(), "hash", block.Hash(), "txs", len(results), "size", len(blob), "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// prune deletes the traces stored for a block reorged out of the chain,
+// regardless of their tracer config.
+func (ix *TraceIndexer) prune(block *types.Block) {
+	var (
+		db             = ix.backend.ChainDb()
+		number         = block.NumberU64()
+		batch          = db.NewBatch()
+		pruned         int
+		hashes, config = rawdb.ReadAllBlockTraceIDs(db, number)
+	)
+	for i, hash := range hashes {
+		if hash == block.Hash() {
+			rawdb.DeleteBlockTraces(batch, number, hash, config[i])
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to prune reorged block traces", "number", number, "hash", block.Hash(), "err", err)
+		return
+	}
+	log.Debug("Pruned reorged block traces", "number", number, "hash", block.Hash(), "traces", pruned)
+}
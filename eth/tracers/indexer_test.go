@@ -0,0 +1,179 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func init() {
+	RegisterLookup(false, func(name string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
+		if name != "gasTracer" {
+			return nil, errors.New("not found")
+		}
+		return new(gasTracer), nil
+	})
+}
+
+// gasTracer is a minimal tracer reporting the gas used by a transaction.
+type gasTracer struct {
+	gasLimit uint64
+	gasUsed  uint64
+}
+
+func (t *gasTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+func (t *gasTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {}
+func (t *gasTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+func (t *gasTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+func (t *gasTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+func (t *gasTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (t *gasTracer) CaptureTxStart(gasLimit uint64)                       { t.gasLimit = gasLimit }
+func (t *gasTracer) CaptureTxEnd(restGas uint64)                          { t.gasUsed = t.gasLimit - restGas }
+func (t *gasTracer) Stop(err error)                                       {}
+func (t *gasTracer) GetResult() (json.RawMessage, error) {
+	return json.RawMessage(fmt.Sprintf(`{"gasUsed":%d}`, t.gasUsed)), nil
+}
+
+func TestTraceIndexer(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+	}
+	generator := func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), types.HomesteadSigner{}, accounts[0].key)
+		b.AddTx(tx)
+	}
+	backend := newTestBackend(t, 0, genesis, nil)
+	defer backend.chain.Stop()
+
+	indexer, err := NewTraceIndexer(backend, "gasTracer", json.RawMessage(`{ "unused": true }`))
+	if err != nil {
+		t.Fatalf("failed to create indexer: %v", err)
+	}
+	if err := indexer.Start(); err != nil {
+		t.Fatalf("failed to start indexer: %v", err)
+	}
+	defer indexer.Stop()
+
+	// Import a few blocks and wait for them to be indexed
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, generator)
+	if _, err := backend.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	for _, block := range blocks {
+		for start := time.Now(); !rawdb.HasBlockTraces(backend.chaindb, block.NumberU64(), block.Hash(), indexer.configHash); {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("block #%d not indexed", block.NumberU64())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	// Indexed traces must match re-executed ones and be served from disk for
+	// the same tracer config, regardless of its formatting
+	var (
+		api    = NewAPI(backend)
+		tracer = "gasTracer"
+		block  = blocks[2]
+	)
+	want := fmt.Sprintf(`[{"result":{"gasUsed":%d}}]`, params.TxGas)
+	if blob := rawdb.ReadBlockTraces(backend.chaindb, block.NumberU64(), block.Hash(), indexer.configHash); string(blob) != want {
+		t.Fatalf("indexed traces mismatch: have %s, want %s", blob, want)
+	}
+	rawdb.WriteBlockTraces(backend.chaindb, block.NumberU64(), block.Hash(), indexer.configHash, []byte(`[{"result":"indexed"}]`))
+
+	for i, test := range []struct {
+		config json.RawMessage
+		want   string
+	}{
+		{json.RawMessage(`{"unused":true}`), `[{"result":"indexed"}]`},
+		{json.RawMessage(`{"unused": false}`), want},
+		{nil, want},
+	} {
+		results, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(block.NumberU64()), &TraceConfig{Tracer: &tracer, TracerConfig: test.config})
+		if err != nil {
+			t.Fatalf("test %d: failed to trace block: %v", i, err)
+		}
+		if have, _ := json.Marshal(results); string(have) != test.want {
+			t.Errorf("test %d: result mismatch: have %s, want %s", i, have, test.want)
+		}
+	}
+	// Reorging the indexed blocks out of the chain should prune their traces
+	_, fork, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 5, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := backend.chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to import fork: %v", err)
+	}
+	for _, block := range blocks {
+		for start := time.Now(); rawdb.HasBlockTraces(backend.chaindb, block.NumberU64(), block.Hash(), indexer.configHash); {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("reorged block #%d not pruned", block.NumberU64())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	// Unknown tracers must be rejected upfront
+	if _, err := NewTraceIndexer(backend, "unknownTracer", nil); err == nil {
+		t.Errorf("unknown tracer accepted")
+	}
+}
+
+// Tests that the tracer config hash identifies the named interpreted tracers by
+// their code too, so modified ones don't serve stale traces.
+func TestTraceConfigHashCode(t *testing.T) {
+	code := "{result: function() { return 1; }}"
+	RegisterCodeLookup(func(name string) (string, bool) {
+		if name != "codeTracer" {
+			return "", false
+		}
+		return code, true
+	})
+	var (
+		tracer = "codeTracer"
+		config = &TraceConfig{Tracer: &tracer}
+	)
+	first, ok := traceConfigHash(config)
+	if !ok {
+		t.Fatal("failed to hash tracer config")
+	}
+	code = "{result: function() { return 2; }}"
+	if second, _ := traceConfigHash(config); second == first {
+		t.Errorf("tracer code change not reflected in config hash")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// flatCallTrace is the subset of a flatCallTracer frame checked by the tests.
+type flatCallTrace struct {
+	Type   string `json:"type"`
+	Action struct {
+		CallType      string          `json:"callType,omitempty"`
+		From          *common.Address `json:"from,omitempty"`
+		To            *common.Address `json:"to,omitempty"`
+		Address       *common.Address `json:"address,omitempty"`
+		RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	} `json:"action"`
+	Result *struct {
+		Address *common.Address `json:"address,omitempty"`
+	} `json:"result,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	Subtraces    int          `json:"subtraces"`
+	TraceAddress []int        `json:"traceAddress"`
+	BlockNumber  *uint64      `json:"blockNumber"`
+	TxHash       *common.Hash `json:"transactionHash"`
+}
+
+// flattenCallTrace converts a nested call trace into the flat frames expected
+// from the flatCallTracer.
+func flattenCallTrace(call *callTrace, traceAddress []int, number uint64, hash common.Hash) []flatCallTrace {
+	var (
+		flat     flatCallTrace
+		from, to = call.From, call.To
+		result   = &struct {
+			Address *common.Address `json:"address,omitempty"`
+		}{}
+	)
+	switch call.Type {
+	case "CREATE", "CREATE2":
+		flat.Type = "create"
+		flat.Action.From = &from
+		result.Address = &to
+	case "SELFDESTRUCT":
+		flat.Type = "suicide"
+		flat.Action.Address, flat.Action.RefundAddress = &from, &to
+		result = nil
+	default:
+		flat.Type = "call"
+		flat.Action.CallType = strings.ToLower(call.Type)
+		flat.Action.From, flat.Action.To = &from, &to
+	}
+	if call.Error == "" {
+		flat.Result = result
+	}
+	flat.Error = call.Error
+	flat.Subtraces = len(call.Calls)
+	flat.TraceAddress = traceAddress
+	flat.BlockNumber, flat.TxHash = &number, &hash
+
+	frames := []flatCallTrace{flat}
+	for i := range call.Calls {
+		frames = append(frames, flattenCallTrace(&call.Calls[i], append(append([]int{}, traceAddress...), i), number, hash)...)
+	}
+	return frames
+}
+
+// traceCallTest executes the transaction of a callTracer dataset with the given
+// tracer, returning the trace result.
+func traceCallTest(t *testing.T, test *callTracerTest, tracerName string, config json.RawMessage) json.RawMessage {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	var (
+		number    = new(big.Int).SetUint64(uint64(test.Context.Number))
+		signer    = types.MakeSigner(test.Genesis.Config, number)
+		origin, _ = signer.Sender(tx)
+		txContext = vm.TxContext{
+			Origin:   origin,
+			GasPrice: tx.GasPrice(),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Coinbase:    test.Context.Miner,
+			BlockNumber: number,
+			Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+			Difficulty:  (*big.Int)(test.Context.Difficulty),
+			GasLimit:    uint64(test.Context.GasLimit),
+		}
+		_, statedb = tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+	)
+	tracer, err := tracers.New(tracerName, &tracers.Context{BlockNumber: number, TxHash: tx.Hash()}, config)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", tracerName, err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
+
+// txHash returns the hash of the transaction of a callTracer dataset.
+func (test *callTracerTest) txHash(t *testing.T) common.Hash {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	return tx.Hash()
+}
+
+// Iterates over the callTracer datasets and checks that the flat call tracer
+// reports the same call frames, flattened in depth-first order.
+func TestFlatCallTracerNative(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			test := new(callTracerTest)
+			if blob, err := os.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			if test.TracerConfig != nil {
+				t.Skip("callTracer specific config")
+			}
+			res := traceCallTest(t, test, "flatCallTracer", json.RawMessage(`{"includePrecompiles":true}`))
+			var have []flatCallTrace
+			if err := json.Unmarshal(res, &have); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			want := flattenCallTrace(test.Result, []int{}, uint64(test.Context.Number), test.txHash(t))
+			if !reflect.DeepEqual(have, want) {
+				haveJSON, _ := json.MarshalIndent(have, "", " ")
+				wantJSON, _ := json.MarshalIndent(want, "", " ")
+				t.Fatalf("trace mismatch: \nhave %s\nwant %s", haveJSON, wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+// Iterates over the callTracer datasets and checks that the mux tracer produces
+// the same results as its tracers run one by one.
+func TestMuxTracer(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			test := new(callTracerTest)
+			if blob, err := os.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			callConfig := test.TracerConfig
+			if callConfig == nil {
+				callConfig = json.RawMessage(`{}`)
+			}
+			configs := map[string]json.RawMessage{
+				"callTracer":     callConfig,
+				"4byteTracer":    nil,
+				"flatCallTracer": json.RawMessage(`{"convertParityErrors":true}`),
+			}
+			config, err := json.Marshal(configs)
+			if err != nil {
+				t.Fatalf("failed to encode mux config: %v", err)
+			}
+			var results map[string]json.RawMessage
+			if err := json.Unmarshal(traceCallTest(t, test, "muxTracer", config), &results); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			if len(results) != len(configs) {
+				t.Fatalf("result count mismatch: have %d, want %d", len(results), len(configs))
+			}
+			// The call trace must match the dataset
+			call := new(callTrace)
+			if err := json.Unmarshal(results["callTracer"], call); err != nil {
+				t.Fatalf("failed to unmarshal call trace: %v", err)
+			}
+			if !jsonEqual(call, test.Result) {
+				t.Fatalf("call trace mismatch: \nhave %+v\nwant %+v", call, test.Result)
+			}
+			// The other traces must match standalone runs
+			for _, name := range []string{"4byteTracer", "flatCallTracer"} {
+				var have, want interface{}
+				if err := json.Unmarshal(results[name], &have); err != nil {
+					t.Fatalf("failed to unmarshal %s result: %v", name, err)
+				}
+				if err := json.Unmarshal(traceCallTest(t, test, name, configs[name]), &want); err != nil {
+					t.Fatalf("failed to unmarshal %s result: %v", name, err)
+				}
+				if !reflect.DeepEqual(have, want) {
+					t.Errorf("%s result mismatch: have %v, want %v", name, have, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMuxTracerNoTracers(t *testing.T) {
+	for _, config := range []json.RawMessage{nil, json.RawMessage(`{}`)} {
+		if _, err := tracers.New("muxTracer", new(tracers.Context), config); err == nil {
+			t.Errorf("config %q: expected error", config)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package js
+
+import (
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// budgetCheckInterval is the interval at which the consumption of a running
+// tracer function is rechecked.
+const budgetCheckInterval = 10 * time.Millisecond
+
+// vmBudget enforces the execution time and allocation limits inside the JS VM.
+// Every invocation of tracer code is bracketed by enter and leave, while it runs
+// a timer periodically charges the consumption and interrupts the VM once any
+// of the budgets is exhausted.
+//
+// Go doesn't account allocations per goroutine, so they are measured on the
+// process wide allocation counter while tracer code is running. Allocations of
+// concurrently running goroutines are charged too, making it an upper bound.
+type vmBudget struct {
+	vm        *goja.Runtime
+	maxTime   time.Duration // Maximum time spent executing tracer code
+	maxAllocs uint64        // Maximum number of bytes allocated by tracer code
+
+	running bool          // Whether tracer code is currently executing
+	start   time.Time     // Time when the consumption was last charged
+	allocs  uint64        // Allocation counter when the consumption was last charged
+	time    time.Duration // Execution time charged so far
+	alloced uint64        // Allocated bytes charged so far
+	timer   *time.Timer   // Timer rechecking the budget of running code
+	lock    sync.Mutex
+}
+
+// newVMBudget creates an execution budget for the given VM, or nil if neither
+// limit is set.
+func newVMBudget(vm *goja.Runtime, maxTime time.Duration, maxAllocs uint64) *vmBudget {
+	if maxTime == 0 && maxAllocs == 0 {
+		return nil
+	}
+	return &vmBudget{vm: vm, maxTime: maxTime, maxAllocs: maxAllocs}
+}
+
+// enter marks the start of executing tracer code.
+func (b *vmBudget) enter() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.running = true
+	b.start = time.Now()
+	if b.maxAllocs != 0 {
+		b.allocs = heapAllocs()
+	}
+	b.schedule()
+}
+
+// leave marks the end of executing tracer code, returning an error if the code
+// exhausted any of the budgets.
+func (b *vmBudget) leave() error {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.running = false
+	b.timer.Stop()
+	return b.charge()
+}
+
+// charge accounts the consumption of the running tracer code since the last
+// charge, returning an error if any of the budgets is exhausted. The caller
+// must hold the lock.
+func (b *vmBudget) charge() error {
+	now := time.Now()
+	b.time += now.Sub(b.start)
+	b.start = now
+
+	if b.maxAllocs != 0 {
+		allocs := heapAllocs()
+		b.alloced += allocs - b.allocs
+		b.allocs = allocs
+	}
+	if b.maxTime != 0 && b.time > b.maxTime {
+		return errTimeLimit
+	}
+	if b.maxAllocs != 0 && b.alloced > b.maxAllocs {
+		return errAllocLimit
+	}
+	return nil
+}
+
+// schedule arms the timer to recheck the budget of the running tracer code,
+// at the latest when the remaining execution time runs out. The caller must
+// hold the lock.
+func (b *vmBudget) schedule() {
+	wait := budgetCheckInterval
+	if b.maxTime != 0 && b.maxTime-b.time < wait {
+		wait = b.maxTime - b.time
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(wait, b.check)
+	} else {
+		b.timer.Reset(wait)
+	}
+}
+
+// check charges the consumption of the running tracer code, interrupting the
+// VM if any of the budgets is exhausted.
+func (b *vmBudget) check() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return
+	}
+	if err := b.charge(); err != nil {
+		b.vm.Interrupt(err)
+		return
+	}
+	b.schedule()
+}
+
+// heapAllocs returns the cumulative number of bytes allocated on the heap by
+// the process.
+func heapAllocs() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
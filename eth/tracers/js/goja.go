@@ -43,6 +43,7 @@ func init() {
 		panic(err)
 	}
 	tracers.RegisterLookup(true, newJsTracer)
+	tracers.RegisterCodeLookup(lookupTracer)
 }
 
 // bigIntProgram is compiled once and the exported function mostly invoked to convert
@@ -84,6 +85,27 @@ func fromBuf(vm *goja.Runtime, bufType goja.Value, buf goja.Value, allowString b
 	return nil, fmt.Errorf("invalid buffer type")
 }
 
+var (
+	errStepLimit   = errors.New("tracer step limit exceeded")
+	errMemoryLimit = errors.New("tracer memory limit exceeded")
+	errTimeLimit   = errors.New("tracer execution time limit exceeded")
+	errAllocLimit  = errors.New("tracer allocation limit exceeded")
+)
+
+// Limits are the resource limits of a single tracer run, requested through the
+// `limits` field of the tracer config. Zero values mean unlimited.
+//
+// The memory limit covers the data handed over to the tracer by the tracing
+// API, such as memory slices, code and buffers, and the size of its result. The
+// time and allocation limits are enforced inside the JavaScript VM, interrupting
+// the tracer code as soon as it exhausts either of them.
+type Limits struct {
+	MaxSteps  uint64 `json:"maxSteps"`  // Maximum number of tracer function invocations
+	MaxMemory uint64 `json:"maxMemory"` // Maximum number of bytes passed to and returned by the tracer
+	MaxTime   string `json:"maxTime"`   // Maximum time spent executing tracer code, e.g. "100ms"
+	MaxAllocs uint64 `json:"maxAllocs"` // Maximum number of bytes allocated while executing tracer code
+}
+
 // jsTracer is an implementation of the Tracer interface which evaluates
 // JS functions on the relevant EVM hooks. It uses Goja as its JS engine.
 type jsTracer struct {
@@ -99,6 +121,10 @@ type jsTracer struct {
 	gasLimit          uint64                // Amount of gas bought for the whole tx
 	err               error                 // Any error that should stop tracing
 	obj               *goja.Object          // Trace object
+	limits            Limits                // Resource limits of the tracer
+	steps             uint64                // Number of tracer function invocations
+	memory            uint64                // Number of bytes passed to the tracer
+	budget            *vmBudget             // Execution budget of the tracer code, nil if unlimited
 
 	// Methods exposed by tracer
 	result goja.Callable
@@ -120,21 +146,42 @@ type jsTracer struct {
 }
 
 // newJsTracer instantiates a new JS tracer instance. code is either
-// the name of a built-in JS tracer or a Javascript snippet which
+// the name of a built-in or custom JS tracer or a Javascript snippet which
 // evaluates to an expression returning an object with certain methods.
 // The methods `result` and `fault` are required to be present.
 // The methods `step`, `enter`, and `exit` are optional, but note that
 // `enter` and `exit` always go together.
 func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
-	if c, ok := assetTracers[code]; ok {
+	if c, ok := lookupTracer(code); ok {
 		code = c
 	}
+	program, err := compileTracer(code)
+	if err != nil {
+		return nil, err
+	}
+	// Extract the resource limits from the tracer config
+	var config struct {
+		Limits Limits `json:"limits"`
+	}
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, fmt.Errorf("invalid tracer config: %v", err)
+		}
+	}
+	var maxTime time.Duration
+	if config.Limits.MaxTime != "" {
+		if maxTime, err = time.ParseDuration(config.Limits.MaxTime); err != nil {
+			return nil, fmt.Errorf("invalid tracer time limit: %v", err)
+		}
+	}
 	vm := goja.New()
 	// By default field names are exported to JS as is, i.e. capitalized.
 	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
 	t := &jsTracer{
-		vm:  vm,
-		ctx: make(map[string]goja.Value),
+		vm:     vm,
+		ctx:    make(map[string]goja.Value),
+		limits: config.Limits,
+		budget: newVMBudget(vm, maxTime, config.Limits.MaxAllocs),
 	}
 	if ctx == nil {
 		ctx = new(tracers.Context)
@@ -149,7 +196,11 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 
 	t.setTypeConverters()
 	t.setBuiltinFunctions()
-	ret, err := vm.RunString("(" + code + ")")
+	t.budget.enter()
+	ret, err := vm.RunProgram(program)
+	if berr := t.budget.leave(); err == nil {
+		err = berr
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +235,7 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 		if cfg != nil {
 			cfgStr = string(cfg)
 		}
-		if _, err := setup(obj, vm.ToValue(cfgStr)); err != nil {
+		if _, err := t.call(setup, obj, vm.ToValue(cfgStr)); err != nil {
 			return nil, err
 		}
 	}
@@ -262,7 +313,10 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	log.refund = t.env.StateDB.GetRefund()
 	log.depth = depth
 	log.err = err
-	if _, err := t.step(t.obj, t.logValue, t.dbValue); err != nil {
+	if !t.countStep("step") {
+		return
+	}
+	if _, err := t.call(t.step, t.obj, t.logValue, t.dbValue); err != nil {
 		t.onError("step", err)
 	}
 }
@@ -274,7 +328,10 @@ func (t *jsTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	}
 	// Other log fields have been already set as part of the last CaptureState.
 	t.log.err = err
-	if _, err := t.fault(t.obj, t.logValue, t.dbValue); err != nil {
+	if !t.countStep("fault") {
+		return
+	}
+	if _, err := t.call(t.fault, t.obj, t.logValue, t.dbValue); err != nil {
 		t.onError("fault", err)
 	}
 }
@@ -308,7 +365,10 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 		t.frame.value = new(big.Int).SetBytes(value.Bytes())
 	}
 
-	if _, err := t.enter(t.obj, t.frameValue); err != nil {
+	if !t.countStep("enter") {
+		return
+	}
+	if _, err := t.call(t.enter, t.obj, t.frameValue); err != nil {
 		t.onError("enter", err)
 	}
 }
@@ -324,7 +384,10 @@ func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	t.frameResult.output = common.CopyBytes(output)
 	t.frameResult.err = err
 
-	if _, err := t.exit(t.obj, t.frameResultValue); err != nil {
+	if !t.countStep("exit") {
+		return
+	}
+	if _, err := t.call(t.exit, t.obj, t.frameResultValue); err != nil {
 		t.onError("exit", err)
 	}
 }
@@ -332,7 +395,7 @@ func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 // GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
 func (t *jsTracer) GetResult() (json.RawMessage, error) {
 	ctx := t.vm.ToValue(t.ctx)
-	res, err := t.result(t.obj, ctx, t.dbValue)
+	res, err := t.call(t.result, t.obj, ctx, t.dbValue)
 	if err != nil {
 		return nil, wrapError("result", err)
 	}
@@ -340,6 +403,9 @@ func (t *jsTracer) GetResult() (json.RawMessage, error) {
 	if err != nil {
 		return nil, err
 	}
+	if t.limits.MaxMemory != 0 && t.memory+uint64(len(encoded)) > t.limits.MaxMemory {
+		return nil, wrapError("result", errMemoryLimit)
+	}
 	return json.RawMessage(encoded), t.err
 }
 
@@ -358,6 +424,28 @@ func (t *jsTracer) onError(context string, err error) {
 	t.env.Cancel()
 }
 
+// countStep accounts for an invocation of a tracer function, stopping the
+// tracing if it exceeds the step limit.
+func (t *jsTracer) countStep(context string) bool {
+	t.steps++
+	if t.limits.MaxSteps != 0 && t.steps > t.limits.MaxSteps {
+		t.onError(context, errStepLimit)
+		return false
+	}
+	return true
+}
+
+// call invokes a tracer function, charging its execution to the budget of the
+// tracer code.
+func (t *jsTracer) call(fn goja.Callable, this goja.Value, args ...goja.Value) (goja.Value, error) {
+	t.budget.enter()
+	res, err := fn(this, args...)
+	if berr := t.budget.leave(); err == nil && berr != nil {
+		return nil, berr
+	}
+	return res, err
+}
+
 func wrapError(context string, err error) error {
 	return fmt.Errorf("%v    in server-side tracer function '%v'", err, context)
 }
@@ -498,6 +586,10 @@ func (t *jsTracer) setTypeConverters() error {
 	// Cache uint8ArrayType once to be used every time for less overhead.
 	uint8ArrayType := t.vm.Get("Uint8Array")
 	toBufWrapper := func(vm *goja.Runtime, val []byte) (goja.Value, error) {
+		t.memory += uint64(len(val))
+		if t.limits.MaxMemory != 0 && t.memory > t.limits.MaxMemory {
+			return nil, errMemoryLimit
+		}
 		return toBuf(vm, uint8ArrayType, val)
 	}
 	t.toBuf = toBufWrapper
@@ -0,0 +1,109 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package js
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// programCacheSize is the number of compiled tracer programs kept around for
+// reuse across requests.
+const programCacheSize = 128
+
+var (
+	programCache, _ = lru.New(programCacheSize)
+
+	// customTracers are the JavaScript tracers loaded by the operator, keyed by
+	// name like the built-in ones.
+	customTracers   = make(map[string]string)
+	customTracersMu sync.RWMutex
+)
+
+// compileTracer compiles the source of a tracer into a program, which can then
+// be run in any runtime. Programs are cached by the hash of their code, so that
+// the same tracer sent by consecutive requests is only compiled once.
+func compileTracer(code string) (*goja.Program, error) {
+	hash := crypto.Keccak256Hash([]byte(code))
+	if program, ok := programCache.Get(hash); ok {
+		return program.(*goja.Program), nil
+	}
+	program, err := goja.Compile("", "("+code+")", false)
+	if err != nil {
+		return nil, err
+	}
+	programCache.Add(hash, program)
+	return program, nil
+}
+
+// lookupTracer returns the code of a built-in or custom tracer by name.
+func lookupTracer(name string) (string, bool) {
+	if code, ok := assetTracers[name]; ok {
+		return code, true
+	}
+	customTracersMu.RLock()
+	defer customTracersMu.RUnlock()
+
+	code, ok := customTracers[name]
+	return code, ok
+}
+
+// LoadTracers reads the JavaScript tracers from the .js files in a directory,
+// making each of them callable by its file name without the extension, the
+// same way as the built-in tracers. All tracers are compiled upfront, so any
+// invalid one fails the whole load.
+func LoadTracers(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	tracers := make(map[string]string)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".js") {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), ".js")
+		if _, ok := assetTracers[name]; ok {
+			return fmt.Errorf("tracer %s conflicts with a built-in tracer", name)
+		}
+		code, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		if _, err := compileTracer(string(code)); err != nil {
+			return fmt.Errorf("invalid tracer %s: %w", name, err)
+		}
+		tracers[name] = string(code)
+	}
+	customTracersMu.Lock()
+	defer customTracersMu.Unlock()
+
+	for name, code := range tracers {
+		customTracers[name] = code
+		log.Info("Loaded custom tracer", "name", name)
+	}
+	return nil
+}
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"errors"
 	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -304,3 +306,130 @@ func TestSetup(t *testing.T) {
 		t.Errorf("tracer returned wrong result. have: %s, want: \"bar\"\n", string(have))
 	}
 }
+
+func TestTracerLimits(t *testing.T) {
+	code := "{res: [], step: function(log) { this.res.push(toHex(log.contract.getAddress())); }, fault: function() {}, result: function() { return this.res; }}"
+	for i, tt := range []struct {
+		limits string
+		fail   error
+	}{
+		{limits: `{}`},
+		{limits: `{"maxSteps": 3}`},
+		{limits: `{"maxSteps": 2}`, fail: errStepLimit},
+		{limits: `{"maxMemory": 1000}`},
+		{limits: `{"maxMemory": 50}`, fail: errMemoryLimit},  // 3 addresses passed in
+		{limits: `{"maxMemory": 100}`, fail: errMemoryLimit}, // 3 addresses passed in, 3 hex strings returned
+	} {
+		tracer, err := newJsTracer(code, nil, json.RawMessage(`{"limits":`+tt.limits+`}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = runTrace(tracer, testCtx(), params.TestChainConfig)
+		if tt.fail == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if tt.fail != nil && (err == nil || !strings.Contains(err.Error(), tt.fail.Error())) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.fail)
+		}
+	}
+}
+
+// Tests that the time and allocation limits are enforced inside the VM, also
+// interrupting tracer code which never returns.
+func TestTracerVMLimits(t *testing.T) {
+	for i, tt := range []struct {
+		code   string
+		limits string
+		fail   error
+	}{
+		{
+			code:   "{step: function() { for (;;) {} }, fault: function() {}, result: function() { return null; }}",
+			limits: `{"maxTime": "50ms"}`,
+			fail:   errTimeLimit,
+		},
+		{
+			code:   "{res: [], step: function() { for (;;) { this.res.push({}); } }, fault: function() {}, result: function() { return null; }}",
+			limits: `{"maxAllocs": 1000000}`,
+			fail:   errAllocLimit,
+		},
+		{
+			code:   "{step: function() {}, fault: function() {}, result: function() { return null; }}",
+			limits: `{"maxTime": "1s", "maxAllocs": 100000000}`,
+		},
+	} {
+		tracer, err := newJsTracer(tt.code, nil, json.RawMessage(`{"limits":`+tt.limits+`}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = runTrace(tracer, testCtx(), params.TestChainConfig)
+		if tt.fail == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if tt.fail != nil && (err == nil || !strings.Contains(err.Error(), tt.fail.Error())) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.fail)
+		}
+	}
+	// Malformed configs must be rejected instead of silently dropping the limits
+	code := "{step: function() {}, fault: function() {}, result: function() { return null; }}"
+	for _, cfg := range []string{`{"limits": {"maxTime": "never"}}`, `{"limits": {"maxSteps": "1"}}`, `[]`} {
+		if _, err := newJsTracer(code, nil, json.RawMessage(cfg)); err == nil {
+			t.Errorf("config %s: no error", cfg)
+		}
+	}
+}
+
+func TestProgramCache(t *testing.T) {
+	code := "{step: function() {}, fault: function() {}, result: function() { return 'cached'; }}"
+	first, err := compileTracer(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := compileTracer(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("program compiled twice")
+	}
+	// Cached programs must be usable by multiple tracers
+	for i := 0; i < 2; i++ {
+		tracer, err := newJsTracer(code, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res, err := runTrace(tracer, testCtx(), params.TestChainConfig); err != nil || string(res) != `"cached"` {
+			t.Errorf("tracer %d: result mismatch: have %s (%v), want %s", i, res, err, `"cached"`)
+		}
+	}
+}
+
+func TestLoadTracers(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "customTracer.js"), []byte("{count: 0, step: function() { this.count++; }, fault: function() {}, result: function() { return this.count; }}"), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a tracer"), 0644)
+
+	if err := LoadTracers(dir); err != nil {
+		t.Fatalf("failed to load tracers: %v", err)
+	}
+	tracer, err := newJsTracer("customTracer", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create custom tracer: %v", err)
+	}
+	if res, err := runTrace(tracer, testCtx(), params.TestChainConfig); err != nil || string(res) != `3` {
+		t.Errorf("result mismatch: have %s (%v), want %s", res, err, `3`)
+	}
+	// Invalid tracers and built-in name clashes must be rejected
+	invalid := t.TempDir()
+	os.WriteFile(filepath.Join(invalid, "brokenTracer.js"), []byte("{step: function() {"), 0644)
+	if err := LoadTracers(invalid); err == nil {
+		t.Errorf("invalid tracer loaded")
+	}
+	clash := t.TempDir()
+	os.WriteFile(filepath.Join(clash, "opcountTracer.js"), []byte("{fault: function() {}, result: function() {}}"), 0644)
+	if err := LoadTracers(clash); err == nil {
+		t.Errorf("built-in tracer overridden")
+	}
+	if _, err := newJsTracer("brokenTracer", nil, nil); err == nil {
+		t.Errorf("rejected tracer registered")
+	}
+}
@@ -55,10 +55,42 @@ type Config struct {
 	EnableReturnData bool // enable return data capture
 	Debug            bool // print output during capture end
 	Limit            int  // maximum length of output, but zero means unlimited
+	Streaming        bool // encode logs as they are captured instead of accumulating them
+	Compact          bool // encode logs as deltas against the previous step (implies streaming)
+
+	// Note, streamed logs are only delivered incrementally by a logger created
+	// with NewStreamingStructLogger. Otherwise the encoded logs are buffered for
+	// the result, in which case only the compact encoding reduces the memory use.
+
+	// Step ranges outside of which memory and storage are not captured, all steps
+	// being captured if no range is given
+	MemoryRanges  []StepRange `json:"memoryRanges,omitempty"`
+	StorageRanges []StepRange `json:"storageRanges,omitempty"`
+
 	// Chain overrides, can be used to execute a trace using future fork rules
 	Overrides *params.ChainConfig `json:"overrides,omitempty"`
 }
 
+// StepRange is an inclusive range of execution steps, counted from zero.
+type StepRange struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// inRanges returns whether the given step is within any of the ranges, or true
+// if there are no ranges at all.
+func inRanges(ranges []StepRange, step uint64) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if r.From <= step && step <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
 //go:generate go run github.com/fjl/gencodec -type StructLog -field-override structLogMarshaling -out gen_structlog.go
 
 // StructLog is emitted to the EVM each cycle and lists information about the current internal state
@@ -112,6 +144,8 @@ type StructLogger struct {
 
 	storage  map[common.Address]Storage
 	logs     []StructLog
+	stream   *structLogStream // Incremental encoder used instead of logs if streaming
+	steps    uint64
 	output   []byte
 	err      error
 	gasLimit uint64
@@ -129,6 +163,19 @@ func NewStructLogger(cfg *Config) *StructLogger {
 	if cfg != nil {
 		logger.cfg = *cfg
 	}
+	if logger.cfg.Streaming || logger.cfg.Compact {
+		logger.stream = newStructLogStream(logger.cfg.Compact, nil)
+	}
+	return logger
+}
+
+// NewStreamingStructLogger returns a new logger which hands every encoded log
+// to the sink as soon as it is captured, never retaining it. The result only
+// holds the execution outcome with an empty log list. If the sink fails, the
+// tracing is aborted with its error.
+func NewStreamingStructLogger(cfg *Config, sink func(json.RawMessage) error) *StructLogger {
+	logger := NewStructLogger(cfg)
+	logger.stream = newStructLogStream(logger.cfg.Compact, sink)
 	return logger
 }
 
@@ -137,6 +184,10 @@ func (l *StructLogger) Reset() {
 	l.storage = make(map[common.Address]Storage)
 	l.output = make([]byte, 0)
 	l.logs = l.logs[:0]
+	if l.stream != nil {
+		l.stream = newStructLogStream(l.cfg.Compact, l.stream.sink)
+	}
+	l.steps = 0
 	l.err = nil
 }
 
@@ -155,37 +206,61 @@ func (l *StructLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, s
 		return
 	}
 	// check if already accumulated the specified number of logs
-	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs) {
+	if l.cfg.Limit != 0 && uint64(l.cfg.Limit) <= l.steps {
 		return
 	}
+	var (
+		step           = l.steps
+		captureMemory  = l.cfg.EnableMemory && inRanges(l.cfg.MemoryRanges, step)
+		captureStorage = !l.cfg.DisableStorage && inRanges(l.cfg.StorageRanges, step)
+	)
+	l.steps++
 
 	memory := scope.Memory
 	stack := scope.Stack
 	contract := scope.Contract
-	// Copy a snapshot of the current memory state to a new buffer
+	// Snapshot the current memory state. Streamed logs are encoded right away,
+	// so they can reference the live memory instead of a copy.
 	var mem []byte
-	if l.cfg.EnableMemory {
-		mem = make([]byte, len(memory.Data()))
-		copy(mem, memory.Data())
+	if captureMemory {
+		if l.stream != nil {
+			if mem = memory.Data(); mem == nil {
+				mem = []byte{}
+			}
+		} else {
+			mem = make([]byte, len(memory.Data()))
+			copy(mem, memory.Data())
+		}
 	}
-	// Copy a snapshot of the current stack state to a new buffer
+	// Snapshot the current stack state
 	var stck []uint256.Int
 	if !l.cfg.DisableStack {
-		stck = make([]uint256.Int, len(stack.Data()))
-		for i, item := range stack.Data() {
-			stck[i] = item
+		if l.stream != nil {
+			if stck = stack.Data(); stck == nil {
+				stck = []uint256.Int{}
+			}
+		} else {
+			stck = make([]uint256.Int, len(stack.Data()))
+			for i, item := range stack.Data() {
+				stck[i] = item
+			}
 		}
 	}
 	stackData := stack.Data()
 	stackLen := len(stackData)
-	// Copy a snapshot of the current storage to a new container
-	var storage Storage
+	// Track the storage changes, snapshotting the storage of the contract if
+	// requested for this step
+	var (
+		storage Storage
+		slot    common.Hash
+	)
 	if !l.cfg.DisableStorage && (op == vm.SLOAD || op == vm.SSTORE) {
 		// initialise new changed values storage container for this contract
 		// if not present.
 		if l.storage[contract.Address()] == nil {
 			l.storage[contract.Address()] = make(Storage)
 		}
+		tracked := true
 		// capture SLOAD opcodes and record the read entry in the local storage
 		if op == vm.SLOAD && stackLen >= 1 {
 			var (
@@ -193,7 +268,7 @@ func (l *StructLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, s
 				value   = l.env.StateDB.GetState(contract.Address(), address)
 			)
 			l.storage[contract.Address()][address] = value
-			storage = l.storage[contract.Address()].Copy()
+			slot = address
 		} else if op == vm.SSTORE && stackLen >= 2 {
 			// capture SSTORE opcodes and record the written entry in the local storage.
 			var (
@@ -201,7 +276,16 @@ func (l *StructLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, s
 				address = common.Hash(stackData[stackLen-1].Bytes32())
 			)
 			l.storage[contract.Address()][address] = value
-			storage = l.storage[contract.Address()].Copy()
+			slot = address
+		} else {
+			tracked = false
+		}
+		if tracked && captureStorage {
+			if l.stream != nil {
+				storage = l.storage[contract.Address()]
+			} else {
+				storage = l.storage[contract.Address()].Copy()
+			}
 		}
 	}
 	var rdata []byte
@@ -211,6 +295,12 @@ func (l *StructLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, s
 	}
 	// create a new snapshot of the EVM.
 	log := StructLog{pc, op, gas, cost, mem, memory.Len(), stck, rdata, storage, depth, l.env.StateDB.GetRefund(), err}
+	if l.stream != nil {
+		if err := l.stream.write(&log, slot); err != nil {
+			l.Stop(err)
+		}
+		return
+	}
 	l.logs = append(l.logs, log)
 }
 
@@ -249,6 +339,9 @@ func (l *StructLogger) GetResult() (json.RawMessage, error) {
 	if failed && l.err != vm.ErrExecutionReverted {
 		returnVal = ""
 	}
+	if l.stream != nil {
+		return l.stream.result(l.usedGas, failed, returnVal), nil
+	}
 	return json.Marshal(&ExecutionResult{
 		Gas:         l.usedGas,
 		Failed:      failed,
@@ -271,7 +364,8 @@ func (l *StructLogger) CaptureTxEnd(restGas uint64) {
 	l.usedGas = l.gasLimit - restGas
 }
 
-// StructLogs returns the captured log entries.
+// StructLogs returns the captured log entries. Streamed log entries are not
+// retained, so nothing is returned for them.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
 // Error returns the VM error captured by the trace.
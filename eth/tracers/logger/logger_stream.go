@@ -0,0 +1,171 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// compactStructLog is the encoding of a step as a delta against the previously
+// encoded one. Omitted fields are unchanged, apart from the storage which only
+// holds the slot accessed by the step.
+type compactStructLog struct {
+	Pc        uint64            `json:"pc"`
+	Op        string            `json:"op"`
+	Gas       uint64            `json:"gas"`
+	GasCost   uint64            `json:"gasCost"`
+	Depth     int               `json:"depth,omitempty"`     // Call depth, if changed
+	Refund    *uint64           `json:"refund,omitempty"`    // Refund counter, if changed
+	Error     string            `json:"error,omitempty"`     // Execution error of the step
+	StackPop  int               `json:"stackPop,omitempty"`  // Number of items removed from the top of the stack
+	StackPush []string          `json:"stackPush,omitempty"` // Items added to the top of the stack, after the removals
+	MemSize   *int              `json:"memSize,omitempty"`   // Memory size, if changed (growing memory is zero filled)
+	Memory    map[string]string `json:"memory,omitempty"`    // Changed 32 byte memory words, keyed by offset
+	Storage   map[string]string `json:"storage,omitempty"`   // Storage slot accessed by the step
+}
+
+// structLogStream incrementally encodes the structured logs of an execution as
+// they are captured, so that they are never held in memory in decoded form. The
+// encoded logs are handed to a sink if one is given, otherwise they are buffered
+// for the result.
+//
+// In compact mode, each step is encoded as a delta against the previous one: the
+// stack as the items popped and pushed, the memory as the changed words and the
+// storage as the accessed slot, deduplicating the data repeated across steps.
+type structLogStream struct {
+	compact bool
+	sink    func(json.RawMessage) error // Consumer of the encoded logs, nil if buffered
+	buf     bytes.Buffer
+	count   int
+
+	// Last encoded state, used as the base of the compact deltas
+	stack  []uint256.Int
+	memory []byte
+	depth  int
+	refund uint64
+}
+
+// newStructLogStream creates an empty structured log stream.
+func newStructLogStream(compact bool, sink func(json.RawMessage) error) *structLogStream {
+	return &structLogStream{compact: compact, sink: sink}
+}
+
+// write encodes a structured log and appends it to the stream. The log may
+// reference live EVM data, which is not retained. The accessed storage slot is
+// only used by the compact encoding.
+func (s *structLogStream) write(log *StructLog, slot common.Hash) error {
+	var (
+		blob []byte
+		err  error
+	)
+	if s.compact {
+		blob, err = json.Marshal(s.delta(log, slot))
+	} else {
+		blob, err = json.Marshal(formatLogs([]StructLog{*log})[0])
+	}
+	if err != nil {
+		return err
+	}
+	if s.sink != nil {
+		s.count++
+		return s.sink(blob)
+	}
+	if s.count > 0 {
+		s.buf.WriteByte(',')
+	}
+	s.buf.Write(blob)
+	s.count++
+	return nil
+}
+
+// delta creates the compact encoding of a structured log, updating the base
+// state to it.
+func (s *structLogStream) delta(log *StructLog, slot common.Hash) *compactStructLog {
+	enc := &compactStructLog{
+		Pc:      log.Pc,
+		Op:      log.Op.String(),
+		Gas:     log.Gas,
+		GasCost: log.GasCost,
+		Error:   log.ErrorString(),
+	}
+	if log.Depth != s.depth {
+		enc.Depth, s.depth = log.Depth, log.Depth
+	}
+	if log.RefundCounter != s.refund || s.count == 0 {
+		refund := log.RefundCounter
+		enc.Refund, s.refund = &refund, refund
+	}
+	// Encode the stack as the items replacing the top of the previous one
+	if log.Stack != nil {
+		shared := 0
+		for shared < len(s.stack) && shared < len(log.Stack) && s.stack[shared] == log.Stack[shared] {
+			shared++
+		}
+		enc.StackPop = len(s.stack) - shared
+		for _, item := range log.Stack[shared:] {
+			enc.StackPush = append(enc.StackPush, item.Hex())
+		}
+		s.stack = append(s.stack[:shared], log.Stack[shared:]...)
+	}
+	// Encode the memory as the words differing from the previous one
+	if log.Memory != nil {
+		if len(log.Memory) != len(s.memory) {
+			size := len(log.Memory)
+			enc.MemSize = &size
+		}
+		for i := 0; i+32 <= len(log.Memory); i += 32 {
+			word := log.Memory[i : i+32]
+			if i+32 <= len(s.memory) && bytes.Equal(word, s.memory[i:i+32]) {
+				continue
+			}
+			if i+32 > len(s.memory) && bytes.Equal(word, zeroWord[:]) {
+				continue // grown memory is zero filled
+			}
+			if enc.Memory == nil {
+				enc.Memory = make(map[string]string)
+			}
+			enc.Memory[strconv.Itoa(i)] = fmt.Sprintf("%x", word)
+		}
+		s.memory = append(s.memory[:0], log.Memory...)
+	}
+	// Encode the storage as the accessed slot only
+	if log.Storage != nil {
+		enc.Storage = map[string]string{
+			fmt.Sprintf("%x", slot): fmt.Sprintf("%x", log.Storage[slot]),
+		}
+	}
+	return enc
+}
+
+// zeroWord is an empty memory word.
+var zeroWord [32]byte
+
+// result assembles the json-encoded execution result around the streamed logs.
+func (s *structLogStream) result(gas uint64, failed bool, returnValue string) json.RawMessage {
+	head := fmt.Sprintf(`{"gas":%d,"failed":%t,"returnValue":%q,"structLogs":[`, gas, failed, returnValue)
+
+	res := make([]byte, 0, len(head)+s.buf.Len()+2)
+	res = append(res, head...)
+	res = append(res, s.buf.Bytes()...)
+	return append(res, "]}"...)
+}
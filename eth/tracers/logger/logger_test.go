@@ -17,9 +17,12 @@
 package logger
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -105,3 +108,132 @@ func TestStructLogMarshalingOmitEmpty(t *testing.T) {
 		})
 	}
 }
+
+// runStructLogger executes a test contract with the given logger config and
+// returns the json-encoded result.
+func runStructLogger(t *testing.T, cfg *Config) json.RawMessage {
+	var (
+		logger   = NewStructLogger(cfg)
+		env      = vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, &dummyStatedb{}, params.TestChainConfig, vm.Config{Debug: true, Tracer: logger})
+		contract = vm.NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 100000)
+	)
+	contract.Code = []byte{
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.SLOAD),
+		byte(vm.PUSH1), 0xff, byte(vm.PUSH1), 0x40, byte(vm.MSTORE8),
+		byte(vm.POP), byte(vm.STOP),
+	}
+	logger.CaptureStart(env, common.Address{}, contract.Address(), false, nil, 0, nil)
+	if _, err := env.Interpreter().Run(contract, []byte{}, false); err != nil {
+		t.Fatal(err)
+	}
+	logger.CaptureEnd(nil, 0, 0, nil)
+	res, err := logger.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// Tests that streamed logs are encoded identically to the accumulated ones.
+func TestStreamingStructLogger(t *testing.T) {
+	cfg := &Config{EnableMemory: true}
+	want := runStructLogger(t, cfg)
+
+	cfg.Streaming = true
+	if have := runStructLogger(t, cfg); string(have) != string(want) {
+		t.Fatalf("streamed result mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
+
+// Tests that the compact delta encoding reconstructs the full logs.
+func TestCompactStructLogger(t *testing.T) {
+	var full ExecutionResult
+	if err := json.Unmarshal(runStructLogger(t, &Config{EnableMemory: true}), &full); err != nil {
+		t.Fatal(err)
+	}
+	var compact struct {
+		StructLogs []compactStructLog `json:"structLogs"`
+	}
+	if err := json.Unmarshal(runStructLogger(t, &Config{EnableMemory: true, Compact: true}), &compact); err != nil {
+		t.Fatal(err)
+	}
+	if len(compact.StructLogs) != len(full.StructLogs) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(compact.StructLogs), len(full.StructLogs))
+	}
+	var (
+		stack  []string
+		memory []byte
+		depth  int
+		refund uint64
+	)
+	for i, log := range compact.StructLogs {
+		want := full.StructLogs[i]
+
+		// Apply the delta to the reconstructed state
+		if log.Depth != 0 {
+			depth = log.Depth
+		}
+		if log.Refund != nil {
+			refund = *log.Refund
+		}
+		stack = append(stack[:len(stack)-log.StackPop], log.StackPush...)
+		if log.MemSize != nil {
+			if *log.MemSize < len(memory) {
+				memory = memory[:*log.MemSize]
+			} else {
+				memory = append(memory, make([]byte, *log.MemSize-len(memory))...)
+			}
+		}
+		for offset, word := range log.Memory {
+			pos, _ := strconv.Atoi(offset)
+			data, _ := hex.DecodeString(word)
+			copy(memory[pos:], data)
+		}
+		// Compare the reconstructed state with the full log
+		if log.Pc != want.Pc || log.Op != want.Op || log.Gas != want.Gas || log.GasCost != want.GasCost || depth != want.Depth || refund != want.RefundCounter {
+			t.Errorf("step %d: header mismatch: have %+v, want %+v", i, log, want)
+		}
+		if (len(stack) != 0 || len(*want.Stack) != 0) && !reflect.DeepEqual(stack, *want.Stack) {
+			t.Errorf("step %d: stack mismatch: have %v, want %v", i, stack, *want.Stack)
+		}
+		var words []string
+		for j := 0; j+32 <= len(memory); j += 32 {
+			words = append(words, fmt.Sprintf("%x", memory[j:j+32]))
+		}
+		if (len(words) != 0 || len(*want.Memory) != 0) && !reflect.DeepEqual(words, *want.Memory) {
+			t.Errorf("step %d: memory mismatch: have %v, want %v", i, words, *want.Memory)
+		}
+		if (log.Storage == nil) != (want.Storage == nil) {
+			t.Errorf("step %d: storage presence mismatch: have %v, want %v", i, log.Storage, want.Storage)
+		}
+		for slot, value := range log.Storage {
+			if (*want.Storage)[slot] != value {
+				t.Errorf("step %d: storage mismatch: have %s=%s, want %v", i, slot, value, *want.Storage)
+			}
+		}
+	}
+}
+
+// Tests that memory and storage are only captured within the configured steps.
+func TestStructLoggerCaptureRanges(t *testing.T) {
+	var res ExecutionResult
+	cfg := &Config{
+		EnableMemory:  true,
+		MemoryRanges:  []StepRange{{From: 2, To: 3}, {From: 9, To: 9}},
+		StorageRanges: []StepRange{{From: 7, To: 7}},
+	}
+	if err := json.Unmarshal(runStructLogger(t, cfg), &res); err != nil {
+		t.Fatal(err)
+	}
+	for i, log := range res.StructLogs {
+		if have, want := log.Memory != nil, i == 2 || i == 3 || i == 9; have != want {
+			t.Errorf("step %d: memory capture mismatch: have %v, want %v", i, have, want)
+		}
+		// Step 5 is the SSTORE out of range, step 7 the SLOAD in range
+		if have, want := log.Storage != nil, i == 7; have != want {
+			t.Errorf("step %d: storage capture mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
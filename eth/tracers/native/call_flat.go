@@ -0,0 +1,282 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("flatCallTracer", newFlatCallTracer)
+}
+
+// parityErrors maps EVM errors to their textual form used by Parity-style
+// tracers. Errors not listed are reported unchanged.
+var parityErrors = map[string]string{
+	vm.ErrExecutionReverted.Error():        "Reverted",
+	vm.ErrOutOfGas.Error():                 "Out of gas",
+	vm.ErrCodeStoreOutOfGas.Error():        "Out of gas",
+	vm.ErrInvalidJump.Error():              "Bad jump destination",
+	vm.ErrWriteProtection.Error():          "Mutable Call In Static Context",
+	vm.ErrDepth.Error():                    "Call stack limit reached",
+	vm.ErrInsufficientBalance.Error():      "Insufficient balance for transfer",
+	vm.ErrContractAddressCollision.Error(): "Contract address collision",
+}
+
+// flatCallFrame is a single call frame in the flat, Parity-style trace format.
+type flatCallFrame struct {
+	Action              flatCallAction  `json:"action"`
+	BlockHash           *common.Hash    `json:"blockHash"`
+	BlockNumber         *uint64         `json:"blockNumber"`
+	Error               string          `json:"error,omitempty"`
+	Result              *flatCallResult `json:"result,omitempty"`
+	Subtraces           int             `json:"subtraces"`
+	TraceAddress        []int           `json:"traceAddress"`
+	TransactionHash     *common.Hash    `json:"transactionHash"`
+	TransactionPosition *uint64         `json:"transactionPosition"`
+	Type                string          `json:"type"`
+}
+
+// flatCallAction is the input of a flat call frame. Calls, creations and self
+// destructs populate different fields.
+type flatCallAction struct {
+	Address       string `json:"address,omitempty"`
+	Balance       string `json:"balance,omitempty"`
+	CallType      string `json:"callType,omitempty"`
+	From          string `json:"from,omitempty"`
+	Gas           string `json:"gas,omitempty"`
+	Init          string `json:"init,omitempty"`
+	Input         string `json:"input,omitempty"`
+	RefundAddress string `json:"refundAddress,omitempty"`
+	To            string `json:"to,omitempty"`
+	Value         string `json:"value,omitempty"`
+}
+
+// flatCallResult is the outcome of a successful flat call frame.
+type flatCallResult struct {
+	Address string `json:"address,omitempty"`
+	Code    string `json:"code,omitempty"`
+	GasUsed string `json:"gasUsed,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// flatCallTracer reports the call frames of a transaction as a flat list, each
+// frame identified by its path in the call tree, in the format of Parity's
+// trace module. It is built on top of the nested callTracer.
+type flatCallTracer struct {
+	tracer      *callTracer
+	config      flatCallTracerConfig
+	ctx         *tracers.Context
+	precompiles []common.Address
+}
+
+type flatCallTracerConfig struct {
+	ConvertParityErrors bool `json:"convertParityErrors"` // If true, EVM errors are reported in the Parity format
+	IncludePrecompiles  bool `json:"includePrecompiles"`  // If true, calls to precompiles are reported too
+}
+
+// newFlatCallTracer returns a native go tracer which reports the call frames of
+// a tx as a flat list, and implements vm.EVMLogger.
+func newFlatCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config flatCallTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	tracer, err := newCallTracer(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &flatCallTracer{tracer: tracer.(*callTracer), config: config, ctx: ctx}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *flatCallTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.tracer.CaptureStart(env, from, to, create, input, gas, value)
+
+	rules := env.ChainConfig().Rules(env.Context.BlockNumber, env.Context.Random != nil)
+	t.precompiles = vm.ActivePrecompiles(rules)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *flatCallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	t.tracer.CaptureEnd(output, gasUsed, d, err)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *flatCallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *flatCallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *flatCallTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.tracer.CaptureEnter(typ, from, to, input, gas, value)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *flatCallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.tracer.CaptureExit(output, gasUsed, err)
+}
+
+func (*flatCallTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (*flatCallTracer) CaptureTxEnd(restGas uint64) {}
+
+// GetResult returns the json-encoded flat list of call traces, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *flatCallTracer) GetResult() (json.RawMessage, error) {
+	if len(t.tracer.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.flatten(&t.tracer.callstack[0], []int{}, nil))
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.tracer.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *flatCallTracer) Stop(err error) {
+	t.tracer.Stop(err)
+}
+
+// flatten appends the given call frame and all its descendants to the flat
+// trace list, in depth-first order.
+func (t *flatCallTracer) flatten(frame *callFrame, traceAddress []int, frames []flatCallFrame) []flatCallFrame {
+	var calls []*callFrame
+	for i := range frame.Calls {
+		if !t.config.IncludePrecompiles && t.isPrecompiled(&frame.Calls[i]) {
+			continue
+		}
+		calls = append(calls, &frame.Calls[i])
+	}
+	flat := t.convert(frame)
+	flat.Subtraces = len(calls)
+	flat.TraceAddress = traceAddress
+
+	frames = append(frames, flat)
+	for i, call := range calls {
+		childAddress := make([]int, len(traceAddress)+1)
+		copy(childAddress, traceAddress)
+		childAddress[len(traceAddress)] = i
+
+		frames = t.flatten(call, childAddress, frames)
+	}
+	return frames
+}
+
+// convert creates the flat counterpart of a call frame, without any information
+// about its position in the call tree.
+func (t *flatCallTracer) convert(frame *callFrame) flatCallFrame {
+	var flat flatCallFrame
+	switch frame.Type {
+	case vm.CREATE.String(), vm.CREATE2.String():
+		flat.Type = "create"
+		flat.Action = flatCallAction{
+			From:  frame.From,
+			Gas:   frame.Gas,
+			Init:  frame.Input,
+			Value: orZero(frame.Value),
+		}
+		flat.Result = &flatCallResult{
+			Address: frame.To,
+			Code:    frame.Output,
+			GasUsed: frame.GasUsed,
+		}
+	case vm.SELFDESTRUCT.String():
+		flat.Type = "suicide"
+		flat.Action = flatCallAction{
+			Address:       frame.From,
+			RefundAddress: frame.To,
+			Balance:       orZero(frame.Value),
+		}
+	default:
+		flat.Type = "call"
+		flat.Action = flatCallAction{
+			CallType: strings.ToLower(frame.Type),
+			From:     frame.From,
+			To:       frame.To,
+			Gas:      frame.Gas,
+			Input:    frame.Input,
+			Value:    orZero(frame.Value),
+		}
+		flat.Result = &flatCallResult{
+			GasUsed: frame.GasUsed,
+			Output:  frame.Output,
+		}
+	}
+	if frame.Error != "" {
+		flat.Error, flat.Result = frame.Error, nil
+		if t.config.ConvertParityErrors {
+			if converted, ok := parityErrors[frame.Error]; ok {
+				flat.Error = converted
+			} else if strings.HasPrefix(frame.Error, "invalid opcode") {
+				flat.Error = "Bad instruction"
+			} else if strings.HasPrefix(frame.Error, "stack underflow") || strings.HasPrefix(frame.Error, "stack limit reached") {
+				flat.Error = "Out of stack"
+			}
+		}
+	}
+	// Fill in the transaction context if the call was not dangling
+	if t.ctx != nil && t.ctx.BlockNumber != nil {
+		var (
+			hash, txHash = t.ctx.BlockHash, t.ctx.TxHash
+			number       = t.ctx.BlockNumber.Uint64()
+			position     = uint64(t.ctx.TxIndex)
+		)
+		flat.BlockHash, flat.BlockNumber = &hash, &number
+		flat.TransactionHash, flat.TransactionPosition = &txHash, &position
+	}
+	return flat
+}
+
+// isPrecompiled returns whether a call frame is a call into a precompile.
+func (t *flatCallTracer) isPrecompiled(frame *callFrame) bool {
+	switch frame.Type {
+	case vm.CALL.String(), vm.CALLCODE.String(), vm.DELEGATECALL.String(), vm.STATICCALL.String():
+	default:
+		return false
+	}
+	to := common.HexToAddress(frame.To)
+	for _, p := range t.precompiles {
+		if p == to {
+			return true
+		}
+	}
+	return false
+}
+
+// orZero returns the given hex quantity, or zero if it's empty.
+func orZero(value string) string {
+	if value == "" {
+		return "0x0"
+	}
+	return value
+}
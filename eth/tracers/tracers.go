@@ -20,6 +20,7 @@ package tracers
 import (
 	"encoding/json"
 	"errors"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -28,9 +29,10 @@ import (
 // Context contains some contextual infos for a transaction execution that is not
 // available from within the EVM object.
 type Context struct {
-	BlockHash common.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
-	TxIndex   int         // Index of the transaction within a block (zero if dangling tx or call)
-	TxHash    common.Hash // Hash of the transaction being traced (zero if dangling call)
+	BlockHash   common.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
+	BlockNumber *big.Int    // Number of the block the tx is contained within (nil if dangling tx or call)
+	TxIndex     int         // Index of the transaction within a block (zero if dangling tx or call)
+	TxHash      common.Hash // Hash of the transaction being traced (zero if dangling call)
 }
 
 // Tracer interface extends vm.EVMLogger and additionally
@@ -44,8 +46,11 @@ type Tracer interface {
 
 type lookupFunc func(string, *Context, json.RawMessage) (Tracer, error)
 
+type codeLookupFunc func(string) (string, bool)
+
 var (
-	lookups []lookupFunc
+	lookups     []lookupFunc
+	codeLookups []codeLookupFunc
 )
 
 // RegisterLookup registers a method as a lookup for tracers, meaning that
@@ -60,6 +65,24 @@ func RegisterLookup(wildcard bool, lookup lookupFunc) {
 	}
 }
 
+// RegisterCodeLookup registers a method resolving the name of a tracer into its
+// source code. It's meant for interpreted engines (js), whose named tracers may
+// change between runs without being renamed.
+func RegisterCodeLookup(lookup codeLookupFunc) {
+	codeLookups = append(codeLookups, lookup)
+}
+
+// Code returns the source code of the named tracer, if it's implemented by an
+// interpreted engine.
+func Code(name string) (string, bool) {
+	for _, lookup := range codeLookups {
+		if code, ok := lookup(name); ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
 // New returns a new instance of a tracer, by iterating through the
 // registered lookups.
 func New(code string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
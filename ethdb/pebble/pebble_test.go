@@ -0,0 +1,70 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build arm64 || amd64
+// +build arm64 amd64
+
+package pebble
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/dbtest"
+)
+
+func TestPebbleDB(t *testing.T) {
+	t.Run("DatabaseSuite", func(t *testing.T) {
+		dbtest.TestDatabaseSuite(t, func() ethdb.KeyValueStore {
+			db, err := pebble.Open("", &pebble.Options{
+				FS: vfs.NewMem(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &Database{
+				db: db,
+			}
+		})
+	})
+}
+
+func TestPebbleDBOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(dir, 16, 16, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err != errDatabaseClosed {
+		t.Fatalf("write after close: have %v, want %v", err, errDatabaseClosed)
+	}
+	db, err = New(dir, 16, 16, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if val, err := db.Get([]byte("key")); err != nil || string(val) != "value" {
+		t.Fatalf("value mismatch after reopen: have %q (%v), want %q", val, err, "value")
+	}
+}
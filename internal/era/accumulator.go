@@ -0,0 +1,79 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// accumulatorDepth is the depth of the merkle tree holding MaxEra1Size leaves.
+const accumulatorDepth = 13
+
+// zeroHashes are the roots of the empty subtrees at each depth.
+var zeroHashes = func() [accumulatorDepth + 1][32]byte {
+	var hashes [accumulatorDepth + 1][32]byte
+	for i := 1; i <= accumulatorDepth; i++ {
+		hashes[i] = sha256.Sum256(append(hashes[i-1][:], hashes[i-1][:]...))
+	}
+	return hashes
+}()
+
+// ComputeAccumulator calculates the accumulator root of an epoch, which is the
+// SSZ hash tree root of the list of header records:
+//
+//	HeaderRecord := Container { block_hash: Bytes32, total_difficulty: Uint256 }
+//	Accumulator  := List[HeaderRecord, MaxEra1Size]
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, fmt.Errorf("number of hashes and total difficulties mismatch: %d != %d", len(hashes), len(tds))
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	layer := make([][32]byte, len(hashes))
+	for i, hash := range hashes {
+		td, err := encodeTotalDifficulty(tds[i])
+		if err != nil {
+			return common.Hash{}, err
+		}
+		layer[i] = sha256.Sum256(append(common.CopyBytes(hash[:]), td...))
+	}
+	// Merkleize the records, padding with the empty subtrees up to the limit
+	for depth := 0; depth < accumulatorDepth; depth++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[depth])
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+	root := zeroHashes[accumulatorDepth]
+	if len(layer) > 0 {
+		root = layer[0]
+	}
+	// Mix in the length of the list
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(hashes)))
+	return sha256.Sum256(append(root[:], length[:]...)), nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era/e2store"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+var (
+	// errBuilderFull is returned if the archive already holds the maximum
+	// number of blocks.
+	errBuilderFull = errors.New("exceeds maximum era1 size")
+
+	// errBuilderEmpty is returned if an archive without blocks is finalized.
+	errBuilderEmpty = errors.New("no blocks added")
+)
+
+// Builder is used to create Era1 archives of block data.
+//
+// The blocks must be added in order, starting with the first block of the
+// epoch. Once all blocks are added, Finalize must be called to write the
+// accumulator root and the block index.
+type Builder struct {
+	w       *e2store.Writer
+	start   *uint64       // number of the first block, nil if no block is added
+	indexes []uint64      // positions of the block tuples
+	hashes  []common.Hash // hashes of the added blocks
+	tds     []*big.Int    // total difficulties of the added blocks
+	written int           // number of bytes written so far
+
+	buf    *bytes.Buffer  // buffer for the compressed values
+	snappy *snappy.Writer // framed snappy encoder
+}
+
+// NewBuilder returns a new Builder writing the archive to w.
+func NewBuilder(w io.Writer) *Builder {
+	buf := bytes.NewBuffer(nil)
+	return &Builder{
+		w:      e2store.NewWriter(w),
+		buf:    buf,
+		snappy: snappy.NewBufferedWriter(buf),
+	}
+}
+
+// Add appends a block, its receipts and the total difficulty of the chain up to
+// and including the block to the archive.
+func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int) error {
+	header, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		return err
+	}
+	body, err := rlp.EncodeToBytes(block.Body())
+	if err != nil {
+		return err
+	}
+	rs, err := rlp.EncodeToBytes(receipts)
+	if err != nil {
+		return err
+	}
+	return b.AddRLP(header, body, rs, block.NumberU64(), block.Hash(), td)
+}
+
+// AddRLP appends an already RLP encoded block to the archive. The receipts must
+// be in their consensus encoding.
+func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash common.Hash, td *big.Int) error {
+	if len(b.indexes) >= MaxEra1Size {
+		return errBuilderFull
+	}
+	// Write the version entry before the first block
+	if b.start == nil {
+		n, err := b.w.Write(TypeVersion, nil)
+		if err != nil {
+			return err
+		}
+		b.written += n
+		b.start = &number
+	}
+	if want := *b.start + uint64(len(b.indexes)); number != want {
+		return fmt.Errorf("non-contiguous block: have %d, want %d", number, want)
+	}
+	tdBytes, err := encodeTotalDifficulty(td)
+	if err != nil {
+		return err
+	}
+	b.indexes = append(b.indexes, uint64(b.written))
+	b.hashes = append(b.hashes, hash)
+	b.tds = append(b.tds, new(big.Int).Set(td))
+
+	// Write the block tuple
+	if err := b.snappyWrite(TypeCompressedHeader, header); err != nil {
+		return err
+	}
+	if err := b.snappyWrite(TypeCompressedBody, body); err != nil {
+		return err
+	}
+	if err := b.snappyWrite(TypeCompressedReceipts, receipts); err != nil {
+		return err
+	}
+	n, err := b.w.Write(TypeTotalDifficulty, tdBytes)
+	b.written += n
+	return err
+}
+
+// Finalize writes the accumulator root and the block index to the archive,
+// returning the accumulator root.
+func (b *Builder) Finalize() (common.Hash, error) {
+	if b.start == nil {
+		return common.Hash{}, errBuilderEmpty
+	}
+	root, err := ComputeAccumulator(b.hashes, b.tds)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	n, err := b.w.Write(TypeAccumulator, root[:])
+	if err != nil {
+		return common.Hash{}, err
+	}
+	b.written += n
+
+	// Write the block index, the offsets are relative to the index position
+	var (
+		base  = int64(b.written)
+		count = len(b.indexes)
+		index = make([]byte, 16+count*8)
+	)
+	binary.LittleEndian.PutUint64(index, *b.start)
+	for i, offset := range b.indexes {
+		binary.LittleEndian.PutUint64(index[8+i*8:], uint64(int64(offset)-base))
+	}
+	binary.LittleEndian.PutUint64(index[8+count*8:], uint64(count))
+
+	n, err = b.w.Write(TypeBlockIndex, index)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	b.written += n
+	return root, nil
+}
+
+// snappyWrite compresses the value with framed snappy and writes it with the
+// given entry type.
+func (b *Builder) snappyWrite(typ uint16, value []byte) error {
+	b.buf.Reset()
+	b.snappy.Reset(b.buf)
+	if _, err := b.snappy.Write(value); err != nil {
+		return fmt.Errorf("error snappy encoding: %w", err)
+	}
+	if err := b.snappy.Flush(); err != nil {
+		return fmt.Errorf("error flushing snappy encoding: %w", err)
+	}
+	n, err := b.w.Write(typ, b.buf.Bytes())
+	b.written += n
+	return err
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package e2store implements the e2store container format, a simple
+// type-length-value encoding of consecutive entries.
+//
+// Each entry is prefixed by an 8 byte header:
+//
+//	type (2 bytes) | length (4 bytes, little endian) | reserved (2 bytes, zero)
+package e2store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// headerSize is the size of the entry header in bytes.
+const headerSize = 8
+
+var (
+	// errReservedBytes is returned if the reserved bytes of an entry header
+	// are not zero.
+	errReservedBytes = errors.New("reserved bytes are non-zero")
+
+	// errTooLarge is returned if an entry is larger than the length field can
+	// represent.
+	errTooLarge = errors.New("entry value too large")
+)
+
+// Entry is a variable-length-data record in an e2store.
+type Entry struct {
+	Type  uint16
+	Value []byte
+}
+
+// Writer writes entries in the e2store format to an underlying writer.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single entry with the given type and value, returning the
+// number of bytes written including the header.
+func (w *Writer) Write(typ uint16, value []byte) (int, error) {
+	if uint64(len(value)) > uint64(^uint32(0)) {
+		return 0, errTooLarge
+	}
+	var header [headerSize]byte
+	binary.LittleEndian.PutUint16(header[0:2], typ)
+	binary.LittleEndian.PutUint32(header[2:6], uint32(len(value)))
+
+	n, err := w.w.Write(header[:])
+	if err != nil {
+		return n, err
+	}
+	m, err := w.w.Write(value)
+	return n + m, err
+}
+
+// Reader reads entries in the e2store format from a random access source.
+type Reader struct {
+	r io.ReaderAt
+}
+
+// NewReader returns a new Reader that reads from r.
+func NewReader(r io.ReaderAt) *Reader {
+	return &Reader{r: r}
+}
+
+// ReadAt reads the entry located at the given offset, returning it along with
+// the total number of bytes it occupies including the header.
+func (r *Reader) ReadAt(off int64) (*Entry, int64, error) {
+	typ, length, err := r.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry := &Entry{Type: typ, Value: make([]byte, length)}
+	if length > 0 {
+		if _, err := r.r.ReadAt(entry.Value, off+headerSize); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, 0, err
+		}
+	}
+	return entry, headerSize + int64(length), nil
+}
+
+// ReaderAt returns an io.Reader for the value of the entry located at the given
+// offset, along with the total number of bytes the entry occupies including the
+// header. The value itself is not loaded.
+func (r *Reader) ReaderAt(expectType uint16, off int64) (io.Reader, int64, error) {
+	typ, length, err := r.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ != expectType {
+		return nil, 0, fmt.Errorf("wrong entry type at %d: have %#x, want %#x", off, typ, expectType)
+	}
+	return io.NewSectionReader(r.r, off+headerSize, int64(length)), headerSize + int64(length), nil
+}
+
+// ReadMetadataAt reads the header of the entry located at the given offset,
+// returning the entry type and the length of its value.
+func (r *Reader) ReadMetadataAt(off int64) (uint16, uint32, error) {
+	var header [headerSize]byte
+	if n, err := r.r.ReadAt(header[:], off); err != nil {
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, err
+	}
+	if header[6] != 0 || header[7] != 0 {
+		return 0, 0, errReservedBytes
+	}
+	return binary.LittleEndian.Uint16(header[0:2]), binary.LittleEndian.Uint32(header[2:6]), nil
+}
+
+// Find returns the first entry with the given type, searching from the given
+// offset. The io.EOF error is returned if no such entry exists.
+func (r *Reader) Find(want uint16, off int64) (*Entry, error) {
+	for {
+		typ, length, err := r.ReadMetadataAt(off)
+		if err != nil {
+			return nil, err
+		}
+		if typ == want {
+			entry, _, err := r.ReadAt(off)
+			return entry, err
+		}
+		off += headerSize + int64(length)
+	}
+}
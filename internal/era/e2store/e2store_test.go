@@ -0,0 +1,117 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package e2store
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestEncode(t *testing.T) {
+	for _, test := range []struct {
+		entries []Entry
+		want    string
+	}{
+		{
+			entries: []Entry{{0xffff, nil}},
+			want:    "ffff000000000000",
+		},
+		{
+			entries: []Entry{{42, common.Hex2Bytes("beef")}},
+			want:    "2a00020000000000beef",
+		},
+		{
+			entries: []Entry{
+				{42, common.Hex2Bytes("beef")},
+				{9, common.Hex2Bytes("abcdabcd")},
+			},
+			want: "2a00020000000000beef0900040000000000abcdabcd",
+		},
+	} {
+		var (
+			b bytes.Buffer
+			w = NewWriter(&b)
+		)
+		for _, e := range test.entries {
+			if _, err := w.Write(e.Type, e.Value); err != nil {
+				t.Fatalf("failed to write entry: %v", err)
+			}
+		}
+		if have := common.Bytes2Hex(b.Bytes()); have != test.want {
+			t.Fatalf("encoding mismatch: have %s, want %s", have, test.want)
+		}
+		// Read the entries back
+		var (
+			r   = NewReader(bytes.NewReader(b.Bytes()))
+			off int64
+		)
+		for i, want := range test.entries {
+			entry, n, err := r.ReadAt(off)
+			if err != nil {
+				t.Fatalf("entry %d: failed to read: %v", i, err)
+			}
+			if entry.Type != want.Type || !bytes.Equal(entry.Value, want.Value) {
+				t.Fatalf("entry %d: mismatch: have %x:%x, want %x:%x", i, entry.Type, entry.Value, want.Type, want.Value)
+			}
+			off += n
+		}
+		if _, _, err := r.ReadAt(off); err != io.EOF {
+			t.Fatalf("unexpected error at the end: %v", err)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, test := range []struct {
+		have string
+		err  error
+	}{
+		{"ffff000000000001", errReservedBytes},
+		{"2a00020000000000be", io.ErrUnexpectedEOF},
+		{"2a000200", io.ErrUnexpectedEOF},
+	} {
+		r := NewReader(bytes.NewReader(common.Hex2Bytes(test.have)))
+		if _, _, err := r.ReadAt(0); err != test.err {
+			t.Fatalf("input %s: unexpected error: have %v, want %v", test.have, err, test.err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	var (
+		b bytes.Buffer
+		w = NewWriter(&b)
+	)
+	w.Write(1, []byte{1})
+	w.Write(2, []byte{2})
+	w.Write(3, []byte{3})
+
+	r := NewReader(bytes.NewReader(b.Bytes()))
+	entry, err := r.Find(3, 0)
+	if err != nil {
+		t.Fatalf("failed to find entry: %v", err)
+	}
+	if !bytes.Equal(entry.Value, []byte{3}) {
+		t.Fatalf("wrong entry value: %x", entry.Value)
+	}
+	if _, err := r.Find(4, 0); err != io.EOF {
+		t.Fatalf("unexpected error for missing entry: %v", err)
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package era implements the Era1 history archive format. An Era1 file is an
+// e2store container holding the headers, bodies, receipts and total difficulties
+// of a fixed-size range of blocks (an epoch), followed by an accumulator root
+// committing to the block hashes and total difficulties and by an index for
+// random access:
+//
+//	era1 := Version | block-tuple* | Accumulator | BlockIndex
+//	block-tuple := CompressedHeader | CompressedBody | CompressedReceipts | TotalDifficulty
+//
+// The headers, bodies and receipts are RLP encoded and compressed with the
+// framed snappy format.
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era/e2store"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// Entry types of the Era1 format.
+const (
+	TypeVersion            uint16 = 0x3265
+	TypeCompressedHeader   uint16 = 0x03
+	TypeCompressedBody     uint16 = 0x04
+	TypeCompressedReceipts uint16 = 0x05
+	TypeTotalDifficulty    uint16 = 0x06
+	TypeAccumulator        uint16 = 0x07
+	TypeBlockIndex         uint16 = 0x3266
+)
+
+// MaxEra1Size is the maximum number of blocks in an Era1 file.
+const MaxEra1Size = 8192
+
+var (
+	// errOutOfRange is returned if the requested block is not contained in the
+	// archive.
+	errOutOfRange = errors.New("block out of range")
+
+	// errInvalidIndex is returned if the block index of the archive is corrupted.
+	errInvalidIndex = errors.New("invalid block index")
+)
+
+// Filename returns a recognizable Era1-formatted file name for the specified
+// network, epoch and accumulator root.
+func Filename(network string, epoch int, root common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%s.era1", network, epoch, root.Hex()[2:10])
+}
+
+// ReadDir reads all the Era1 files of the given network in a directory,
+// returning their names ordered by epoch. An error is returned if the epochs
+// are not contiguous.
+func ReadDir(dir, network string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+	var (
+		next  uint64
+		names []string
+	)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".era1" {
+			continue
+		}
+		parts := strings.Split(entry.Name(), "-")
+		if len(parts) != 3 || parts[0] != network {
+			continue // Invalid era1 filename or another network, skip
+		}
+		epoch, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed era1 filename: %s", entry.Name())
+		}
+		if len(names) > 0 && epoch != next {
+			return nil, fmt.Errorf("missing epoch %d", next)
+		}
+		next = epoch + 1
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// ReadAtSeekCloser is the file interface required to access an archive.
+type ReadAtSeekCloser interface {
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
+// Era reads an Era1 file.
+type Era struct {
+	f      ReadAtSeekCloser // the underlying file
+	s      *e2store.Reader  // the e2store reader
+	start  uint64           // number of the first block
+	count  uint64           // number of the blocks in the archive
+	length int64            // total size of the file
+}
+
+// Open opens the Era1 file with the given name.
+func Open(filename string) (*Era, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	e, err := From(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// From returns an Era backed by the given file, which is closed along with
+// the archive.
+func From(f ReadAtSeekCloser) (*Era, error) {
+	length, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	e := &Era{f: f, s: e2store.NewReader(f), length: length}
+	if err := e.loadIndex(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// loadIndex reads the position of the block index from the tail of the file.
+//
+//	BlockIndex := header | starting-number | offset* | count
+func (e *Era) loadIndex() error {
+	if e.length < 8 {
+		return errInvalidIndex
+	}
+	var buf [8]byte
+	if _, err := e.f.ReadAt(buf[:], e.length-8); err != nil {
+		return err
+	}
+	e.count = binary.LittleEndian.Uint64(buf[:])
+	if e.count == 0 || e.count > MaxEra1Size || int64(e.count*8+24) > e.length {
+		return errInvalidIndex
+	}
+	typ, _, err := e.s.ReadMetadataAt(e.indexOffset())
+	if err != nil {
+		return err
+	}
+	if typ != TypeBlockIndex {
+		return errInvalidIndex
+	}
+	if _, err := e.f.ReadAt(buf[:], e.indexOffset()+8); err != nil {
+		return err
+	}
+	e.start = binary.LittleEndian.Uint64(buf[:])
+	return nil
+}
+
+// indexOffset returns the position of the block index entry in the file.
+func (e *Era) indexOffset() int64 {
+	return e.length - int64(e.count*8+24)
+}
+
+// Close closes the underlying file.
+func (e *Era) Close() error {
+	return e.f.Close()
+}
+
+// Start returns the number of the first block in the archive.
+func (e *Era) Start() uint64 {
+	return e.start
+}
+
+// Count returns the number of blocks in the archive.
+func (e *Era) Count() uint64 {
+	return e.count
+}
+
+// blockOffset returns the position of the header entry of the given block.
+func (e *Era) blockOffset(number uint64) (int64, error) {
+	if number < e.start || number >= e.start+e.count {
+		return 0, errOutOfRange
+	}
+	var buf [8]byte
+	if _, err := e.f.ReadAt(buf[:], e.indexOffset()+16+int64(number-e.start)*8); err != nil {
+		return 0, err
+	}
+	off := e.indexOffset() + int64(binary.LittleEndian.Uint64(buf[:]))
+	if off < 0 || off >= e.indexOffset() {
+		return 0, errInvalidIndex
+	}
+	return off, nil
+}
+
+// readEntry reads the n-th entry of the given block tuple, returning the value
+// decompressed if it's a snappy encoded one.
+func (e *Era) readEntry(number uint64, n int, typ uint16) ([]byte, error) {
+	off, err := e.blockOffset(number)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		_, length, err := e.s.ReadMetadataAt(off)
+		if err != nil {
+			return nil, err
+		}
+		off += 8 + int64(length)
+	}
+	r, _, err := e.s.ReaderAt(typ, off)
+	if err != nil {
+		return nil, err
+	}
+	if typ == TypeTotalDifficulty {
+		return io.ReadAll(r)
+	}
+	return io.ReadAll(snappy.NewReader(r))
+}
+
+// GetRawHeaderByNumber returns the RLP encoded header with the given number.
+func (e *Era) GetRawHeaderByNumber(number uint64) ([]byte, error) {
+	return e.readEntry(number, 0, TypeCompressedHeader)
+}
+
+// GetRawBodyByNumber returns the RLP encoded body of the block with the given
+// number.
+func (e *Era) GetRawBodyByNumber(number uint64) ([]byte, error) {
+	return e.readEntry(number, 1, TypeCompressedBody)
+}
+
+// GetRawReceiptsByNumber returns the RLP encoded receipts of the block with the
+// given number, in their consensus encoding.
+func (e *Era) GetRawReceiptsByNumber(number uint64) ([]byte, error) {
+	return e.readEntry(number, 2, TypeCompressedReceipts)
+}
+
+// GetHeaderByNumber returns the header with the given number.
+func (e *Era) GetHeaderByNumber(number uint64) (*types.Header, error) {
+	data, err := e.GetRawHeaderByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(data, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// GetBlockByNumber returns the block with the given number.
+func (e *Era) GetBlockByNumber(number uint64) (*types.Block, error) {
+	header, err := e.GetHeaderByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	data, err := e.GetRawBodyByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	body := new(types.Body)
+	if err := rlp.DecodeBytes(data, body); err != nil {
+		return nil, err
+	}
+	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles).WithWithdrawals(body.Withdrawals), nil
+}
+
+// GetReceiptsByNumber returns the receipts of the block with the given number.
+func (e *Era) GetReceiptsByNumber(number uint64) (types.Receipts, error) {
+	data, err := e.GetRawReceiptsByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	var receipts types.Receipts
+	if err := rlp.DecodeBytes(data, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// GetTotalDifficultyByNumber returns the total difficulty of the chain up to
+// and including the block with the given number.
+func (e *Era) GetTotalDifficultyByNumber(number uint64) (*big.Int, error) {
+	data, err := e.readEntry(number, 3, TypeTotalDifficulty)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTotalDifficulty(data)
+}
+
+// InitialTD returns the total difficulty of the chain before the first block
+// of the archive.
+func (e *Era) InitialTD() (*big.Int, error) {
+	header, err := e.GetHeaderByNumber(e.start)
+	if err != nil {
+		return nil, err
+	}
+	td, err := e.GetTotalDifficultyByNumber(e.start)
+	if err != nil {
+		return nil, err
+	}
+	return td.Sub(td, header.Difficulty), nil
+}
+
+// Accumulator returns the accumulator root stored in the archive.
+func (e *Era) Accumulator() (common.Hash, error) {
+	off, err := e.blockOffset(e.start + e.count - 1)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	entry, err := e.s.Find(TypeAccumulator, off)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(entry.Value) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid accumulator length %d", len(entry.Value))
+	}
+	return common.BytesToHash(entry.Value), nil
+}
+
+// Verify recomputes the accumulator root from the archived headers and total
+// difficulties, checking it against the stored one.
+func (e *Era) Verify() error {
+	want, err := e.Accumulator()
+	if err != nil {
+		return err
+	}
+	var (
+		hashes = make([]common.Hash, 0, e.count)
+		tds    = make([]*big.Int, 0, e.count)
+	)
+	for number := e.start; number < e.start+e.count; number++ {
+		header, err := e.GetHeaderByNumber(number)
+		if err != nil {
+			return err
+		}
+		if header.Number.Uint64() != number {
+			return fmt.Errorf("header number mismatch: have %d, want %d", header.Number, number)
+		}
+		td, err := e.GetTotalDifficultyByNumber(number)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, header.Hash())
+		tds = append(tds, td)
+	}
+	have, err := ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return err
+	}
+	if have != want {
+		return fmt.Errorf("accumulator mismatch: have %x, want %x", have, want)
+	}
+	return nil
+}
+
+// encodeTotalDifficulty encodes the total difficulty as a little endian 256
+// bit integer.
+func encodeTotalDifficulty(td *big.Int) ([]byte, error) {
+	if td.Sign() < 0 || td.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid total difficulty %v", td)
+	}
+	buf := td.FillBytes(make([]byte, 32))
+	reverse(buf)
+	return buf, nil
+}
+
+// decodeTotalDifficulty decodes a little endian 256 bit total difficulty.
+func decodeTotalDifficulty(data []byte) (*big.Int, error) {
+	if len(data) != 32 {
+		return nil, fmt.Errorf("invalid total difficulty length %d", len(data))
+	}
+	buf := common.CopyBytes(data)
+	reverse(buf)
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// reverse reverses the given byte slice in place.
+func reverse(buf []byte) {
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"hash"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/crypto/sha3"
+)
+
+// testHasher is the helper tool for transaction/receipt list hashing.
+// The original hasher is trie, in order to get rid of import cycle,
+// use the testing hasher instead.
+type testHasher struct {
+	hasher hash.Hash
+}
+
+func newHasher() *testHasher {
+	return &testHasher{hasher: sha3.NewLegacyKeccak256()}
+}
+
+func (h *testHasher) Reset() {
+	h.hasher.Reset()
+}
+
+func (h *testHasher) Update(key, val []byte) {
+	h.hasher.Write(key)
+	h.hasher.Write(val)
+}
+
+func (h *testHasher) Hash() common.Hash {
+	return common.BytesToHash(h.hasher.Sum(nil))
+}
+
+// makeTestBlocks creates a contiguous list of blocks with a transaction and a
+// receipt each, starting at the given number. The last block is a post-Shanghai
+// block carrying a withdrawal.
+func makeTestBlocks(start uint64, n int) ([]*types.Block, []types.Receipts, []*big.Int) {
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		tds      []*big.Int
+		td       = big.NewInt(1000)
+	)
+	for i := 0; i < n; i++ {
+		number := start + uint64(i)
+		tx := types.NewTransaction(number, common.Address{0x01}, big.NewInt(int64(i)), 21000, big.NewInt(1), nil)
+		receipt := &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			Logs:              []*types.Log{{Address: common.Address{0x02}, Topics: []common.Hash{{0x03}}, Data: []byte{byte(i)}}},
+			TxHash:            tx.Hash(),
+			GasUsed:           21000,
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(int64(i + 1)),
+			GasLimit:   8_000_000,
+			Extra:      []byte("era"),
+		}
+		var block *types.Block
+		if i == n-1 {
+			withdrawals := []*types.Withdrawal{{Index: number, Validator: 1, Address: common.Address{0x04}, Amount: 32}}
+			block = types.NewBlockWithWithdrawals(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt}, withdrawals, newHasher())
+		} else {
+			block = types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt}, newHasher())
+		}
+		td = new(big.Int).Add(td, header.Difficulty)
+
+		blocks = append(blocks, block)
+		receipts = append(receipts, types.Receipts{receipt})
+		tds = append(tds, td)
+	}
+	return blocks, receipts, tds
+}
+
+func TestEra1Builder(t *testing.T) {
+	var (
+		f, _ = os.Create(filepath.Join(t.TempDir(), "test.era1"))
+		b    = NewBuilder(f)
+
+		blocks, receipts, tds = makeTestBlocks(128, 128)
+	)
+	for i, block := range blocks {
+		if err := b.Add(block, receipts[i], tds[i]); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+	}
+	root, err := b.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize: %v", err)
+	}
+	f.Close()
+
+	e, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+
+	if e.Start() != 128 || e.Count() != 128 {
+		t.Fatalf("range mismatch: have %d+%d, want 128+128", e.Start(), e.Count())
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("accumulator mismatch: have %x, want %x, err %v", have, root, err)
+	}
+	if err := e.Verify(); err != nil {
+		t.Fatalf("failed to verify archive: %v", err)
+	}
+	if td, err := e.InitialTD(); err != nil || td.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("initial total difficulty mismatch: have %v, want 1000, err %v", td, err)
+	}
+	// Random access on the blocks
+	for _, i := range []int{0, 1, 64, 127} {
+		block, err := e.GetBlockByNumber(blocks[i].NumberU64())
+		if err != nil {
+			t.Fatalf("failed to read block %d: %v", i, err)
+		}
+		if block.Hash() != blocks[i].Hash() || block.Transactions()[0].Hash() != blocks[i].Transactions()[0].Hash() {
+			t.Fatalf("block %d mismatch", i)
+		}
+		if want := block.Header().WithdrawalsHash; want != nil {
+			if len(block.Withdrawals()) != 1 || types.DeriveSha(block.Withdrawals(), newHasher()) != *want {
+				t.Fatalf("block %d: withdrawals mismatch", i)
+			}
+		} else if block.Withdrawals() != nil {
+			t.Fatalf("block %d: unexpected withdrawals", i)
+		}
+		rs, err := e.GetReceiptsByNumber(blocks[i].NumberU64())
+		if err != nil {
+			t.Fatalf("failed to read receipts %d: %v", i, err)
+		}
+		if types.DeriveSha(rs, newHasher()) != blocks[i].ReceiptHash() {
+			t.Fatalf("receipts %d mismatch", i)
+		}
+		td, err := e.GetTotalDifficultyByNumber(blocks[i].NumberU64())
+		if err != nil || td.Cmp(tds[i]) != 0 {
+			t.Fatalf("total difficulty %d mismatch: have %v, want %v, err %v", i, td, tds[i], err)
+		}
+	}
+	if _, err := e.GetBlockByNumber(127); err != errOutOfRange {
+		t.Fatalf("unexpected error for block below range: %v", err)
+	}
+	if _, err := e.GetBlockByNumber(256); err != errOutOfRange {
+		t.Fatalf("unexpected error for block above range: %v", err)
+	}
+	// Iterate over the entire archive
+	var (
+		it = NewIterator(e)
+		n  int
+	)
+	for it.Next() {
+		if it.Block().Hash() != blocks[n].Hash() || len(it.Block().Withdrawals()) != len(blocks[n].Withdrawals()) || it.TotalDifficulty().Cmp(tds[n]) != 0 || len(it.Receipts()) != 1 {
+			t.Fatalf("iterated block %d mismatch", n)
+		}
+		n++
+	}
+	if it.Error() != nil || n != len(blocks) {
+		t.Fatalf("iteration failed, blocks %d, err %v", n, it.Error())
+	}
+}
+
+func TestEra1BuilderErrors(t *testing.T) {
+	b := NewBuilder(new(nopWriter))
+	if _, err := b.Finalize(); err != errBuilderEmpty {
+		t.Fatalf("unexpected error for empty archive: %v", err)
+	}
+	blocks, receipts, tds := makeTestBlocks(0, 3)
+	if err := b.Add(blocks[0], receipts[0], tds[0]); err != nil {
+		t.Fatalf("failed to add block: %v", err)
+	}
+	if err := b.Add(blocks[2], receipts[2], tds[2]); err == nil {
+		t.Fatal("non-contiguous block accepted")
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	// The root of the empty list is the empty tree root mixed with zero length
+	root, err := ComputeAccumulator(nil, nil)
+	if err != nil {
+		t.Fatalf("failed to compute accumulator: %v", err)
+	}
+	if want := sha256.Sum256(append(zeroHashes[accumulatorDepth][:], make([]byte, 32)...)); root != want {
+		t.Fatalf("empty accumulator mismatch: have %x, want %x", root, want)
+	}
+	// The accumulator commits to both the hashes and the total difficulties
+	hashes := []common.Hash{{0x01}, {0x02}}
+	a, _ := ComputeAccumulator(hashes, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	b, _ := ComputeAccumulator(hashes, []*big.Int{big.NewInt(1), big.NewInt(3)})
+	c, _ := ComputeAccumulator(hashes[:1], []*big.Int{big.NewInt(1)})
+	if a == b || a == c || b == c {
+		t.Fatal("accumulator collision")
+	}
+	if _, err := ComputeAccumulator(hashes, nil); err == nil {
+		t.Fatal("mismatching records accepted")
+	}
+}
+
+type nopWriter struct{}
+
+func (w *nopWriter) Write(p []byte) (int, error) { return len(p), nil }
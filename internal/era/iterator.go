@@ -0,0 +1,81 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Iterator walks over the blocks of an archive in order.
+type Iterator struct {
+	e    *Era
+	next uint64 // number of the next block to load
+
+	block    *types.Block
+	receipts types.Receipts
+	td       *big.Int
+	err      error
+}
+
+// NewIterator returns an iterator positioned before the first block of the
+// archive.
+func NewIterator(e *Era) *Iterator {
+	return &Iterator{e: e, next: e.Start()}
+}
+
+// Next loads the next block, returning false if the iteration is exhausted or
+// an error occurred.
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.next >= it.e.Start()+it.e.Count() {
+		it.block, it.receipts, it.td = nil, nil, nil
+		return false
+	}
+	if it.block, it.err = it.e.GetBlockByNumber(it.next); it.err != nil {
+		return false
+	}
+	if it.receipts, it.err = it.e.GetReceiptsByNumber(it.next); it.err != nil {
+		return false
+	}
+	if it.td, it.err = it.e.GetTotalDifficultyByNumber(it.next); it.err != nil {
+		return false
+	}
+	it.next++
+	return true
+}
+
+// Block returns the current block.
+func (it *Iterator) Block() *types.Block {
+	return it.block
+}
+
+// Receipts returns the receipts of the current block.
+func (it *Iterator) Receipts() types.Receipts {
+	return it.receipts
+}
+
+// TotalDifficulty returns the total difficulty of the chain up to and including
+// the current block.
+func (it *Iterator) TotalDifficulty() *big.Int {
+	return it.td
+}
+
+// Error returns the error occurred during the iteration, if any.
+func (it *Iterator) Error() error {
+	return it.err
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -72,8 +73,13 @@ func (s *EthereumAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 }
 
 // MaxPriorityFeePerGas returns a suggestion for a gas tip cap for dynamic fee transactions.
-func (s *EthereumAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
-	tipcap, err := s.b.SuggestGasTipCap(ctx)
+// The optional urgency ("low", "medium" or "high") trades inclusion speed for cost.
+func (s *EthereumAPI) MaxPriorityFeePerGas(ctx context.Context, urgency *gasprice.Urgency) (*hexutil.Big, error) {
+	level := gasprice.UrgencyMedium
+	if urgency != nil {
+		level = *urgency
+	}
+	tipcap, err := s.b.SuggestGasTipCapWithUrgency(ctx, level)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +121,56 @@ func (s *EthereumAPI) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHe
 	return results, nil
 }
 
+type feeTierResult struct {
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	InclusionProbability float64         `json:"inclusionProbability"`
+	ExpectedBlocks       *hexutil.Uint64 `json:"expectedBlocks"`
+}
+
+type feeEstimateResult struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BaseFee     *hexutil.Big   `json:"baseFeePerGas"`
+	Slow        *feeTierResult `json:"slow"`
+	Standard    *feeTierResult `json:"standard"`
+	Fast        *feeTierResult `json:"fast"`
+}
+
+// EstimateFees returns slow, standard and fast fee suggestions for dynamic fee
+// transactions, each with the share of recent blocks that would have included
+// it and the expected number of blocks until inclusion given the pending pool.
+// The expected block count is null if no recent block would have included it.
+func (s *EthereumAPI) EstimateFees(ctx context.Context) (*feeEstimateResult, error) {
+	estimate, err := s.b.EstimateFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &feeEstimateResult{
+		BlockNumber: hexutil.Uint64(estimate.Number),
+		BaseFee:     (*hexutil.Big)(estimate.BaseFee),
+	}
+	for _, tier := range estimate.Tiers {
+		res := &feeTierResult{
+			MaxFeePerGas:         (*hexutil.Big)(tier.MaxFeePerGas),
+			MaxPriorityFeePerGas: (*hexutil.Big)(tier.MaxPriorityFeePerGas),
+			InclusionProbability: tier.InclusionProbability,
+		}
+		if tier.ExpectedBlocks > 0 {
+			blocks := hexutil.Uint64(tier.ExpectedBlocks)
+			res.ExpectedBlocks = &blocks
+		}
+		switch tier.Urgency {
+		case gasprice.UrgencyLow:
+			result.Slow = res
+		case gasprice.UrgencyMedium:
+			result.Standard = res
+		case gasprice.UrgencyHigh:
+			result.Fast = res
+		}
+	}
+	return result, nil
+}
+
 // Syncing returns false in case the node is currently not syncing with the network. It can be up to date or has not
 // yet received the latest block headers from its pears. In case it is synchronizing:
 // - startingBlock: block number this node started to synchronise from
@@ -1204,6 +1260,12 @@ func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	if head.BaseFee != nil {
 		result["baseFeePerGas"] = (*hexutil.Big)(head.BaseFee)
 	}
+	if head.WithdrawalsHash != nil {
+		result["withdrawalsRoot"] = head.WithdrawalsHash
+	}
+	if head.ExcessDataGas != nil {
+		result["excessDataGas"] = (*hexutil.Big)(head.ExcessDataGas)
+	}
 
 	return result
 }
@@ -1240,7 +1302,9 @@ func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool, config *param
 		uncleHashes[i] = uncle.Hash()
 	}
 	fields["uncles"] = uncleHashes
-
+	if block.Header().WithdrawalsHash != nil {
+		fields["withdrawals"] = block.Withdrawals()
+	}
 	return fields, nil
 }
 
@@ -1286,6 +1350,9 @@ type RPCTransaction struct {
 	V                *hexutil.Big      `json:"v"`
 	R                *hexutil.Big      `json:"r"`
 	S                *hexutil.Big      `json:"s"`
+
+	MaxFeePerDataGas    *hexutil.Big  `json:"maxFeePerDataGas,omitempty"`
+	BlobVersionedHashes []common.Hash `json:"blobVersionedHashes,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1323,7 +1390,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		al := tx.AccessList()
 		result.Accesses = &al
 		result.ChainID = (*hexutil.Big)(tx.ChainId())
-	case types.DynamicFeeTxType:
+	case types.DynamicFeeTxType, types.BlobTxType:
 		al := tx.AccessList()
 		result.Accesses = &al
 		result.ChainID = (*hexutil.Big)(tx.ChainId())
@@ -1337,6 +1404,10 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		} else {
 			result.GasPrice = (*hexutil.Big)(tx.GasFeeCap())
 		}
+		if tx.Type() == types.BlobTxType {
+			result.MaxFeePerDataGas = (*hexutil.Big)(tx.DataGasFeeCap())
+			result.BlobVersionedHashes = tx.DataHashes()
+		}
 	}
 	return result
 }
@@ -1602,6 +1673,10 @@ func (s *TransactionAPI) GetRawTransactionByHash(ctx context.Context, hash commo
 func (s *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
 	if err != nil {
+		// The transaction is known, but its history is pruned locally
+		if errors.Is(err, core.ErrHistoryPruned) {
+			return nil, err
+		}
 		// When the transaction doesn't exist, the RPC method should return JSON null
 		// as per specification.
 		return nil, nil
@@ -0,0 +1,446 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single request.
+	maxSimulateBlocks = 256
+
+	// simulateBlockTime is the timestamp increment of simulated blocks whose
+	// time is not overridden.
+	simulateBlockTime = 12
+
+	// errCodeSimulateReverted and errCodeSimulateVMError are the error codes of
+	// calls reverted by the contract and aborted by the EVM respectively.
+	errCodeSimulateReverted = 3
+	errCodeSimulateVMError  = -32015
+)
+
+var (
+	// transferAddress is the pseudo contract emitting the ETH transfer logs.
+	transferAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+	// transferTopic is the topic of the ETH transfer logs, matching the ERC-20
+	// Transfer event.
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	errSimulateNoBlocks   = errors.New("empty block list")
+	errSimulateTooMany    = fmt.Errorf("too many blocks, at most %d allowed", maxSimulateBlocks)
+	errSimulateBlockOrder = errors.New("block numbers and timestamps must be increasing")
+)
+
+// SimBlock is a simulated block, executing its calls in order on top of the
+// state left by the preceding blocks.
+type SimBlock struct {
+	BlockOverrides *BlockOverrides   `json:"blockOverrides"`
+	StateOverrides *StateOverride    `json:"stateOverrides"`
+	Calls          []TransactionArgs `json:"calls"`
+}
+
+// SimOpts are the inputs to eth_simulate.
+type SimOpts struct {
+	BlockStateCalls []SimBlock `json:"blockStateCalls"`
+	TraceTransfers  bool       `json:"traceTransfers"`
+}
+
+// simCallError is the failure of a simulated call.
+type simCallError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+// simCallResult is the outcome of a simulated call.
+type simCallResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []*types.Log   `json:"logs"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Status     hexutil.Uint64 `json:"status"`
+	Error      *simCallError  `json:"error,omitempty"`
+}
+
+// simBlockResult is the outcome of a simulated block.
+type simBlockResult struct {
+	Number       hexutil.Uint64   `json:"number"`
+	Hash         common.Hash      `json:"hash"`
+	ParentHash   common.Hash      `json:"parentHash"`
+	Timestamp    hexutil.Uint64   `json:"timestamp"`
+	GasLimit     hexutil.Uint64   `json:"gasLimit"`
+	GasUsed      hexutil.Uint64   `json:"gasUsed"`
+	FeeRecipient common.Address   `json:"miner"`
+	BaseFee      *hexutil.Big     `json:"baseFeePerGas,omitempty"`
+	Calls        []*simCallResult `json:"calls"`
+}
+
+// MakeHeader returns a copy of the given header with the overrides applied.
+func (diff *BlockOverrides) MakeHeader(header *types.Header) *types.Header {
+	header = types.CopyHeader(header)
+	if diff == nil {
+		return header
+	}
+	if diff.Number != nil {
+		header.Number = diff.Number.ToInt()
+	}
+	if diff.Difficulty != nil {
+		header.Difficulty = diff.Difficulty.ToInt()
+	}
+	if diff.Time != nil {
+		header.Time = diff.Time.ToInt().Uint64()
+	}
+	if diff.GasLimit != nil {
+		header.GasLimit = uint64(*diff.GasLimit)
+	}
+	if diff.Coinbase != nil {
+		header.Coinbase = *diff.Coinbase
+	}
+	if diff.Random != nil {
+		header.MixDigest = *diff.Random
+	}
+	if diff.BaseFee != nil {
+		header.BaseFee = diff.BaseFee.ToInt()
+	}
+	return header
+}
+
+// Simulate executes a sequence of blocks, each consisting of a list of calls,
+// on top of the given block. State changes carry over from call to call and
+// from block to block, allowing multi-step interactions to be previewed. Each
+// block may override header fields and account states before its calls run.
+//
+// Failing calls don't abort the simulation, their error is reported in their
+// result instead. Calls that couldn't be included in a block at all (e.g. due
+// to an insufficient balance or the block gas limit) fail the whole request.
+//
+// If transfer tracing is enabled, ETH transfers are reported as ERC-20 style
+// Transfer logs emitted by 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE.
+func (s *BlockChainAPI) Simulate(ctx context.Context, opts SimOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]*simBlockResult, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, errSimulateNoBlocks
+	}
+	if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, errSimulateTooMany
+	}
+	if blockNrOrHash == nil {
+		n := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &n
+	}
+	state, parent, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Setup a context cancelling the simulation once the timeout is reached
+	var cancel context.CancelFunc
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	sim := &simulator{
+		b:              s.b,
+		state:          state,
+		traceTransfers: opts.TraceTransfers,
+		gasCap:         s.b.RPCGasCap(),
+	}
+	results := make([]*simBlockResult, 0, len(opts.BlockStateCalls))
+	for _, block := range opts.BlockStateCalls {
+		header, err := sim.makeHeader(parent, block.BlockOverrides)
+		if err != nil {
+			return nil, err
+		}
+		result, err := sim.processBlock(ctx, header, &block)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		parent = header
+	}
+	return results, nil
+}
+
+// simulator executes the simulated blocks on a shared state.
+type simulator struct {
+	b              Backend
+	state          *state.StateDB
+	traceTransfers bool
+	gasCap         uint64
+}
+
+// makeHeader creates the header of a simulated block following parent. Header
+// fields not overridden are derived from the parent.
+func (sim *simulator) makeHeader(parent *types.Header, overrides *BlockOverrides) (*types.Header, error) {
+	header := overrides.MakeHeader(&types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Difficulty: parent.Difficulty,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + simulateBlockTime,
+		MixDigest:  parent.MixDigest,
+	})
+	if header.Number.Cmp(parent.Number) <= 0 || header.Time <= parent.Time {
+		return nil, fmt.Errorf("%w: block %d (time %d) follows block %d (time %d)", errSimulateBlockOrder, header.Number, header.Time, parent.Number, parent.Time)
+	}
+	if header.BaseFee == nil && sim.b.ChainConfig().IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(sim.b.ChainConfig(), parent)
+	}
+	return header, nil
+}
+
+// processBlock applies the state overrides of a simulated block and executes
+// its calls in order, finalizing the header once all of them ran.
+func (sim *simulator) processBlock(ctx context.Context, header *types.Header, block *SimBlock) (*simBlockResult, error) {
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, err
+	}
+	var (
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		results = make([]*simCallResult, 0, len(block.Calls))
+		logs    []*types.Log
+		evm     *vm.EVM
+		vmError func() error
+		tracer  *simTracer
+	)
+	for i, args := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Calls without a gas limit may use up the remaining block gas
+		if args.Gas == nil {
+			gas := hexutil.Uint64(gp.Gas())
+			args.Gas = &gas
+		}
+		msg, err := args.ToMessage(sim.gasCap, header.BaseFee)
+		if err != nil {
+			return nil, err
+		}
+		if evm == nil {
+			config := &vm.Config{NoBaseFee: true}
+			if sim.traceTransfers {
+				tracer = new(simTracer)
+				config.Debug, config.Tracer = true, tracer
+			}
+			if evm, vmError, err = sim.b.GetEVM(ctx, msg, sim.state, header, config); err != nil {
+				return nil, err
+			}
+			// Abort the running call once the simulation is cancelled
+			go func(evm *vm.EVM) {
+				<-ctx.Done()
+				evm.Cancel()
+			}(evm)
+		} else {
+			evm.Reset(core.NewEVMTxContext(msg), sim.state)
+		}
+		// Calls are not real transactions, identify them by their position
+		txHash := simCallHash(header.Number.Uint64(), i)
+		sim.state.Prepare(txHash, i)
+		if tracer != nil {
+			tracer.reset(txHash, i)
+		}
+		result, err := core.ApplyMessage(evm, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", sim.b.RPCEVMTimeout())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		sim.state.Finalise(true)
+		header.GasUsed += result.UsedGas
+
+		res := &simCallResult{
+			ReturnData: result.Return(),
+			GasUsed:    hexutil.Uint64(result.UsedGas),
+			Status:     hexutil.Uint64(types.ReceiptStatusSuccessful),
+		}
+		if tracer != nil {
+			res.Logs = tracer.logs
+		} else {
+			res.Logs = sim.state.GetLogs(txHash, common.Hash{})
+		}
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		if result.Failed() {
+			res.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if len(result.Revert()) > 0 {
+				revert := newRevertError(result)
+				res.Error = &simCallError{Code: errCodeSimulateReverted, Message: revert.Error(), Data: revert.reason}
+			} else {
+				res.Error = &simCallError{Code: errCodeSimulateVMError, Message: result.Err.Error()}
+			}
+		}
+		logs = append(logs, res.Logs...)
+		results = append(results, res)
+	}
+	// All calls executed, seal the block and point the logs to it
+	hash := header.Hash()
+	for i, l := range logs {
+		l.BlockNumber, l.BlockHash, l.Index = header.Number.Uint64(), hash, uint(i)
+	}
+	res := &simBlockResult{
+		Number:       hexutil.Uint64(header.Number.Uint64()),
+		Hash:         hash,
+		ParentHash:   header.ParentHash,
+		Timestamp:    hexutil.Uint64(header.Time),
+		GasLimit:     hexutil.Uint64(header.GasLimit),
+		GasUsed:      hexutil.Uint64(header.GasUsed),
+		FeeRecipient: header.Coinbase,
+		Calls:        results,
+	}
+	if header.BaseFee != nil {
+		res.BaseFee = (*hexutil.Big)(header.BaseFee)
+	}
+	log.Debug("Simulated block", "number", header.Number, "calls", len(results), "gas", header.GasUsed)
+	return res, nil
+}
+
+// simCallHash derives the pseudo transaction hash of a simulated call.
+func simCallHash(number uint64, index int) common.Hash {
+	var blob [16]byte
+	binary.BigEndian.PutUint64(blob[:8], number)
+	binary.BigEndian.PutUint64(blob[8:], uint64(index))
+	return crypto.Keccak256Hash(blob[:])
+}
+
+// simTracer collects the logs of a simulated call interleaved with pseudo logs
+// of its ETH transfers, discarding those of reverted call frames.
+type simTracer struct {
+	txHash common.Hash
+	txIdx  uint
+	logs   []*types.Log
+	frames []int // Number of logs collected when each active call frame started
+}
+
+// reset prepares the tracer for the next call.
+func (t *simTracer) reset(txHash common.Hash, txIdx int) {
+	t.txHash, t.txIdx = txHash, uint(txIdx)
+	t.logs, t.frames = nil, t.frames[:0]
+}
+
+func (t *simTracer) addLog(address common.Address, topics []common.Hash, data []byte) {
+	t.logs = append(t.logs, &types.Log{
+		Address: address,
+		Topics:  topics,
+		Data:    data,
+		TxHash:  t.txHash,
+		TxIndex: t.txIdx,
+	})
+}
+
+func (t *simTracer) addTransfer(from, to common.Address, value *big.Int) {
+	if value == nil || value.Sign() == 0 {
+		return
+	}
+	topics := []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())}
+	t.addLog(transferAddress, topics, common.BigToHash(value).Bytes())
+}
+
+func (t *simTracer) enter() {
+	t.frames = append(t.frames, len(t.logs))
+}
+
+func (t *simTracer) exit(err error) {
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil {
+		t.logs = t.logs[:start]
+	}
+}
+
+func (t *simTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *simTracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *simTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.enter()
+	t.addTransfer(from, to, value)
+}
+
+func (t *simTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	t.exit(err)
+}
+
+func (t *simTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.enter()
+	// Delegated calls run in the caller's context and move no funds
+	if typ != vm.DELEGATECALL && typ != vm.CALLCODE {
+		t.addTransfer(from, to, value)
+	}
+}
+
+func (t *simTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+func (t *simTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || op < vm.LOG0 || op > vm.LOG4 {
+		return
+	}
+	// Tracing happens before the memory is expanded for the log, so the data
+	// may reach past the current memory and has to be zero padded.
+	var (
+		stack  = scope.Stack
+		offset = stack.Back(0)
+		size   = stack.Back(1)
+		topics = make([]common.Hash, int(op-vm.LOG0))
+	)
+	for i := range topics {
+		topics[i] = stack.Back(2 + i).Bytes32()
+	}
+	t.addLog(scope.Contract.Address(), topics, paddedMemory(scope.Memory, offset.Uint64(), size.Uint64()))
+}
+
+// paddedMemory returns a copy of the given memory range, zero padding the
+// part beyond the current memory size.
+func paddedMemory(mem *vm.Memory, offset, size uint64) []byte {
+	if size == 0 {
+		return nil
+	}
+	data := make([]byte, size)
+	if offset < uint64(mem.Len()) {
+		copy(data, mem.Data()[offset:])
+	}
+	return data
+}
+
+func (t *simTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
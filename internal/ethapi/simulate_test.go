@@ -0,0 +1,159 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// simulateBackend is a mock backend executing calls on an in-memory state.
+type simulateBackend struct {
+	*backendMock
+	state *state.StateDB
+}
+
+func (b *simulateBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return b.state.Copy(), b.current, nil
+}
+
+func (b *simulateBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	context := core.NewEVMBlockContext(header, nil, &header.Coinbase)
+	return vm.NewEVM(context, core.NewEVMTxContext(msg), state, b.config, *vmConfig), func() error { return nil }, nil
+}
+
+func TestSimulate(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0x1000000000000000000000000000000000000000")
+		counter  = common.HexToAddress("0x2000000000000000000000000000000000000000")
+		reverter = common.HexToAddress("0x3000000000000000000000000000000000000000")
+		receiver = common.HexToAddress("0x4000000000000000000000000000000000000000")
+		logger   = common.HexToAddress("0x5000000000000000000000000000000000000000")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	// Counter incrementing slot 0, logging and returning the new value
+	statedb.SetCode(counter, common.FromHex("600054600101806000558060005260006000a160206000f3"))
+	// Reverter reverting with 0xdead
+	statedb.SetCode(reverter, common.FromHex("61dead60005260206000fd"))
+	// Logger storing 1 at 0 and logging 64 bytes, reaching past expanded memory
+	statedb.SetCode(logger, common.FromHex("600160005260406000a0"))
+	statedb.Finalise(true)
+
+	backend := &simulateBackend{backendMock: newBackendMock(), state: statedb}
+	api := NewBlockChainAPI(backend)
+
+	balance := (*hexutil.Big)(big.NewInt(1000))
+	value := (*hexutil.Big)(big.NewInt(100))
+	number := (*hexutil.Big)(big.NewInt(1200))
+
+	results, err := api.Simulate(context.Background(), SimOpts{
+		BlockStateCalls: []SimBlock{
+			{
+				StateOverrides: &StateOverride{sender: OverrideAccount{Balance: &balance}},
+				Calls: []TransactionArgs{
+					{From: &sender, To: &counter},
+					{From: &sender, To: &counter},
+					{From: &sender, To: &receiver, Value: value},
+				},
+			},
+			{
+				BlockOverrides: &BlockOverrides{Number: number},
+				Calls: []TransactionArgs{
+					{From: &sender, To: &counter},
+					{From: &sender, To: &reverter},
+					{From: &sender, To: &logger},
+				},
+			},
+		},
+		TraceTransfers: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("block count mismatch: have %d, want %d", len(results), 2)
+	}
+	// State must carry over between calls and blocks
+	for i, want := range []struct {
+		block, call int
+		value       uint64
+	}{{0, 0, 1}, {0, 1, 2}, {1, 0, 3}} {
+		res := results[want.block].Calls[want.call]
+		if have := new(big.Int).SetBytes(res.ReturnData).Uint64(); have != want.value {
+			t.Errorf("counter %d: return mismatch: have %d, want %d", i, have, want.value)
+		}
+		if len(res.Logs) != 1 || res.Logs[0].Topics[0] != common.BigToHash(new(big.Int).SetUint64(want.value)) {
+			t.Errorf("counter %d: log mismatch: %v", i, res.Logs)
+		}
+		if res.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || res.Error != nil {
+			t.Errorf("counter %d: unexpected failure: %v", i, res.Error)
+		}
+	}
+	// Value transfers must be reported as logs
+	transfer := results[0].Calls[2]
+	if len(transfer.Logs) != 1 {
+		t.Fatalf("transfer log count mismatch: have %d, want %d", len(transfer.Logs), 1)
+	}
+	if l := transfer.Logs[0]; l.Address != transferAddress || l.Topics[1] != common.BytesToHash(sender.Bytes()) || l.Topics[2] != common.BytesToHash(receiver.Bytes()) || new(big.Int).SetBytes(l.Data).Cmp(value.ToInt()) != 0 {
+		t.Errorf("transfer log mismatch: %v", l)
+	}
+	if l := transfer.Logs[0]; l.Index != 2 || l.BlockNumber != 1101 || l.BlockHash != results[0].Hash {
+		t.Errorf("transfer log position mismatch: index %d, block %d (%x)", l.Index, l.BlockNumber, l.BlockHash)
+	}
+	// Block overrides must be honored and blocks chained
+	if results[0].Number != 1101 || results[1].Number != 1200 || results[1].ParentHash != results[0].Hash {
+		t.Errorf("block chaining mismatch: %d -> %d (%x != %x)", results[0].Number, results[1].Number, results[1].ParentHash, results[0].Hash)
+	}
+	// Reverts must be reported with their data
+	revert := results[1].Calls[1]
+	if revert.Status != hexutil.Uint64(types.ReceiptStatusFailed) || revert.Error == nil {
+		t.Fatalf("revert not reported")
+	}
+	if revert.Error.Code != errCodeSimulateReverted || revert.Error.Data != hexutil.Encode(common.LeftPadBytes([]byte{0xde, 0xad}, 32)) {
+		t.Errorf("revert mismatch: %+v", revert.Error)
+	}
+	if len(revert.Logs) != 0 {
+		t.Errorf("reverted call logs not discarded: %v", revert.Logs)
+	}
+	// Logs reading unexpanded memory must be zero padded
+	logged := results[1].Calls[2]
+	if logged.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || len(logged.Logs) != 1 {
+		t.Fatalf("logger failed: %+v", logged.Error)
+	}
+	if want := append(common.LeftPadBytes([]byte{1}, 32), make([]byte, 32)...); !bytes.Equal(logged.Logs[0].Data, want) {
+		t.Errorf("log data mismatch: have %x, want %x", logged.Logs[0].Data, want)
+	}
+	// Block numbers must be increasing
+	_, err = api.Simulate(context.Background(), SimOpts{
+		BlockStateCalls: []SimBlock{{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(1100))}}},
+	}, nil)
+	if err == nil {
+		t.Errorf("non-increasing block number accepted")
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
@@ -253,6 +254,12 @@ func (b *backendMock) deactivateLondon() {
 func (b *backendMock) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return big.NewInt(42), nil
 }
+func (b *backendMock) SuggestGasTipCapWithUrgency(ctx context.Context, urgency gasprice.Urgency) (*big.Int, error) {
+	return big.NewInt(42), nil
+}
+func (b *backendMock) EstimateFees(ctx context.Context) (*gasprice.FeeEstimate, error) {
+	return nil, nil
+}
 func (b *backendMock) CurrentHeader() *types.Header     { return b.current }
 func (b *backendMock) ChainConfig() *params.ChainConfig { return b.config }
 
@@ -28,6 +28,7 @@ var Modules = map[string]string{
 	"personal": PersonalJs,
 	"rpc":      RpcJs,
 	"txpool":   TxpoolJs,
+	"trace":    TraceJs,
 	"les":      LESJs,
 	"vflux":    VfluxJs,
 }
@@ -436,6 +437,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceCallMany',
+			call: 'debug_traceCallMany',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -490,6 +497,21 @@ web3._extend({
 			call: 'debug_dbAncients',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'startStatePruning',
+			call: 'debug_startStatePruning',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'stopStatePruning',
+			call: 'debug_stopStatePruning',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'statePruningStatus',
+			call: 'debug_statePruningStatus',
+			params: 0
+		}),
 	],
 	properties: []
 });
@@ -595,6 +617,17 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'simulate',
+			call: 'eth_simulate',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'estimateFees',
+			call: 'eth_estimateFees',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'getLogs',
 			call: 'eth_getLogs',
@@ -756,10 +789,46 @@ web3._extend({
 });
 `
 
+const TraceJs = `
+web3._extend({
+	property: 'trace',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'block',
+			call: 'trace_block',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'transaction',
+			call: 'trace_transaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'filter',
+			call: 'trace_filter',
+			params: 1
+		}),
+	]
+});
+`
+
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'export',
+			call: 'txpool_export',
+		}),
+		new web3._extend.Method({
+			name: 'import',
+			call: 'txpool_import',
+			params: 1,
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -268,10 +268,18 @@ func (b *LesApiBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 	return b.gpo.SuggestTipCap(ctx)
 }
 
+func (b *LesApiBackend) SuggestGasTipCapWithUrgency(ctx context.Context, urgency gasprice.Urgency) (*big.Int, error) {
+	return b.gpo.SuggestTipCapWithUrgency(ctx, urgency)
+}
+
 func (b *LesApiBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (firstBlock *big.Int, reward [][]*big.Int, baseFee []*big.Int, gasUsedRatio []float64, err error) {
 	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
 }
 
+func (b *LesApiBackend) EstimateFees(ctx context.Context) (*gasprice.FeeEstimate, error) {
+	return b.gpo.EstimateFees(ctx)
+}
+
 func (b *LesApiBackend) ChainDb() ethdb.Database {
 	return b.eth.chainDb
 }
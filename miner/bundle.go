@@ -0,0 +1,282 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxBundles is the maximum number of bundles tracked by the bundle pool
+// across all target blocks.
+const maxBundles = 1024
+
+var (
+	// errBundleEmpty is returned if a bundle without transactions is submitted.
+	errBundleEmpty = errors.New("bundle contains no transactions")
+
+	// errBundleOutdated is returned if a bundle targets an already mined block.
+	errBundleOutdated = errors.New("bundle targets a past block")
+
+	// errBundleTimestamp is returned if a bundle has an empty timestamp range.
+	errBundleTimestamp = errors.New("bundle min timestamp above max timestamp")
+
+	// errBundlePoolFull is returned if the bundle pool cannot accept any more bundles.
+	errBundlePoolFull = errors.New("bundle pool is full")
+
+	// errBundleReverted is returned if a bundle transaction reverted without being
+	// explicitly allowed to do so.
+	errBundleReverted = errors.New("bundle transaction reverted")
+)
+
+var (
+	bundleAcceptMeter  = metrics.NewRegisteredMeter("miner/bundles/accept", nil)
+	bundleIncludeMeter = metrics.NewRegisteredMeter("miner/bundles/include", nil)
+	bundleRejectMeter  = metrics.NewRegisteredMeter("miner/bundles/reject", nil)
+)
+
+// Bundle is an ordered group of transactions that must be included together in
+// the target block, at the top of it, or not at all.
+type Bundle struct {
+	Txs               types.Transactions // Transactions to include, in order
+	BlockNumber       uint64             // Number of the block the bundle is targeting
+	MinTimestamp      uint64             // Minimum block timestamp to include in (0 = no limit)
+	MaxTimestamp      uint64             // Maximum block timestamp to include in (0 = no limit)
+	RevertingTxHashes []common.Hash      // Transactions permitted to revert without dropping the bundle
+}
+
+// Hash returns a unique identifier of the bundle, derived from the hashes of
+// the contained transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// canRevert returns whether the given transaction of the bundle is permitted to
+// revert without invalidating the entire bundle.
+func (b *Bundle) canRevert(hash common.Hash) bool {
+	for _, allowed := range b.RevertingTxHashes {
+		if allowed == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// simulatedBundle is a bundle that was successfully executed on top of the
+// pending state, along with the revenue it generates for the block producer.
+type simulatedBundle struct {
+	bundle  *Bundle
+	gasUsed uint64
+	profit  *big.Int // Coinbase balance increase, fees and direct payments included
+	price   *big.Int // Profit per unit of gas used, the bundle's effective gas price
+}
+
+// bundlePool tracks the bundles submitted to the miner until their target block
+// is mined.
+type bundlePool struct {
+	bundles map[common.Hash]*Bundle
+	lock    sync.Mutex
+}
+
+// newBundlePool creates an empty bundle pool.
+func newBundlePool() *bundlePool {
+	return &bundlePool{
+		bundles: make(map[common.Hash]*Bundle),
+	}
+}
+
+// add validates a bundle against the current chain head and inserts it into
+// the pool.
+func (p *bundlePool) add(bundle *Bundle, head uint64) error {
+	if len(bundle.Txs) == 0 {
+		return errBundleEmpty
+	}
+	if bundle.BlockNumber <= head {
+		return fmt.Errorf("%w: head %d, target %d", errBundleOutdated, head, bundle.BlockNumber)
+	}
+	if bundle.MaxTimestamp != 0 && bundle.MinTimestamp > bundle.MaxTimestamp {
+		return errBundleTimestamp
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prune(head)
+	if len(p.bundles) >= maxBundles {
+		return errBundlePoolFull
+	}
+	p.bundles[bundle.Hash()] = bundle
+	bundleAcceptMeter.Mark(1)
+	return nil
+}
+
+// pending returns all the bundles that can be included into a block with the
+// given number and timestamp. Bundles targeting earlier blocks are discarded.
+func (p *bundlePool) pending(number uint64, timestamp uint64) []*Bundle {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prune(number - 1)
+
+	var bundles []*Bundle
+	for _, bundle := range p.bundles {
+		if bundle.BlockNumber != number {
+			continue
+		}
+		if bundle.MinTimestamp != 0 && timestamp < bundle.MinTimestamp {
+			continue
+		}
+		if bundle.MaxTimestamp != 0 && timestamp > bundle.MaxTimestamp {
+			continue
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles
+}
+
+// prune drops all the bundles targeting blocks up to and including the given
+// one. The caller must hold the pool lock.
+func (p *bundlePool) prune(head uint64) {
+	for hash, bundle := range p.bundles {
+		if bundle.BlockNumber <= head {
+			delete(p.bundles, hash)
+		}
+	}
+}
+
+// simulateBundles executes each of the given bundles on top of the current
+// state of the sealing environment, discarding the ones which fail and sorting
+// the rest by their effective gas price, most profitable first.
+func (w *worker) simulateBundles(env *environment, bundles []*Bundle) []*simulatedBundle {
+	var simulated []*simulatedBundle
+	for _, bundle := range bundles {
+		sim, err := w.simulateBundle(env, bundle)
+		if err != nil {
+			log.Trace("Discarding failed bundle", "hash", bundle.Hash(), "err", err)
+			bundleRejectMeter.Mark(1)
+			continue
+		}
+		simulated = append(simulated, sim)
+	}
+	sort.SliceStable(simulated, func(i, j int) bool {
+		return simulated[i].price.Cmp(simulated[j].price) > 0
+	})
+	return simulated
+}
+
+// simulateBundle executes a bundle on a copy of the state of the sealing
+// environment and measures the revenue it generates.
+func (w *worker) simulateBundle(env *environment, bundle *Bundle) (*simulatedBundle, error) {
+	var (
+		state   = env.state.Copy()
+		gasPool = new(core.GasPool).AddGas(env.gasPool.Gas())
+		header  = types.CopyHeader(env.header)
+		before  = state.GetBalance(env.coinbase)
+	)
+	for i, tx := range bundle.Txs {
+		state.Prepare(tx.Hash(), env.tcount+i)
+
+		receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, gasPool, state, header, tx, &header.GasUsed, *w.chain.GetVMConfig())
+		if err != nil {
+			return nil, err
+		}
+		if receipt.Status == types.ReceiptStatusFailed && !bundle.canRevert(tx.Hash()) {
+			return nil, fmt.Errorf("%w: %v", errBundleReverted, tx.Hash())
+		}
+	}
+	var (
+		gasUsed = header.GasUsed - env.header.GasUsed
+		profit  = new(big.Int).Sub(state.GetBalance(env.coinbase), before)
+		price   = new(big.Int)
+	)
+	if gasUsed > 0 {
+		price.Div(profit, new(big.Int).SetUint64(gasUsed))
+	}
+	return &simulatedBundle{bundle: bundle, gasUsed: gasUsed, profit: profit, price: price}, nil
+}
+
+// commitBundles includes the given simulated bundles into the sealing block,
+// most profitable first. Since earlier bundles might change the state a later
+// one depends on, each bundle is re-executed and atomically rolled back if any
+// of its transactions fails.
+func (w *worker) commitBundles(env *environment, bundles []*simulatedBundle) {
+	for _, sim := range bundles {
+		if env.gasPool.Gas() < sim.gasUsed {
+			continue
+		}
+		if err := w.commitBundle(env, sim.bundle); err != nil {
+			log.Trace("Bundle inclusion failed", "hash", sim.bundle.Hash(), "err", err)
+			bundleRejectMeter.Mark(1)
+			continue
+		}
+		bundleIncludeMeter.Mark(1)
+	}
+}
+
+// commitBundle applies all the transactions of a bundle onto the sealing
+// environment, reverting every change if any of them fails.
+//
+// The journal is cleared after every applied transaction, so the state can't be
+// rolled back to a snapshot taken before the bundle. A copy of it is restored
+// instead.
+func (w *worker) commitBundle(env *environment, bundle *Bundle) error {
+	var (
+		state    = env.state.Copy()
+		gasPool  = *env.gasPool
+		gasUsed  = env.header.GasUsed
+		tcount   = env.tcount
+		txs      = len(env.txs)
+		receipts = len(env.receipts)
+		blobs    = env.blobs
+		sidecars = len(env.sidecars)
+	)
+	for _, tx := range bundle.Txs {
+		env.state.Prepare(tx.Hash(), env.tcount)
+
+		_, err := w.commitTransaction(env, tx)
+		if err == nil && env.receipts[len(env.receipts)-1].Status == types.ReceiptStatusFailed && !bundle.canRevert(tx.Hash()) {
+			err = fmt.Errorf("%w: %v", errBundleReverted, tx.Hash())
+		}
+		if err != nil {
+			env.state.StopPrefetcher()
+			env.state = state
+			*env.gasPool = gasPool
+			env.header.GasUsed = gasUsed
+			env.tcount = tcount
+			env.txs = env.txs[:txs]
+			env.receipts = env.receipts[:receipts]
+			env.blobs = blobs
+			env.sidecars = env.sidecars[:sidecars]
+			return err
+		}
+		env.tcount++
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the bundle pool rejects malformed bundles and only hands out the
+// ones matching the block being built.
+func TestBundlePool(t *testing.T) {
+	var (
+		pool = newBundlePool()
+		txs  = types.Transactions{pendingTxs[0]}
+	)
+	if err := pool.add(&Bundle{BlockNumber: 2}, 1); !errors.Is(err, errBundleEmpty) {
+		t.Fatalf("empty bundle error mismatch: have %v, want %v", err, errBundleEmpty)
+	}
+	if err := pool.add(&Bundle{Txs: txs, BlockNumber: 1}, 1); !errors.Is(err, errBundleOutdated) {
+		t.Fatalf("outdated bundle error mismatch: have %v, want %v", err, errBundleOutdated)
+	}
+	if err := pool.add(&Bundle{Txs: txs, BlockNumber: 2, MinTimestamp: 10, MaxTimestamp: 5}, 1); !errors.Is(err, errBundleTimestamp) {
+		t.Fatalf("timestamp bundle error mismatch: have %v, want %v", err, errBundleTimestamp)
+	}
+	if err := pool.add(&Bundle{Txs: txs, BlockNumber: 2, MinTimestamp: 10, MaxTimestamp: 20}, 1); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if err := pool.add(&Bundle{Txs: types.Transactions{newTxs[0]}, BlockNumber: 3}, 1); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if bundles := pool.pending(2, 5); len(bundles) != 0 {
+		t.Fatalf("bundle returned before its min timestamp")
+	}
+	if bundles := pool.pending(2, 15); len(bundles) != 1 {
+		t.Fatalf("pending bundle count mismatch: have %d, want %d", len(bundles), 1)
+	}
+	if bundles := pool.pending(3, 15); len(bundles) != 1 || bundles[0].BlockNumber != 3 {
+		t.Fatalf("pending bundle mismatch: have %v", bundles)
+	}
+	if len(pool.bundles) != 1 {
+		t.Fatalf("outdated bundles not pruned: have %d, want %d", len(pool.bundles), 1)
+	}
+}
+
+// Tests that bundles are included atomically at the top of the block, with the
+// most profitable ones preferred and reverting ones dropped unless allowed.
+func TestBundleInclusion(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		signer = types.LatestSigner(ethashChainConfig)
+		revert = common.FromHex("0x60006000fd") // PUSH1 0 PUSH1 0 REVERT
+	)
+	transfer := func(nonce uint64, price int64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &testUserAddress,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(price * params.InitialBaseFee),
+		})
+	}
+	creation := func(nonce uint64, price int64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			Value:    big.NewInt(0),
+			Gas:      100000,
+			GasPrice: big.NewInt(price * params.InitialBaseFee),
+			Data:     revert,
+		})
+	}
+	generate := func() *types.Block {
+		block, _, err := w.generateWork(&generateParams{
+			timestamp: uint64(time.Now().Unix()),
+			coinbase:  common.HexToAddress("0xc0ffee"),
+		})
+		if err != nil {
+			t.Fatalf("failed to generate block: %v", err)
+		}
+		return block
+	}
+	// A reverting bundle must be discarded in favour of a cheaper valid one
+	reverting := &Bundle{Txs: types.Transactions{creation(0, 20)}, BlockNumber: 1}
+	valid := &Bundle{Txs: types.Transactions{transfer(0, 10), transfer(1, 10)}, BlockNumber: 1}
+	for _, bundle := range []*Bundle{reverting, valid} {
+		if err := w.bundles.add(bundle, 0); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	block := generate()
+	if have := block.Transactions(); len(have) != 2 || have[0].Hash() != valid.Txs[0].Hash() || have[1].Hash() != valid.Txs[1].Hash() {
+		t.Fatalf("included transactions mismatch: have %v", have)
+	}
+	// Permitting the revert should make the more profitable bundle win, while
+	// dropping the conflicting one entirely
+	reverting.RevertingTxHashes = []common.Hash{reverting.Txs[0].Hash()}
+
+	block = generate()
+	if have := block.Transactions(); len(have) != 1 || have[0].Hash() != reverting.Txs[0].Hash() {
+		t.Fatalf("included transactions mismatch: have %v", have)
+	}
+}
+
+// Tests that a bundle whose later transaction fails while being committed is
+// rolled back entirely, even though the journal was cleared by the preceding
+// transactions of the bundle.
+func TestBundleCommitRollback(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	signer := types.LatestSigner(ethashChainConfig)
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &testUserAddress,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(10 * params.InitialBaseFee),
+		})
+	}
+	env, err := w.prepareWork(&generateParams{
+		timestamp: uint64(time.Now().Unix()),
+		coinbase:  common.HexToAddress("0xc0ffee"),
+	})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	defer env.discard()
+
+	env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	gas := env.gasPool.Gas()
+	if err := w.commitBundle(env, &Bundle{Txs: types.Transactions{transfer(0), transfer(5)}, BlockNumber: 1}); err == nil {
+		t.Fatalf("bundle with a nonce gap committed")
+	}
+	if len(env.txs) != 0 || len(env.receipts) != 0 || env.tcount != 0 {
+		t.Fatalf("failed bundle left transactions behind: %d txs, %d receipts, tcount %d", len(env.txs), len(env.receipts), env.tcount)
+	}
+	if env.gasPool.Gas() != gas || env.header.GasUsed != 0 {
+		t.Fatalf("failed bundle consumed gas: pool %d, want %d, used %d", env.gasPool.Gas(), gas, env.header.GasUsed)
+	}
+	if nonce := env.state.GetNonce(testBankAddress); nonce != 0 {
+		t.Fatalf("failed bundle state not reverted: nonce %d", nonce)
+	}
+	// The environment must remain usable for subsequent bundles
+	if err := w.commitBundle(env, &Bundle{Txs: types.Transactions{transfer(0), transfer(1)}, BlockNumber: 1}); err != nil {
+		t.Fatalf("failed to commit bundle: %v", err)
+	}
+	if len(env.txs) != 2 || env.state.GetNonce(testBankAddress) != 2 {
+		t.Fatalf("bundle commit mismatch: %d txs, nonce %d", len(env.txs), env.state.GetNonce(testBankAddress))
+	}
+}
@@ -53,6 +53,9 @@ type Config struct {
 	GasPrice   *big.Int       // Minimum gas price for mining a transaction
 	Recommit   time.Duration  // The time interval for miner to re-create mining work.
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+	Ordering   string         // Name of the transaction ordering strategy (greedy, fifo, revenue)
+
+	OrderingStrategy OrderingStrategy `toml:"-"` // Custom transaction ordering strategy, overriding Ordering
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -234,6 +237,13 @@ func (miner *Miner) DisablePreseal() {
 	miner.worker.disablePreseal()
 }
 
+// AddBundle submits a bundle of transactions to be included atomically at the
+// top of its target block. Bundles are simulated when the block is built and
+// the most profitable ones are picked.
+func (miner *Miner) AddBundle(bundle *Bundle) error {
+	return miner.worker.bundles.add(bundle, miner.eth.BlockChain().CurrentBlock().NumberU64())
+}
+
 // SubscribePendingLogs starts delivering logs from pending transactions
 // to the given channel.
 func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
@@ -245,8 +255,8 @@ func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscript
 // there is always a result that will be returned through the result channel.
 // The difference is that if the execution fails, the returned result is nil
 // and the concrete error is dropped silently.
-func (miner *Miner) GetSealingBlockAsync(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, noTxs bool) (chan *types.Block, error) {
-	resCh, _, err := miner.worker.getSealingBlock(parent, timestamp, coinbase, random, noTxs)
+func (miner *Miner) GetSealingBlockAsync(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, withdrawals types.Withdrawals, noTxs bool) (chan *SealingResult, error) {
+	resCh, _, err := miner.worker.getSealingBlock(parent, timestamp, coinbase, random, withdrawals, noTxs)
 	if err != nil {
 		return nil, err
 	}
@@ -256,8 +266,8 @@ func (miner *Miner) GetSealingBlockAsync(parent common.Hash, timestamp uint64, c
 // GetSealingBlockSync creates a sealing block according to the given parameters.
 // If the generation is failed or the underlying work is already closed, an error
 // will be returned.
-func (miner *Miner) GetSealingBlockSync(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, noTxs bool) (*types.Block, error) {
-	resCh, errCh, err := miner.worker.getSealingBlock(parent, timestamp, coinbase, random, noTxs)
+func (miner *Miner) GetSealingBlockSync(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, withdrawals types.Withdrawals, noTxs bool) (*SealingResult, error) {
+	resCh, errCh, err := miner.worker.getSealingBlock(parent, timestamp, coinbase, random, withdrawals, noTxs)
 	if err != nil {
 		return nil, err
 	}
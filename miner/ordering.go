@@ -0,0 +1,277 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"container/heap"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxRevenueSimulations is the maximum number of accounts whose pending
+// transactions the revenue maximising strategy simulates for each block. The
+// transactions of the remaining accounts are ordered by price after them.
+const maxRevenueSimulations = 512
+
+// TransactionSet is an ordered stream of transactions that respects the nonce
+// order of each account, consumed by the worker while filling a block.
+type TransactionSet interface {
+	// Peek returns the next transaction to include, or nil if none are left.
+	Peek() *types.Transaction
+
+	// Shift replaces the current best transaction with the next one from the
+	// same account.
+	Shift()
+
+	// Pop removes the best transaction along with all the subsequent ones from
+	// the same account, as they became unexecutable.
+	Pop()
+}
+
+// OrderingContext is the information about the block being built that is made
+// available to the ordering strategies.
+type OrderingContext struct {
+	Config   *params.ChainConfig // Chain configuration of the block being built
+	Chain    core.ChainContext   // Chain to retrieve ancestor headers from
+	Header   *types.Header       // Header of the block being built
+	State    *state.StateDB      // State the transactions are applied on, must not be modified
+	Signer   types.Signer        // Signer to recover the transaction senders with
+	VMConfig vm.Config           // EVM configuration to simulate transactions with
+}
+
+// OrderingStrategy decides the order in which the pending transactions of the
+// pool are included into a block.
+type OrderingStrategy interface {
+	// Name returns the identifier the strategy can be selected by.
+	Name() string
+
+	// Order arranges the pending local and remote transactions into a list of
+	// transaction sets, which are committed into the block one after the other.
+	// The transaction maps are owned by the strategy.
+	Order(ctx *OrderingContext, locals, remotes map[common.Address]types.Transactions) []TransactionSet
+}
+
+// OrderingStrategyByName returns the built-in ordering strategy with the given
+// name. An empty name selects the default greedy strategy.
+func OrderingStrategyByName(name string) (OrderingStrategy, error) {
+	switch name {
+	case "", "greedy":
+		return GreedyOrdering{}, nil
+	case "fifo":
+		return FIFOOrdering{}, nil
+	case "revenue":
+		return RevenueOrdering{}, nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering strategy %q", name)
+	}
+}
+
+// GreedyOrdering includes the local transactions first and the remote ones
+// afterwards, both sorted by effective miner tip while respecting nonces.
+type GreedyOrdering struct{}
+
+// Name implements OrderingStrategy.
+func (GreedyOrdering) Name() string { return "greedy" }
+
+// Order implements OrderingStrategy.
+func (GreedyOrdering) Order(ctx *OrderingContext, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	var sets []TransactionSet
+	if len(locals) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(ctx.Signer, locals, ctx.Header.BaseFee))
+	}
+	if len(remotes) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(ctx.Signer, remotes, ctx.Header.BaseFee))
+	}
+	return sets
+}
+
+// FIFOOrdering includes all transactions strictly in the order they arrived in
+// the pool, regardless of their fees or origin, while respecting nonces.
+type FIFOOrdering struct{}
+
+// Name implements OrderingStrategy.
+func (FIFOOrdering) Name() string { return "fifo" }
+
+// Order implements OrderingStrategy.
+func (FIFOOrdering) Order(ctx *OrderingContext, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	for addr, txs := range remotes {
+		locals[addr] = txs
+	}
+	return []TransactionSet{newTransactionsByScoreAndNonce(locals, func(a, b *types.Transaction) bool {
+		return a.Time().Before(b.Time())
+	}, ctx.Header.BaseFee)}
+}
+
+// RevenueOrdering simulates the pending transactions of each account on top of
+// the block state and includes them ordered by the revenue they generate for
+// the block producer per unit of gas, fees and direct coinbase payments alike.
+type RevenueOrdering struct{}
+
+// Name implements OrderingStrategy.
+func (RevenueOrdering) Name() string { return "revenue" }
+
+// Order implements OrderingStrategy.
+func (RevenueOrdering) Order(ctx *OrderingContext, locals, remotes map[common.Address]types.Transactions) []TransactionSet {
+	for addr, txs := range remotes {
+		locals[addr] = txs
+	}
+	// Simulate the most promising accounts only, ordering the rest by price
+	addrs := make([]common.Address, 0, len(locals))
+	for addr := range locals {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return locals[addrs[i]][0].EffectiveGasTipCmp(locals[addrs[j]][0], ctx.Header.BaseFee) > 0
+	})
+	var (
+		simulated = make(map[common.Address]types.Transactions)
+		scores    = make(map[common.Hash]*big.Int)
+	)
+	for i, addr := range addrs {
+		if i >= maxRevenueSimulations {
+			break
+		}
+		if txs := simulateRevenue(ctx, locals[addr], scores); len(txs) > 0 {
+			simulated[addr] = txs
+		}
+		delete(locals, addr)
+	}
+	sets := []TransactionSet{newTransactionsByScoreAndNonce(simulated, func(a, b *types.Transaction) bool {
+		if cmp := scores[a.Hash()].Cmp(scores[b.Hash()]); cmp != 0 {
+			return cmp > 0
+		}
+		return a.Time().Before(b.Time())
+	}, ctx.Header.BaseFee)}
+	if len(locals) > 0 {
+		sets = append(sets, types.NewTransactionsByPriceAndNonce(ctx.Signer, locals, ctx.Header.BaseFee))
+	}
+	return sets
+}
+
+// simulateRevenue executes the nonce ordered transactions of an account on a
+// copy of the block state, recording the coinbase revenue per gas generated by
+// each. The transactions up to the first failing one are returned.
+func simulateRevenue(ctx *OrderingContext, txs types.Transactions, scores map[common.Hash]*big.Int) types.Transactions {
+	var (
+		statedb = ctx.State.Copy()
+		header  = types.CopyHeader(ctx.Header)
+		gasPool = new(core.GasPool).AddGas(header.GasLimit)
+	)
+	for i, tx := range txs {
+		var (
+			before  = statedb.GetBalance(header.Coinbase)
+			gasUsed = header.GasUsed
+		)
+		statedb.Prepare(tx.Hash(), i)
+		if _, err := core.ApplyTransaction(ctx.Config, ctx.Chain, &header.Coinbase, gasPool, statedb, header, tx, &header.GasUsed, ctx.VMConfig); err != nil {
+			return txs[:i]
+		}
+		revenue := new(big.Int).Sub(statedb.GetBalance(header.Coinbase), before)
+		if used := header.GasUsed - gasUsed; used > 0 {
+			revenue.Div(revenue, new(big.Int).SetUint64(used))
+		}
+		scores[tx.Hash()] = revenue
+	}
+	return txs
+}
+
+// transactionsByScoreAndNonce is a transaction set yielding the transactions
+// according to an arbitrary ordering of the account heads, while respecting
+// the nonce order within each account.
+type transactionsByScoreAndNonce struct {
+	txs   map[common.Address]types.Transactions // Per account nonce-sorted list of transactions
+	heads *txHeads                              // Next transaction for each unique account
+}
+
+// newTransactionsByScoreAndNonce creates a transaction set ordering the account
+// heads by the given less function. Transactions whose fee cap is below the
+// base fee are discarded along with the rest of their account.
+func newTransactionsByScoreAndNonce(txs map[common.Address]types.Transactions, less func(a, b *types.Transaction) bool, baseFee *big.Int) *transactionsByScoreAndNonce {
+	heads := &txHeads{less: less}
+	for from, accTxs := range txs {
+		if len(accTxs) == 0 || (baseFee != nil && accTxs[0].GasFeeCapIntCmp(baseFee) < 0) {
+			delete(txs, from)
+			continue
+		}
+		heads.items = append(heads.items, txHead{from: from, tx: accTxs[0]})
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(heads)
+
+	return &transactionsByScoreAndNonce{txs: txs, heads: heads}
+}
+
+// Peek implements TransactionSet.
+func (t *transactionsByScoreAndNonce) Peek() *types.Transaction {
+	if len(t.heads.items) == 0 {
+		return nil
+	}
+	return t.heads.items[0].tx
+}
+
+// Shift implements TransactionSet.
+func (t *transactionsByScoreAndNonce) Shift() {
+	from := t.heads.items[0].from
+	if txs, ok := t.txs[from]; ok && len(txs) > 0 {
+		t.heads.items[0].tx, t.txs[from] = txs[0], txs[1:]
+		heap.Fix(t.heads, 0)
+		return
+	}
+	heap.Pop(t.heads)
+}
+
+// Pop implements TransactionSet.
+func (t *transactionsByScoreAndNonce) Pop() {
+	heap.Pop(t.heads)
+}
+
+// txHead is the next transaction of an account in a transaction set.
+type txHead struct {
+	from common.Address
+	tx   *types.Transaction
+}
+
+// txHeads implements the heap interface over account heads using a custom
+// ordering function.
+type txHeads struct {
+	items []txHead
+	less  func(a, b *types.Transaction) bool
+}
+
+func (h *txHeads) Len() int           { return len(h.items) }
+func (h *txHeads) Less(i, j int) bool { return h.less(h.items[i].tx, h.items[j].tx) }
+func (h *txHeads) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *txHeads) Push(x interface{}) {
+	h.items = append(h.items, x.(txHead))
+}
+
+func (h *txHeads) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	x := old[n-1]
+	h.items = old[0 : n-1]
+	return x
+}
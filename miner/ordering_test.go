@@ -0,0 +1,158 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// orderingTester is a minimal environment to run the ordering strategies in,
+// with two funded accounts and a block on top of an empty chain.
+type orderingTester struct {
+	ctx        *OrderingContext
+	keyA, keyB *ecdsa.PrivateKey
+	addrA      common.Address
+	addrB      common.Address
+}
+
+func newOrderingTester(t *testing.T) *orderingTester {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	keyA, _ := crypto.GenerateKey()
+	keyB, _ := crypto.GenerateKey()
+	tester := &orderingTester{
+		keyA:  keyA,
+		keyB:  keyB,
+		addrA: crypto.PubkeyToAddress(keyA.PublicKey),
+		addrB: crypto.PubkeyToAddress(keyB.PublicKey),
+	}
+	statedb.AddBalance(tester.addrA, big.NewInt(params.Ether))
+	statedb.AddBalance(tester.addrB, big.NewInt(params.Ether))
+
+	tester.ctx = &OrderingContext{
+		Config: params.TestChainConfig,
+		Header: &types.Header{
+			Number:   big.NewInt(1),
+			GasLimit: 10000000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+			Coinbase: common.HexToAddress("0xc0ffee"),
+		},
+		State:  statedb,
+		Signer: types.LatestSigner(params.TestChainConfig),
+	}
+	return tester
+}
+
+// transfer creates a signed value transfer with the given gas price.
+func (tester *orderingTester) transfer(key *ecdsa.PrivateKey, nonce uint64, to common.Address, value *big.Int, price int64) *types.Transaction {
+	tx := types.MustSignNewTx(key, tester.ctx.Signer, &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    value,
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(price * params.InitialBaseFee),
+	})
+	time.Sleep(time.Millisecond) // Ensure distinct arrival times
+	return tx
+}
+
+// drain consumes all the transactions of the sets in order.
+func drain(sets []TransactionSet) []*types.Transaction {
+	var txs []*types.Transaction
+	for _, set := range sets {
+		for tx := set.Peek(); tx != nil; tx = set.Peek() {
+			txs = append(txs, tx)
+			set.Shift()
+		}
+	}
+	return txs
+}
+
+// Tests that the built-in strategies can be looked up by name.
+func TestOrderingStrategyByName(t *testing.T) {
+	for _, name := range []string{"greedy", "fifo", "revenue"} {
+		strategy, err := OrderingStrategyByName(name)
+		if err != nil {
+			t.Fatalf("failed to resolve strategy %q: %v", name, err)
+		}
+		if strategy.Name() != name {
+			t.Fatalf("strategy name mismatch: have %q, want %q", strategy.Name(), name)
+		}
+	}
+	if strategy, err := OrderingStrategyByName(""); err != nil || strategy.Name() != "greedy" {
+		t.Fatalf("default strategy mismatch: have %v (%v), want greedy", strategy, err)
+	}
+	if _, err := OrderingStrategyByName("random"); err == nil {
+		t.Fatalf("unknown strategy resolved")
+	}
+}
+
+// Tests that the FIFO strategy includes transactions in arrival order across
+// accounts regardless of price, while still honouring nonces.
+func TestFIFOOrdering(t *testing.T) {
+	tester := newOrderingTester(t)
+	var (
+		a0 = tester.transfer(tester.keyA, 0, tester.addrB, common.Big1, 1)
+		b0 = tester.transfer(tester.keyB, 0, tester.addrA, common.Big1, 10)
+		a1 = tester.transfer(tester.keyA, 1, tester.addrB, common.Big1, 20)
+	)
+	locals := map[common.Address]types.Transactions{tester.addrA: {a0, a1}}
+	remotes := map[common.Address]types.Transactions{tester.addrB: {b0}}
+
+	txs := drain(FIFOOrdering{}.Order(tester.ctx, locals, remotes))
+	if len(txs) != 3 || txs[0] != a0 || txs[1] != b0 || txs[2] != a1 {
+		t.Fatalf("transaction order mismatch: have %v", txs)
+	}
+}
+
+// Tests that the revenue strategy prefers transactions paying the coinbase
+// directly over ones with a higher gas price.
+func TestRevenueOrdering(t *testing.T) {
+	tester := newOrderingTester(t)
+	var (
+		direct = tester.transfer(tester.keyA, 0, tester.ctx.Header.Coinbase, big.NewInt(params.Ether/10), 2)
+		priced = tester.transfer(tester.keyB, 0, tester.addrA, common.Big1, 10)
+	)
+	greedy := drain(GreedyOrdering{}.Order(tester.ctx,
+		map[common.Address]types.Transactions{},
+		map[common.Address]types.Transactions{tester.addrA: {direct}, tester.addrB: {priced}},
+	))
+	if len(greedy) != 2 || greedy[0] != priced {
+		t.Fatalf("greedy transaction order mismatch: have %v", greedy)
+	}
+	revenue := drain(RevenueOrdering{}.Order(tester.ctx,
+		map[common.Address]types.Transactions{},
+		map[common.Address]types.Transactions{tester.addrA: {direct}, tester.addrB: {priced}},
+	))
+	if len(revenue) != 2 || revenue[0] != direct {
+		t.Fatalf("revenue transaction order mismatch: have %v", revenue)
+	}
+	// The simulation must not touch the block state
+	if balance := tester.ctx.State.GetBalance(tester.ctx.Header.Coinbase); balance.Sign() != 0 {
+		t.Fatalf("block state modified: coinbase balance %v", balance)
+	}
+}
@@ -80,6 +80,7 @@ const (
 var (
 	errBlockInterruptedByNewHead  = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit = errors.New("recommit interrupt while building block")
+	errBlobsFull                  = errors.New("block blob limit reached")
 )
 
 // environment is the worker's current environment and holds all
@@ -98,6 +99,9 @@ type environment struct {
 	txs      []*types.Transaction
 	receipts []*types.Receipt
 	uncles   map[common.Hash]*types.Header
+
+	blobs    int                    // Number of blobs referenced by the included transactions
+	sidecars []*types.BlobTxSidecar // Sidecars of the included blob transactions
 }
 
 // copy creates a deep copy of environment.
@@ -111,6 +115,7 @@ func (env *environment) copy() *environment {
 		coinbase:  env.coinbase,
 		header:    types.CopyHeader(env.header),
 		receipts:  copyReceipts(env.receipts),
+		blobs:     env.blobs,
 	}
 	if env.gasPool != nil {
 		gasPool := *env.gasPool
@@ -120,6 +125,8 @@ func (env *environment) copy() *environment {
 	// to do the expensive deep copy for them.
 	cpy.txs = make([]*types.Transaction, len(env.txs))
 	copy(cpy.txs, env.txs)
+	cpy.sidecars = make([]*types.BlobTxSidecar, len(env.sidecars))
+	copy(cpy.sidecars, env.sidecars)
 	cpy.uncles = make(map[common.Hash]*types.Header)
 	for hash, uncle := range env.uncles {
 		cpy.uncles[hash] = uncle
@@ -170,10 +177,17 @@ type newWorkReq struct {
 // getWorkReq represents a request for getting a new sealing work with provided parameters.
 type getWorkReq struct {
 	params *generateParams
-	result chan *types.Block // non-blocking channel
+	result chan *SealingResult // non-blocking channel
 	err    chan error
 }
 
+// SealingResult is a generated sealing block along with the sidecars of the
+// blob transactions it contains, which are never part of the block itself.
+type SealingResult struct {
+	Block    *types.Block
+	Sidecars []*types.BlobTxSidecar
+}
+
 // intervalAdjust represents a resubmitting interval adjustment.
 type intervalAdjust struct {
 	ratio float64
@@ -188,6 +202,7 @@ type worker struct {
 	engine      consensus.Engine
 	eth         Backend
 	chain       *core.BlockChain
+	ordering    OrderingStrategy // Strategy deciding the order of the included transactions
 
 	// Feeds
 	pendingLogsFeed event.Feed
@@ -213,6 +228,7 @@ type worker struct {
 
 	wg sync.WaitGroup
 
+	bundles      *bundlePool                  // A set of transaction bundles to include atomically.
 	current      *environment                 // An environment for current running cycle.
 	localUncles  map[common.Hash]*types.Block // A set of side blocks generated locally as the possible uncle blocks.
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
@@ -264,6 +280,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), sealingLogAtDepth),
 		pendingTasks:       make(map[common.Hash]*task),
+		bundles:            newBundlePool(),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
@@ -276,6 +293,16 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		resubmitIntervalCh: make(chan time.Duration),
 		resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
 	}
+	// Resolve the transaction ordering strategy, falling back to the default
+	worker.ordering = config.OrderingStrategy
+	if worker.ordering == nil {
+		ordering, err := OrderingStrategyByName(config.Ordering)
+		if err != nil {
+			log.Warn("Sanitizing miner ordering strategy", "provided", config.Ordering, "updated", "greedy", "err", err)
+			ordering = GreedyOrdering{}
+		}
+		worker.ordering = ordering
+	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
 	// Subscribe events for blockchain
@@ -534,13 +561,13 @@ func (w *worker) mainLoop() {
 			w.commitWork(req.interrupt, req.noempty, req.timestamp)
 
 		case req := <-w.getWorkCh:
-			block, err := w.generateWork(req.params)
+			block, sidecars, err := w.generateWork(req.params)
 			if err != nil {
 				req.err <- err
 				req.result <- nil
 			} else {
 				req.err <- nil
-				req.result <- block
+				req.result <- &SealingResult{Block: block, Sidecars: sidecars}
 			}
 		case ev := <-w.chainSideCh:
 			// Short circuit for duplicate side blocks
@@ -823,6 +850,21 @@ func (w *worker) updateSnapshot(env *environment) {
 }
 
 func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*types.Log, error) {
+	// Blob transactions can only be included along with their sidecar, which is
+	// kept apart from the block
+	var sidecar *types.BlobTxSidecar
+	if tx.Type() == types.BlobTxType {
+		if env.blobs+len(tx.DataHashes()) > params.MaxBlobsPerBlock {
+			return nil, errBlobsFull
+		}
+		if sidecar = tx.BlobTxSidecar(); sidecar == nil {
+			sidecar = w.eth.TxPool().BlobSidecar(tx.Hash())
+		}
+		if sidecar == nil {
+			return nil, core.ErrMissingBlobSidecar
+		}
+		tx = tx.WithoutBlobTxSidecar()
+	}
 	snap := env.state.Snapshot()
 
 	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *w.chain.GetVMConfig())
@@ -832,17 +874,23 @@ func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*
 	}
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
+	if sidecar != nil {
+		env.blobs += len(tx.DataHashes())
+		env.sidecars = append(env.sidecars, sidecar)
+	}
 
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByPriceAndNonce, interrupt *int32) error {
+func (w *worker) commitTransactions(env *environment, txs TransactionSet, interrupt *int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
 	}
-	var coalescedLogs []*types.Log
-
+	var (
+		coalescedLogs []*types.Log
+		conditions    = w.eth.TxPool().Conditions()
+	)
 	for {
 		// In the following three cases, we will interrupt the execution of the transaction.
 		// (1) new head block event arrival, the interrupt signal is 1
@@ -888,6 +936,19 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 			txs.Pop()
 			continue
 		}
+		// Skip the account if the preconditions of a conditional transaction
+		// don't hold for the block being built
+		if cond := conditions[tx.Hash()]; cond != nil {
+			err := cond.CheckBlock(env.header.Number.Uint64(), env.header.Time)
+			if err == nil {
+				err = cond.CheckState(env.state)
+			}
+			if err != nil {
+				log.Trace("Skipping conditional transaction", "hash", tx.Hash(), "err", err)
+				txs.Pop()
+				continue
+			}
+		}
 		// Start executing the transaction
 		env.state.Prepare(tx.Hash(), env.tcount)
 
@@ -914,6 +975,11 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 			env.tcount++
 			txs.Shift()
 
+		case errors.Is(err, errBlobsFull):
+			// Pop the blob transaction not fitting the block without shifting in the next from the account
+			log.Trace("Blob limit exceeded for current block", "sender", from)
+			txs.Pop()
+
 		case errors.Is(err, core.ErrTxTypeNotSupported):
 			// Pop the unsupported transaction without shifting in the next from the account
 			log.Trace("Skipping unsupported transaction type", "sender", from, "type", tx.Type())
@@ -960,6 +1026,8 @@ type generateParams struct {
 	noUncle    bool           // Flag whether the uncle block inclusion is allowed
 	noExtra    bool           // Flag whether the extra field assignment is allowed
 	noTxs      bool           // Flag whether an empty block without any transaction is expected
+
+	withdrawals types.Withdrawals // List of withdrawals to include in block.
 }
 
 // prepareWork constructs the sealing task according to the given parameters,
@@ -1010,6 +1078,10 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
 		}
 	}
+	// Set the excess data gas if we are on an EIP-4844 chain
+	if w.chainConfig.IsCancun(header.Number) {
+		header.ExcessDataGas = misc.CalcExcessDataGas(parent.ExcessDataGas(), misc.CountBlobs(parent.Transactions()))
+	}
 	// Run the consensus preparation with the default or customized consensus engine.
 	if err := w.engine.Prepare(w.chain, header); err != nil {
 		log.Error("Failed to prepare header for sealing", "err", err)
@@ -1048,8 +1120,14 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 // into the given sealing block. The transaction selection and ordering strategy can
 // be customized with the plugin in the future.
 func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
+	// Place the most profitable bundles targeting this block at the top of it
+	if bundles := w.bundles.pending(env.header.Number.Uint64(), env.header.Time); len(bundles) > 0 {
+		if env.gasPool == nil {
+			env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+		}
+		w.commitBundles(env, w.simulateBundles(env, bundles))
+	}
 	// Split the pending transactions into locals and remotes
-	// Fill the block with all available pending transactions.
 	pending := w.eth.TxPool().Pending(true)
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
@@ -1058,14 +1136,17 @@ func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
 			localTxs[account] = txs
 		}
 	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
-		if err := w.commitTransactions(env, txs, interrupt); err != nil {
-			return err
-		}
+	// Fill the block with all available pending transactions, in the order
+	// decided by the configured strategy
+	ctx := &OrderingContext{
+		Config:   w.chainConfig,
+		Chain:    w.chain,
+		Header:   env.header,
+		State:    env.state,
+		Signer:   env.signer,
+		VMConfig: *w.chain.GetVMConfig(),
 	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+	for _, txs := range w.ordering.Order(ctx, localTxs, remoteTxs) {
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
@@ -1073,18 +1154,23 @@ func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
 	return nil
 }
 
-// generateWork generates a sealing block based on the given parameters.
-func (w *worker) generateWork(params *generateParams) (*types.Block, error) {
+// generateWork generates a sealing block based on the given parameters, along
+// with the sidecars of the blob transactions included in it.
+func (w *worker) generateWork(params *generateParams) (*types.Block, []*types.BlobTxSidecar, error) {
 	work, err := w.prepareWork(params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer work.discard()
 
 	if !params.noTxs {
 		w.fillTransactions(nil, work)
 	}
-	return w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, work.unclelist(), work.receipts)
+	block, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, work.unclelist(), work.receipts, params.withdrawals)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, work.sidecars, nil
 }
 
 // commitWork generates several new sealing tasks based on the parent block
@@ -1142,7 +1228,7 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// Create a local environment copy, avoid the data race with snapshot state.
 		// https://github.com/ethereum/go-ethereum/issues/24299
 		env := env.copy()
-		block, err := w.engine.FinalizeAndAssemble(w.chain, env.header, env.state, env.txs, env.unclelist(), env.receipts)
+		block, err := w.engine.FinalizeAndAssemble(w.chain, env.header, env.state, env.txs, env.unclelist(), env.receipts, nil)
 		if err != nil {
 			return err
 		}
@@ -1170,21 +1256,22 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 // getSealingBlock generates the sealing block based on the given parameters.
 // The generation result will be passed back via the given channel no matter
 // the generation itself succeeds or not.
-func (w *worker) getSealingBlock(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, noTxs bool) (chan *types.Block, chan error, error) {
+func (w *worker) getSealingBlock(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, withdrawals types.Withdrawals, noTxs bool) (chan *SealingResult, chan error, error) {
 	var (
-		resCh = make(chan *types.Block, 1)
+		resCh = make(chan *SealingResult, 1)
 		errCh = make(chan error, 1)
 	)
 	req := &getWorkReq{
 		params: &generateParams{
-			timestamp:  timestamp,
-			forceTime:  true,
-			parentHash: parent,
-			coinbase:   coinbase,
-			random:     random,
-			noUncle:    true,
-			noExtra:    true,
-			noTxs:      noTxs,
+			timestamp:   timestamp,
+			forceTime:   true,
+			parentHash:  parent,
+			coinbase:    coinbase,
+			random:      random,
+			withdrawals: withdrawals,
+			noUncle:     true,
+			noExtra:     true,
+			noTxs:       noTxs,
 		},
 		result: resCh,
 		err:    errCh,
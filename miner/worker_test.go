@@ -633,8 +633,8 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 
 	// This API should work even when the automatic sealing is not enabled
 	for _, c := range cases {
-		resChan, errChan, _ := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, false)
-		block := <-resChan
+		resChan, errChan, _ := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, nil, false)
+		result := <-resChan
 		err := <-errChan
 		if c.expectErr {
 			if err == nil {
@@ -644,15 +644,15 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 			if err != nil {
 				t.Errorf("Unexpected error %v", err)
 			}
-			assertBlock(block, c.expectNumber, c.coinbase, c.random)
+			assertBlock(result.Block, c.expectNumber, c.coinbase, c.random)
 		}
 	}
 
 	// This API should work even when the automatic sealing is enabled
 	w.start()
 	for _, c := range cases {
-		resChan, errChan, _ := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, false)
-		block := <-resChan
+		resChan, errChan, _ := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, nil, false)
+		result := <-resChan
 		err := <-errChan
 		if c.expectErr {
 			if err == nil {
@@ -662,7 +662,73 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 			if err != nil {
 				t.Errorf("Unexpected error %v", err)
 			}
-			assertBlock(block, c.expectNumber, c.coinbase, c.random)
+			assertBlock(result.Block, c.expectNumber, c.coinbase, c.random)
+		}
+	}
+}
+
+// Tests that conditional transactions are only included into sealed blocks if
+// their preconditions hold for the block being built.
+func TestConditionalTransactions(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		signer   = types.LatestSigner(ethashChainConfig)
+		one, two = uint64(1), uint64(2)
+	)
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &testUserAddress,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+	}
+	// The first transaction may only land in block 1 on an untouched account,
+	// the second one not before block 2
+	passing, failing := transfer(1), transfer(2)
+	if err := b.txPool.AddConditional(passing, &core.TxConditions{
+		BlockNumberMin: &one,
+		BlockNumberMax: &one,
+		KnownAccounts: map[common.Address]*core.KnownAccount{
+			testUserAddress: {StorageSlots: map[common.Hash]common.Hash{{}: {}}},
+		},
+	}); err != nil {
+		t.Fatalf("failed to add passing conditional transaction: %v", err)
+	}
+	if err := b.txPool.AddConditional(failing, &core.TxConditions{BlockNumberMin: &two}); err != nil {
+		t.Fatalf("failed to add failing conditional transaction: %v", err)
+	}
+	for deadline := time.Now().Add(time.Second); ; {
+		if status := b.txPool.Status([]common.Hash{passing.Hash(), failing.Hash()}); status[0] == core.TxStatusPending && status[1] == core.TxStatusPending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("conditional transactions not promoted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resCh, errCh, err := w.getSealingBlock(b.chain.CurrentBlock().Hash(), uint64(time.Now().Unix()), testUserAddress, common.Hash{}, nil, false)
+	if err != nil {
+		t.Fatalf("failed to request sealing block: %v", err)
+	}
+	result := <-resCh
+	if err := <-errCh; err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	want := []common.Hash{pendingTxs[0].Hash(), passing.Hash()}
+	have := result.Block.Transactions()
+	if len(have) != len(want) {
+		t.Fatalf("included transaction count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i, tx := range have {
+		if tx.Hash() != want[i] {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, tx.Hash(), want[i])
 		}
 	}
 }
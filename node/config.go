@@ -77,6 +77,11 @@ type Config struct {
 	// is created by New and destroyed when the node is stopped.
 	KeyStoreDir string `toml:",omitempty"`
 
+	// DBEngine is the database engine to use for newly created databases, either
+	// "leveldb" or "pebble". Existing databases are always opened with the engine
+	// they were created by. If empty, leveldb is used for new databases.
+	DBEngine string `toml:",omitempty"`
+
 	// ExternalSigner specifies an external URI for a clef-type signer
 	ExternalSigner string `toml:",omitempty"`
 
@@ -153,6 +153,15 @@ const (
 	Bls12381MapG1Gas          uint64 = 5500   // Gas price for BLS12-381 mapping field element to G1 operation
 	Bls12381MapG2Gas          uint64 = 110000 // Gas price for BLS12-381 mapping field element to G2 operation
 
+	BlobTxPointEvaluationPrecompileGas uint64 = 50000 // Gas price for the point evaluation precompile
+
+	DataGasPerBlob             = 1 << 17 // Gas consumption of a single data blob (== blob byte size)
+	TargetDataGasPerBlock      = 1 << 18 // Target consumable data gas for data blobs per block (for 1559-like pricing)
+	MaxDataGasPerBlock         = 1 << 19 // Maximum consumable data gas for data blobs per block
+	MaxBlobsPerBlock           = MaxDataGasPerBlock / DataGasPerBlob
+	MinDataGasPrice            = 1       // Minimum gas price for data blobs
+	DataGasPriceUpdateFraction = 2225652 // Controls the maximum rate of change for data gas price
+
 	// The Refund Quotient is the cap on how much of the used gas can be refunded. Before EIP-3529,
 	// up to half the consumed gas could be refunded. Redefined as 1/5th in EIP-3529
 	RefundQuotient        uint64 = 2
@@ -87,9 +87,17 @@ type Database struct {
 	childrenSize common.StorageSize // Storage size of the external children tracking
 	preimages    *preimageStore     // The store for caching preimages
 
+	pathdb    *pathDatabase // Backend of the path-based scheme, nil in hash-based scheme
+	flushHook FlushHook     // Hook invoked before flushing trie nodes into disk
+
 	lock sync.RWMutex
 }
 
+// FlushHook is invoked by the hash-based trie database right before a batch of
+// trie nodes is written into disk. The hook may append additional entries into
+// the batch, which are persisted atomically together with the nodes.
+type FlushHook func(batch ethdb.Batch) error
+
 // rawNode is a simple binary blob used to differentiate between collapsed trie
 // nodes and already encoded RLP binary blobs (while at the same time store them
 // in the same cache fields).
@@ -268,6 +276,19 @@ type Config struct {
 	Cache     int    // Memory allowance (MB) to use for caching trie nodes in memory
 	Journal   string // Journal of clean cache to survive node restarts
 	Preimages bool   // Flag whether the preimage of trie key is recorded
+
+	// Scheme is the storage scheme of trie nodes, either rawdb.HashScheme or
+	// rawdb.PathScheme. The hash scheme is used if it's empty.
+	Scheme string
+
+	// StateHistory is the number of recent state histories retained in the
+	// path scheme, 0 for retaining all of them.
+	StateHistory uint64
+
+	// ReadOnly opens the path scheme without the state history freezer. It's
+	// meant for auxiliary instances which don't modify the persistent state,
+	// since the freezer can only be held by a single instance.
+	ReadOnly bool
 }
 
 // NewDatabase creates a new trie database to store ephemeral trie content before
@@ -301,6 +322,9 @@ func NewDatabaseWithConfig(diskdb ethdb.KeyValueStore, config *Config) *Database
 		}},
 		preimages: preimage,
 	}
+	if config != nil && config.Scheme == rawdb.PathScheme {
+		db.pathdb = newPathDatabase(diskdb, cleans, config.StateHistory, !config.ReadOnly)
+	}
 	return db
 }
 
@@ -384,6 +408,10 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 	if hash == (common.Hash{}) {
 		return nil, errors.New("not found")
 	}
+	// Nodes can't be retrieved by hash only in the path-based scheme
+	if db.pathdb != nil {
+		return nil, errors.New("not supported in path scheme")
+	}
 	// Retrieve the node from the clean cache if available
 	if db.cleans != nil {
 		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
@@ -472,6 +500,10 @@ func (db *Database) Dereference(root common.Hash) {
 		log.Error("Attempted to dereference the trie cache meta root")
 		return
 	}
+	// Stale states are dropped implicitly in the path-based scheme
+	if db.pathdb != nil {
+		return
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -575,7 +607,7 @@ func (db *Database) Cap(limit common.StorageSize) error {
 
 		// If we exceeded the ideal batch size, commit and reset
 		if batch.ValueSize() >= ethdb.IdealBatchSize {
-			if err := batch.Write(); err != nil {
+			if err := db.writeBatch(batch); err != nil {
 				log.Error("Failed to write flush list to disk", "err", err)
 				return err
 			}
@@ -591,7 +623,7 @@ func (db *Database) Cap(limit common.StorageSize) error {
 		oldest = node.flushNext
 	}
 	// Flush out any remainder data from the last batch
-	if err := batch.Write(); err != nil {
+	if err := db.writeBatch(batch); err != nil {
 		log.Error("Failed to write flush list to disk", "err", err)
 		return err
 	}
@@ -646,6 +678,19 @@ func (db *Database) Commit(node common.Hash, report bool, callback func(common.H
 			return err
 		}
 	}
+	// Flatten all the layers of the given state in the path-based scheme
+	if db.pathdb != nil {
+		if err := db.pathdb.commit(node); err != nil {
+			log.Error("Failed to commit trie from trie database", "err", err)
+			return err
+		}
+		logger := log.Info
+		if !report {
+			logger = log.Debug
+		}
+		logger("Persisted trie from memory database", "root", node, "time", time.Since(start))
+		return nil
+	}
 	// Move the trie itself into the batch, flushing if enough data is accumulated
 	nodes, storage := len(db.dirties), db.dirtiesSize
 
@@ -655,7 +700,7 @@ func (db *Database) Commit(node common.Hash, report bool, callback func(common.H
 		return err
 	}
 	// Trie mostly committed to disk, flush any batch leftovers
-	if err := batch.Write(); err != nil {
+	if err := db.writeBatch(batch); err != nil {
 		log.Error("Failed to write trie to disk", "err", err)
 		return err
 	}
@@ -708,7 +753,7 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 		callback(hash)
 	}
 	if batch.ValueSize() >= ethdb.IdealBatchSize {
-		if err := batch.Write(); err != nil {
+		if err := db.writeBatch(batch); err != nil {
 			return err
 		}
 		db.lock.Lock()
@@ -722,6 +767,39 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 	return nil
 }
 
+// writeBatch flushes the batch of trie nodes into disk, invoking the installed
+// flush hook first if there is any.
+func (db *Database) writeBatch(batch ethdb.Batch) error {
+	db.lock.RLock()
+	hook := db.flushHook
+	db.lock.RUnlock()
+
+	if hook != nil {
+		if err := hook(batch); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// SetFlushHook installs a hook which is invoked before every batch of trie
+// nodes is flushed into disk, nil removes the installed one. It's only used
+// in the hash-based scheme.
+func (db *Database) SetFlushHook(hook FlushHook) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.flushHook = hook
+}
+
+// ResetCleans drops all the cached clean trie nodes, e.g. after the persistent
+// trie nodes were deleted from disk.
+func (db *Database) ResetCleans() {
+	if db.cleans != nil {
+		db.cleans.Reset()
+	}
+}
+
 // cleaner is a database batch replayer that takes a batch of write operations
 // and cleans up the trie database from anything written to disk.
 type cleaner struct {
@@ -773,7 +851,13 @@ func (c *cleaner) Delete(key []byte) error {
 
 // Update inserts the dirty nodes in provided nodeset into database and
 // link the account trie with multiple storage tries if necessary.
+//
+// It's only supported in the hash-based scheme, use UpdateState instead
+// if the scheme is not known.
 func (db *Database) Update(nodes *MergedNodeSet) error {
+	if db.pathdb != nil {
+		return errors.New("not supported in path scheme")
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -834,6 +918,11 @@ func (db *Database) Size() (common.StorageSize, common.StorageSize) {
 	if db.preimages != nil {
 		preimageSize = db.preimages.size()
 	}
+	if db.pathdb != nil {
+		db.pathdb.lock.RLock()
+		defer db.pathdb.lock.RUnlock()
+		return db.pathdb.size, preimageSize
+	}
 	return db.dirtiesSize + db.childrenSize + metadataSize - metarootRefs, preimageSize
 }
 
@@ -889,3 +978,88 @@ func (db *Database) CommitPreimages() error {
 	}
 	return db.preimages.commit(true)
 }
+
+// Scheme returns the storage scheme of trie nodes, either rawdb.HashScheme
+// or rawdb.PathScheme.
+func (db *Database) Scheme() string {
+	if db.pathdb != nil {
+		return rawdb.PathScheme
+	}
+	return rawdb.HashScheme
+}
+
+// UpdateState inserts the dirty nodes of the state transition from parent to
+// root into database. In the hash-based scheme it's equivalent to Update.
+func (db *Database) UpdateState(root common.Hash, parent common.Hash, nodes *MergedNodeSet) error {
+	if db.pathdb == nil {
+		return db.Update(nodes)
+	}
+	return db.pathdb.update(root, parent, nodes)
+}
+
+// CapLayers flattens the oldest in-memory states below the given root into
+// disk, retaining at most the given number of layers in memory. It's a noop
+// in the hash-based scheme, which is capped by memory allowance via Cap.
+func (db *Database) CapLayers(root common.Hash, layers int) error {
+	if db.pathdb == nil {
+		return nil
+	}
+	return db.pathdb.cap(root, layers)
+}
+
+// Initialized reports whether the state with the given root, or any state in
+// the path-based scheme, is present in the database.
+func (db *Database) Initialized(root common.Hash) bool {
+	if db.pathdb != nil {
+		blob, _ := rawdb.ReadAccountTrieNode(db.diskdb, nil)
+		return len(blob) != 0
+	}
+	return rawdb.HasTrieNode(db.diskdb, root)
+}
+
+// Journal persists the in-memory states from the given root down to disk, so
+// that they can be restored after restart. It's a noop in the hash-based scheme.
+func (db *Database) Journal(root common.Hash) error {
+	if db.pathdb == nil {
+		return nil
+	}
+	return db.pathdb.journal(root)
+}
+
+// Recoverable reports whether the persistent state can be reverted to the
+// state with the given root. It's only supported in the path-based scheme.
+func (db *Database) Recoverable(root common.Hash) bool {
+	if db.pathdb == nil {
+		return false
+	}
+	return db.pathdb.recoverable(root)
+}
+
+// Recover reverts the persistent state to the state with the given root using
+// the state histories, dropping all the in-memory states. It's only supported
+// in the path-based scheme.
+func (db *Database) Recover(root common.Hash) error {
+	if db.pathdb == nil {
+		return errors.New("not supported in hash scheme")
+	}
+	return db.pathdb.recover(root)
+}
+
+// Reset drops all the in-memory states and reloads the persistent state, which
+// must match the given root, e.g. after the state was synced directly into disk.
+// Resetting to the empty root wipes the persistent state. It's only supported in
+// the path-based scheme.
+func (db *Database) Reset(root common.Hash) error {
+	if db.pathdb == nil {
+		return errors.New("not supported in hash scheme")
+	}
+	return db.pathdb.reset(root)
+}
+
+// Close releases the resources held by the database.
+func (db *Database) Close() error {
+	if db.pathdb == nil {
+		return nil
+	}
+	return db.pathdb.close()
+}
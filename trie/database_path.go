@@ -0,0 +1,826 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	pathCommitTimeTimer  = metrics.NewRegisteredResettingTimer("trie/path/commit/time", nil)
+	pathCommitNodesMeter = metrics.NewRegisteredMeter("trie/path/commit/nodes", nil)
+	pathCommitSizeMeter  = metrics.NewRegisteredMeter("trie/path/commit/size", nil)
+	pathHistorySizeMeter = metrics.NewRegisteredMeter("trie/path/history/size", nil)
+)
+
+var (
+	// errStateUnrecoverable is returned if the requested state can't be
+	// reverted to, either because it's unknown or because the required
+	// state histories are not (or no longer) available.
+	errStateUnrecoverable = errors.New("state is unrecoverable")
+
+	// errUnexpectedNode is returned if the node stored on disk at the
+	// requested path doesn't match the expected node hash.
+	errUnexpectedNode = errors.New("unexpected node")
+)
+
+// journalVersion is the version of the trie layer journal format. Journals
+// with a different version are discarded on startup.
+const journalVersion uint64 = 0
+
+// pathNode is a trie node tracked in a diff layer. The node is deleted if
+// the blob is empty.
+type pathNode struct {
+	hash common.Hash
+	blob []byte
+}
+
+// diffLayer is the collection of trie nodes changed by a single state
+// transition, kept in memory on top of the persistent state.
+type diffLayer struct {
+	root   common.Hash                          // Root hash of the state this layer represents
+	parent common.Hash                          // Root hash of the parent state
+	id     uint64                               // Sequential identifier of the state
+	nodes  map[common.Hash]map[string]*pathNode // Changed trie nodes, keyed by owner and path
+	size   common.StorageSize                   // Approximate memory usage of the layer
+}
+
+// dirtyNode is a node in the lookup index aggregated over all diff layers.
+type dirtyNode struct {
+	blob []byte // Rlp encoded node blob
+	refs int    // Number of diff layers containing the node
+}
+
+// stateHistory is the reverse diff of a single state transition, containing
+// the original value of all the changed trie nodes. It's stored in the state
+// freezer and used to roll back the persistent state.
+type stateHistory struct {
+	Parent common.Hash   // Root hash of the state before the transition
+	Root   common.Hash   // Root hash of the state after the transition
+	Nodes  []historyNode // Original trie nodes, empty blob for non-existent ones
+}
+
+// historyNode is the original value of a trie node changed in a transition.
+type historyNode struct {
+	Owner common.Hash
+	Path  []byte
+	Blob  []byte
+}
+
+// journalNode is a trie node of a journalled diff layer.
+type journalNode struct {
+	Path []byte
+	Blob []byte // Empty for deleted nodes
+}
+
+// journalNodes is the set of journalled nodes of a single trie.
+type journalNodes struct {
+	Owner common.Hash
+	Nodes []journalNode
+}
+
+// journalLayer is a diff layer in the journal.
+type journalLayer struct {
+	Root   common.Hash
+	Parent common.Hash
+	ID     uint64
+	Nodes  []journalNodes
+}
+
+// journal is the persisted form of all in-memory diff layers, used to survive
+// node restarts.
+type journal struct {
+	Version  uint64
+	DiskRoot common.Hash
+	Layers   []journalLayer // Layers ordered from bottom to top
+}
+
+// nodeKey returns the lookup key of the node with the given owner and path.
+func nodeKey(owner common.Hash, path []byte) string {
+	if owner == (common.Hash{}) {
+		return string(rawdb.TrieNodeAccountPrefix) + string(path)
+	}
+	return string(rawdb.TrieNodeStoragePrefix) + string(owner.Bytes()) + string(path)
+}
+
+// sortedOwners returns the owners of the given node sets in sorted order.
+func sortedOwners(nodes map[common.Hash]map[string]*pathNode) []common.Hash {
+	owners := make([]common.Hash, 0, len(nodes))
+	for owner := range nodes {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool { return bytes.Compare(owners[i][:], owners[j][:]) < 0 })
+	return owners
+}
+
+// sortedPaths returns the paths of the given node set in sorted order.
+func sortedPaths(nodes map[string]*pathNode) []string {
+	paths := make([]string, 0, len(nodes))
+	for path := range nodes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// pathDatabase is the backend of the trie database in the path-based scheme.
+// Trie nodes are stored on disk keyed by owner and path, so there's always
+// exactly one persistent state and stale nodes are overwritten implicitly.
+// Recent states are kept in memory as a tree of diff layers on top of it, and
+// every state transition flattened into disk is recorded as a state history
+// in the state freezer, which allows reverting the persistent state.
+type pathDatabase struct {
+	diskdb  ethdb.KeyValueStore // Persistent storage for trie nodes
+	cleans  *fastcache.Cache    // GC friendly memory cache of clean node RLPs, keyed by path
+	freezer *rawdb.Freezer      // Freezer for state histories, nil if not available
+	history uint64              // Number of recent state histories to retain, 0 for all
+
+	diskRoot common.Hash                           // Root hash of the persistent state
+	diskID   uint64                                // Identifier of the persistent state
+	layers   map[common.Hash]*diffLayer            // In-memory diff layers, keyed by state root
+	index    map[string]map[common.Hash]*dirtyNode // Lookup index of all nodes in the diff layers
+	size     common.StorageSize                    // Memory usage of all the diff layers
+
+	lock sync.RWMutex
+}
+
+// newPathDatabase initializes the path-based trie database backend. The state
+// histories are only maintained by the writer of the persistent state, since
+// the state freezer can only be opened once.
+func newPathDatabase(diskdb ethdb.KeyValueStore, cleans *fastcache.Cache, history uint64, writer bool) *pathDatabase {
+	db := &pathDatabase{
+		diskdb:   diskdb,
+		cleans:   cleans,
+		history:  history,
+		diskRoot: diskStateRoot(diskdb),
+		diskID:   rawdb.ReadPersistentStateID(diskdb),
+		layers:   make(map[common.Hash]*diffLayer),
+		index:    make(map[string]map[common.Hash]*dirtyNode),
+	}
+	if writer {
+		db.openFreezer()
+	}
+	db.loadJournal()
+	return db
+}
+
+// diskStateRoot returns the root hash of the persistent state in path scheme.
+func diskStateRoot(diskdb ethdb.KeyValueReader) common.Hash {
+	blob, hash := rawdb.ReadAccountTrieNode(diskdb, nil)
+	if len(blob) == 0 {
+		return emptyRoot
+	}
+	return hash
+}
+
+// openFreezer opens the state freezer if the database has an ancient store,
+// aligning the stored state histories with the persistent state.
+func (db *pathDatabase) openFreezer() {
+	stater, ok := db.diskdb.(ethdb.AncientStater)
+	if !ok {
+		return
+	}
+	dir, err := stater.AncientDatadir()
+	if err != nil || dir == "" {
+		return
+	}
+	freezer, err := rawdb.NewStateFreezer(dir, false)
+	if err != nil {
+		log.Warn("Failed to open state history freezer", "err", err)
+		return
+	}
+	head, err := freezer.Ancients()
+	if err != nil {
+		log.Warn("Failed to retrieve state history head", "err", err)
+		freezer.Close()
+		return
+	}
+	switch {
+	case head > db.diskID:
+		// Dangling histories left over by a crash, drop them.
+		if err := freezer.TruncateHead(db.diskID); err != nil {
+			log.Warn("Failed to truncate dangling state histories", "err", err)
+			freezer.Close()
+			return
+		}
+		log.Warn("Truncated dangling state histories", "number", head-db.diskID)
+
+	case head < db.diskID:
+		// Histories are missing, e.g. the freezer got removed. Continue the
+		// id sequence from the freezer head, the chain verification during
+		// recovery prevents applying histories across the gap.
+		log.Warn("State histories are missing", "persistent", db.diskID, "history", head)
+		db.diskID = head
+	}
+	db.freezer = freezer
+}
+
+// node retrieves the node with the given owner, path and hash, either from
+// the diff layers or from the persistent state. The returned blob is always
+// a copy, safe to be modified by the caller.
+func (db *pathDatabase) node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	key := nodeKey(owner, path)
+
+	// Retrieve the node from the diff layers if available
+	db.lock.RLock()
+	dirty := db.index[key][hash]
+	var blob []byte
+	if dirty != nil {
+		blob = common.CopyBytes(dirty.blob)
+	}
+	db.lock.RUnlock()
+
+	if dirty != nil {
+		memcacheDirtyHitMeter.Mark(1)
+		memcacheDirtyReadMeter.Mark(int64(len(blob)))
+		return blob, nil
+	}
+	memcacheDirtyMissMeter.Mark(1)
+
+	// Retrieve the node from the clean cache if available. The cache
+	// is keyed by path, so the node hash must be checked explicitly.
+	if db.cleans != nil {
+		if blob := db.cleans.Get(nil, []byte(key)); len(blob) > 0 {
+			h := newHasher(false)
+			match := common.BytesToHash(h.hashData(blob)) == hash
+			returnHasherToPool(h)
+
+			if match {
+				memcacheCleanHitMeter.Mark(1)
+				memcacheCleanReadMeter.Mark(int64(len(blob)))
+				return blob, nil
+			}
+		}
+	}
+	// Content unavailable in memory, attempt to retrieve from disk
+	blob, nhash := rawdb.ReadTrieNodeWithPath(db.diskdb, owner, path)
+	if len(blob) == 0 || nhash != hash {
+		return nil, fmt.Errorf("%w: owner %x path %x, want %x, got %x", errUnexpectedNode, owner, path, hash, nhash)
+	}
+	if db.cleans != nil {
+		db.cleans.Set([]byte(key), blob)
+		memcacheCleanMissMeter.Mark(1)
+		memcacheCleanWriteMeter.Mark(int64(len(blob)))
+	}
+	return blob, nil
+}
+
+// newDiffLayer creates a diff layer from the dirty nodes of a state transition.
+func newDiffLayer(root, parent common.Hash, id uint64, nodes *MergedNodeSet) *diffLayer {
+	layer := &diffLayer{
+		root:   root,
+		parent: parent,
+		id:     id,
+		nodes:  make(map[common.Hash]map[string]*pathNode),
+	}
+	for owner, set := range nodes.sets {
+		subset := make(map[string]*pathNode, len(set.nodes)+len(set.deletes))
+		for _, path := range set.deletes {
+			subset[path] = &pathNode{}
+		}
+		for path, n := range set.nodes {
+			subset[path] = &pathNode{hash: n.hash, blob: n.rlp()}
+		}
+		for path, n := range subset {
+			layer.size += common.StorageSize(len(path) + len(n.blob) + common.HashLength)
+		}
+		layer.nodes[owner] = subset
+	}
+	return layer
+}
+
+// addLayer links the given diff layer into the layer tree and the node index.
+// The caller must hold the write lock.
+func (db *pathDatabase) addLayer(layer *diffLayer) {
+	for owner, subset := range layer.nodes {
+		for path, n := range subset {
+			if len(n.blob) == 0 {
+				continue
+			}
+			key := nodeKey(owner, []byte(path))
+			nodes := db.index[key]
+			if nodes == nil {
+				nodes = make(map[common.Hash]*dirtyNode)
+				db.index[key] = nodes
+			}
+			if dirty := nodes[n.hash]; dirty != nil {
+				dirty.refs++
+			} else {
+				nodes[n.hash] = &dirtyNode{blob: n.blob, refs: 1}
+			}
+			memcacheDirtyWriteMeter.Mark(int64(len(n.blob)))
+		}
+	}
+	db.layers[layer.root] = layer
+	db.size += layer.size
+}
+
+// removeLayer unlinks the given diff layer from the layer tree and the node
+// index. The caller must hold the write lock.
+func (db *pathDatabase) removeLayer(layer *diffLayer) {
+	for owner, subset := range layer.nodes {
+		for path, n := range subset {
+			if len(n.blob) == 0 {
+				continue
+			}
+			key := nodeKey(owner, []byte(path))
+			nodes := db.index[key]
+			if dirty := nodes[n.hash]; dirty != nil {
+				if dirty.refs--; dirty.refs == 0 {
+					delete(nodes, n.hash)
+				}
+			}
+			if len(nodes) == 0 {
+				delete(db.index, key)
+			}
+		}
+	}
+	delete(db.layers, layer.root)
+	db.size -= layer.size
+}
+
+// update creates a new diff layer for the state transition from parent to
+// root, containing the given dirty nodes.
+func (db *pathDatabase) update(root, parent common.Hash, nodes *MergedNodeSet) error {
+	// The zero hash is used by the callers to denote the empty state
+	if root == (common.Hash{}) {
+		root = emptyRoot
+	}
+	if parent == (common.Hash{}) {
+		parent = emptyRoot
+	}
+	// Nothing to do if the state didn't change
+	if root == parent {
+		return nil
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Skip if the state is already known, e.g. a block is re-imported
+	if _, ok := db.layers[root]; ok || root == db.diskRoot {
+		return nil
+	}
+	var id uint64
+	if parent == db.diskRoot {
+		id = db.diskID + 1
+	} else if layer := db.layers[parent]; layer != nil {
+		id = layer.id + 1
+	} else {
+		return fmt.Errorf("parent state %x is not available", parent)
+	}
+	db.addLayer(newDiffLayer(root, parent, id, nodes))
+	return nil
+}
+
+// cap flattens the diff layers below the given root into the persistent
+// state, until at most the given number of layers is kept in memory.
+func (db *pathDatabase) cap(root common.Hash, layers int) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Collect the layers from the given root down to the disk state
+	var chain []*diffLayer
+	for current := root; current != db.diskRoot; {
+		layer := db.layers[current]
+		if layer == nil {
+			return fmt.Errorf("state %x is not available", root)
+		}
+		chain = append(chain, layer)
+		current = layer.parent
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	for i := len(chain) - 1; i >= layers; i-- {
+		if err := db.persist(chain[i]); err != nil {
+			return err
+		}
+	}
+	db.pruneForks()
+	return nil
+}
+
+// persist flattens the given diff layer into the persistent state. The layer
+// must be the direct descendant of the disk state. The caller must hold the
+// write lock.
+func (db *pathDatabase) persist(layer *diffLayer) error {
+	var (
+		start = time.Now()
+		batch = db.diskdb.NewBatch()
+		nodes int
+		hist  = &stateHistory{Parent: db.diskRoot, Root: layer.root}
+	)
+	for _, owner := range sortedOwners(layer.nodes) {
+		subset := layer.nodes[owner]
+		for _, path := range sortedPaths(subset) {
+			n := subset[path]
+
+			prev, _ := rawdb.ReadTrieNodeWithPath(db.diskdb, owner, []byte(path))
+			hist.Nodes = append(hist.Nodes, historyNode{Owner: owner, Path: []byte(path), Blob: prev})
+
+			key := nodeKey(owner, []byte(path))
+			if len(n.blob) == 0 {
+				rawdb.DeleteTrieNodeWithPath(batch, owner, []byte(path))
+				if db.cleans != nil {
+					db.cleans.Del([]byte(key))
+				}
+			} else {
+				rawdb.WriteTrieNodeWithPath(batch, owner, []byte(path), n.blob)
+				if db.cleans != nil {
+					db.cleans.Set([]byte(key), n.blob)
+				}
+			}
+			nodes++
+		}
+	}
+	// Write the state history before the state itself, dangling histories
+	// are truncated on startup if the state write doesn't make it.
+	if err := db.writeHistory(layer.id, hist); err != nil {
+		return err
+	}
+	if db.diskID == 0 || rawdb.ReadStateID(db.diskdb, db.diskRoot) == nil {
+		rawdb.WriteStateID(batch, db.diskRoot, db.diskID)
+	}
+	rawdb.WriteStateID(batch, layer.root, layer.id)
+	rawdb.WritePersistentStateID(batch, layer.id)
+	size := batch.ValueSize()
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	db.diskRoot, db.diskID = layer.root, layer.id
+	db.removeLayer(layer)
+	db.pruneHistory()
+
+	pathCommitTimeTimer.Update(time.Since(start))
+	pathCommitNodesMeter.Mark(int64(nodes))
+	pathCommitSizeMeter.Mark(int64(size))
+	log.Debug("Persisted trie layer", "id", layer.id, "root", layer.root, "nodes", nodes, "size", common.StorageSize(size), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// pruneForks drops all the diff layers which are not descendants of the
+// persistent state anymore. The caller must hold the write lock.
+func (db *pathDatabase) pruneForks() {
+	linked := map[common.Hash]bool{db.diskRoot: true}
+
+	var check func(layer *diffLayer) bool
+	check = func(layer *diffLayer) bool {
+		if ok, known := linked[layer.root]; known {
+			return ok
+		}
+		var ok bool
+		if layer.parent == db.diskRoot {
+			ok = true
+		} else if parent := db.layers[layer.parent]; parent != nil {
+			ok = check(parent)
+		}
+		linked[layer.root] = ok
+		return ok
+	}
+	var stale []*diffLayer
+	for _, layer := range db.layers {
+		if !check(layer) {
+			stale = append(stale, layer)
+		}
+	}
+	for _, layer := range stale {
+		db.removeLayer(layer)
+	}
+}
+
+// writeHistory stores the state history with the given id in the freezer.
+// The caller must hold the write lock.
+func (db *pathDatabase) writeHistory(id uint64, hist *stateHistory) error {
+	if db.freezer == nil {
+		return nil
+	}
+	head, err := db.freezer.Ancients()
+	if err != nil {
+		return err
+	}
+	if head > id-1 {
+		if err := db.freezer.TruncateHead(id - 1); err != nil {
+			return err
+		}
+	} else if head < id-1 {
+		// Histories are not continuous anymore, which only happens if the
+		// state was modified without history tracking. Stop tracking it.
+		log.Warn("State history gap detected, disabling state history", "head", head, "id", id)
+		db.freezer.Close()
+		db.freezer = nil
+		return nil
+	}
+	blob, err := rlp.EncodeToBytes(hist)
+	if err != nil {
+		return err
+	}
+	pathHistorySizeMeter.Mark(int64(len(blob)))
+	return rawdb.WriteStateHistory(db.freezer, id, blob)
+}
+
+// pruneHistory drops the state histories beyond the configured retention
+// along with the lookups of the states which can't be reverted to anymore.
+// The caller must hold the write lock.
+func (db *pathDatabase) pruneHistory() {
+	if db.freezer == nil || db.history == 0 || db.diskID <= db.history {
+		return
+	}
+	tail, err := db.freezer.Tail()
+	if err != nil {
+		return
+	}
+	limit := db.diskID - db.history
+	if limit <= tail {
+		return
+	}
+	batch := db.diskdb.NewBatch()
+	for item := tail; item < limit; item++ {
+		var hist stateHistory
+		if err := rlp.DecodeBytes(rawdb.ReadStateHistory(db.freezer, item+1), &hist); err != nil {
+			continue
+		}
+		rawdb.DeleteStateID(batch, hist.Parent)
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to delete stale state lookups", "err", err)
+		return
+	}
+	if err := db.freezer.TruncateTail(limit); err != nil {
+		log.Error("Failed to truncate state histories", "err", err)
+	}
+}
+
+// recoverable reports whether the persistent state can be reverted to the
+// state with the given root.
+func (db *pathDatabase) recoverable(root common.Hash) bool {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.recoverableLocked(root)
+}
+
+// recoverableLocked is the lock free version of recoverable. The caller must
+// hold the lock.
+func (db *pathDatabase) recoverableLocked(root common.Hash) bool {
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil || db.freezer == nil || *id >= db.diskID {
+		return false
+	}
+	tail, err := db.freezer.Tail()
+	if err != nil {
+		return false
+	}
+	head, err := db.freezer.Ancients()
+	if err != nil {
+		return false
+	}
+	// The histories with the ids (id, diskID] are required, stored in the
+	// items [id, diskID).
+	return *id >= tail && head >= db.diskID
+}
+
+// recover reverts the persistent state to the state with the given root by
+// applying the state histories in reverse order. All in-memory diff layers
+// are discarded, since they are built on top of the reverted state.
+func (db *pathDatabase) recover(root common.Hash) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if !db.recoverableLocked(root) {
+		return errStateUnrecoverable
+	}
+	target := *rawdb.ReadStateID(db.diskdb, root)
+
+	// Load and verify the history chain before touching any state
+	var (
+		histories []*stateHistory
+		current   = db.diskRoot
+	)
+	for id := db.diskID; id > target; id-- {
+		hist := new(stateHistory)
+		if err := rlp.DecodeBytes(rawdb.ReadStateHistory(db.freezer, id), hist); err != nil {
+			return fmt.Errorf("invalid state history %d: %v", id, err)
+		}
+		if hist.Root != current {
+			return fmt.Errorf("%w: history %d root mismatch, want %x, got %x", errStateUnrecoverable, id, current, hist.Root)
+		}
+		histories = append(histories, hist)
+		current = hist.Parent
+	}
+	if current != root {
+		return fmt.Errorf("%w: history chain ends at %x", errStateUnrecoverable, current)
+	}
+	db.layers = make(map[common.Hash]*diffLayer)
+	db.index = make(map[string]map[common.Hash]*dirtyNode)
+	db.size = 0
+
+	start := time.Now()
+	for _, hist := range histories {
+		batch := db.diskdb.NewBatch()
+		for _, n := range hist.Nodes {
+			if len(n.Blob) == 0 {
+				rawdb.DeleteTrieNodeWithPath(batch, n.Owner, n.Path)
+			} else {
+				rawdb.WriteTrieNodeWithPath(batch, n.Owner, n.Path, n.Blob)
+			}
+		}
+		rawdb.DeleteStateID(batch, hist.Root)
+		rawdb.WritePersistentStateID(batch, db.diskID-1)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		db.diskRoot, db.diskID = hist.Parent, db.diskID-1
+		if err := db.freezer.TruncateHead(db.diskID); err != nil {
+			return err
+		}
+	}
+	if db.cleans != nil {
+		db.cleans.Reset()
+	}
+	log.Info("Reverted persistent state", "root", root, "histories", len(histories), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// journal persists the diff layers from the given root down to the disk state,
+// so they can be restored after a restart.
+func (db *pathDatabase) journal(root common.Hash) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var chain []*diffLayer
+	for current := root; current != db.diskRoot; {
+		layer := db.layers[current]
+		if layer == nil {
+			return fmt.Errorf("state %x is not available", root)
+		}
+		chain = append(chain, layer)
+		current = layer.parent
+	}
+	j := journal{Version: journalVersion, DiskRoot: db.diskRoot}
+	for i := len(chain) - 1; i >= 0; i-- {
+		layer := chain[i]
+		entry := journalLayer{Root: layer.root, Parent: layer.parent, ID: layer.id}
+		for _, owner := range sortedOwners(layer.nodes) {
+			subset := layer.nodes[owner]
+			nodes := journalNodes{Owner: owner}
+			for _, path := range sortedPaths(subset) {
+				nodes.Nodes = append(nodes.Nodes, journalNode{Path: []byte(path), Blob: subset[path].blob})
+			}
+			entry.Nodes = append(entry.Nodes, nodes)
+		}
+		j.Layers = append(j.Layers, entry)
+	}
+	blob, err := rlp.EncodeToBytes(&j)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteTrieJournal(db.diskdb, blob)
+	log.Info("Persisted trie layers to journal", "layers", len(chain), "size", common.StorageSize(len(blob)))
+	return nil
+}
+
+// loadJournal restores the journalled diff layers if they belong to the
+// current persistent state.
+func (db *pathDatabase) loadJournal() {
+	blob := rawdb.ReadTrieJournal(db.diskdb)
+	if len(blob) == 0 {
+		return
+	}
+	var j journal
+	if err := rlp.DecodeBytes(blob, &j); err != nil {
+		log.Warn("Failed to decode trie journal", "err", err)
+		return
+	}
+	if j.Version != journalVersion || j.DiskRoot != db.diskRoot {
+		log.Info("Discarded stale trie journal", "version", j.Version, "root", j.DiskRoot)
+		return
+	}
+	h := newHasher(false)
+	defer returnHasherToPool(h)
+
+	for _, entry := range j.Layers {
+		if entry.Parent != db.diskRoot && db.layers[entry.Parent] == nil {
+			log.Warn("Discarded dangling trie journal layer", "root", entry.Root, "parent", entry.Parent)
+			break
+		}
+		layer := &diffLayer{
+			root:   entry.Root,
+			parent: entry.Parent,
+			id:     entry.ID,
+			nodes:  make(map[common.Hash]map[string]*pathNode),
+		}
+		for _, set := range entry.Nodes {
+			subset := make(map[string]*pathNode, len(set.Nodes))
+			for _, n := range set.Nodes {
+				node := &pathNode{blob: n.Blob}
+				if len(n.Blob) != 0 {
+					node.hash = common.BytesToHash(h.hashData(n.Blob))
+				}
+				subset[string(n.Path)] = node
+				layer.size += common.StorageSize(len(n.Path) + len(n.Blob) + common.HashLength)
+			}
+			layer.nodes[set.Owner] = subset
+		}
+		db.addLayer(layer)
+	}
+	log.Info("Loaded trie layers from journal", "layers", len(db.layers), "size", db.size)
+}
+
+// reset discards all diff layers and re-initializes the database from the
+// persistent state, which must have the given root. If the root is empty,
+// the persistent state is wiped entirely.
+func (db *pathDatabase) reset(root common.Hash) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if root == emptyRoot {
+		batch := db.diskdb.NewBatch()
+		for _, prefix := range [][]byte{rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix} {
+			it := db.diskdb.NewIterator(prefix, nil)
+			for it.Next() {
+				key := it.Key()
+				if !rawdb.IsAccountTrieNode(key) && !rawdb.IsStorageTrieNode(key) {
+					continue
+				}
+				batch.Delete(key)
+				if batch.ValueSize() > ethdb.IdealBatchSize {
+					if err := batch.Write(); err != nil {
+						it.Release()
+						return err
+					}
+					batch.Reset()
+				}
+			}
+			it.Release()
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	} else if disk := diskStateRoot(db.diskdb); disk != root {
+		return fmt.Errorf("persistent state mismatch, want %x, got %x", root, disk)
+	}
+	db.layers = make(map[common.Hash]*diffLayer)
+	db.index = make(map[string]map[common.Hash]*dirtyNode)
+	db.size = 0
+	db.diskRoot = root
+	if db.cleans != nil {
+		db.cleans.Reset()
+	}
+	batch := db.diskdb.NewBatch()
+	rawdb.WriteStateID(batch, root, db.diskID)
+	rawdb.WritePersistentStateID(batch, db.diskID)
+	return batch.Write()
+}
+
+// commit flattens all the diff layers below and including the given root into
+// the persistent state.
+func (db *pathDatabase) commit(root common.Hash) error {
+	if err := db.cap(root, 0); err != nil {
+		return err
+	}
+	// Mark the database as path-based even if nothing was written
+	if !rawdb.HasPersistentStateID(db.diskdb) {
+		rawdb.WritePersistentStateID(db.diskdb, db.diskID)
+	}
+	return nil
+}
+
+// close releases the state freezer.
+func (db *pathDatabase) close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.freezer == nil {
+		return nil
+	}
+	err := db.freezer.Close()
+	db.freezer = nil
+	return err
+}
@@ -0,0 +1,379 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// newPathTestDatabase creates a disk database with a state freezer attached.
+func newPathTestDatabase(t *testing.T) ethdb.Database {
+	db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	return db
+}
+
+// pathTestStates applies a series of state transitions on top of an empty
+// state, returning the roots and the contents of all the states. Every
+// transition updates some entries and deletes some others.
+func pathTestStates(t *testing.T, db *Database, n int) ([]common.Hash, []map[string][]byte) {
+	var (
+		parent   = emptyRoot
+		roots    []common.Hash
+		contents []map[string][]byte
+		content  = make(map[string][]byte)
+	)
+	for i := 0; i < n; i++ {
+		tr, err := New(common.Hash{}, parent, db)
+		if err != nil {
+			t.Fatalf("state %d: failed to open trie: %v", i, err)
+		}
+		for j := 0; j < 32; j++ {
+			key := common.LeftPadBytes([]byte{byte(j), byte(i % 4)}, 32)
+			val := []byte(fmt.Sprintf("value-%d-%d", i, j))
+			tr.Update(key, val)
+			content[string(key)] = val
+		}
+		if i > 0 {
+			for j := 0; j < 16; j++ {
+				key := common.LeftPadBytes([]byte{byte(j), byte((i - 1) % 4)}, 32)
+				tr.Delete(key)
+				delete(content, string(key))
+			}
+		}
+		root, nodes, err := tr.Commit(false)
+		if err != nil {
+			t.Fatalf("state %d: failed to commit trie: %v", i, err)
+		}
+		if err := db.UpdateState(root, parent, NewWithNodeSet(nodes)); err != nil {
+			t.Fatalf("state %d: failed to update database: %v", i, err)
+		}
+		snapshot := make(map[string][]byte)
+		for k, v := range content {
+			snapshot[k] = v
+		}
+		roots = append(roots, root)
+		contents = append(contents, snapshot)
+		parent = root
+	}
+	return roots, contents
+}
+
+// checkPathTrie ensures that the state with the given root is accessible and
+// has the expected content.
+func checkPathTrie(t *testing.T, db *Database, root common.Hash, content map[string][]byte) {
+	t.Helper()
+
+	tr, err := New(common.Hash{}, root, db)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", root, err)
+	}
+	for key, val := range content {
+		have, err := tr.TryGet([]byte(key))
+		if err != nil {
+			t.Fatalf("trie %x: failed to retrieve %x: %v", root, key, err)
+		}
+		if !bytes.Equal(have, val) {
+			t.Fatalf("trie %x: entry %x mismatch, have %x, want %x", root, key, have, val)
+		}
+	}
+	var count int
+	it := NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		count++
+	}
+	if it.Err != nil {
+		t.Fatalf("trie %x: failed to iterate: %v", root, it.Err)
+	}
+	if count != len(content) {
+		t.Fatalf("trie %x: entry count mismatch, have %d, want %d", root, count, len(content))
+	}
+}
+
+// Tests that the in-memory layers are flattened into disk while retaining the
+// accessibility of all the live states.
+func TestPathDatabaseCapLayers(t *testing.T) {
+	diskdb := newPathTestDatabase(t)
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme})
+	defer db.Close()
+
+	roots, contents := pathTestStates(t, db, 8)
+	for i, root := range roots {
+		checkPathTrie(t, db, root, contents[i])
+	}
+	if err := db.CapLayers(roots[7], 2); err != nil {
+		t.Fatalf("failed to cap layers: %v", err)
+	}
+	if id := rawdb.ReadPersistentStateID(diskdb); id != 6 {
+		t.Fatalf("persistent state id mismatch, have %d, want %d", id, 6)
+	}
+	for i := 5; i < len(roots); i++ {
+		checkPathTrie(t, db, roots[i], contents[i])
+	}
+	if _, err := New(common.Hash{}, roots[4], db); err == nil {
+		t.Fatal("flattened state is still accessible")
+	}
+	// Flatten everything and ensure no stale node is left on disk
+	if err := db.Commit(roots[7], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	checkPathTrie(t, db, roots[7], contents[7])
+
+	reference := NewDatabaseWithConfig(memorydb.New(), &Config{Scheme: rawdb.PathScheme})
+	refRoots, _ := pathTestStates(t, reference, 8)
+	if err := reference.Commit(refRoots[7], false, nil); err != nil {
+		t.Fatalf("failed to commit reference state: %v", err)
+	}
+	if have, want := countPathNodes(diskdb), countPathNodes(reference.diskdb); have != want {
+		t.Fatalf("persisted node count mismatch, have %d, want %d", have, want)
+	}
+}
+
+// countPathNodes returns the number of trie nodes stored in path scheme.
+func countPathNodes(db ethdb.Iteratee) int {
+	var count int
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if rawdb.IsAccountTrieNode(it.Key()) || rawdb.IsStorageTrieNode(it.Key()) {
+			count++
+		}
+	}
+	return count
+}
+
+// Tests that the persistent state can be reverted with the state histories.
+func TestPathDatabaseRecover(t *testing.T) {
+	diskdb := newPathTestDatabase(t)
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme})
+	defer db.Close()
+
+	roots, contents := pathTestStates(t, db, 8)
+	if err := db.Commit(roots[7], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if db.Recoverable(roots[7]) {
+		t.Fatal("disk state is reported recoverable")
+	}
+	if db.Recoverable(common.HexToHash("0xdeadbeef")) {
+		t.Fatal("unknown state is reported recoverable")
+	}
+	for i := 6; i >= 0; i -= 3 {
+		if !db.Recoverable(roots[i]) {
+			t.Fatalf("state %d is not recoverable", i)
+		}
+		if err := db.Recover(roots[i]); err != nil {
+			t.Fatalf("failed to recover state %d: %v", i, err)
+		}
+		checkPathTrie(t, db, roots[i], contents[i])
+	}
+	// Revert everything, the state must be empty afterwards
+	if err := db.Recover(emptyRoot); err != nil {
+		t.Fatalf("failed to recover empty state: %v", err)
+	}
+	if n := countPathNodes(diskdb); n != 0 {
+		t.Fatalf("stale nodes left after full revert: %d", n)
+	}
+	// Ensure the state transitions can be reapplied
+	roots2, contents2 := pathTestStates(t, db, 8)
+	for i := range roots {
+		if roots[i] != roots2[i] {
+			t.Fatalf("state %d root mismatch after revert", i)
+		}
+	}
+	if err := db.Commit(roots2[7], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	checkPathTrie(t, db, roots2[7], contents2[7])
+}
+
+// Tests that the state histories beyond the retention limit are pruned.
+func TestPathDatabaseHistoryLimit(t *testing.T) {
+	diskdb := newPathTestDatabase(t)
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme, StateHistory: 3})
+	defer db.Close()
+
+	roots, _ := pathTestStates(t, db, 8)
+	if err := db.Commit(roots[7], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	for i := 0; i < len(roots)-1; i++ {
+		if want := i >= 4; db.Recoverable(roots[i]) != want {
+			t.Fatalf("state %d: recoverable mismatch, want %v", i, want)
+		}
+	}
+	if err := db.Recover(roots[3]); err == nil {
+		t.Fatal("recovered pruned state")
+	}
+}
+
+// Tests that the in-memory layers survive a restart via the journal.
+func TestPathDatabaseJournal(t *testing.T) {
+	diskdb := newPathTestDatabase(t)
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme})
+
+	roots, contents := pathTestStates(t, db, 8)
+	if err := db.CapLayers(roots[7], 4); err != nil {
+		t.Fatalf("failed to cap layers: %v", err)
+	}
+	if err := db.Journal(roots[7]); err != nil {
+		t.Fatalf("failed to journal layers: %v", err)
+	}
+	db.Close()
+
+	db = NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme})
+	defer db.Close()
+	for i := 3; i < len(roots); i++ {
+		checkPathTrie(t, db, roots[i], contents[i])
+	}
+	// The restored layers must be flattened with consistent ids
+	if err := db.Commit(roots[7], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if id := rawdb.ReadPersistentStateID(diskdb); id != 8 {
+		t.Fatalf("persistent state id mismatch, have %d, want %d", id, 8)
+	}
+	if !db.Recoverable(roots[0]) {
+		t.Fatal("state histories are not continuous")
+	}
+}
+
+// Tests that a trie which gets emptied reports all of its nodes as deleted.
+func TestPathDatabaseDeleteAll(t *testing.T) {
+	diskdb := memorydb.New()
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme})
+
+	roots, contents := pathTestStates(t, db, 2)
+	if err := db.Commit(roots[1], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	tr, _ := New(common.Hash{}, roots[1], db)
+	for key := range contents[1] {
+		tr.Delete([]byte(key))
+	}
+	root, nodes, err := tr.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if root != emptyRoot || nodes == nil || len(nodes.deletes) == 0 {
+		t.Fatalf("deletions are not reported, root %x", root)
+	}
+	if err := db.UpdateState(root, roots[1], NewWithNodeSet(nodes)); err != nil {
+		t.Fatalf("failed to update database: %v", err)
+	}
+	if err := db.Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if n := countPathNodes(diskdb); n != 0 {
+		t.Fatalf("stale nodes left after deleting all entries: %d", n)
+	}
+}
+
+// Tests that the stack trie commits the same nodes as the trie in path scheme.
+func TestStackTriePathScheme(t *testing.T) {
+	var (
+		stackdb = memorydb.New()
+		stack   = NewStackTrieWithScheme(stackdb, common.Hash{}, rawdb.PathScheme)
+		trie    = NewEmpty(NewDatabaseWithConfig(memorydb.New(), &Config{Scheme: rawdb.PathScheme}))
+		content = make(map[string][]byte)
+	)
+	for i := 0; i < 1000; i++ {
+		key := common.LeftPadBytes([]byte{byte(i >> 8), byte(i)}, 32)
+		if i%7 == 0 {
+			key[5] = byte(i)
+		}
+		val := bytes.Repeat([]byte{byte(i)}, 1+i%40)
+		content[string(key)] = val
+		trie.Update(key, val)
+	}
+	// Stack trie requires keys to be inserted in order
+	it := NewIterator(trie.NodeIterator(nil))
+	for it.Next() {
+		stack.TryUpdate(it.Key, it.Value)
+	}
+	root, err := stack.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit stack trie: %v", err)
+	}
+	if root != trie.Hash() {
+		t.Fatalf("root mismatch, have %x, want %x", root, trie.Hash())
+	}
+	db := NewDatabaseWithConfig(stackdb, &Config{Scheme: rawdb.PathScheme, ReadOnly: true})
+	checkPathTrie(t, db, root, content)
+
+	// Every node written by the stack trie must match the committed trie
+	_, nodes, _ := trie.Commit(false)
+	if have, want := countPathNodes(stackdb), len(nodes.nodes); have != want {
+		t.Fatalf("node count mismatch, have %d, want %d", have, want)
+	}
+	for path, n := range nodes.nodes {
+		blob, hash := rawdb.ReadAccountTrieNode(stackdb, []byte(path))
+		if hash != n.hash || !bytes.Equal(blob, n.rlp()) {
+			t.Fatalf("node %x mismatch", path)
+		}
+	}
+}
+
+// Tests that a trie can be synced into the path scheme.
+func TestPathSync(t *testing.T) {
+	_, srcTrie, srcData := makeTestTrie()
+
+	diskdb := memorydb.New()
+	sched := NewSync(srcTrie.Hash(), diskdb, nil, rawdb.PathScheme)
+
+	paths, _, _ := sched.Missing(100)
+	for len(paths) > 0 {
+		for _, path := range paths {
+			syncPath := NewSyncPath([]byte(path))
+			data, _, err := srcTrie.TryGetNode(syncPath[len(syncPath)-1])
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for path %x: %v", path, err)
+			}
+			if err := sched.ProcessNode(NodeSyncResult{path, data}); err != nil {
+				t.Fatalf("failed to process result %v", err)
+			}
+		}
+		batch := diskdb.NewBatch()
+		if err := sched.Commit(batch); err != nil {
+			t.Fatalf("failed to commit data: %v", err)
+		}
+		batch.Write()
+		paths, _, _ = sched.Missing(100)
+	}
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme, ReadOnly: true})
+	tr, err := NewStateTrie(common.Hash{}, srcTrie.Hash(), db)
+	if err != nil {
+		t.Fatalf("failed to open synced trie: %v", err)
+	}
+	for key, val := range srcData {
+		if have := tr.Get([]byte(key)); !bytes.Equal(have, val) {
+			t.Fatalf("entry %x: content mismatch: have %x, want %x", key, have, val)
+		}
+	}
+	if err := checkTrieConsistency(db, srcTrie.Hash()); err != nil {
+		t.Fatalf("inconsistent trie: %v", err)
+	}
+}
@@ -30,6 +30,15 @@ type memoryNode struct {
 	node node        // Cached collapsed trie node, or raw rlp data
 }
 
+// rlp returns the raw rlp encoded blob of the cached trie node, either directly
+// from the cache, or by regenerating it from the collapsed node.
+func (n *memoryNode) rlp() []byte {
+	if node, ok := n.node.(rawNode); ok {
+		return node
+	}
+	return nodeToBytes(n.node)
+}
+
 // NodeSet contains all dirty nodes collected during the commit operation.
 // Each node is keyed by path. It's not thread-safe to use.
 type NodeSet struct {
@@ -37,6 +46,11 @@ type NodeSet struct {
 	paths  []string               // the path of dirty nodes, sort by insertion order
 	nodes  map[string]*memoryNode // the map of dirty nodes, keyed by node path
 	leaves []*leaf                // the list of dirty leaves
+
+	// deletes is the list of node paths removed from the trie. It's only
+	// tracked in the path-based scheme, where deleted nodes have to be
+	// explicitly wiped from the disk.
+	deletes []string
 }
 
 // NewNodeSet initializes an empty node set to be used for tracking dirty nodes
@@ -60,9 +74,21 @@ func (set *NodeSet) addLeaf(node *leaf) {
 	set.leaves = append(set.leaves, node)
 }
 
-// Len returns the number of dirty nodes contained in the set.
+// markDeleted marks the node at the given path as deleted.
+func (set *NodeSet) markDeleted(path string) {
+	set.deletes = append(set.deletes, path)
+}
+
+// MarkDeleted marks the node at the given path as deleted, e.g. when the whole
+// storage trie of a destructed account is wiped.
+func (set *NodeSet) MarkDeleted(path []byte) {
+	set.markDeleted(string(path))
+}
+
+// Len returns the number of dirty nodes contained in the set, including
+// the deleted ones.
 func (set *NodeSet) Len() int {
-	return len(set.nodes)
+	return len(set.nodes) + len(set.deletes)
 }
 
 // MergedNodeSet represents a merged dirty node set for a group of tries.
@@ -25,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -37,10 +38,11 @@ var stPool = sync.Pool{
 	},
 }
 
-func stackTrieFromPool(db ethdb.KeyValueWriter, owner common.Hash) *StackTrie {
+func stackTrieFromPool(db ethdb.KeyValueWriter, owner common.Hash, scheme string) *StackTrie {
 	st := stPool.Get().(*StackTrie)
 	st.db = db
 	st.owner = owner
+	st.scheme = scheme
 	return st
 }
 
@@ -59,6 +61,7 @@ type StackTrie struct {
 	key      []byte               // key chunk covered by this (leaf|ext) node
 	children [16]*StackTrie       // list of children (for branch and exts)
 	db       ethdb.KeyValueWriter // Pointer to the commit db, can be nil
+	scheme   string               // Storage scheme of the committed nodes
 }
 
 // NewStackTrie allocates and initializes an empty trie.
@@ -79,6 +82,17 @@ func NewStackTrieWithOwner(db ethdb.KeyValueWriter, owner common.Hash) *StackTri
 	}
 }
 
+// NewStackTrieWithScheme allocates and initializes an empty trie, whose nodes
+// are committed into the given database in the specified storage scheme.
+func NewStackTrieWithScheme(db ethdb.KeyValueWriter, owner common.Hash, scheme string) *StackTrie {
+	return &StackTrie{
+		owner:    owner,
+		nodeType: emptyNode,
+		db:       db,
+		scheme:   scheme,
+	}
+}
+
 // NewFromBinary initialises a serialized stacktrie with the given db.
 func NewFromBinary(data []byte, db ethdb.KeyValueWriter) (*StackTrie, error) {
 	var st StackTrie
@@ -169,16 +183,16 @@ func (st *StackTrie) setDb(db ethdb.KeyValueWriter) {
 	}
 }
 
-func newLeaf(owner common.Hash, key, val []byte, db ethdb.KeyValueWriter) *StackTrie {
-	st := stackTrieFromPool(db, owner)
+func newLeaf(owner common.Hash, key, val []byte, db ethdb.KeyValueWriter, scheme string) *StackTrie {
+	st := stackTrieFromPool(db, owner, scheme)
 	st.nodeType = leafNode
 	st.key = append(st.key, key...)
 	st.val = val
 	return st
 }
 
-func newExt(owner common.Hash, key []byte, child *StackTrie, db ethdb.KeyValueWriter) *StackTrie {
-	st := stackTrieFromPool(db, owner)
+func newExt(owner common.Hash, key []byte, child *StackTrie, db ethdb.KeyValueWriter, scheme string) *StackTrie {
+	st := stackTrieFromPool(db, owner, scheme)
 	st.nodeType = extNode
 	st.key = append(st.key, key...)
 	st.children[0] = child
@@ -200,7 +214,7 @@ func (st *StackTrie) TryUpdate(key, value []byte) error {
 	if len(value) == 0 {
 		panic("deletion not supported")
 	}
-	st.insert(k[:len(k)-1], value)
+	st.insert(k[:len(k)-1], value, nil)
 	return nil
 }
 
@@ -213,6 +227,7 @@ func (st *StackTrie) Update(key, value []byte) {
 func (st *StackTrie) Reset() {
 	st.owner = common.Hash{}
 	st.db = nil
+	st.scheme = ""
 	st.key = st.key[:0]
 	st.val = nil
 	for i := range st.children {
@@ -233,9 +248,19 @@ func (st *StackTrie) getDiffIndex(key []byte) int {
 	return len(st.key)
 }
 
+// childPath returns the path of a child node with the given key relative to
+// the node at the given path. Paths are only tracked if nodes are committed
+// in the path-based scheme.
+func (st *StackTrie) childPath(path []byte, key ...byte) []byte {
+	if st.db == nil || st.scheme != rawdb.PathScheme {
+		return nil
+	}
+	return concat(path, key...)
+}
+
 // Helper function to that inserts a (key, value) pair into
-// the trie.
-func (st *StackTrie) insert(key, value []byte) {
+// the trie. The prefix is the path of the node.
+func (st *StackTrie) insert(key, value []byte, prefix []byte) {
 	switch st.nodeType {
 	case branchNode: /* Branch */
 		idx := int(key[0])
@@ -244,7 +269,7 @@ func (st *StackTrie) insert(key, value []byte) {
 		for i := idx - 1; i >= 0; i-- {
 			if st.children[i] != nil {
 				if st.children[i].nodeType != hashedNode {
-					st.children[i].hash()
+					st.children[i].hash(st.childPath(prefix, byte(i)))
 				}
 				break
 			}
@@ -252,9 +277,9 @@ func (st *StackTrie) insert(key, value []byte) {
 
 		// Add new child
 		if st.children[idx] == nil {
-			st.children[idx] = newLeaf(st.owner, key[1:], value, st.db)
+			st.children[idx] = newLeaf(st.owner, key[1:], value, st.db, st.scheme)
 		} else {
-			st.children[idx].insert(key[1:], value)
+			st.children[idx].insert(key[1:], value, st.childPath(prefix, key[0]))
 		}
 
 	case extNode: /* Ext */
@@ -269,7 +294,7 @@ func (st *StackTrie) insert(key, value []byte) {
 		if diffidx == len(st.key) {
 			// Ext key and key segment are identical, recurse into
 			// the child node.
-			st.children[0].insert(key[diffidx:], value)
+			st.children[0].insert(key[diffidx:], value, st.childPath(prefix, st.key...))
 			return
 		}
 		// Save the original part. Depending if the break is
@@ -278,14 +303,14 @@ func (st *StackTrie) insert(key, value []byte) {
 		// node directly.
 		var n *StackTrie
 		if diffidx < len(st.key)-1 {
-			n = newExt(st.owner, st.key[diffidx+1:], st.children[0], st.db)
+			n = newExt(st.owner, st.key[diffidx+1:], st.children[0], st.db, st.scheme)
 		} else {
 			// Break on the last byte, no need to insert
 			// an extension node: reuse the current node
 			n = st.children[0]
 		}
 		// Convert to hash
-		n.hash()
+		n.hash(st.childPath(prefix, st.key[:diffidx+1]...))
 		var p *StackTrie
 		if diffidx == 0 {
 			// the break is on the first byte, so
@@ -298,12 +323,12 @@ func (st *StackTrie) insert(key, value []byte) {
 			// the common prefix is at least one byte
 			// long, insert a new intermediate branch
 			// node.
-			st.children[0] = stackTrieFromPool(st.db, st.owner)
+			st.children[0] = stackTrieFromPool(st.db, st.owner, st.scheme)
 			st.children[0].nodeType = branchNode
 			p = st.children[0]
 		}
 		// Create a leaf for the inserted part
-		o := newLeaf(st.owner, key[diffidx+1:], value, st.db)
+		o := newLeaf(st.owner, key[diffidx+1:], value, st.db, st.scheme)
 
 		// Insert both child leaves where they belong:
 		origIdx := st.key[diffidx]
@@ -339,7 +364,7 @@ func (st *StackTrie) insert(key, value []byte) {
 			// Convert current node into an ext,
 			// and insert a child branch node.
 			st.nodeType = extNode
-			st.children[0] = NewStackTrieWithOwner(st.db, st.owner)
+			st.children[0] = NewStackTrieWithScheme(st.db, st.owner, st.scheme)
 			st.children[0].nodeType = branchNode
 			p = st.children[0]
 		}
@@ -348,11 +373,11 @@ func (st *StackTrie) insert(key, value []byte) {
 		// value and another containing the new value. The child leaf
 		// is hashed directly in order to free up some memory.
 		origIdx := st.key[diffidx]
-		p.children[origIdx] = newLeaf(st.owner, st.key[diffidx+1:], st.val, st.db)
-		p.children[origIdx].hash()
+		p.children[origIdx] = newLeaf(st.owner, st.key[diffidx+1:], st.val, st.db, st.scheme)
+		p.children[origIdx].hash(st.childPath(prefix, st.key[:diffidx+1]...))
 
 		newIdx := key[diffidx]
-		p.children[newIdx] = newLeaf(st.owner, key[diffidx+1:], value, st.db)
+		p.children[newIdx] = newLeaf(st.owner, key[diffidx+1:], value, st.db, st.scheme)
 
 		// Finally, cut off the key part that has been passed
 		// over to the children.
@@ -383,14 +408,16 @@ func (st *StackTrie) insert(key, value []byte) {
 //   - And the 'st.type' will be 'hashedNode' AGAIN
 //
 // This method also sets 'st.type' to hashedNode, and clears 'st.key'.
-func (st *StackTrie) hash() {
+// The path is the position of the node in the trie, only required if nodes
+// are committed in the path-based scheme.
+func (st *StackTrie) hash(path []byte) {
 	h := newHasher(false)
 	defer returnHasherToPool(h)
 
-	st.hashRec(h)
+	st.hashRec(h, path)
 }
 
-func (st *StackTrie) hashRec(hasher *hasher) {
+func (st *StackTrie) hashRec(hasher *hasher, path []byte) {
 	// The switch below sets this to the RLP-encoding of this node.
 	var encodedNode []byte
 
@@ -412,7 +439,7 @@ func (st *StackTrie) hashRec(hasher *hasher) {
 				continue
 			}
 
-			child.hashRec(hasher)
+			child.hashRec(hasher, st.childPath(path, byte(i)))
 			if len(child.val) < 32 {
 				nodes[i] = rawNode(child.val)
 			} else {
@@ -428,7 +455,7 @@ func (st *StackTrie) hashRec(hasher *hasher) {
 		encodedNode = hasher.encodedBytes()
 
 	case extNode:
-		st.children[0].hashRec(hasher)
+		st.children[0].hashRec(hasher, st.childPath(path, st.key...))
 
 		sz := hexToCompactInPlace(st.key)
 		n := rawShortNode{Key: st.key[:sz]}
@@ -470,7 +497,11 @@ func (st *StackTrie) hashRec(hasher *hasher) {
 	if st.db != nil {
 		// TODO! Is it safe to Put the slice here?
 		// Do all db implementations copy the value provided?
-		st.db.Put(st.val, encodedNode)
+		if st.scheme == rawdb.PathScheme {
+			rawdb.WriteTrieNodeWithPath(st.db, st.owner, path, encodedNode)
+		} else {
+			st.db.Put(st.val, encodedNode)
+		}
 	}
 }
 
@@ -479,7 +510,7 @@ func (st *StackTrie) Hash() (h common.Hash) {
 	hasher := newHasher(false)
 	defer returnHasherToPool(hasher)
 
-	st.hashRec(hasher)
+	st.hashRec(hasher, nil)
 	if len(st.val) == 32 {
 		copy(h[:], st.val)
 		return h
@@ -509,7 +540,7 @@ func (st *StackTrie) Commit() (h common.Hash, err error) {
 	hasher := newHasher(false)
 	defer returnHasherToPool(hasher)
 
-	st.hashRec(hasher)
+	st.hashRec(hasher, nil)
 	if len(st.val) == 32 {
 		copy(h[:], st.val)
 		return h, nil
@@ -521,6 +552,10 @@ func (st *StackTrie) Commit() (h common.Hash, err error) {
 	hasher.sha.Reset()
 	hasher.sha.Write(st.val)
 	hasher.sha.Read(h[:])
-	st.db.Put(h[:], st.val)
+	if st.scheme == rawdb.PathScheme {
+		rawdb.WriteTrieNodeWithPath(st.db, st.owner, nil, st.val)
+	} else {
+		st.db.Put(h[:], st.val)
+	}
 	return h, nil
 }
@@ -23,6 +23,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -103,7 +104,11 @@ func New(owner common.Hash, root common.Hash, db *Database) (*Trie, error) {
 	trie := &Trie{
 		owner: owner,
 		db:    db,
-		//tracer: newTracer(),
+	}
+	// Deleted nodes have to be tracked in the path-based scheme, since
+	// they are keyed by path and won't be overwritten automatically.
+	if db != nil && db.Scheme() == rawdb.PathScheme {
+		trie.tracer = newTracer()
 	}
 	if root != (common.Hash{}) && root != emptyRoot {
 		rootnode, err := trie.resolveHash(root[:], nil)
@@ -557,16 +562,32 @@ func (t *Trie) resolve(n node, prefix []byte) (node, error) {
 // node hash and path prefix.
 func (t *Trie) resolveHash(n hashNode, prefix []byte) (node, error) {
 	hash := common.BytesToHash(n)
+	if t.db.Scheme() == rawdb.PathScheme {
+		blob, err := t.db.pathdb.node(t.owner, prefix, hash)
+		if err != nil {
+			return nil, &MissingNodeError{Owner: t.owner, NodeHash: hash, Path: prefix, err: err}
+		}
+		// The returned blob is always in its own copy,
+		// safe to use mustDecodeNodeUnsafe for decoding.
+		return mustDecodeNodeUnsafe(n, blob), nil
+	}
 	if node := t.db.node(hash); node != nil {
 		return node, nil
 	}
 	return nil, &MissingNodeError{Owner: t.owner, NodeHash: hash, Path: prefix}
 }
 
-// resolveHash loads rlp-encoded node blob from the underlying database
+// resolveBlob loads rlp-encoded node blob from the underlying database
 // with the provided node hash and path prefix.
 func (t *Trie) resolveBlob(n hashNode, prefix []byte) ([]byte, error) {
 	hash := common.BytesToHash(n)
+	if t.db.Scheme() == rawdb.PathScheme {
+		blob, err := t.db.pathdb.node(t.owner, prefix, hash)
+		if err != nil {
+			return nil, &MissingNodeError{Owner: t.owner, NodeHash: hash, Path: prefix, err: err}
+		}
+		return blob, nil
+	}
 	blob, _ := t.db.Node(hash)
 	if len(blob) != 0 {
 		return blob, nil
@@ -591,8 +612,15 @@ func (t *Trie) Hash() common.Hash {
 func (t *Trie) Commit(collectLeaf bool) (common.Hash, *NodeSet, error) {
 	defer t.tracer.reset()
 
+	// The trie is either empty from the beginning or all nodes got deleted.
+	// In the latter case, the deletions still need to be reported.
 	if t.root == nil {
-		return emptyRoot, nil, nil
+		set := NewNodeSet(t.owner)
+		t.markDeletions(set)
+		if set.Len() == 0 {
+			return emptyRoot, nil, nil
+		}
+		return emptyRoot, set, nil
 	}
 	// Derive the hash for all dirty nodes first. We hold the assumption
 	// in the following procedure that all nodes are hashed.
@@ -611,10 +639,22 @@ func (t *Trie) Commit(collectLeaf bool) (common.Hash, *NodeSet, error) {
 	if err != nil {
 		return common.Hash{}, nil, err
 	}
+	t.markDeletions(nodes)
 	t.root = newRoot
 	return rootHash, nodes, nil
 }
 
+// markDeletions adds all tracked node deletions into the given set. Paths
+// which are overwritten by dirty nodes in the set are skipped.
+func (t *Trie) markDeletions(set *NodeSet) {
+	for _, path := range t.tracer.deleteList() {
+		if _, ok := set.nodes[string(path)]; ok {
+			continue
+		}
+		set.markDeleted(string(path))
+	}
+}
+
 // hashRoot calculates the root hash of the given trie
 func (t *Trie) hashRoot() (node, node, error) {
 	if t.root == nil {